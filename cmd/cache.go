@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	awspkg "github.com/guimove/clusterfit/internal/aws"
+	"github.com/guimove/clusterfit/internal/config"
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the EC2 instance type and pricing cache",
+	Long: `ClusterFit caches EC2 instance type catalogs and per-region pricing
+under ~/.cache/clusterfit (or cache.dir) so repeated runs skip the AWS
+and pricing API calls. Catalogs and prices expire independently
+(cache.instance_types_ttl and cache.pricing_ttl).`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached entries with their age",
+	RunE:  runCacheList,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached entries",
+	RunE:  runCacheClear,
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Pre-fetch instance types and pricing for the configured region",
+	RunE:  runCacheWarm,
+}
+
+func init() {
+	f := cacheWarmCmd.Flags()
+	f.StringSlice("families", nil, "instance families to price (default: all in the catalog)")
+	f.StringSlice("architectures", []string{"amd64", "arm64"}, "CPU architectures (amd64, arm64)")
+	f.Bool("refresh", false, "ignore cached values and re-fetch everything")
+
+	cacheCmd.AddCommand(cacheListCmd, cacheClearCmd, cacheWarmCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// resolveCacheDir returns the configured cache directory, defaulting to
+// ~/.cache/clusterfit.
+func resolveCacheDir() string {
+	if cfg.Cache.Dir != "" {
+		return cfg.Cache.Dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "clusterfit")
+}
+
+// providerOptions returns the AWS provider options derived from the config.
+func providerOptions() []awspkg.ProviderOption {
+	return []awspkg.ProviderOption{
+		awspkg.WithCacheTTLs(cfg.Cache.InstanceTypesTTL, cfg.Cache.PricingTTL),
+		awspkg.WithPriceSource(priceSource()),
+	}
+}
+
+// priceSource returns the price source selected by pricing.source.
+func priceSource() awspkg.PriceSource {
+	switch cfg.Pricing.Source {
+	case config.PricingSourceOfferFile:
+		return awspkg.NewOfferFileSource(cfg.Pricing.OfferFile)
+	case config.PricingSourcePriceSheet:
+		return awspkg.NewPriceSheetSource(cfg.Pricing.PriceSheet)
+	default:
+		return awspkg.NewRunsOnSource()
+	}
+}
+
+func runCacheList(cmd *cobra.Command, args []string) error {
+	cache := awspkg.NewFileCache(resolveCacheDir())
+	entries, err := cache.Entries()
+	if err != nil {
+		return fmt.Errorf("reading cache: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("Cache is empty (%s)\n", cache.Dir())
+		return nil
+	}
+
+	fmt.Printf("%-50s %10s %12s %s\n", "KEY", "SIZE", "AGE", "STATUS")
+	fmt.Printf("%s\n", strings.Repeat("-", 85))
+	for _, e := range entries {
+		age := time.Since(e.ModTime)
+		status := "fresh"
+		if ttl := cacheEntryTTL(e.Key); ttl > 0 && age > ttl {
+			status = "expired"
+		}
+		fmt.Printf("%-50s %10s %12s %s\n", e.Key, formatBytes(e.Size), age.Truncate(time.Minute), status)
+	}
+	fmt.Printf("\n%d entries in %s\n", len(entries), cache.Dir())
+	return nil
+}
+
+// cacheEntryTTL returns the TTL that applies to a cache key, or 0 if unknown.
+// Pricing entries also expire per instance type, so a fresh file may still
+// hold some stale prices that the next run refreshes.
+func cacheEntryTTL(key string) time.Duration {
+	switch {
+	case strings.HasPrefix(key, "instance-types-"):
+		return cfg.Cache.InstanceTypesTTL
+	case strings.HasPrefix(key, "pricing-"):
+		return cfg.Cache.PricingTTL
+	}
+	return 0
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache := awspkg.NewFileCache(resolveCacheDir())
+	if err := cache.Clear(); err != nil {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+	fmt.Printf("Cleared %s\n", cache.Dir())
+	return nil
+}
+
+func runCacheWarm(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	opts := providerOptions()
+	if refresh, _ := cmd.Flags().GetBool("refresh"); refresh {
+		opts = append(opts, awspkg.WithCacheRefresh())
+	}
+	provider, err := awspkg.NewAWSProvider(ctx, cfg.Cluster.Region, resolveCacheDir(), opts...)
+	if err != nil {
+		return err
+	}
+
+	families, _ := cmd.Flags().GetStringSlice("families")
+	archFlags, _ := cmd.Flags().GetStringSlice("architectures")
+	archs := make([]model.Architecture, len(archFlags))
+	for i, a := range archFlags {
+		archs[i] = model.Architecture(a)
+	}
+
+	filter := awspkg.InstanceFilter{
+		Families:              families,
+		Architectures:         archs,
+		CurrentGenerationOnly: cfg.Instances.CurrentGenerationOnly,
+		ExcludeBareMetal:      cfg.Instances.ExcludeBareMetal,
+		ExcludeBurstable:      cfg.Instances.ExcludeBurstable,
+		MinVCPUs:              cfg.Instances.MinVCPUs,
+		MaxVCPUs:              cfg.Instances.MaxVCPUs,
+	}
+
+	fmt.Printf("Warming cache for %s...\n", cfg.Cluster.Region)
+	templates, err := provider.GetInstanceTypes(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	priced := 0
+	for _, t := range templates {
+		if t.OnDemandPricePerHour > 0 {
+			priced++
+		}
+	}
+	fmt.Printf("Cached %d instance types (%d priced) in %s\n", len(templates), priced, resolveCacheDir())
+	return nil
+}
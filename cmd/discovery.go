@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"k8s.io/client-go/kubernetes"
+
 	"github.com/guimove/clusterfit/internal/kube"
 	"github.com/guimove/clusterfit/internal/metrics"
+	"github.com/guimove/clusterfit/internal/model"
 )
 
 // resolveCollector creates a MetricsCollector by either using the explicit
@@ -15,11 +18,21 @@ import (
 // When running outside the cluster (kubeconfig mode), it automatically sets up
 // a port-forward tunnel to the discovered service. The returned cleanup function
 // must be called to close the tunnel (it is nil when no tunnel was created).
+//
+// When kubernetes.collect_constraints is set, pod scheduling constraints are read
+// from the Kubernetes API instead of kube-state-metrics.
 func resolveCollector(ctx context.Context) (*metrics.PrometheusCollector, func(), error) {
 	// Explicit URL takes precedence
 	if cfg.Prometheus.URL != "" {
-		c, err := metrics.NewPrometheusCollector(cfg.Prometheus.URL,
-			metrics.WithTimeout(cfg.Prometheus.Timeout))
+		opts := []metrics.PrometheusOption{metrics.WithTimeout(cfg.Prometheus.Timeout)}
+		if cfg.Kubernetes.CollectConstraints {
+			client, _, _, _, err := kube.NewClient(cfg.Kubernetes.Kubeconfig, cfg.Kubernetes.Context)
+			if err != nil {
+				return nil, nil, fmt.Errorf("connecting to Kubernetes: %w", err)
+			}
+			opts = append(opts, constraintListerOption(client))
+		}
+		c, err := metrics.NewPrometheusCollector(cfg.Prometheus.URL, opts...)
 		return c, nil, err
 	}
 
@@ -67,8 +80,11 @@ func resolveCollector(ctx context.Context) (*metrics.PrometheusCollector, func()
 			}
 		}
 
-		c, err := metrics.NewPrometheusCollector(promURL,
-			metrics.WithTimeout(cfg.Prometheus.Timeout))
+		opts := []metrics.PrometheusOption{metrics.WithTimeout(cfg.Prometheus.Timeout)}
+		if cfg.Kubernetes.CollectConstraints {
+			opts = append(opts, constraintListerOption(client))
+		}
+		c, err := metrics.NewPrometheusCollector(promURL, opts...)
 		if err != nil {
 			if cleanup != nil {
 				cleanup()
@@ -80,3 +96,10 @@ func resolveCollector(ctx context.Context) (*metrics.PrometheusCollector, func()
 
 	return nil, nil, fmt.Errorf("provide --prometheus-url or use --discover to auto-detect the metrics endpoint")
 }
+
+// constraintListerOption lists pod scheduling constraints across all namespaces.
+func constraintListerOption(client kubernetes.Interface) metrics.PrometheusOption {
+	return metrics.WithConstraintLister(func(ctx context.Context) (map[string]model.PodConstraints, error) {
+		return kube.ListPodConstraints(ctx, client, "")
+	})
+}
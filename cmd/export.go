@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guimove/clusterfit/internal/export"
+	"github.com/guimove/clusterfit/internal/report"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Generate eksctl, Terraform or Karpenter config from a recommendation",
+	Long: `Reads the JSON output of 'clusterfit recommend' or 'clusterfit simulate'
+and turns one recommendation into an eksctl managedNodeGroups entry, a
+Terraform aws_eks_node_group resource, or a Karpenter NodePool.
+
+Node group sizes come from the simulation: the desired size is the simulated
+node count, the minimum is the estimated trough node count (never below the
+HA minimum), and the maximum adds --headroom on top of the desired size.`,
+	RunE: runExport,
+}
+
+func init() {
+	f := exportCmd.Flags()
+	f.String("input", "", "path to a JSON report from 'recommend --output json' or 'simulate --output json' (required)")
+	f.String("format", "", "output format: "+strings.Join(export.Formats, ", ")+" (required)")
+	f.Int("rank", 1, "rank of the recommendation to export")
+	f.String("name", "", "node group or NodePool name (default: derived from the instance types)")
+	f.String("cluster-name", "", "EKS cluster name (default: from the report or config)")
+	f.Int("min-nodes", 0, "minimum node count (default: the report's HA minimum, or simulation.min_nodes)")
+	f.Float64("headroom", 0.25, "fraction of the desired size added to the maximum size")
+	f.String("node-class", "default", "EC2NodeClass referenced by the Karpenter NodePool")
+	f.String("output-file", "", "write output to file")
+
+	_ = exportCmd.MarkFlagRequired("input")
+	_ = exportCmd.MarkFlagRequired("format")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	inputPath, _ := cmd.Flags().GetString("input")
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("reading input file: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	recs, meta, err := report.ReadJSON(in)
+	if err != nil {
+		return err
+	}
+	rank, _ := cmd.Flags().GetInt("rank")
+	if rank < 1 || rank > len(recs) {
+		return fmt.Errorf("--rank %d out of range: the report has %d recommendations", rank, len(recs))
+	}
+
+	opts := export.Options{
+		ClusterName: firstNonEmpty(meta.ClusterName, cfg.Cluster.Name),
+		Region:      firstNonEmpty(meta.Region, cfg.Cluster.Region),
+		MinNodes:    meta.MinNodes,
+	}
+	opts.Name, _ = cmd.Flags().GetString("name")
+	opts.Headroom, _ = cmd.Flags().GetFloat64("headroom")
+	opts.NodeClass, _ = cmd.Flags().GetString("node-class")
+	if name, _ := cmd.Flags().GetString("cluster-name"); name != "" {
+		opts.ClusterName = name
+	}
+	if n, _ := cmd.Flags().GetInt("min-nodes"); cmd.Flags().Changed("min-nodes") {
+		opts.MinNodes = n
+	} else if opts.MinNodes == 0 {
+		opts.MinNodes = cfg.Simulation.MinNodes
+	}
+	if opts.Headroom < 0 {
+		return fmt.Errorf("--headroom must not be negative")
+	}
+
+	var w io.Writer = os.Stdout
+	if outFile, _ := cmd.Flags().GetString("output-file"); outFile != "" {
+		f, err := os.Create(outFile)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	return export.Write(w, format, recs[rank-1], opts)
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/guimove/clusterfit/internal/metrics"
 	"github.com/guimove/clusterfit/internal/model"
+	"github.com/guimove/clusterfit/internal/orchestrator"
 )
 
 var inspectCmd = &cobra.Command{
@@ -27,10 +28,15 @@ resource usage patterns. The JSON output can be fed to 'clusterfit simulate'.`,
 func init() {
 	f := inspectCmd.Flags()
 	f.Duration("window", 7*24*time.Hour, "metrics lookback window")
-	f.Float64("percentile", 0.95, "percentile for sizing")
+	f.Float64("percentile", 0.95, "percentile for sizing (1.0 = max over the window)")
+	f.Float64Slice("extra-percentiles", nil, "further percentiles to store with each pod's usage (default 0.5,0.99)")
 	f.String("output", "table", "output format: table, json")
 	f.String("sort-by", "cpu", "sort workloads by: cpu, memory, name")
 	f.String("output-file", "", "write output to file")
+	f.Bool("series", false, "also store per-pod usage series over the window (range queries), for 'simulate --replay'")
+	f.Duration("series-step", 15*time.Minute, "resolution of the usage series")
+
+	addSizingFlags(inspectCmd)
 
 	rootCmd.AddCommand(inspectCmd)
 }
@@ -44,6 +50,21 @@ func runInspect(cmd *cobra.Command, args []string) error {
 	if p, _ := cmd.Flags().GetFloat64("percentile"); cmd.Flags().Changed("percentile") {
 		cfg.Metrics.Percentile = p
 	}
+	if p, _ := cmd.Flags().GetFloat64Slice("extra-percentiles"); cmd.Flags().Changed("extra-percentiles") {
+		cfg.Metrics.ExtraPercentiles = p
+	}
+	if err := applySizingFlags(cmd); err != nil {
+		return err
+	}
+	if s, _ := cmd.Flags().GetBool("series"); s {
+		cfg.Metrics.Series = true
+	}
+	if step, _ := cmd.Flags().GetDuration("series-step"); cmd.Flags().Changed("series-step") {
+		cfg.Metrics.SeriesStep = step
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
 
 	collector, cleanup, err := resolveCollector(ctx)
 	if err != nil {
@@ -66,8 +87,13 @@ func runInspect(cmd *cobra.Command, args []string) error {
 		},
 		ExcludeNamespaces: cfg.Metrics.ExcludeNamespaces,
 		Percentile:        cfg.Metrics.Percentile,
+		ExtraPercentiles:  cfg.Metrics.ExtraPercentiles,
 		StepInterval:      cfg.Metrics.Step,
 	}
+	opts.CPUSizing, opts.MemorySizing = orchestrator.SizingFromConfig(cfg.Metrics)
+	if cfg.Metrics.Series {
+		opts.SeriesStep = cfg.Metrics.SeriesStep
+	}
 
 	state, err := collector.Collect(ctx, opts)
 	if err != nil {
@@ -101,10 +127,19 @@ func runInspect(cmd *cobra.Command, args []string) error {
 	// Table output
 	_, _ = fmt.Fprintf(w, "Cluster: %s (%s)\n", state.ClusterName, state.Region)
 	_, _ = fmt.Fprintf(w, "Backend: %s\n", collector.BackendType())
-	_, _ = fmt.Fprintf(w, "Workloads: %d | DaemonSets: %d\n\n", len(state.Workloads), len(state.DaemonSets))
+	_, _ = fmt.Fprintf(w, "Workloads: %d (%d pods) | DaemonSets: %d\n", len(state.Workloads), state.WorkloadCount(), len(state.DaemonSets))
+	if state.ConstraintsSource != "" {
+		_, _ = fmt.Fprintf(w, "Constraints: %s (%d pinned pods)\n", state.ConstraintsSource, state.PinnedWorkloads())
+	} else {
+		_, _ = fmt.Fprintf(w, "Constraints: none collected\n")
+	}
+	if tl := state.Timeline(); tl != nil {
+		_, _ = fmt.Fprintf(w, "Series: %d controllers, %d steps of %s\n", len(tl.Series), tl.Steps(), tl.Step)
+	}
+	_, _ = fmt.Fprintf(w, "\n")
 
-	_, _ = fmt.Fprintf(w, "%-30s %-15s %8s %10s %8s %10s %s\n",
-		"POD", "NAMESPACE", "CPU(m)", "MEM(MiB)", "REQ_CPU", "REQ_MEM", "FLAGS")
+	_, _ = fmt.Fprintf(w, "%-30s %-15s %4s %8s %10s %8s %10s %s\n",
+		"WORKLOAD", "NAMESPACE", "PODS", "CPU(m)", "MEM(MiB)", "REQ_CPU", "REQ_MEM", "FLAGS")
 	_, _ = fmt.Fprintf(w, "%s\n", strings.Repeat("-", 100))
 
 	for _, wp := range state.Workloads {
@@ -115,10 +150,18 @@ func runInspect(cmd *cobra.Command, args []string) error {
 		if wp.IsDaemonSet {
 			flags += "[ds]"
 		}
+		if wp.IsPinned() {
+			flags += "[pinned"
+			if wp.Architecture != "" {
+				flags += ":" + string(wp.Architecture)
+			}
+			flags += "]"
+		}
 
-		_, _ = fmt.Fprintf(w, "%-30s %-15s %8d %10d %8d %10d %s\n",
+		_, _ = fmt.Fprintf(w, "%-30s %-15s %4d %8d %10d %8d %10d %s\n",
 			truncate(wp.Name, 30),
 			truncate(wp.Namespace, 15),
+			wp.ReplicaCount(),
 			wp.EffectiveCPUMillis,
 			wp.EffectiveMemoryBytes/(1024*1024),
 			wp.Requested.CPUMillis,
@@ -26,6 +26,8 @@ func init() {
 	f.String("sort-by", "price", "sort by: price, vcpu, memory, type")
 	f.Bool("include-spot", false, "include spot prices")
 	f.StringSlice("architectures", nil, "filter by architecture (amd64, arm64)")
+	f.Bool("no-cache", false, "disable caching")
+	f.String("export", "", "write the instance catalog to this file (JSON, or YAML for .yaml/.yml) for simulate and what-if --instance-catalog")
 
 	rootCmd.AddCommand(pricingCmd)
 }
@@ -33,7 +35,12 @@ func init() {
 func runPricing(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
-	provider, err := awspkg.NewAWSProvider(ctx, cfg.Cluster.Region, "")
+	cacheDir := ""
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); !noCache {
+		cacheDir = resolveCacheDir()
+	}
+
+	provider, err := awspkg.NewAWSProvider(ctx, cfg.Cluster.Region, cacheDir, providerOptions()...)
 	if err != nil {
 		return err
 	}
@@ -69,15 +76,18 @@ func runPricing(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Enrich with pricing
-	if _, err := provider.EnrichWithPricing(ctx, templates); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: could not fetch all pricing data: %v\n", err)
-	}
-
 	// Sort
 	sortBy, _ := cmd.Flags().GetString("sort-by")
 	sortTemplates(templates, sortBy)
 
+	if path, _ := cmd.Flags().GetString("export"); path != "" {
+		if err := awspkg.WriteCatalog(path, awspkg.NewInstanceCatalog(cfg.Cluster.Region, templates)); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d instance types in %s to %s\n", len(templates), cfg.Cluster.Region, path)
+		return nil
+	}
+
 	// Display
 	includeSpot, _ := cmd.Flags().GetBool("include-spot")
 
@@ -4,12 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	awspkg "github.com/guimove/clusterfit/internal/aws"
+	"github.com/guimove/clusterfit/internal/karpenter"
+	"github.com/guimove/clusterfit/internal/model"
 	"github.com/guimove/clusterfit/internal/orchestrator"
 )
 
@@ -25,19 +26,42 @@ a ranked list of instance type recommendations.`,
 func init() {
 	f := recommendCmd.Flags()
 	f.Duration("window", 7*24*time.Hour, "metrics lookback window")
-	f.Float64("percentile", 0.95, "percentile for sizing (0.0-1.0)")
+	f.Float64("percentile", 0.95, "percentile for sizing (0.0-1.0; 1.0 = max over the window)")
 	f.StringSlice("families", nil, "EC2 instance families to consider")
 	f.StringSlice("architectures", nil, "CPU architectures (amd64, arm64)")
 	f.Float64("spot-ratio", 0, "fraction of nodes to run as spot (0.0-1.0)")
+	f.StringSlice("zones", nil, "availability zones to spread nodes across (e.g. us-east-1a,us-east-1b)")
+	f.Int("zone-count", 0, "number of availability zones to spread nodes across (names derived from the region)")
 	f.StringSlice("exclude-namespaces", nil, "namespaces to exclude")
 	f.Int("top", 5, "number of recommendations to show")
 	f.String("output", "table", "output format: table, json, markdown")
 	f.String("output-file", "", "write output to file")
 	f.Bool("no-cache", false, "disable caching")
+	f.StringSlice("karpenter", nil, "Karpenter NodePool/EC2NodeClass YAML files or directories; simulates Karpenter provisioning")
+	f.String("algorithm", "best-fit-decreasing", "bin-packing algorithm: best-fit-decreasing, first-fit-decreasing, dot-product, local-search, or branch-and-bound")
+	f.Duration("exact-time-budget", 5*time.Second, "search time per scenario for the branch-and-bound algorithm")
+	f.Bool("cross-family", false, "also simulate mixed pools combining several instance families")
+	f.Bool("compare-packers", false, "also pack the top recommendations with every algorithm and show the node-count delta")
+	f.String("replicas", "current", "replica count to pack controllers at: current, min, typical (p95), or peak, from the observed and HPA replica range")
+	f.Bool("peak-aware", false, "pack by the combined usage of each node's pods over time, from per-pod usage series")
+	f.Float64("overcommit-percentile", 0.99, "peak-aware packing: share of timesteps a node's combined usage must fit its capacity")
+	f.Bool("replay", false, "replay autoscaling over the metrics window for the top recommendations")
+	f.Duration("replay-step", 15*time.Minute, "resolution of the autoscaling replay")
+	f.String("replay-policy", "cluster-autoscaler", "autoscaler to replay: cluster-autoscaler or karpenter")
+
+	addSizingFlags(recommendCmd)
 
 	rootCmd.AddCommand(recommendCmd)
 }
 
+// loadNodePools reads the configured Karpenter manifests, if any.
+func loadNodePools() ([]model.NodePool, error) {
+	if !cfg.Karpenter.Enabled() {
+		return nil, nil
+	}
+	return karpenter.LoadNodePools(cfg.Karpenter.Manifests)
+}
+
 func runRecommend(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
@@ -48,6 +72,9 @@ func runRecommend(cmd *cobra.Command, args []string) error {
 	if p, _ := cmd.Flags().GetFloat64("percentile"); cmd.Flags().Changed("percentile") {
 		cfg.Metrics.Percentile = p
 	}
+	if err := applySizingFlags(cmd); err != nil {
+		return err
+	}
 	if fam, _ := cmd.Flags().GetStringSlice("families"); len(fam) > 0 {
 		cfg.Instances.Families = fam
 	}
@@ -57,6 +84,12 @@ func runRecommend(cmd *cobra.Command, args []string) error {
 	if sr, _ := cmd.Flags().GetFloat64("spot-ratio"); cmd.Flags().Changed("spot-ratio") {
 		cfg.Simulation.SpotRatio = sr
 	}
+	if zones, _ := cmd.Flags().GetStringSlice("zones"); len(zones) > 0 {
+		cfg.Simulation.Zones = zones
+	}
+	if n, _ := cmd.Flags().GetInt("zone-count"); cmd.Flags().Changed("zone-count") {
+		cfg.Simulation.ZoneCount = n
+	}
 	if ns, _ := cmd.Flags().GetStringSlice("exclude-namespaces"); len(ns) > 0 {
 		cfg.Metrics.ExcludeNamespaces = ns
 	}
@@ -66,11 +99,49 @@ func runRecommend(cmd *cobra.Command, args []string) error {
 	if f, _ := cmd.Flags().GetString("output"); cmd.Flags().Changed("output") {
 		cfg.Output.Format = f
 	}
+	if k, _ := cmd.Flags().GetStringSlice("karpenter"); len(k) > 0 {
+		cfg.Karpenter.Manifests = k
+	}
+	if a, _ := cmd.Flags().GetString("algorithm"); cmd.Flags().Changed("algorithm") {
+		cfg.Simulation.Algorithm = a
+	}
+	if d, _ := cmd.Flags().GetDuration("exact-time-budget"); cmd.Flags().Changed("exact-time-budget") {
+		cfg.Simulation.ExactTimeBudget = d
+	}
+	if cf, _ := cmd.Flags().GetBool("cross-family"); cf {
+		cfg.Simulation.CrossFamily.Enabled = true
+	}
+	if c, _ := cmd.Flags().GetBool("compare-packers"); c {
+		cfg.Simulation.ComparePackers = true
+	}
+	if r, _ := cmd.Flags().GetString("replicas"); cmd.Flags().Changed("replicas") {
+		cfg.Simulation.Replicas = r
+	}
+	if pa, _ := cmd.Flags().GetBool("peak-aware"); pa {
+		cfg.Simulation.PeakAware = true
+	}
+	if p, _ := cmd.Flags().GetFloat64("overcommit-percentile"); cmd.Flags().Changed("overcommit-percentile") {
+		cfg.Simulation.OvercommitPercentile = p
+	}
+	if r, _ := cmd.Flags().GetBool("replay"); r {
+		cfg.Replay.Enabled = true
+	}
+	if step, _ := cmd.Flags().GetDuration("replay-step"); cmd.Flags().Changed("replay-step") {
+		cfg.Replay.Step = step
+	}
+	if p, _ := cmd.Flags().GetString("replay-policy"); cmd.Flags().Changed("replay-policy") {
+		cfg.Replay.Policy = p
+	}
 
 	if err := cfg.Validate(); err != nil {
 		return err
 	}
 
+	nodePools, err := loadNodePools()
+	if err != nil {
+		return err
+	}
+
 	// Create metrics collector
 	collector, cleanup, err := resolveCollector(ctx)
 	if err != nil {
@@ -88,11 +159,10 @@ func runRecommend(cmd *cobra.Command, args []string) error {
 	// Create AWS provider
 	cacheDir := ""
 	if noCache, _ := cmd.Flags().GetBool("no-cache"); !noCache {
-		home, _ := os.UserHomeDir()
-		cacheDir = filepath.Join(home, ".cache", "clusterfit")
+		cacheDir = resolveCacheDir()
 	}
 
-	provider, err := awspkg.NewAWSProvider(ctx, cfg.Cluster.Region, cacheDir)
+	provider, err := awspkg.NewAWSProvider(ctx, cfg.Cluster.Region, cacheDir, providerOptions()...)
 	if err != nil {
 		return fmt.Errorf("creating AWS provider: %w", err)
 	}
@@ -111,6 +181,7 @@ func runRecommend(cmd *cobra.Command, args []string) error {
 	// Run orchestrator
 	orch := orchestrator.New(collector, provider, cfg)
 	orch.Writer = w
+	orch.NodePools = nodePools
 
 	_, err = orch.Recommend(ctx)
 	return err
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/guimove/clusterfit/internal/model"
+	"github.com/guimove/clusterfit/internal/orchestrator"
+	"github.com/guimove/clusterfit/internal/report"
+)
+
+var rightsizeCmd = &cobra.Command{
+	Use:   "rightsize",
+	Short: "Recommend pod requests and limits, and the node savings of applying them",
+	Long: `Accepts a cluster state JSON file (from 'clusterfit inspect --output json'),
+proposes requests and limits for each controller from the observed usage of
+its pods, and re-runs the node simulation with the right-sized requests to
+compare the savings of fixing requests with those of changing instances.`,
+	RunE: runRightsize,
+}
+
+func init() {
+	f := rightsizeCmd.Flags()
+	f.String("input", "", "path to cluster state JSON file (required)")
+	f.String("baseline", "", "instance type the cluster runs on today (default: the best one for the current requests)")
+	f.StringSlice("instance-types", nil, "specific instance types to simulate")
+	f.String("instance-catalog", "", "instance catalog file (JSON or YAML) from 'clusterfit pricing --export'")
+	f.String("strategy", "both", "simulation strategy: homogeneous, mixed, or both")
+	f.Float64("cpu-percentile", 0.95, "CPU usage percentile the request covers (1.0 = max over the window)")
+	f.Float64("memory-percentile", 1.0, "memory usage percentile the request covers (1.0 = max over the window)")
+	f.Float64("cpu-headroom", 1.0, "multiplier on CPU usage for the request")
+	f.Float64("memory-headroom", 1.15, "multiplier on memory usage for the request")
+	f.Int("top", 20, "number of workloads to list (0 = all)")
+	f.String("output", "table", "output format: table, json, markdown")
+	addSizingFlags(rightsizeCmd)
+
+	_ = rightsizeCmd.MarkFlagRequired("input")
+	rootCmd.AddCommand(rightsizeCmd)
+}
+
+func runRightsize(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	inputPath, _ := cmd.Flags().GetString("input")
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("reading input file: %w", err)
+	}
+
+	var state model.ClusterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parsing cluster state: %w", err)
+	}
+
+	if strategy, _ := cmd.Flags().GetString("strategy"); strategy != "" {
+		cfg.Simulation.Strategy = strategy
+	}
+	if p, _ := cmd.Flags().GetFloat64("cpu-percentile"); cmd.Flags().Changed("cpu-percentile") {
+		cfg.Rightsize.CPU.Percentile = p
+	}
+	if p, _ := cmd.Flags().GetFloat64("memory-percentile"); cmd.Flags().Changed("memory-percentile") {
+		cfg.Rightsize.Memory.Percentile = p
+	}
+	if h, _ := cmd.Flags().GetFloat64("cpu-headroom"); cmd.Flags().Changed("cpu-headroom") {
+		cfg.Rightsize.CPU.Headroom = h
+	}
+	if h, _ := cmd.Flags().GetFloat64("memory-headroom"); cmd.Flags().Changed("memory-headroom") {
+		cfg.Rightsize.Memory.Headroom = h
+	}
+	if n, _ := cmd.Flags().GetInt("top"); cmd.Flags().Changed("top") {
+		cfg.Rightsize.Top = n
+	}
+	if f, _ := cmd.Flags().GetString("output"); cmd.Flags().Changed("output") {
+		cfg.Output.Format = f
+	}
+	if err := applySizingFlags(cmd); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	policy := orchestrator.RightsizePolicyFromConfig(cfg.Rightsize)
+	warnMissingPercentiles(&state, "rightsizing", policy.CPU.Percentile, policy.Memory.Percentile)
+	cpuSizing, memSizing := orchestrator.SizingFromConfig(cfg.Metrics)
+	warnMissingSizingPercentiles(&state, cpuSizing, memSizing)
+
+	templates, err := simulationTemplates(cmd, state.Region)
+	if err != nil {
+		return err
+	}
+	var baseline *model.NodeTemplate
+	if it, _ := cmd.Flags().GetString("baseline"); it != "" {
+		for i := range templates {
+			if templates[i].InstanceType == it {
+				baseline = &templates[i]
+				break
+			}
+		}
+		if baseline == nil {
+			return fmt.Errorf("unknown baseline instance type: %s", it)
+		}
+	}
+	if types, _ := cmd.Flags().GetStringSlice("instance-types"); len(types) > 0 {
+		if templates, err = filterTemplates(templates, types); err != nil {
+			return err
+		}
+	}
+
+	orch := &orchestrator.Orchestrator{Config: cfg, Writer: os.Stdout}
+	rr, err := orch.Rightsize(ctx, &state, templates, baseline)
+	if err != nil {
+		return err
+	}
+
+	meta := report.ReportMeta{
+		ClusterName:  state.ClusterName,
+		Region:       state.Region,
+		TotalPods:    state.WorkloadCount(),
+		TotalDaemons: len(state.DaemonSets),
+		Percentile:   cfg.Metrics.Percentile,
+		Sizing:       orchestrator.SizingDescription(cfg.Metrics),
+		MinNodes:     cfg.Simulation.MinNodes,
+		WindowStart:  state.MetricsWindow.Start,
+		WindowEnd:    state.MetricsWindow.End,
+	}
+	opts := report.RightsizeOptions{
+		Policy: policy,
+		Top:    cfg.Rightsize.Top,
+	}
+	return report.WriteRightsize(cfg.Output.Format, os.Stdout, rr, meta, opts)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/guimove/clusterfit/internal/config"
+	"github.com/guimove/clusterfit/internal/model"
 )
 
 var (
@@ -47,6 +48,7 @@ func init() {
 	rootCmd.PersistentFlags().String("kube-context", "", "Kubernetes context name")
 	rootCmd.PersistentFlags().BoolP("discover", "d", false, "auto-discover Prometheus endpoint from Kubernetes")
 	rootCmd.PersistentFlags().String("discovery-namespace", "", "limit service discovery to a namespace")
+	rootCmd.PersistentFlags().Bool("kube-constraints", false, "read pod node selectors and tolerations from the Kubernetes API")
 
 	_ = viper.BindPFlag("cluster.region", rootCmd.PersistentFlags().Lookup("region"))
 	_ = viper.BindPFlag("prometheus.url", rootCmd.PersistentFlags().Lookup("prometheus-url"))
@@ -54,6 +56,7 @@ func init() {
 	_ = viper.BindPFlag("kubernetes.context", rootCmd.PersistentFlags().Lookup("kube-context"))
 	_ = viper.BindPFlag("kubernetes.enabled", rootCmd.PersistentFlags().Lookup("discover"))
 	_ = viper.BindPFlag("kubernetes.discovery_namespace", rootCmd.PersistentFlags().Lookup("discovery-namespace"))
+	_ = viper.BindPFlag("kubernetes.collect_constraints", rootCmd.PersistentFlags().Lookup("kube-constraints"))
 }
 
 func loadConfig() error {
@@ -87,3 +90,54 @@ func loadConfig() error {
 
 	return cfg.Validate()
 }
+
+// addSizingFlags registers the per-resource sizing policy flags.
+func addSizingFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.String("cpu-sizing", "", "CPU sizing policy SOURCE[:PERCENTILE[:HEADROOM]], SOURCE one of request, usage, max, limit (e.g. usage:0.9)")
+	f.String("memory-sizing", "", "memory sizing policy SOURCE[:PERCENTILE[:HEADROOM]] (e.g. max:1.0:1.1)")
+}
+
+// applySizingFlags sets the sizing policies given by flag in the config.
+func applySizingFlags(cmd *cobra.Command) error {
+	for _, fl := range []struct {
+		name string
+		conf *config.SizingConf
+	}{
+		{"cpu-sizing", &cfg.Metrics.Sizing.CPU},
+		{"memory-sizing", &cfg.Metrics.Sizing.Memory},
+	} {
+		spec, _ := cmd.Flags().GetString(fl.name)
+		if !cmd.Flags().Changed(fl.name) {
+			continue
+		}
+		p, err := model.ParseSizingPolicy(spec)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", fl.name, err)
+		}
+		*fl.conf = config.SizingConf{Source: p.Source, Percentile: p.Percentile, Headroom: p.Headroom}
+	}
+	return nil
+}
+
+// warnMissingPercentiles warns about each CPU and memory usage percentile,
+// read for the given purpose, that the snapshot did not store, naming the
+// one used instead.
+func warnMissingPercentiles(state *model.ClusterState, purpose string, cpu, mem float64) {
+	for _, s := range state.MissingPercentiles(cpu, mem) {
+		fmt.Fprintf(os.Stderr, "Warning: the snapshot has no %s %s usage for %s; using %s instead\n",
+			s.Resource, model.PercentileLabel(s.Wanted), purpose, model.PercentileLabel(s.Used))
+	}
+}
+
+// warnMissingSizingPercentiles checks the usage percentiles of the sizing
+// policies. Policies sized by request read no usage and are not checked.
+func warnMissingSizingPercentiles(state *model.ClusterState, cpu, mem model.SizingPolicy) {
+	pct := func(p model.SizingPolicy) float64 {
+		if p.Source == model.SizeByRequest {
+			return 1 // Max is always stored
+		}
+		return p.Percentile
+	}
+	warnMissingPercentiles(state, "sizing", pct(cpu), pct(mem))
+}
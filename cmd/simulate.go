@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	awspkg "github.com/guimove/clusterfit/internal/aws"
 	"github.com/guimove/clusterfit/internal/model"
 	"github.com/guimove/clusterfit/internal/orchestrator"
 	"github.com/guimove/clusterfit/internal/report"
+	"github.com/guimove/clusterfit/internal/simulation"
 )
 
 var simulateCmd = &cobra.Command{
@@ -25,10 +28,25 @@ func init() {
 	f := simulateCmd.Flags()
 	f.String("input", "", "path to cluster state JSON file (required)")
 	f.StringSlice("instance-types", nil, "specific instance types to simulate")
+	f.String("instance-catalog", "", "instance catalog file (JSON or YAML) from 'clusterfit pricing --export'")
+	f.StringSlice("karpenter", nil, "Karpenter NodePool/EC2NodeClass YAML files or directories; simulates Karpenter provisioning")
 	f.String("strategy", "both", "simulation strategy: homogeneous, mixed, or both")
 	f.Float64("spot-ratio", 0, "fraction of nodes to run as spot")
+	f.StringSlice("zones", nil, "availability zones to spread nodes across (e.g. us-east-1a,us-east-1b)")
+	f.Int("zone-count", 0, "number of availability zones to spread nodes across (names derived from the region)")
 	f.String("output", "table", "output format: table, json, markdown")
 	f.Int("top", 5, "number of recommendations")
+	f.String("algorithm", "best-fit-decreasing", "bin-packing algorithm: best-fit-decreasing, first-fit-decreasing, dot-product, local-search, or branch-and-bound")
+	f.Duration("exact-time-budget", 5*time.Second, "search time per scenario for the branch-and-bound algorithm")
+	f.Bool("cross-family", false, "also simulate mixed pools combining several instance families")
+	f.Bool("compare-packers", false, "also pack the top recommendations with every algorithm and show the node-count delta")
+	f.String("replicas", "current", "replica count to pack controllers at: current, min, typical (p95), or peak, from the observed and HPA replica range")
+	f.Bool("peak-aware", false, "pack by the combined usage of each node's pods over time (needs a snapshot from 'inspect --series')")
+	f.Float64("overcommit-percentile", 0.99, "peak-aware packing: share of timesteps a node's combined usage must fit its capacity")
+	f.Bool("replay", false, "replay autoscaling for the top recommendations over the usage series of the snapshot (from 'inspect --series')")
+	f.String("replay-policy", "cluster-autoscaler", "autoscaler to replay: cluster-autoscaler or karpenter")
+
+	addSizingFlags(simulateCmd)
 
 	_ = simulateCmd.MarkFlagRequired("input")
 	rootCmd.AddCommand(simulateCmd)
@@ -54,18 +72,92 @@ func runSimulate(cmd *cobra.Command, args []string) error {
 	if sr, _ := cmd.Flags().GetFloat64("spot-ratio"); cmd.Flags().Changed("spot-ratio") {
 		cfg.Simulation.SpotRatio = sr
 	}
+	if zones, _ := cmd.Flags().GetStringSlice("zones"); len(zones) > 0 {
+		cfg.Simulation.Zones = zones
+	}
+	if n, _ := cmd.Flags().GetInt("zone-count"); cmd.Flags().Changed("zone-count") {
+		cfg.Simulation.ZoneCount = n
+	}
 	if f, _ := cmd.Flags().GetString("output"); cmd.Flags().Changed("output") {
 		cfg.Output.Format = f
 	}
 	if n, _ := cmd.Flags().GetInt("top"); cmd.Flags().Changed("top") {
 		cfg.Output.TopN = n
 	}
+	if k, _ := cmd.Flags().GetStringSlice("karpenter"); len(k) > 0 {
+		cfg.Karpenter.Manifests = k
+	}
+	if a, _ := cmd.Flags().GetString("algorithm"); cmd.Flags().Changed("algorithm") {
+		cfg.Simulation.Algorithm = a
+	}
+	if d, _ := cmd.Flags().GetDuration("exact-time-budget"); cmd.Flags().Changed("exact-time-budget") {
+		cfg.Simulation.ExactTimeBudget = d
+	}
+	if cf, _ := cmd.Flags().GetBool("cross-family"); cf {
+		cfg.Simulation.CrossFamily.Enabled = true
+	}
+	if c, _ := cmd.Flags().GetBool("compare-packers"); c {
+		cfg.Simulation.ComparePackers = true
+	}
+	if r, _ := cmd.Flags().GetString("replicas"); cmd.Flags().Changed("replicas") {
+		cfg.Simulation.Replicas = r
+	}
+	if pa, _ := cmd.Flags().GetBool("peak-aware"); pa {
+		cfg.Simulation.PeakAware = true
+	}
+	if p, _ := cmd.Flags().GetFloat64("overcommit-percentile"); cmd.Flags().Changed("overcommit-percentile") {
+		cfg.Simulation.OvercommitPercentile = p
+	}
+	if r, _ := cmd.Flags().GetBool("replay"); r {
+		cfg.Replay.Enabled = true
+	}
+	if p, _ := cmd.Flags().GetString("replay-policy"); cmd.Flags().Changed("replay-policy") {
+		cfg.Replay.Policy = p
+	}
 
-	// Build instance templates — in simulate mode, use simple predefined types
-	// or load from a separate file
-	templates := defaultSimulationTemplates()
+	if err := applySizingFlags(cmd); err != nil {
+		return err
+	}
 
-	orch := &orchestrator.Orchestrator{Config: cfg, Writer: os.Stdout}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Re-derive effective sizes from the stored usage under the configured
+	// sizing policies
+	if !cfg.Metrics.Sizing.IsZero() {
+		cpuSizing, memSizing := orchestrator.SizingFromConfig(cfg.Metrics)
+		warnMissingSizingPercentiles(&state, cpuSizing, memSizing)
+		if kept := state.Resize(cpuSizing, memSizing); kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d pods have no stored usage; keeping their effective sizes\n", kept)
+		}
+	}
+
+	// Pack controllers at the requested replica level
+	observed := state
+	if cfg.Simulation.Replicas != model.ReplicasCurrent {
+		if !state.HasReplicaRanges() {
+			fmt.Fprintf(os.Stderr, "Warning: the snapshot has no replica ranges; packing at current replicas\n")
+		}
+		state = state.AtReplicas(cfg.Simulation.Replicas)
+	}
+
+	nodePools, err := loadNodePools()
+	if err != nil {
+		return err
+	}
+
+	templates, err := simulationTemplates(cmd, state.Region)
+	if err != nil {
+		return err
+	}
+	if types, _ := cmd.Flags().GetStringSlice("instance-types"); len(types) > 0 {
+		if templates, err = filterTemplates(templates, types); err != nil {
+			return err
+		}
+	}
+
+	orch := &orchestrator.Orchestrator{Config: cfg, Writer: os.Stdout, NodePools: nodePools}
 	recs, err := orch.Simulate(ctx, &state, templates)
 	if err != nil {
 		return err
@@ -78,77 +170,136 @@ func runSimulate(cmd *cobra.Command, args []string) error {
 		TotalPods:    state.WorkloadCount(),
 		TotalDaemons: len(state.DaemonSets),
 		Percentile:   cfg.Metrics.Percentile,
+		Sizing:       orchestrator.SizingDescription(cfg.Metrics),
+		Replicas:     cfg.Simulation.Replicas,
+		MinNodes:     cfg.Simulation.MinNodes,
 		WindowStart:  state.MetricsWindow.Start,
 		WindowEnd:    state.MetricsWindow.End,
+		Warnings:     append(simulation.KarpenterWarnings(nodePools), orch.PackerWarnings(&state)...),
+		NodePools:    orch.NodePoolSuggestions(recs),
+	}
+	if len(recs) > 0 {
+		meta.Zones = recs[0].SimulationResult.InstanceConfig.Zones
+	}
+	if cfg.Replay.Enabled && len(recs) > 0 {
+		if meta.Replays, err = orch.ReplayState(ctx, &state, recs); err != nil {
+			return err
+		}
+	}
+	if cfg.Simulation.ComparePackers && len(recs) > 0 {
+		if meta.PackerComparisons, err = orch.ComparePackers(ctx, &state, recs); err != nil {
+			return err
+		}
+	}
+	if meta.ReplicaLevels, err = orch.ReplicaLevels(ctx, &observed, recs); err != nil {
+		return err
 	}
 
 	return reporter.Report(ctx, recs, meta)
 }
 
-// defaultSimulationTemplates returns a set of common instance types for offline simulation.
+// simulationTemplates returns the instance templates for offline commands:
+// the --instance-catalog file when given, the built-in set otherwise, with
+// spot interruption rates when configured.
+func simulationTemplates(cmd *cobra.Command, stateRegion string) ([]model.NodeTemplate, error) {
+	templates, err := catalogTemplates(cmd, stateRegion)
+	if err != nil {
+		return nil, err
+	}
+	region := stateRegion
+	if region == "" {
+		region = cfg.Cluster.Region
+	}
+	if err := orchestrator.ApplySpotInterruptions(cfg, region, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// catalogTemplates returns the --instance-catalog templates, or the built-in
+// set without one.
+func catalogTemplates(cmd *cobra.Command, stateRegion string) ([]model.NodeTemplate, error) {
+	path, _ := cmd.Flags().GetString("instance-catalog")
+	if path == "" {
+		return defaultSimulationTemplates(), nil
+	}
+
+	catalog, err := awspkg.LoadCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := catalog.Templates()
+	if err != nil {
+		return nil, err
+	}
+	if catalog.Region != "" && stateRegion != "" && catalog.Region != stateRegion {
+		fmt.Fprintf(os.Stderr, "Warning: instance catalog is for %s but the cluster is in %s; prices may not match\n",
+			catalog.Region, stateRegion)
+	}
+	return templates, nil
+}
+
+// defaultSimulationTemplates returns a set of common instance types for offline
+// simulation, priced at us-east-1 on-demand rates. Allocatable capacity and max
+// pods use the same EKS formulas as the live provider.
 func defaultSimulationTemplates() []model.NodeTemplate {
 	types := []struct {
 		name   string
-		family string
-		gen    int
-		size   string
 		vcpus  int32
 		memMiB int64
 		enis   int32
 		ipv4   int32
 		price  float64
-		arch   model.Architecture
 	}{
-		{"m5.large", "m5", 5, "large", 2, 8192, 3, 10, 0.096, model.ArchAMD64},
-		{"m5.xlarge", "m5", 5, "xlarge", 4, 16384, 4, 15, 0.192, model.ArchAMD64},
-		{"m5.2xlarge", "m5", 5, "2xlarge", 8, 32768, 4, 15, 0.384, model.ArchAMD64},
-		{"m5.4xlarge", "m5", 5, "4xlarge", 16, 65536, 8, 30, 0.768, model.ArchAMD64},
-		{"c5.large", "c5", 5, "large", 2, 4096, 3, 10, 0.085, model.ArchAMD64},
-		{"c5.xlarge", "c5", 5, "xlarge", 4, 8192, 4, 15, 0.170, model.ArchAMD64},
-		{"c5.2xlarge", "c5", 5, "2xlarge", 8, 16384, 4, 15, 0.340, model.ArchAMD64},
-		{"r5.large", "r5", 5, "large", 2, 16384, 3, 10, 0.126, model.ArchAMD64},
-		{"r5.xlarge", "r5", 5, "xlarge", 4, 32768, 4, 15, 0.252, model.ArchAMD64},
-		{"r5.2xlarge", "r5", 5, "2xlarge", 8, 65536, 4, 15, 0.504, model.ArchAMD64},
-		{"m6i.large", "m6i", 6, "large", 2, 8192, 3, 10, 0.096, model.ArchAMD64},
-		{"m6i.xlarge", "m6i", 6, "xlarge", 4, 16384, 4, 15, 0.192, model.ArchAMD64},
-		{"m6i.2xlarge", "m6i", 6, "2xlarge", 8, 32768, 4, 15, 0.384, model.ArchAMD64},
-		{"m7g.large", "m7g", 7, "large", 2, 8192, 3, 10, 0.0816, model.ArchARM64},
-		{"m7g.xlarge", "m7g", 7, "xlarge", 4, 16384, 4, 15, 0.1632, model.ArchARM64},
-		{"m7g.2xlarge", "m7g", 7, "2xlarge", 8, 32768, 4, 15, 0.3264, model.ArchARM64},
+		{"m5.large", 2, 8192, 3, 10, 0.096},
+		{"m5.xlarge", 4, 16384, 4, 15, 0.192},
+		{"m5.2xlarge", 8, 32768, 4, 15, 0.384},
+		{"m5.4xlarge", 16, 65536, 8, 30, 0.768},
+		{"c5.large", 2, 4096, 3, 10, 0.085},
+		{"c5.xlarge", 4, 8192, 4, 15, 0.170},
+		{"c5.2xlarge", 8, 16384, 4, 15, 0.340},
+		{"r5.large", 2, 16384, 3, 10, 0.126},
+		{"r5.xlarge", 4, 32768, 4, 15, 0.252},
+		{"r5.2xlarge", 8, 65536, 4, 15, 0.504},
+		{"m6i.large", 2, 8192, 3, 10, 0.096},
+		{"m6i.xlarge", 4, 16384, 4, 15, 0.192},
+		{"m6i.2xlarge", 8, 32768, 4, 15, 0.384},
+		{"m7g.large", 2, 8192, 3, 10, 0.0816},
+		{"m7g.xlarge", 4, 16384, 4, 15, 0.1632},
+		{"m7g.2xlarge", 8, 32768, 4, 15, 0.3264},
 	}
 
 	templates := make([]model.NodeTemplate, len(types))
 	for i, t := range types {
 		templates[i] = model.NodeTemplate{
-			InstanceType:           t.name,
-			InstanceFamily:         t.family,
-			Generation:             t.gen,
-			Size:                   t.size,
-			VCPUs:                  t.vcpus,
-			MemoryMiB:              t.memMiB,
-			MaxENIs:                t.enis,
-			IPv4PerENI:             t.ipv4,
-			MaxPods:                computeMaxPods(t.enis, t.ipv4),
-			OnDemandPricePerHour:   t.price,
-			Architecture:           t.arch,
-			CapacityType:           model.CapacityOnDemand,
-			CurrentGeneration:      true,
-			Region:                 "us-east-1",
-			AllocatableCPUMillis:   int64(t.vcpus)*1000 - 80,  // Simplified reservation
-			AllocatableMemoryBytes: t.memMiB*1024*1024 - 512*1024*1024, // Simplified
+			InstanceType:         t.name,
+			VCPUs:                t.vcpus,
+			MemoryMiB:            t.memMiB,
+			MaxENIs:              t.enis,
+			IPv4PerENI:           t.ipv4,
+			OnDemandPricePerHour: t.price,
+			CurrentGeneration:    true,
+			Region:               "us-east-1",
 		}
+		awspkg.CompleteTemplate(&templates[i])
 	}
 
 	return templates
 }
 
-func computeMaxPods(enis, ipv4 int32) int32 {
-	pods := enis*ipv4 - 1
-	if pods > 250 {
-		pods = 250
+// filterTemplates keeps the templates whose instance type is listed.
+func filterTemplates(templates []model.NodeTemplate, instanceTypes []string) ([]model.NodeTemplate, error) {
+	byType := make(map[string]model.NodeTemplate, len(templates))
+	for _, t := range templates {
+		byType[t.InstanceType] = t
 	}
-	if pods < 1 {
-		pods = 1
+	filtered := make([]model.NodeTemplate, 0, len(instanceTypes))
+	for _, it := range instanceTypes {
+		t, ok := byType[it]
+		if !ok {
+			return nil, fmt.Errorf("instance type %s is not in the instance catalog", it)
+		}
+		filtered = append(filtered, t)
 	}
-	return pods
+	return filtered, nil
 }
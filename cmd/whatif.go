@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/guimove/clusterfit/internal/model"
+	"github.com/guimove/clusterfit/internal/orchestrator"
 	"github.com/guimove/clusterfit/internal/simulation"
 )
 
@@ -27,6 +29,7 @@ func init() {
 	f.String("input", "", "path to cluster state JSON file (required)")
 	f.String("baseline", "", "baseline instance type (e.g., m5.xlarge)")
 	f.StringSlice("candidates", nil, "candidate instance types to compare")
+	f.String("instance-catalog", "", "instance catalog file (JSON or YAML) from 'clusterfit pricing --export'")
 	f.Float64("scale-factor", 1.0, "multiply workload count by this factor")
 	f.String("output", "table", "output format: table, json")
 
@@ -55,7 +58,10 @@ func runWhatIf(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build scenarios from baseline and candidates
-	allTemplates := defaultSimulationTemplates()
+	allTemplates, err := simulationTemplates(cmd, state.Region)
+	if err != nil {
+		return err
+	}
 	templateMap := make(map[string]model.NodeTemplate)
 	for _, t := range allTemplates {
 		templateMap[t.InstanceType] = t
@@ -93,10 +99,24 @@ func runWhatIf(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no valid scenarios to compare")
 	}
 
+	region := state.Region
+	if region == "" {
+		region = cfg.Cluster.Region
+	}
+	zones := cfg.Simulation.ZoneNames(region)
+	for i := range scenarios {
+		scenarios[i].Zones = zones
+	}
+
 	weights := model.DefaultScoringWeights()
-	packer := &simulation.BestFitDecreasing{}
+	packer := orchestrator.PackerFor(cfg.Simulation)
 	scorer := simulation.NewScorer(weights)
 	engine := simulation.NewEngine(packer, scorer)
+	commitments, warnings := orchestrator.ResolveCommitments(orchestrator.CommitmentsFromConfig(cfg.Commitments), allTemplates)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	engine.Commitments = commitments
 
 	recs, err := engine.RunAll(ctx, scenarios, state)
 	if err != nil {
@@ -111,16 +131,20 @@ func runWhatIf(cmd *cobra.Command, args []string) error {
 		return enc.Encode(recs)
 	}
 
-	fmt.Printf("\nWhat-If Comparison (%d workloads", len(state.Workloads))
+	fmt.Printf("\nWhat-If Comparison (%d pods", state.WorkloadCount())
 	if scaleFactor > 1.0 {
 		fmt.Printf(", scaled %.1fx", scaleFactor)
 	}
 	fmt.Printf(")\n")
 	fmt.Printf("%s\n\n", strings.Repeat("=", 80))
 
-	fmt.Printf("%-25s %6s %7s %7s %8s %6s\n",
+	committed := !commitments.IsZero()
+	fmt.Printf("%-25s %6s %7s %7s %8s %6s",
 		"Configuration", "Nodes", "CPU%", "Mem%", "$/month", "Score")
-	fmt.Printf("%s\n", strings.Repeat("-", 80))
+	if committed {
+		fmt.Printf(" %8s", "List")
+	}
+	fmt.Printf("\n%s\n", strings.Repeat("-", 80))
 
 	for _, rec := range recs {
 		sr := rec.SimulationResult
@@ -128,15 +152,18 @@ func runWhatIf(cmd *cobra.Command, args []string) error {
 		if strings.Contains(sr.InstanceConfig.Label(), "baseline") {
 			tag = " <--"
 		}
-		fmt.Printf("%-25s %6d %6.1f%% %6.1f%% %8.0f %6.1f%s\n",
+		fmt.Printf("%-25s %6d %6.1f%% %6.1f%% %8.0f %6.1f",
 			sr.InstanceConfig.Label(),
 			sr.TotalNodes,
 			sr.AvgCPUUtilization*100,
 			sr.AvgMemUtilization*100,
 			rec.MonthlyCost,
 			rec.OverallScore,
-			tag,
 		)
+		if committed {
+			fmt.Printf(" %8.0f", rec.ListMonthlyCost)
+		}
+		fmt.Printf("%s\n", tag)
 	}
 
 	fmt.Printf("%s\n", strings.Repeat("-", 80))
@@ -153,11 +180,21 @@ func runWhatIf(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// scaleWorkloads multiplies the pod count by factor: controllers get more
+// replicas, and single pods are duplicated round-robin.
 func scaleWorkloads(workloads []model.WorkloadProfile, factor float64) []model.WorkloadProfile {
-	target := int(float64(len(workloads)) * factor)
-	scaled := make([]model.WorkloadProfile, target)
+	var scaled, pods []model.WorkloadProfile
+	for i := range workloads {
+		w := &workloads[i]
+		if w.Replicas > 0 {
+			scaled = append(scaled, w.WithReplicas(int(math.Round(float64(w.Replicas)*factor))))
+		} else {
+			pods = append(pods, *w)
+		}
+	}
+	target := int(float64(len(pods)) * factor)
 	for i := 0; i < target; i++ {
-		scaled[i] = workloads[i%len(workloads)]
+		scaled = append(scaled, pods[i%len(pods)])
 	}
 	return scaled
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -13,6 +15,13 @@ type FileCache struct {
 	dir string
 }
 
+// CacheEntry describes one cached value on disk.
+type CacheEntry struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
 // NewFileCache creates a new file cache in the given directory.
 func NewFileCache(dir string) *FileCache {
 	return &FileCache{dir: dir}
@@ -80,6 +89,40 @@ func (fc *FileCache) Clear() error {
 	return nil
 }
 
+// Entries lists the cached values sorted by key.
+func (fc *FileCache) Entries() ([]CacheEntry, error) {
+	dirEntries, err := os.ReadDir(fc.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []CacheEntry
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, CacheEntry{
+			Key:     strings.TrimSuffix(e.Name(), ".json"),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// Dir returns the cache directory.
+func (fc *FileCache) Dir() string {
+	return fc.dir
+}
+
 func (fc *FileCache) path(key string) string {
 	return filepath.Join(fc.dir, key+".json")
 }
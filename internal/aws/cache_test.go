@@ -69,3 +69,27 @@ func TestFileCache_Clear(t *testing.T) {
 		t.Error("expected cache miss after clear")
 	}
 }
+
+func TestFileCache_Entries(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewFileCache(dir)
+
+	_ = cache.Set("pricing-us-east-1", map[string]int{"a": 1})
+	_ = cache.Set("instance-types-us-east-1", []string{"m5.large"})
+
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "instance-types-us-east-1" || entries[1].Key != "pricing-us-east-1" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Size == 0 {
+		t.Error("expected non-zero size")
+	}
+
+	empty, err := NewFileCache(dir + "/missing").Entries()
+	if err != nil || len(empty) != 0 {
+		t.Errorf("missing dir: entries=%v err=%v", empty, err)
+	}
+}
@@ -0,0 +1,196 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// InstanceCatalog is an instance type catalog file: the specs and prices of a
+// set of instance types, exported by `clusterfit pricing --export` and loaded
+// by simulate and what-if to run offline against real data. Files ending in
+// .yaml or .yml are YAML; anything else is JSON.
+type InstanceCatalog struct {
+	Region        string         `json:"region,omitempty" yaml:"region,omitempty"`
+	GeneratedAt   time.Time      `json:"generated_at,omitzero" yaml:"generated_at,omitempty"`
+	InstanceTypes []CatalogEntry `json:"instance_types" yaml:"instance_types"`
+}
+
+// CatalogEntry describes one instance type in a catalog. Family, generation
+// and size are derived from the type name, and max pods from the ENI limits.
+// Allocatable capacity is computed with the EKS kubelet reservation formulas
+// unless set explicitly, e.g. for AMIs with custom reservations.
+type CatalogEntry struct {
+	InstanceType           string             `json:"instance_type" yaml:"instance_type"`
+	Architecture           model.Architecture `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	VCPUs                  int32              `json:"vcpus" yaml:"vcpus"`
+	MemoryMiB              int64              `json:"memory_mib" yaml:"memory_mib"`
+	MaxENIs                int32              `json:"max_enis,omitempty" yaml:"max_enis,omitempty"`
+	IPv4PerENI             int32              `json:"ipv4_per_eni,omitempty" yaml:"ipv4_per_eni,omitempty"`
+	MaxPods                int32              `json:"max_pods,omitempty" yaml:"max_pods,omitempty"`
+	AllocatableCPUMillis   int64              `json:"allocatable_cpu_millis,omitempty" yaml:"allocatable_cpu_millis,omitempty"`
+	AllocatableMemoryBytes int64              `json:"allocatable_memory_bytes,omitempty" yaml:"allocatable_memory_bytes,omitempty"`
+	OnDemandPricePerHour   float64            `json:"on_demand_price" yaml:"on_demand_price"`
+	SpotPricePerHour       float64            `json:"spot_price,omitempty" yaml:"spot_price,omitempty"`
+	PreviousGeneration     bool               `json:"previous_generation,omitempty" yaml:"previous_generation,omitempty"`
+}
+
+// NewInstanceCatalog builds a catalog from node templates.
+func NewInstanceCatalog(region string, templates []model.NodeTemplate) InstanceCatalog {
+	c := InstanceCatalog{
+		Region:        region,
+		GeneratedAt:   time.Now().UTC(),
+		InstanceTypes: make([]CatalogEntry, len(templates)),
+	}
+	for i, t := range templates {
+		c.InstanceTypes[i] = CatalogEntry{
+			InstanceType:           t.InstanceType,
+			Architecture:           t.Architecture,
+			VCPUs:                  t.VCPUs,
+			MemoryMiB:              t.MemoryMiB,
+			MaxENIs:                t.MaxENIs,
+			IPv4PerENI:             t.IPv4PerENI,
+			MaxPods:                t.MaxPods,
+			AllocatableCPUMillis:   t.AllocatableCPUMillis,
+			AllocatableMemoryBytes: t.AllocatableMemoryBytes,
+			OnDemandPricePerHour:   t.OnDemandPricePerHour,
+			SpotPricePerHour:       t.SpotPricePerHour,
+			PreviousGeneration:     !t.CurrentGeneration,
+		}
+	}
+	return c
+}
+
+// Templates converts the catalog to node templates, filling in derived fields.
+func (c InstanceCatalog) Templates() ([]model.NodeTemplate, error) {
+	templates := make([]model.NodeTemplate, 0, len(c.InstanceTypes))
+	seen := make(map[string]bool, len(c.InstanceTypes))
+	for i, e := range c.InstanceTypes {
+		if e.InstanceType == "" {
+			return nil, fmt.Errorf("instance_types[%d]: instance_type is required", i)
+		}
+		if seen[e.InstanceType] {
+			return nil, fmt.Errorf("instance_types[%d]: duplicate instance type %s", i, e.InstanceType)
+		}
+		seen[e.InstanceType] = true
+		if e.VCPUs <= 0 || e.MemoryMiB <= 0 {
+			return nil, fmt.Errorf("%s: vcpus and memory_mib must be positive", e.InstanceType)
+		}
+		if e.OnDemandPricePerHour < 0 || e.SpotPricePerHour < 0 {
+			return nil, fmt.Errorf("%s: prices must not be negative", e.InstanceType)
+		}
+
+		t := model.NodeTemplate{
+			InstanceType:           e.InstanceType,
+			Architecture:           e.Architecture,
+			VCPUs:                  e.VCPUs,
+			MemoryMiB:              e.MemoryMiB,
+			MaxENIs:                e.MaxENIs,
+			IPv4PerENI:             e.IPv4PerENI,
+			MaxPods:                e.MaxPods,
+			AllocatableCPUMillis:   e.AllocatableCPUMillis,
+			AllocatableMemoryBytes: e.AllocatableMemoryBytes,
+			OnDemandPricePerHour:   e.OnDemandPricePerHour,
+			SpotPricePerHour:       e.SpotPricePerHour,
+			CapacityType:           model.CapacityOnDemand,
+			CurrentGeneration:      !e.PreviousGeneration,
+			Region:                 c.Region,
+		}
+		CompleteTemplate(&t)
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// CompleteTemplate fills in the fields of a hand-built template that the
+// live provider derives from DescribeInstanceTypes: family, generation and
+// size from the type name, architecture from the family (Graviton families
+// carry a "g" after the generation), max pods from the ENI limits, and
+// allocatable CPU and memory from the EKS kubelet reservation formulas.
+// Fields that are already set are kept.
+func CompleteTemplate(t *model.NodeTemplate) {
+	if t.InstanceFamily == "" {
+		t.InstanceFamily, t.Generation, t.Size = parseInstanceType(t.InstanceType)
+	}
+	if t.Architecture == "" {
+		t.Architecture = model.ArchAMD64
+		if m := instanceTypeRegex.FindStringSubmatch(t.InstanceType); len(m) >= 5 && strings.Contains(m[3], "g") {
+			t.Architecture = model.ArchARM64
+		}
+	}
+	if t.MaxPods == 0 {
+		t.MaxPods = ComputeMaxPods(t.MaxENIs, t.IPv4PerENI)
+	}
+	if t.AllocatableCPUMillis == 0 {
+		t.AllocatableCPUMillis = computeAllocatableCPU(t.VCPUs)
+	}
+	if t.AllocatableMemoryBytes == 0 {
+		t.AllocatableMemoryBytes = computeAllocatableMemory(t.MemoryMiB)
+	}
+	if t.CapacityType == "" {
+		t.CapacityType = model.CapacityOnDemand
+	}
+}
+
+// LoadCatalog reads an instance catalog file. Call Templates on the result
+// to validate it and build node templates.
+func LoadCatalog(path string) (InstanceCatalog, error) {
+	var c InstanceCatalog
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("reading instance catalog: %w", err)
+	}
+
+	if isYAMLPath(path) {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&c)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("parsing instance catalog %s: %w", path, err)
+	}
+	if len(c.InstanceTypes) == 0 {
+		return c, fmt.Errorf("instance catalog %s lists no instance types", path)
+	}
+	return c, nil
+}
+
+// WriteCatalog writes an instance catalog file, as YAML or JSON depending on
+// the file extension.
+func WriteCatalog(path string, c InstanceCatalog) error {
+	var data []byte
+	var err error
+	if isYAMLPath(path) {
+		data, err = yaml.Marshal(c)
+	} else {
+		data, err = json.MarshalIndent(c, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("encoding instance catalog: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing instance catalog: %w", err)
+	}
+	return nil
+}
+
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
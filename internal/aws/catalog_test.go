@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestCompleteTemplate(t *testing.T) {
+	tmpl := model.NodeTemplate{
+		InstanceType: "m7g.xlarge",
+		VCPUs:        4,
+		MemoryMiB:    16384,
+		MaxENIs:      4,
+		IPv4PerENI:   15,
+	}
+	CompleteTemplate(&tmpl)
+
+	if tmpl.InstanceFamily != "m7g" || tmpl.Generation != 7 || tmpl.Size != "xlarge" {
+		t.Errorf("family/generation/size = %s/%d/%s", tmpl.InstanceFamily, tmpl.Generation, tmpl.Size)
+	}
+	if tmpl.Architecture != model.ArchARM64 {
+		t.Errorf("architecture = %s, want arm64 for a Graviton family", tmpl.Architecture)
+	}
+	if tmpl.MaxPods != 59 {
+		t.Errorf("max pods = %d, want 58", tmpl.MaxPods)
+	}
+	if want := computeAllocatableCPU(4); tmpl.AllocatableCPUMillis != want {
+		t.Errorf("allocatable CPU = %d, want %d", tmpl.AllocatableCPUMillis, want)
+	}
+	if want := computeAllocatableMemory(16384); tmpl.AllocatableMemoryBytes != want {
+		t.Errorf("allocatable memory = %d, want %d", tmpl.AllocatableMemoryBytes, want)
+	}
+	if tmpl.CapacityType != model.CapacityOnDemand {
+		t.Errorf("capacity type = %s", tmpl.CapacityType)
+	}
+
+	amd := model.NodeTemplate{InstanceType: "c6gn.large"}
+	CompleteTemplate(&amd)
+	if amd.Architecture != model.ArchARM64 {
+		t.Errorf("c6gn architecture = %s, want arm64", amd.Architecture)
+	}
+	amd = model.NodeTemplate{InstanceType: "m7a.large", AllocatableCPUMillis: 1500}
+	CompleteTemplate(&amd)
+	if amd.Architecture != model.ArchAMD64 {
+		t.Errorf("m7a architecture = %s, want amd64", amd.Architecture)
+	}
+	if amd.AllocatableCPUMillis != 1500 {
+		t.Errorf("explicit allocatable CPU overwritten: %d", amd.AllocatableCPUMillis)
+	}
+}
+
+func TestCatalog_RoundTrip(t *testing.T) {
+	templates := []model.NodeTemplate{
+		{InstanceType: "m5.large", VCPUs: 2, MemoryMiB: 8192, MaxENIs: 3, IPv4PerENI: 10,
+			OnDemandPricePerHour: 0.096, SpotPricePerHour: 0.035, CurrentGeneration: true},
+		{InstanceType: "r6g.2xlarge", VCPUs: 8, MemoryMiB: 65536, MaxENIs: 4, IPv4PerENI: 15,
+			OnDemandPricePerHour: 0.4032, CurrentGeneration: true},
+	}
+	for i := range templates {
+		CompleteTemplate(&templates[i])
+		templates[i].Region = "eu-west-1"
+	}
+
+	for _, name := range []string{"catalog.json", "catalog.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := WriteCatalog(path, NewInstanceCatalog("eu-west-1", templates)); err != nil {
+				t.Fatal(err)
+			}
+			catalog, err := LoadCatalog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if catalog.Region != "eu-west-1" || catalog.GeneratedAt.IsZero() {
+				t.Errorf("catalog metadata = %q, %v", catalog.Region, catalog.GeneratedAt)
+			}
+			got, err := catalog.Templates()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(templates) {
+				t.Fatalf("got %d templates, want %d", len(got), len(templates))
+			}
+			for i := range got {
+				w, g := templates[i], got[i]
+				if g.InstanceType != w.InstanceType || g.InstanceFamily != w.InstanceFamily ||
+					g.Architecture != w.Architecture || g.MaxPods != w.MaxPods ||
+					g.AllocatableCPUMillis != w.AllocatableCPUMillis ||
+					g.AllocatableMemoryBytes != w.AllocatableMemoryBytes ||
+					g.OnDemandPricePerHour != w.OnDemandPricePerHour ||
+					g.SpotPricePerHour != w.SpotPricePerHour ||
+					g.CurrentGeneration != w.CurrentGeneration || g.Region != w.Region {
+					t.Errorf("template %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCatalog_HandWritten(t *testing.T) {
+	path := writeSheet(t, "catalog.yaml", `
+region: us-east-1
+instance_types:
+  - instance_type: c7i.xlarge
+    vcpus: 4
+    memory_mib: 8192
+    max_enis: 4
+    ipv4_per_eni: 15
+    on_demand_price: 0.1785
+`)
+	catalog, err := LoadCatalog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := catalog.Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := templates[0]
+	if got.InstanceFamily != "c7i" || got.MaxPods != 59 || got.Architecture != model.ArchAMD64 {
+		t.Errorf("derived fields = %+v", got)
+	}
+	if got.AllocatableCPUMillis != computeAllocatableCPU(4) || got.AllocatableMemoryBytes != computeAllocatableMemory(8192) {
+		t.Errorf("allocatable = %d millis, %d bytes; want EKS formulas", got.AllocatableCPUMillis, got.AllocatableMemoryBytes)
+	}
+	if !got.CurrentGeneration || got.Region != "us-east-1" {
+		t.Errorf("metadata = %+v", got)
+	}
+}
+
+func TestLoadCatalog_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty.json":     `{"instance_types": []}`,
+		"unknown.json":   `{"instance_types": [{"instance_type": "m5.large", "vcpu": 2}]}`,
+		"nocpu.json":     `{"instance_types": [{"instance_type": "m5.large", "memory_mib": 8192}]}`,
+		"duplicate.yaml": "instance_types:\n  - {instance_type: m5.large, vcpus: 2, memory_mib: 8192}\n  - {instance_type: m5.large, vcpus: 2, memory_mib: 8192}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			catalog, err := LoadCatalog(writeSheet(t, name, content))
+			if err == nil {
+				_, err = catalog.Templates()
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -25,6 +25,71 @@ const (
 
 // GetInstanceTypes retrieves EC2 instance types matching the filter.
 func (p *AWSProvider) GetInstanceTypes(ctx context.Context, filter InstanceFilter) ([]model.NodeTemplate, error) {
+	catalog, err := p.describeInstanceTypes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply client-side filters
+	var templates []model.NodeTemplate
+	familySet := toSet(filter.Families)
+	archSet := toArchSet(filter.Architectures)
+
+	for _, tmpl := range catalog {
+		// Family filter
+		if len(familySet) > 0 && !familySet[tmpl.InstanceFamily] {
+			continue
+		}
+
+		// Architecture filter
+		if len(archSet) > 0 && !archSet[tmpl.Architecture] {
+			continue
+		}
+
+		// vCPU range filter
+		if filter.MinVCPUs > 0 && tmpl.VCPUs < filter.MinVCPUs {
+			continue
+		}
+		if filter.MaxVCPUs > 0 && tmpl.VCPUs > filter.MaxVCPUs {
+			continue
+		}
+
+		templates = append(templates, tmpl)
+	}
+
+	if len(templates) == 0 {
+		return nil, ErrNoInstanceTypes
+	}
+
+	// Enrich with on-demand and spot pricing (best-effort — simulation
+	// works without prices but scoring/cost will be zero). Failures are
+	// collected in the provider's pricing summary for the report.
+	summary, err := p.EnrichWithPricing(ctx, templates)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pricing data: %w", err)
+	}
+	switch {
+	case summary.Priced == 0:
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: no pricing data found for %s (costs will show as $0)\n", p.region)
+	case len(summary.Failures) > 0:
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: pricing unavailable for %d of %d instance types (costs will show as $0)\n",
+			len(summary.Failures), summary.Requested)
+	}
+
+	return templates, nil
+}
+
+// describeInstanceTypes returns the region's instance type catalog narrowed by
+// the server-side filters (generation, bare metal, burstable). Catalogs are
+// cached per region and server-side filter; family, architecture and vCPU
+// filters are applied by the caller so one catalog serves every run.
+func (p *AWSProvider) describeInstanceTypes(ctx context.Context, filter InstanceFilter) ([]model.NodeTemplate, error) {
+	key := instanceTypesCacheKey(p.region, filter)
+	var catalog []model.NodeTemplate
+	if p.cacheGet(key, p.instanceTypesTTL, &catalog) && len(catalog) > 0 {
+		return catalog, nil
+	}
+
 	var filters []ec2types.Filter
 
 	if filter.CurrentGenerationOnly {
@@ -48,13 +113,12 @@ func (p *AWSProvider) GetInstanceTypes(ctx context.Context, filter InstanceFilte
 		})
 	}
 
-	var allTypes []ec2types.InstanceTypeInfo
 	var nextToken *string
 
 	for {
 		input := &ec2.DescribeInstanceTypesInput{
-			Filters:   filters,
-			NextToken: nextToken,
+			Filters:    filters,
+			NextToken:  nextToken,
 			MaxResults: aws.Int32(100),
 		}
 
@@ -63,7 +127,9 @@ func (p *AWSProvider) GetInstanceTypes(ctx context.Context, filter InstanceFilte
 			return nil, fmt.Errorf("describing instance types: %w", err)
 		}
 
-		allTypes = append(allTypes, output.InstanceTypes...)
+		for _, it := range output.InstanceTypes {
+			catalog = append(catalog, convertInstanceType(it, p.region))
+		}
 
 		if output.NextToken == nil {
 			break
@@ -71,49 +137,24 @@ func (p *AWSProvider) GetInstanceTypes(ctx context.Context, filter InstanceFilte
 		nextToken = output.NextToken
 	}
 
-	// Apply client-side filters and convert to model
-	var templates []model.NodeTemplate
-	familySet := toSet(filter.Families)
-	archSet := toArchSet(filter.Architectures)
-
-	for _, it := range allTypes {
-		tmpl := convertInstanceType(it, p.region)
-
-		// Family filter
-		if len(familySet) > 0 && !familySet[tmpl.InstanceFamily] {
-			continue
-		}
-
-		// Architecture filter
-		if len(archSet) > 0 && !archSet[tmpl.Architecture] {
-			continue
-		}
-
-		// vCPU range filter
-		if filter.MinVCPUs > 0 && tmpl.VCPUs < filter.MinVCPUs {
-			continue
-		}
-		if filter.MaxVCPUs > 0 && tmpl.VCPUs > filter.MaxVCPUs {
-			continue
-		}
+	p.cacheSet(key, catalog)
+	return catalog, nil
+}
 
-		templates = append(templates, tmpl)
+// instanceTypesCacheKey identifies a cached catalog by region and server-side filter,
+// e.g. "instance-types-us-east-1-current-no-metal-no-burstable".
+func instanceTypesCacheKey(region string, filter InstanceFilter) string {
+	key := "instance-types-" + region
+	if filter.CurrentGenerationOnly {
+		key += "-current"
 	}
-
-	if len(templates) == 0 {
-		return nil, ErrNoInstanceTypes
+	if filter.ExcludeBareMetal {
+		key += "-no-metal"
 	}
-
-	// Enrich with on-demand and spot pricing (best-effort — simulation
-	// works without prices but scoring/cost will be zero)
-	priced, err := p.EnrichWithPricing(ctx, templates)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: could not fetch pricing data: %v\n", err)
-	} else if priced == 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: no pricing data found for %s (costs will show as $0)\n", p.region)
+	if filter.ExcludeBurstable {
+		key += "-no-burstable"
 	}
-
-	return templates, nil
+	return key
 }
 
 // convertInstanceType maps an EC2 InstanceTypeInfo to our NodeTemplate.
@@ -0,0 +1,163 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// spotAdvisorBuckets are the Spot Instance Advisor's frequency of
+// interruption ranges, in index order, with the monthly rate used for each:
+// the middle of the range, and 25% for the open-ended top range.
+var spotAdvisorBuckets = []struct {
+	label string
+	rate  float64
+}{
+	{"<5%", 0.025},
+	{"5-10%", 0.075},
+	{"10-15%", 0.125},
+	{"15-20%", 0.175},
+	{">20%", 0.25},
+}
+
+// LoadSpotInterruptions reads the monthly spot interruption rate of each
+// instance type in a region from a local file, keyed by instance type.
+// Files ending in .json are the Spot Instance Advisor data
+// (https://spot-bid-advisor.s3.amazonaws.com/spot-advisor-data.json), of
+// which the Linux ranges of the region are used. Other files are sheets laid
+// out like a PriceSheetSource (CSV with a header row, or a YAML list, with
+// region-specific rows taking precedence) with these fields per row:
+//
+//	instance_type  EC2 instance type (required)
+//	interruption   Spot Advisor range such as "5-10%", or a percentage (required)
+//	region         region the row applies to (optional; empty = all regions)
+func LoadSpotInterruptions(path, region string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening spot interruption data: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" {
+		rates, err := parseSpotAdvisor(f, region)
+		if err != nil {
+			return nil, fmt.Errorf("parsing spot interruption data %s: %w", path, err)
+		}
+		return nonEmptyRates(rates, path, region)
+	}
+
+	rows, err := decodeSheet(f, ext, []string{"instance_type", "interruption"},
+		func(rec sheetRecord) (interruptionRow, error) {
+			return interruptionRow{
+				InstanceType: rec.field("instance_type"),
+				Interruption: rec.field("interruption"),
+				Region:       rec.field("region"),
+			}, nil
+		})
+	if errors.Is(err, errUnsupportedSheet) {
+		return nil, fmt.Errorf("spot interruption data %s: unsupported format (want .json, .csv, .yaml or .yml)", path)
+	}
+	var rates map[string]float64
+	if err == nil {
+		rates, err = interruptionRates(rows, region)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing spot interruption data %s: %w", path, err)
+	}
+	return nonEmptyRates(rates, path, region)
+}
+
+// nonEmptyRates returns the rates, or an error when the file had none for
+// the region, which usually means the wrong region or file.
+func nonEmptyRates(rates map[string]float64, path, region string) (map[string]float64, error) {
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("spot interruption data %s has no rates for %s", path, region)
+	}
+	return rates, nil
+}
+
+// ApplySpotInterruptions sets the spot interruption rate of the templates
+// whose instance type has one.
+func ApplySpotInterruptions(templates []model.NodeTemplate, rates map[string]float64) {
+	for i := range templates {
+		if rate, ok := rates[templates[i].InstanceType]; ok {
+			templates[i].SpotInterruptionRate = rate
+		}
+	}
+}
+
+// parseSpotAdvisor reads the region's Linux interruption ranges from Spot
+// Instance Advisor data.
+func parseSpotAdvisor(r io.Reader, region string) (map[string]float64, error) {
+	var data struct {
+		SpotAdvisor map[string]map[string]map[string]struct {
+			Range int `json:"r"`
+		} `json:"spot_advisor"`
+	}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64)
+	for it, advice := range data.SpotAdvisor[region]["Linux"] {
+		if advice.Range < 0 || advice.Range >= len(spotAdvisorBuckets) {
+			return nil, fmt.Errorf("%s: unknown interruption range %d", it, advice.Range)
+		}
+		rates[it] = spotAdvisorBuckets[advice.Range].rate
+	}
+	return rates, nil
+}
+
+// interruptionRow is one line of a spot interruption sheet.
+type interruptionRow struct {
+	InstanceType string `yaml:"instance_type"`
+	Interruption string `yaml:"interruption"`
+	Region       string `yaml:"region"`
+}
+
+// interruptionRates returns the region's rates from sheet rows.
+func interruptionRates(rows []interruptionRow, region string) (map[string]float64, error) {
+	parsed := make([]float64, len(rows))
+	for i, row := range rows {
+		if row.InstanceType == "" {
+			return nil, fmt.Errorf("row %d: instance_type is required", i+1)
+		}
+		rate, err := parseInterruption(row.Interruption)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		parsed[i] = rate
+	}
+
+	picked := regionalRows(len(rows), region, func(i int) (string, string) {
+		return rows[i].InstanceType, rows[i].Region
+	})
+	rates := make(map[string]float64, len(picked))
+	for it, i := range picked {
+		rates[it] = parsed[i]
+	}
+	return rates, nil
+}
+
+// parseInterruption converts a Spot Advisor range label, or a percentage
+// with or without a % sign, to a monthly rate.
+func parseInterruption(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	for _, b := range spotAdvisorBuckets {
+		if v == b.label {
+			return b.rate, nil
+		}
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil || pct < 0 || pct > 100 {
+		return 0, fmt.Errorf("interruption %q: want a Spot Advisor range such as \"5-10%%\" or a percentage", v)
+	}
+	return pct / 100, nil
+}
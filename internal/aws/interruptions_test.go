@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestLoadSpotInterruptions_SpotAdvisor(t *testing.T) {
+	path := writeSheet(t, "spot-advisor-data.json", `{
+  "ranges": [
+    {"index": 0, "label": "<5%", "dots": 0, "max": 5},
+    {"index": 4, "label": ">20%", "dots": 4, "max": 100}
+  ],
+  "spot_advisor": {
+    "us-east-1": {
+      "Linux": {"m5.large": {"s": 70, "r": 0}, "c5.xlarge": {"s": 60, "r": 4}},
+      "Windows": {"m5.large": {"s": 70, "r": 3}}
+    },
+    "eu-west-1": {"Linux": {"m5.large": {"s": 70, "r": 2}}}
+  }
+}`)
+
+	rates, err := LoadSpotInterruptions(path, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates["m5.large"] != 0.025 || rates["c5.xlarge"] != 0.25 {
+		t.Errorf("rates = %v, want m5.large at 2.5%% and c5.xlarge at 25%%", rates)
+	}
+
+	if _, err := LoadSpotInterruptions(path, "ap-south-1"); err == nil {
+		t.Error("expected an error for a region without data")
+	}
+}
+
+func TestLoadSpotInterruptions_Sheet(t *testing.T) {
+	csvPath := writeSheet(t, "interruptions.csv", `# hand-maintained
+instance_type,interruption,region
+m6i.large,5-10%,
+m6i.large,>20%,eu-west-3
+c6i.large,3,
+r6i.large,12.5%
+`)
+	rates, err := LoadSpotInterruptions(csvPath, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"m6i.large": 0.075, "c6i.large": 0.03, "r6i.large": 0.125}
+	for it, rate := range want {
+		if rates[it] != rate {
+			t.Errorf("%s = %v, want %v", it, rates[it], rate)
+		}
+	}
+	rates, err = LoadSpotInterruptions(csvPath, "eu-west-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates["m6i.large"] != 0.25 {
+		t.Errorf("eu-west-3 m6i.large = %v, want the regional row (25%%)", rates["m6i.large"])
+	}
+
+	yamlPath := writeSheet(t, "interruptions.yaml", `
+- instance_type: m7g.large
+  interruption: "<5%"
+`)
+	rates, err = LoadSpotInterruptions(yamlPath, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates := []model.NodeTemplate{{InstanceType: "m7g.large"}, {InstanceType: "m7g.xlarge"}}
+	ApplySpotInterruptions(templates, rates)
+	if templates[0].SpotInterruptionRate != 0.025 || templates[1].SpotInterruptionRate != 0 {
+		t.Errorf("rates = %v/%v, want 0.025 and unknown", templates[0].SpotInterruptionRate, templates[1].SpotInterruptionRate)
+	}
+}
+
+func TestLoadSpotInterruptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+	}{
+		{"bad range", "i.csv", "instance_type,interruption\nm5.large,often\n"},
+		{"over 100%", "i.csv", "instance_type,interruption\nm5.large,120\n"},
+		{"missing column", "i.csv", "instance_type,rate\nm5.large,5\n"},
+		{"unknown advisor range", "i.json", `{"spot_advisor": {"us-east-1": {"Linux": {"m5.large": {"r": 7}}}}}`},
+		{"unsupported format", "i.txt", "m5.large 5%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadSpotInterruptions(writeSheet(t, tt.file, tt.content), "us-east-1"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
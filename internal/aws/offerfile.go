@@ -0,0 +1,236 @@
+package aws
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OfferFileSource prices instance types from a copy of the AWS Price List
+// bulk offer file for EC2 (offers/v1.0/aws/AmazonEC2/current/<region>/index.json),
+// read from disk so that pricing works without internet access. Gzipped files
+// (".gz") are decompressed on the fly. The offer file carries no spot prices;
+// spot capacity is costed at on-demand rates.
+//
+// Only Linux, shared-tenancy, no-preinstalled-software on-demand rates are
+// used. The file is parsed once per region and streamed, so the multi-hundred
+// megabyte regional offers are never held in memory whole.
+type OfferFileSource struct {
+	path string
+
+	mu       sync.Mutex
+	byRegion map[string]map[string]InstancePrice
+}
+
+// NewOfferFileSource creates a source reading the offer file at path.
+func NewOfferFileSource(path string) *OfferFileSource {
+	return &OfferFileSource{path: path, byRegion: make(map[string]map[string]InstancePrice)}
+}
+
+// Name implements PriceSource.
+func (s *OfferFileSource) Name() string { return "offer file " + s.path }
+
+// Cacheable implements PriceSource.
+func (s *OfferFileSource) Cacheable() bool { return false }
+
+// Prices implements PriceSource.
+func (s *OfferFileSource) Prices(_ context.Context, region string, instanceTypes []string) (map[string]InstancePrice, []PricingFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.byRegion[region]
+	if !ok {
+		var err error
+		table, err = s.load(region)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.byRegion[region] = table
+	}
+	prices, failures := lookupPrices(table, instanceTypes)
+	return prices, failures, nil
+}
+
+func (s *OfferFileSource) load(region string) (map[string]InstancePrice, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening offer file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if strings.HasSuffix(s.path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing offer file: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	table, err := parseOfferFile(r, region)
+	if err != nil {
+		return nil, fmt.Errorf("parsing offer file %s: %w", s.path, err)
+	}
+	if len(table) == 0 {
+		return nil, fmt.Errorf("offer file %s has no Linux on-demand prices for %s", s.path, region)
+	}
+	return table, nil
+}
+
+// offerProduct is one SKU of the "products" section of an offer file.
+type offerProduct struct {
+	ProductFamily string `json:"productFamily"`
+	Attributes    struct {
+		InstanceType    string `json:"instanceType"`
+		RegionCode      string `json:"regionCode"`
+		OperatingSystem string `json:"operatingSystem"`
+		Tenancy         string `json:"tenancy"`
+		PreInstalledSW  string `json:"preInstalledSw"`
+		CapacityStatus  string `json:"capacitystatus"`
+		Operation       string `json:"operation"`
+	} `json:"attributes"`
+}
+
+// matches reports whether the SKU is a plain Linux instance in the region.
+// Attributes missing from older offer files are not checked.
+func (p *offerProduct) matches(region string) bool {
+	a := p.Attributes
+	if p.ProductFamily != "Compute Instance" || a.InstanceType == "" {
+		return false
+	}
+	if a.RegionCode != "" && a.RegionCode != region {
+		return false
+	}
+	return a.OperatingSystem == "Linux" &&
+		a.Tenancy == "Shared" &&
+		(a.PreInstalledSW == "" || a.PreInstalledSW == "NA") &&
+		(a.CapacityStatus == "" || a.CapacityStatus == "Used") &&
+		(a.Operation == "" || a.Operation == "RunInstances")
+}
+
+// offerTerm is one pricing term of a SKU in the "terms" section.
+type offerTerm struct {
+	PriceDimensions map[string]struct {
+		Unit         string            `json:"unit"`
+		PricePerUnit map[string]string `json:"pricePerUnit"`
+	} `json:"priceDimensions"`
+}
+
+// hourlyUSD returns the term's hourly USD rate, or 0 if it has none.
+func (t *offerTerm) hourlyUSD() float64 {
+	for _, d := range t.PriceDimensions {
+		if d.Unit != "Hrs" {
+			continue
+		}
+		if v, err := strconv.ParseFloat(d.PricePerUnit["USD"], 64); err == nil && v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// parseOfferFile streams an EC2 offer file, returning the on-demand Linux
+// price of every instance type in the region. The "products" section precedes
+// "terms" in published files, so matching SKUs are known when terms are read.
+func parseOfferFile(r io.Reader, region string) (map[string]InstancePrice, error) {
+	dec := json.NewDecoder(r)
+	skus := make(map[string]string) // SKU → instance type
+	table := make(map[string]InstancePrice)
+
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "products":
+			return decodeObject(dec, func(sku string) error {
+				var p offerProduct
+				if err := dec.Decode(&p); err != nil {
+					return err
+				}
+				if p.matches(region) {
+					skus[sku] = p.Attributes.InstanceType
+				}
+				return nil
+			})
+		case "terms":
+			return decodeObject(dec, func(kind string) error {
+				if kind != "OnDemand" {
+					return skipValue(dec)
+				}
+				return decodeObject(dec, func(sku string) error {
+					instanceType, ok := skus[sku]
+					if !ok {
+						return skipValue(dec)
+					}
+					var terms map[string]offerTerm
+					if err := dec.Decode(&terms); err != nil {
+						return err
+					}
+					for _, t := range terms {
+						// Several SKUs can describe the same type; keep the cheapest.
+						price := t.hourlyUSD()
+						if cur, seen := table[instanceType]; price > 0 && (!seen || price < cur.OnDemand) {
+							table[instanceType] = InstancePrice{OnDemand: price}
+						}
+					}
+					return nil
+				})
+			})
+		default:
+			return skipValue(dec)
+		}
+	})
+	return table, err
+}
+
+// decodeObject reads a JSON object from dec, calling fn for each key with the
+// decoder positioned at the value. fn must consume the value.
+func decodeObject(dec *json.Decoder, fn func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("expected object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, got %v", tok)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token() // closing '}'
+	return err
+}
+
+// skipValue consumes the next JSON value from dec without decoding it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
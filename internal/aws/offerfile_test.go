@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// offerFixture is a trimmed EC2 offer file: m5.large has a Linux SKU plus
+// Windows and dedicated SKUs that must be ignored, m5.xlarge a Linux SKU in
+// another region, and Reserved terms precede OnDemand ones.
+const offerFixture = `{
+  "formatVersion": "v1.0",
+  "offerCode": "AmazonEC2",
+  "products": {
+    "LINUX1": {"sku": "LINUX1", "productFamily": "Compute Instance", "attributes": {
+      "instanceType": "m5.large", "regionCode": "us-east-1", "operatingSystem": "Linux",
+      "tenancy": "Shared", "preInstalledSw": "NA", "capacitystatus": "Used", "operation": "RunInstances"}},
+    "WIN1": {"sku": "WIN1", "productFamily": "Compute Instance", "attributes": {
+      "instanceType": "m5.large", "regionCode": "us-east-1", "operatingSystem": "Windows",
+      "tenancy": "Shared", "preInstalledSw": "NA", "capacitystatus": "Used", "operation": "RunInstances:0002"}},
+    "DED1": {"sku": "DED1", "productFamily": "Compute Instance", "attributes": {
+      "instanceType": "m5.large", "regionCode": "us-east-1", "operatingSystem": "Linux",
+      "tenancy": "Dedicated", "preInstalledSw": "NA", "capacitystatus": "Used", "operation": "RunInstances"}},
+    "WEST1": {"sku": "WEST1", "productFamily": "Compute Instance", "attributes": {
+      "instanceType": "m5.xlarge", "regionCode": "us-west-2", "operatingSystem": "Linux",
+      "tenancy": "Shared", "preInstalledSw": "NA", "capacitystatus": "Used", "operation": "RunInstances"}},
+    "EBS1": {"sku": "EBS1", "productFamily": "Storage", "attributes": {"volumeType": "gp3"}}
+  },
+  "terms": {
+    "Reserved": {
+      "LINUX1": {"LINUX1.RES": {"priceDimensions": {"LINUX1.RES.D": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0600000000"}}}}}
+    },
+    "OnDemand": {
+      "LINUX1": {"LINUX1.OD": {"priceDimensions": {"LINUX1.OD.D": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0960000000"}}}}},
+      "WIN1": {"WIN1.OD": {"priceDimensions": {"WIN1.OD.D": {"unit": "Hrs", "pricePerUnit": {"USD": "0.1880000000"}}}}},
+      "DED1": {"DED1.OD": {"priceDimensions": {"DED1.OD.D": {"unit": "Hrs", "pricePerUnit": {"USD": "0.1060000000"}}}}},
+      "WEST1": {"WEST1.OD": {"priceDimensions": {"WEST1.OD.D": {"unit": "Hrs", "pricePerUnit": {"USD": "0.1920000000"}}}}}
+    }
+  }
+}`
+
+func TestParseOfferFile(t *testing.T) {
+	table, err := parseOfferFile(strings.NewReader(offerFixture), "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 1 || table["m5.large"].OnDemand != 0.096 {
+		t.Errorf("table = %v, want only m5.large at the Linux on-demand rate 0.096", table)
+	}
+
+	west, err := parseOfferFile(strings.NewReader(offerFixture), "us-west-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if west["m5.xlarge"].OnDemand != 0.192 {
+		t.Errorf("us-west-2 table = %v, want m5.xlarge at 0.192", west)
+	}
+
+	if _, err := parseOfferFile(strings.NewReader(`{"products": [`), "us-east-1"); err == nil {
+		t.Error("expected an error for a truncated offer file")
+	}
+}
+
+func TestOfferFileSource_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(offerFixture)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newAWSProvider(&fakeEC2{}, "us-east-1", t.TempDir(), WithPriceSource(NewOfferFileSource(path)))
+	templates := []model.NodeTemplate{{InstanceType: "m5.large"}, {InstanceType: "m5.xlarge"}}
+	summary, err := p.EnrichWithPricing(context.Background(), templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if templates[0].OnDemandPricePerHour != 0.096 || templates[0].SpotPricePerHour != 0 {
+		t.Errorf("m5.large prices = %v/%v, want 0.096 on-demand and no spot",
+			templates[0].OnDemandPricePerHour, templates[0].SpotPricePerHour)
+	}
+	if len(summary.Failures) != 1 || summary.Failures[0].InstanceType != "m5.xlarge" ||
+		!errors.Is(summary.Failures[0].Err, errNoPricingData) {
+		t.Errorf("failures = %+v, want m5.xlarge without pricing data", summary.Failures)
+	}
+
+	// Local sources are never written to the pricing cache
+	if entries, _ := p.cache.Entries(); len(entries) != 0 {
+		t.Errorf("expected no cache entries, got %v", entries)
+	}
+}
+
+func TestOfferFileSource_MissingFile(t *testing.T) {
+	src := NewOfferFileSource(filepath.Join(t.TempDir(), "missing.json"))
+	if _, _, err := src.Prices(context.Background(), "us-east-1", []string{"m5.large"}); err == nil {
+		t.Error("expected an error for a missing offer file")
+	}
+}
@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PriceSheetSource prices instance types from a static price sheet, for
+// negotiated rates or air-gapped environments. The sheet is a CSV file with
+// a header row, or a YAML list, with these fields per row:
+//
+//	instance_type  EC2 instance type (required)
+//	on_demand      hourly on-demand price in USD (required)
+//	spot           hourly spot price in USD (optional)
+//	region         region the row applies to (optional; empty = all regions)
+//
+// A region-specific row takes precedence over a row without a region.
+type PriceSheetSource struct {
+	path string
+
+	once sync.Once
+	rows []priceSheetRow
+	err  error
+}
+
+// priceSheetRow is one line of a price sheet.
+type priceSheetRow struct {
+	InstanceType string  `yaml:"instance_type"`
+	OnDemand     float64 `yaml:"on_demand"`
+	Spot         float64 `yaml:"spot"`
+	Region       string  `yaml:"region"`
+}
+
+// NewPriceSheetSource creates a source reading the CSV or YAML sheet at path.
+func NewPriceSheetSource(path string) *PriceSheetSource {
+	return &PriceSheetSource{path: path}
+}
+
+// Name implements PriceSource.
+func (s *PriceSheetSource) Name() string { return "price sheet " + s.path }
+
+// Cacheable implements PriceSource.
+func (s *PriceSheetSource) Cacheable() bool { return false }
+
+// Prices implements PriceSource.
+func (s *PriceSheetSource) Prices(_ context.Context, region string, instanceTypes []string) (map[string]InstancePrice, []PricingFailure, error) {
+	s.once.Do(func() {
+		s.rows, s.err = s.load()
+	})
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+
+	table := make(map[string]InstancePrice)
+	picked := regionalRows(len(s.rows), region, func(i int) (string, string) {
+		return s.rows[i].InstanceType, s.rows[i].Region
+	})
+	for it, i := range picked {
+		table[it] = InstancePrice{OnDemand: s.rows[i].OnDemand, Spot: s.rows[i].Spot}
+	}
+	prices, failures := lookupPrices(table, instanceTypes)
+	return prices, failures, nil
+}
+
+func (s *PriceSheetSource) load() ([]priceSheetRow, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening price sheet: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	rows, err := decodeSheet(f, strings.ToLower(filepath.Ext(s.path)),
+		[]string{"instance_type", "on_demand"}, parsePriceSheetRecord)
+	if errors.Is(err, errUnsupportedSheet) {
+		return nil, fmt.Errorf("price sheet %s: unsupported format (want .csv, .yaml or .yml)", s.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing price sheet %s: %w", s.path, err)
+	}
+
+	for i, row := range rows {
+		if row.InstanceType == "" || row.OnDemand <= 0 || row.Spot < 0 {
+			return nil, fmt.Errorf("price sheet %s: row %d needs an instance_type and a positive on_demand price", s.path, i+1)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("price sheet %s has no prices", s.path)
+	}
+	return rows, nil
+}
+
+// parsePriceSheetRecord converts a CSV price sheet record to a row.
+func parsePriceSheetRecord(rec sheetRecord) (priceSheetRow, error) {
+	price := func(name string) (float64, error) {
+		v := rec.field(name)
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(v, 64)
+	}
+	onDemand, err := price("on_demand")
+	if err != nil {
+		return priceSheetRow{}, fmt.Errorf("on_demand: %w", err)
+	}
+	spot, err := price("spot")
+	if err != nil {
+		return priceSheetRow{}, fmt.Errorf("spot: %w", err)
+	}
+	return priceSheetRow{
+		InstanceType: rec.field("instance_type"),
+		OnDemand:     onDemand,
+		Spot:         spot,
+		Region:       rec.field("region"),
+	}, nil
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSheet(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPriceSheetSource_CSV(t *testing.T) {
+	path := writeSheet(t, "prices.csv", `# negotiated rates
+region,instance_type,on_demand,spot
+,m5.large,0.090,0.030
+us-east-1,m5.large,0.080,
+eu-west-1,m5.xlarge,0.200,0.070
+,c5.large,0.075
+`)
+	src := NewPriceSheetSource(path)
+
+	prices, failures, err := src.Prices(context.Background(), "us-east-1", []string{"m5.large", "m5.xlarge", "c5.large"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prices["m5.large"]; got.OnDemand != 0.080 || got.Spot != 0 {
+		t.Errorf("m5.large = %+v, want the us-east-1 row (0.080, no spot)", got)
+	}
+	if got := prices["c5.large"]; got.OnDemand != 0.075 {
+		t.Errorf("c5.large = %+v, want the all-regions row", got)
+	}
+	if len(failures) != 1 || failures[0].InstanceType != "m5.xlarge" {
+		t.Errorf("failures = %+v, want m5.xlarge (only priced in eu-west-1)", failures)
+	}
+
+	prices, _, err = src.Prices(context.Background(), "eu-west-1", []string{"m5.large", "m5.xlarge"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices["m5.large"].OnDemand != 0.090 || prices["m5.xlarge"].Spot != 0.070 {
+		t.Errorf("eu-west-1 prices = %+v", prices)
+	}
+}
+
+func TestPriceSheetSource_YAML(t *testing.T) {
+	path := writeSheet(t, "prices.yaml", `
+- instance_type: m6i.large
+  on_demand: 0.096
+  spot: 0.035
+- instance_type: m6i.large
+  region: ap-south-1
+  on_demand: 0.101
+`)
+	prices, failures, err := NewPriceSheetSource(path).Prices(context.Background(), "ap-south-1", []string{"m6i.large"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 0 || prices["m6i.large"].OnDemand != 0.101 {
+		t.Errorf("prices = %+v, failures = %+v; want the ap-south-1 row", prices, failures)
+	}
+}
+
+func TestPriceSheetSource_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"prices.csv":  "instance_type,spot\nm5.large,0.03\n",
+		"bad.csv":     "instance_type,on_demand\nm5.large,cheap\n",
+		"zero.yaml":   "- instance_type: m5.large\n",
+		"prices.json": "[]",
+	}
+	for name, content := range tests {
+		src := NewPriceSheetSource(writeSheet(t, name, content))
+		if _, _, err := src.Prices(context.Background(), "us-east-1", []string{"m5.large"}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
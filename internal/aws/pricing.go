@@ -2,102 +2,206 @@ package aws
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/guimove/clusterfit/internal/model"
 )
 
-const (
-	// pricingAPIBase is the public EC2 pricing API (no auth required).
-	pricingAPIBase = "https://go.runs-on.com/api/instances"
-
-	// pricingHTTPTimeout is the timeout for each pricing HTTP request.
-	pricingHTTPTimeout = 10 * time.Second
-)
+// InstancePrice holds the hourly on-demand and spot prices for one instance
+// type. A zero spot price means spot pricing is unknown.
+type InstancePrice struct {
+	OnDemand float64
+	Spot     float64
+}
 
-// instancePricing holds the resolved on-demand and spot prices for one instance type.
-type instancePricing struct {
-	OnDemandPrice float64
-	SpotPrice     float64
+// PriceSource looks up EC2 prices for instance types in a region.
+type PriceSource interface {
+	// Name identifies the source in messages, e.g. "runs-on".
+	Name() string
+
+	// Cacheable reports whether prices should be stored in the pricing cache.
+	// Remote sources are cached; local files are cheap to re-read and must
+	// reflect edits immediately.
+	Cacheable() bool
+
+	// Prices returns the prices of the given instance types. Types the source
+	// cannot price are returned as failures; the error is reserved for
+	// problems that affect every type, such as an unreadable file or a
+	// cancelled context.
+	Prices(ctx context.Context, region string, instanceTypes []string) (map[string]InstancePrice, []PricingFailure, error)
 }
 
-// pricingAPIResult maps the runs-on API response fields we need.
-type pricingAPIResult struct {
-	InstanceType  string  `json:"instanceType"`
-	OnDemandPrice float64 `json:"onDemandPrice"`
-	SpotPrice     float64 `json:"spotPrice"`
+// PricingFailure records an instance type whose price could not be fetched.
+type PricingFailure struct {
+	InstanceType string `json:"instance_type"`
+	Attempts     int    `json:"attempts"`
+	Err          error  `json:"-"`
 }
 
-type pricingAPIResponse struct {
-	Results []pricingAPIResult `json:"results"`
+// PricingSummary describes the outcome of pricing enrichment, so that callers
+// can tell fully priced results from ones with missing ($0) prices.
+type PricingSummary struct {
+	Source    string           `json:"source"`
+	Requested int              `json:"requested"`
+	Priced    int              `json:"priced"` // instance types with an on-demand price
+	FromCache int              `json:"from_cache"`
+	Failures  []PricingFailure `json:"failures,omitempty"`
 }
 
-// EnrichWithPricing adds on-demand and spot prices to a slice of NodeTemplates
-// using the public runs-on.com pricing API (no AWS credentials required).
-// Returns the number of templates that got on-demand pricing.
-func (p *AWSProvider) EnrichWithPricing(ctx context.Context, templates []model.NodeTemplate) (int, error) {
-	client := &http.Client{Timeout: pricingHTTPTimeout}
-	priced := 0
+// maxListedFailures limits how many failed instance types a warning names.
+const maxListedFailures = 5
 
-	for i := range templates {
-		pricing, err := fetchInstancePrice(ctx, client, templates[i].InstanceType, p.region)
-		if err != nil {
-			continue
+// Warnings renders the failures as human-readable report warnings.
+// It returns nil when every instance type was priced.
+func (s PricingSummary) Warnings() []string {
+	if len(s.Failures) == 0 {
+		return nil
+	}
+	details := make([]string, 0, maxListedFailures)
+	for i, f := range s.Failures {
+		if i == maxListedFailures {
+			details = append(details, fmt.Sprintf("and %d more", len(s.Failures)-maxListedFailures))
+			break
 		}
-		if pricing.OnDemandPrice > 0 {
-			templates[i].OnDemandPricePerHour = pricing.OnDemandPrice
-			priced++
-		}
-		if pricing.SpotPrice > 0 {
-			templates[i].SpotPricePerHour = pricing.SpotPrice
+		if f.Attempts > 1 {
+			details = append(details, fmt.Sprintf("%s (%v after %d attempts)", f.InstanceType, f.Err, f.Attempts))
+		} else {
+			details = append(details, fmt.Sprintf("%s (%v)", f.InstanceType, f.Err))
 		}
 	}
+	source := ""
+	if s.Source != "" {
+		source = " from " + s.Source
+	}
+	return []string{
+		fmt.Sprintf("Pricing unavailable%s for %d of %d instance types; they are costed at $0 and may rank too high",
+			source, len(s.Failures), s.Requested),
+		"Unpriced: " + strings.Join(details, ", "),
+	}
+}
 
-	return priced, nil
+// errNoPricingData means the source knows no price for the instance type in the region.
+var errNoPricingData = errors.New("no pricing data")
+
+// cachedPrice is one instance type's prices as stored in the pricing cache.
+type cachedPrice struct {
+	OnDemandPrice float64   `json:"on_demand"`
+	SpotPrice     float64   `json:"spot"`
+	FetchedAt     time.Time `json:"fetched_at"`
 }
 
-// fetchInstancePrice queries the public pricing API for a single instance type.
-// Returns both on-demand and lowest spot price across AZs.
-func fetchInstancePrice(ctx context.Context, client *http.Client, instanceType, region string) (*instancePricing, error) {
-	url := fmt.Sprintf("%s/%s?region=%s&platform=Linux/UNIX", pricingAPIBase, instanceType, region)
+// pricingCacheKey identifies the cached prices of a region.
+func pricingCacheKey(region string) string {
+	return "pricing-" + region
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// EnrichWithPricing adds on-demand and spot prices to a slice of NodeTemplates
+// using the provider's price source (the public runs-on.com API by default).
+// Prices from cacheable sources are cached per region; only instance types
+// missing from the cache or older than the pricing TTL are looked up.
+// The returned summary lists every instance type left without a price; the
+// error is non-nil only when the source fails as a whole.
+func (p *AWSProvider) EnrichWithPricing(ctx context.Context, templates []model.NodeTemplate) (PricingSummary, error) {
+	cacheable := p.priceSource.Cacheable()
+	prices := make(map[string]cachedPrice)
+	if cacheable {
+		prices = p.loadCachedPrices()
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	var requested, missing []string
+	seen := make(map[string]bool)
+	for i := range templates {
+		it := templates[i].InstanceType
+		if seen[it] {
+			continue
+		}
+		seen[it] = true
+		requested = append(requested, it)
+		if _, ok := prices[it]; !ok {
+			missing = append(missing, it)
+		}
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("pricing API returned %d for %s", resp.StatusCode, instanceType)
+	summary := PricingSummary{
+		Source:    p.priceSource.Name(),
+		Requested: len(requested),
+		FromCache: len(requested) - len(missing),
 	}
 
-	var pr pricingAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
-		return nil, err
+	if len(missing) > 0 {
+		fresh, failures, err := p.priceSource.Prices(ctx, p.region, missing)
+		if err != nil {
+			return summary, fmt.Errorf("%s: %w", p.priceSource.Name(), err)
+		}
+		now := time.Now()
+		for it, price := range fresh {
+			prices[it] = cachedPrice{
+				OnDemandPrice: price.OnDemand,
+				SpotPrice:     price.Spot,
+				FetchedAt:     now,
+			}
+		}
+		summary.Failures = failures
+		sort.Slice(summary.Failures, func(i, j int) bool {
+			return summary.Failures[i].InstanceType < summary.Failures[j].InstanceType
+		})
+		if cacheable && len(fresh) > 0 {
+			p.cacheSet(pricingCacheKey(p.region), prices)
+		}
 	}
 
-	if len(pr.Results) == 0 {
-		return nil, fmt.Errorf("no pricing data for %s in %s", instanceType, region)
+	pricedTypes := make(map[string]bool)
+	for i := range templates {
+		pricing, ok := prices[templates[i].InstanceType]
+		if !ok {
+			continue
+		}
+		if pricing.OnDemandPrice > 0 {
+			templates[i].OnDemandPricePerHour = pricing.OnDemandPrice
+			pricedTypes[templates[i].InstanceType] = true
+		}
+		if pricing.SpotPrice > 0 {
+			templates[i].SpotPricePerHour = pricing.SpotPrice
+		}
 	}
+	summary.Priced = len(pricedTypes)
+
+	p.recordPricing(requested, pricedTypes, summary.Failures)
+	return summary, nil
+}
 
-	// On-demand is the same across AZs; for spot, pick the lowest
-	result := &instancePricing{
-		OnDemandPrice: pr.Results[0].OnDemandPrice,
-		SpotPrice:     pr.Results[0].SpotPrice,
+// loadCachedPrices returns the region's cached prices, dropping entries older
+// than the pricing TTL. The cache file is rewritten whenever new prices are
+// fetched, so expiry is tracked per entry rather than by file age.
+func (p *AWSProvider) loadCachedPrices() map[string]cachedPrice {
+	prices := make(map[string]cachedPrice)
+	if !p.cacheGet(pricingCacheKey(p.region), p.pricingTTL, &prices) {
+		return make(map[string]cachedPrice)
 	}
-	for _, r := range pr.Results[1:] {
-		if r.SpotPrice > 0 && (result.SpotPrice == 0 || r.SpotPrice < result.SpotPrice) {
-			result.SpotPrice = r.SpotPrice
+	for it, cp := range prices {
+		if time.Since(cp.FetchedAt) > p.pricingTTL {
+			delete(prices, it)
 		}
 	}
+	return prices
+}
 
-	return result, nil
+// lookupPrices picks the requested instance types out of a price table,
+// reporting those the table lacks. Local sources use it to answer Prices.
+func lookupPrices(table map[string]InstancePrice, instanceTypes []string) (map[string]InstancePrice, []PricingFailure) {
+	prices := make(map[string]InstancePrice, len(instanceTypes))
+	var failures []PricingFailure
+	for _, it := range instanceTypes {
+		price, ok := table[it]
+		if !ok {
+			failures = append(failures, PricingFailure{InstanceType: it, Attempts: 1, Err: errNoPricingData})
+			continue
+		}
+		prices[it] = price
+	}
+	return prices, failures
 }
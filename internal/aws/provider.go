@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"sync"
 	"time"
 
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -15,6 +18,14 @@ import (
 
 const credentialCheckTimeout = 3 * time.Second
 
+const (
+	// DefaultInstanceTypesTTL is how long EC2 instance type catalogs are cached.
+	DefaultInstanceTypesTTL = 7 * 24 * time.Hour
+
+	// DefaultPricingTTL is how long on-demand and spot prices are cached.
+	DefaultPricingTTL = 24 * time.Hour
+)
+
 var (
 	ErrAWSCredentials  = errors.New("AWS credentials not found; set AWS_PROFILE, run 'aws sso login', or configure ~/.aws/credentials")
 	ErrNoInstanceTypes = errors.New("no instance types match the specified filters")
@@ -24,6 +35,10 @@ var (
 type PricingProvider interface {
 	GetInstanceTypes(ctx context.Context, filter InstanceFilter) ([]model.NodeTemplate, error)
 	Region() string
+
+	// PricingSummary reports instance types that could not be priced across
+	// all GetInstanceTypes calls so far.
+	PricingSummary() PricingSummary
 }
 
 // InstanceFilter constrains which instance types to consider.
@@ -43,17 +58,62 @@ type ec2API interface {
 }
 
 // AWSProvider implements PricingProvider using the AWS SDK for instance types
-// and the public runs-on.com API for pricing (no pricing:GetProducts permission needed).
+// and a PriceSource for pricing — by default the public runs-on.com API, so no
+// pricing:GetProducts permission is needed.
 type AWSProvider struct {
 	ec2Client ec2API
 	region    string
 	cache     *FileCache
+
+	instanceTypesTTL time.Duration
+	pricingTTL       time.Duration
+	refreshCache     bool // ignore cached values but still store fresh ones
+	priceSource      PriceSource
+
+	// Pricing outcomes accumulated across GetInstanceTypes calls
+	mu              sync.Mutex
+	requestedTypes  map[string]bool
+	pricedTypes     map[string]bool
+	pricingFailures map[string]PricingFailure
+}
+
+// ProviderOption configures the AWS provider.
+type ProviderOption func(*AWSProvider)
+
+// WithCacheTTLs sets how long instance type catalogs and prices are cached.
+// Zero keeps the default.
+func WithCacheTTLs(instanceTypes, pricing time.Duration) ProviderOption {
+	return func(p *AWSProvider) {
+		if instanceTypes > 0 {
+			p.instanceTypesTTL = instanceTypes
+		}
+		if pricing > 0 {
+			p.pricingTTL = pricing
+		}
+	}
+}
+
+// WithCacheRefresh bypasses cached values and overwrites them with fresh data.
+func WithCacheRefresh() ProviderOption {
+	return func(p *AWSProvider) {
+		p.refreshCache = true
+	}
+}
+
+// WithPriceSource replaces the default runs-on.com price source.
+func WithPriceSource(src PriceSource) ProviderOption {
+	return func(p *AWSProvider) {
+		if src != nil {
+			p.priceSource = src
+		}
+	}
 }
 
 // NewAWSProvider creates a provider using the default AWS SDK config chain.
 // IMDS (EC2 metadata) is disabled to avoid long timeouts when running locally.
 // On EC2, use environment variables or instance profile via AWS_PROFILE.
-func NewAWSProvider(ctx context.Context, region string, cacheDir string) (*AWSProvider, error) {
+// An empty cacheDir disables caching.
+func NewAWSProvider(ctx context.Context, region string, cacheDir string, opts ...ProviderOption) (*AWSProvider, error) {
 	cfg, err := awsconfig.LoadDefaultConfig(ctx,
 		awsconfig.WithRegion(region),
 		awsconfig.WithEC2IMDSClientEnableState(imds.ClientDisabled),
@@ -69,16 +129,86 @@ func NewAWSProvider(ctx context.Context, region string, cacheDir string) (*AWSPr
 		return nil, ErrAWSCredentials
 	}
 
+	return newAWSProvider(ec2.NewFromConfig(cfg), region, cacheDir, opts...), nil
+}
+
+// newAWSProvider wires a provider around an EC2 client.
+func newAWSProvider(client ec2API, region string, cacheDir string, opts ...ProviderOption) *AWSProvider {
 	var cache *FileCache
 	if cacheDir != "" {
 		cache = NewFileCache(cacheDir)
 	}
 
-	return &AWSProvider{
-		ec2Client: ec2.NewFromConfig(cfg),
-		region:    region,
-		cache:     cache,
-	}, nil
+	p := &AWSProvider{
+		ec2Client:        client,
+		region:           region,
+		cache:            cache,
+		instanceTypesTTL: DefaultInstanceTypesTTL,
+		pricingTTL:       DefaultPricingTTL,
+		priceSource:      NewRunsOnSource(),
+		requestedTypes:   make(map[string]bool),
+		pricedTypes:      make(map[string]bool),
+		pricingFailures:  make(map[string]PricingFailure),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// cacheGet reads a cached value unless caching is disabled or being refreshed.
+func (p *AWSProvider) cacheGet(key string, ttl time.Duration, dest interface{}) bool {
+	if p.cache == nil || p.refreshCache {
+		return false
+	}
+	return p.cache.Get(key, ttl, dest)
+}
+
+// cacheSet stores a value, warning (but not failing) when the cache is unwritable.
+func (p *AWSProvider) cacheSet(key string, value interface{}) {
+	if p.cache == nil {
+		return
+	}
+	if err := p.cache.Set(key, value); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: could not write cache %s: %v\n", key, err)
+	}
+}
+
+// recordPricing merges one enrichment outcome into the provider-wide summary.
+// An instance type priced by a later call is no longer reported as failed.
+func (p *AWSProvider) recordPricing(requested []string, priced map[string]bool, failures []PricingFailure) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, it := range requested {
+		p.requestedTypes[it] = true
+		delete(p.pricingFailures, it)
+		if priced[it] {
+			p.pricedTypes[it] = true
+		}
+	}
+	for _, f := range failures {
+		if !p.pricedTypes[f.InstanceType] {
+			p.pricingFailures[f.InstanceType] = f
+		}
+	}
+}
+
+// PricingSummary reports the instance types that could not be priced across
+// all GetInstanceTypes calls so far.
+func (p *AWSProvider) PricingSummary() PricingSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := PricingSummary{
+		Requested: len(p.requestedTypes),
+		Priced:    len(p.pricedTypes),
+	}
+	for _, f := range p.pricingFailures {
+		s.Failures = append(s.Failures, f)
+	}
+	sort.Slice(s.Failures, func(i, j int) bool {
+		return s.Failures[i].InstanceType < s.Failures[j].InstanceType
+	})
+	return s
 }
 
 // Region returns the AWS region.
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeEC2 serves a fixed instance type list and counts DescribeInstanceTypes calls.
+type fakeEC2 struct {
+	calls int
+}
+
+func (f *fakeEC2) DescribeInstanceTypes(_ context.Context, _ *ec2.DescribeInstanceTypesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	f.calls++
+	it := func(name string, vcpus int32, memMiB int64) ec2types.InstanceTypeInfo {
+		return ec2types.InstanceTypeInfo{
+			InstanceType:      ec2types.InstanceType(name),
+			CurrentGeneration: aws.Bool(true),
+			VCpuInfo:          &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(vcpus)},
+			MemoryInfo:        &ec2types.MemoryInfo{SizeInMiB: aws.Int64(memMiB)},
+			ProcessorInfo: &ec2types.ProcessorInfo{
+				SupportedArchitectures: []ec2types.ArchitectureType{ec2types.ArchitectureTypeX8664},
+			},
+		}
+	}
+	return &ec2.DescribeInstanceTypesOutput{InstanceTypes: []ec2types.InstanceTypeInfo{
+		it("m5.large", 2, 8192),
+		it("m5.xlarge", 4, 16384),
+	}}, nil
+}
+
+// newPricingServer returns a pricing API stub and a counter of requests served.
+func newPricingServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		instanceType := strings.TrimPrefix(r.URL.Path, "/")
+		_ = json.NewEncoder(w).Encode(pricingAPIResponse{Results: []pricingAPIResult{
+			{InstanceType: instanceType, OnDemandPrice: 0.1, SpotPrice: 0.04},
+		}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestAWSProvider_CachesCatalogAndPricing(t *testing.T) {
+	dir := t.TempDir()
+	srv, hits := newPricingServer(t)
+	ec2Client := &fakeEC2{}
+
+	newProvider := func(opts ...ProviderOption) *AWSProvider {
+		opts = append(opts, WithPriceSource(newTestRunsOnSource(srv.URL)))
+		return newAWSProvider(ec2Client, "us-east-1", dir, opts...)
+	}
+
+	filter := InstanceFilter{CurrentGenerationOnly: true}
+	templates, err := newProvider().GetInstanceTypes(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0].OnDemandPricePerHour != 0.1 {
+		t.Fatalf("unexpected templates: %+v", templates)
+	}
+	if ec2Client.calls != 1 || *hits != 2 {
+		t.Fatalf("first run: %d EC2 calls, %d pricing calls; want 1 and 2", ec2Client.calls, *hits)
+	}
+
+	// Second run is served from the cache, including family-filtered lookups
+	filter.Families = []string{"m5"}
+	templates, err = newProvider().GetInstanceTypes(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if templates[1].SpotPricePerHour != 0.04 {
+		t.Errorf("cached spot price = %v, want 0.04", templates[1].SpotPricePerHour)
+	}
+	if ec2Client.calls != 1 || *hits != 2 {
+		t.Errorf("cached run: %d EC2 calls, %d pricing calls; want no new calls", ec2Client.calls, *hits)
+	}
+
+	// Refresh bypasses the cache
+	if _, err := newProvider(WithCacheRefresh()).GetInstanceTypes(context.Background(), filter); err != nil {
+		t.Fatal(err)
+	}
+	if ec2Client.calls != 2 || *hits != 4 {
+		t.Errorf("refresh: %d EC2 calls, %d pricing calls; want 2 and 4", ec2Client.calls, *hits)
+	}
+}
+
+func TestAWSProvider_PricingTTLIndependent(t *testing.T) {
+	dir := t.TempDir()
+	srv, hits := newPricingServer(t)
+	p := newAWSProvider(&fakeEC2{}, "eu-west-1", dir, WithCacheTTLs(time.Hour, time.Minute),
+		WithPriceSource(newTestRunsOnSource(srv.URL)))
+
+	// Seed one fresh and one stale price
+	if err := p.cache.Set(pricingCacheKey("eu-west-1"), map[string]cachedPrice{
+		"m5.large":  {OnDemandPrice: 0.2, FetchedAt: time.Now()},
+		"m5.xlarge": {OnDemandPrice: 0.2, FetchedAt: time.Now().Add(-time.Hour)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := p.GetInstanceTypes(context.Background(), InstanceFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *hits != 1 {
+		t.Errorf("expected only the stale price to be re-fetched, got %d calls", *hits)
+	}
+	if templates[0].OnDemandPricePerHour != 0.2 || templates[1].OnDemandPricePerHour != 0.1 {
+		t.Errorf("prices = %v/%v, want cached 0.2 and refreshed 0.1",
+			templates[0].OnDemandPricePerHour, templates[1].OnDemandPricePerHour)
+	}
+}
+
+func TestInstanceTypesCacheKey(t *testing.T) {
+	got := instanceTypesCacheKey("us-west-2", InstanceFilter{
+		CurrentGenerationOnly: true,
+		ExcludeBareMetal:      true,
+		Families:              []string{"m7g"}, // client-side, not part of the key
+	})
+	if got != "instance-types-us-west-2-current-no-metal" {
+		t.Errorf("key = %q", got)
+	}
+}
@@ -0,0 +1,264 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// pricingAPIBase is the public EC2 pricing API (no auth required).
+	pricingAPIBase = "https://go.runs-on.com/api/instances"
+
+	// pricingHTTPTimeout is the timeout for each pricing HTTP request.
+	pricingHTTPTimeout = 10 * time.Second
+
+	// pricingWorkers bounds the number of concurrent pricing requests.
+	pricingWorkers = 8
+
+	// pricingRequestsPerSecond caps the request rate across all workers.
+	pricingRequestsPerSecond = 20
+
+	// pricingMaxAttempts is how many times a throttled or failing request is tried.
+	pricingMaxAttempts = 4
+
+	// pricingBaseBackoff is the delay before the first retry; it doubles per attempt.
+	pricingBaseBackoff = 500 * time.Millisecond
+
+	// pricingMaxBackoff caps a single retry delay, including Retry-After hints.
+	pricingMaxBackoff = 30 * time.Second
+)
+
+// RunsOnSource prices instance types one request at a time against the
+// public runs-on.com API, which needs no AWS credentials and also returns
+// spot prices. Requests go through a bounded, rate-limited worker pool that
+// retries throttled (429) and server (5xx) errors with backoff.
+type RunsOnSource struct {
+	baseURL string
+	workers int
+	rps     float64
+	backoff time.Duration
+}
+
+// NewRunsOnSource creates a source for the public runs-on.com pricing API.
+func NewRunsOnSource() *RunsOnSource {
+	return &RunsOnSource{
+		baseURL: pricingAPIBase,
+		workers: pricingWorkers,
+		rps:     pricingRequestsPerSecond,
+		backoff: pricingBaseBackoff,
+	}
+}
+
+// Name implements PriceSource.
+func (s *RunsOnSource) Name() string { return "runs-on" }
+
+// Cacheable implements PriceSource.
+func (s *RunsOnSource) Cacheable() bool { return true }
+
+// Prices implements PriceSource. Only a cancelled context is returned as an error.
+func (s *RunsOnSource) Prices(ctx context.Context, region string, instanceTypes []string) (map[string]InstancePrice, []PricingFailure, error) {
+	results := s.fetchAll(ctx, region, instanceTypes)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	prices := make(map[string]InstancePrice, len(instanceTypes))
+	var failures []PricingFailure
+	for i, res := range results {
+		if res.err != nil {
+			failures = append(failures, PricingFailure{
+				InstanceType: instanceTypes[i],
+				Attempts:     res.attempts,
+				Err:          res.err,
+			})
+			continue
+		}
+		prices[instanceTypes[i]] = *res.price
+	}
+	return prices, failures, nil
+}
+
+// pricingStatusError is a non-200 response from the pricing API.
+type pricingStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // from the Retry-After header, 0 if absent
+}
+
+func (e *pricingStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// retryablePricingError reports whether a failed request is worth retrying:
+// throttling, server errors and transport failures are; missing data is not.
+func retryablePricingError(err error) bool {
+	var se *pricingStatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// pricingAPIResult maps the runs-on API response fields we need.
+type pricingAPIResult struct {
+	InstanceType  string  `json:"instanceType"`
+	OnDemandPrice float64 `json:"onDemandPrice"`
+	SpotPrice     float64 `json:"spotPrice"`
+}
+
+type pricingAPIResponse struct {
+	Results []pricingAPIResult `json:"results"`
+}
+
+// fetchResult is the outcome of pricing one instance type.
+type fetchResult struct {
+	price    *InstancePrice
+	attempts int
+	err      error
+}
+
+// fetchAll prices the given instance types concurrently. Results are
+// returned in input order.
+func (s *RunsOnSource) fetchAll(ctx context.Context, region string, instanceTypes []string) []fetchResult {
+	results := make([]fetchResult, len(instanceTypes))
+	if len(instanceTypes) == 0 {
+		return results
+	}
+
+	client := &http.Client{Timeout: pricingHTTPTimeout}
+	limiter := rate.NewLimiter(rate.Limit(s.rps), 1)
+
+	workers := s.workers
+	if workers > len(instanceTypes) {
+		workers = len(instanceTypes)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = s.fetchWithRetry(ctx, client, limiter, instanceTypes[idx], region)
+			}
+		}()
+	}
+
+dispatch:
+	for i := range instanceTypes {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// fetchWithRetry prices one instance type, retrying retryable errors with
+// jittered exponential backoff. A Retry-After hint longer than the backoff wins.
+func (s *RunsOnSource) fetchWithRetry(ctx context.Context, client *http.Client, limiter *rate.Limiter, instanceType, region string) fetchResult {
+	var lastErr error
+	for attempt := 1; attempt <= pricingMaxAttempts; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return fetchResult{attempts: attempt - 1, err: ctx.Err()}
+		}
+
+		price, err := fetchInstancePrice(ctx, client, s.baseURL, instanceType, region)
+		if err == nil {
+			return fetchResult{price: price, attempts: attempt}
+		}
+		lastErr = err
+		if !retryablePricingError(err) || attempt == pricingMaxAttempts || ctx.Err() != nil {
+			return fetchResult{attempts: attempt, err: lastErr}
+		}
+
+		delay := s.backoff << (attempt - 1)
+		delay += rand.N(delay/2 + 1)
+		var se *pricingStatusError
+		if errors.As(err, &se) && se.RetryAfter > delay {
+			delay = se.RetryAfter
+		}
+		delay = min(delay, pricingMaxBackoff)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fetchResult{attempts: attempt, err: lastErr}
+		}
+	}
+	return fetchResult{attempts: pricingMaxAttempts, err: lastErr}
+}
+
+// fetchInstancePrice queries the public pricing API for a single instance type.
+// Returns both on-demand and lowest spot price across AZs.
+func fetchInstancePrice(ctx context.Context, client *http.Client, baseURL, instanceType, region string) (*InstancePrice, error) {
+	url := fmt.Sprintf("%s/%s?region=%s&platform=Linux/UNIX", baseURL, instanceType, region)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &pricingStatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	var pr pricingAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if len(pr.Results) == 0 {
+		return nil, errNoPricingData
+	}
+
+	// On-demand is the same across AZs; for spot, pick the lowest
+	result := &InstancePrice{
+		OnDemand: pr.Results[0].OnDemandPrice,
+		Spot:     pr.Results[0].SpotPrice,
+	}
+	for _, r := range pr.Results[1:] {
+		if r.SpotPrice > 0 && (result.Spot == 0 || r.SpotPrice < result.Spot) {
+			result.Spot = r.SpotPrice
+		}
+	}
+
+	return result, nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds. HTTP-date
+// values and malformed headers yield 0, falling back to normal backoff.
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
@@ -0,0 +1,190 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// newTestRunsOnSource returns a runs-on source pointed at baseURL with fast
+// backoff and no meaningful rate limit.
+func newTestRunsOnSource(baseURL string) *RunsOnSource {
+	src := NewRunsOnSource()
+	src.baseURL = baseURL
+	src.backoff = time.Millisecond
+	src.rps = 1000
+	return src
+}
+
+// newTestPricingProvider returns an uncached provider pricing against srv.
+func newTestPricingProvider(srv *httptest.Server) *AWSProvider {
+	return newAWSProvider(&fakeEC2{}, "us-east-1", "", WithPriceSource(newTestRunsOnSource(srv.URL)))
+}
+
+func writePrice(w http.ResponseWriter, instanceType string) {
+	_ = json.NewEncoder(w).Encode(pricingAPIResponse{Results: []pricingAPIResult{
+		{InstanceType: instanceType, OnDemandPrice: 0.1, SpotPrice: 0.04},
+	}})
+}
+
+func TestEnrichWithPricing_RetriesThrottling(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writePrice(w, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer srv.Close()
+
+	p := newTestPricingProvider(srv)
+	templates := []model.NodeTemplate{{InstanceType: "m5.large"}}
+	summary, err := p.EnrichWithPricing(context.Background(), templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 3 {
+		t.Errorf("expected 3 requests (two 429s then success), got %d", hits)
+	}
+	if summary.Priced != 1 || len(summary.Failures) != 0 {
+		t.Errorf("summary = %+v, want 1 priced and no failures", summary)
+	}
+	if templates[0].OnDemandPricePerHour != 0.1 {
+		t.Errorf("price = %v, want 0.1", templates[0].OnDemandPricePerHour)
+	}
+}
+
+func TestEnrichWithPricing_ReportsFailures(t *testing.T) {
+	var hits sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		it := strings.TrimPrefix(r.URL.Path, "/")
+		n, _ := hits.LoadOrStore(it, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+		switch it {
+		case "m5.large":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "m5.metal":
+			_ = json.NewEncoder(w).Encode(pricingAPIResponse{})
+		default:
+			writePrice(w, it)
+		}
+	}))
+	defer srv.Close()
+
+	p := newTestPricingProvider(srv)
+	templates := []model.NodeTemplate{
+		{InstanceType: "m5.large"}, {InstanceType: "m5.xlarge"}, {InstanceType: "m5.metal"},
+	}
+	summary, err := p.EnrichWithPricing(context.Background(), templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Requested != 3 || summary.Priced != 1 || len(summary.Failures) != 2 {
+		t.Fatalf("summary = %+v, want 3 requested, 1 priced, 2 failures", summary)
+	}
+	serverErr := summary.Failures[0]
+	if serverErr.InstanceType != "m5.large" || serverErr.Attempts != pricingMaxAttempts {
+		t.Errorf("5xx failure = %+v, want m5.large after %d attempts", serverErr, pricingMaxAttempts)
+	}
+	noData := summary.Failures[1]
+	if noData.InstanceType != "m5.metal" || noData.Attempts != 1 || !errors.Is(noData.Err, errNoPricingData) {
+		t.Errorf("missing-data failure = %+v, want m5.metal after 1 attempt (not retried)", noData)
+	}
+
+	warnings := p.PricingSummary().Warnings()
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "2 of 3 instance types") ||
+		!strings.Contains(warnings[1], "m5.large (HTTP 500 after 4 attempts)") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestEnrichWithPricing_BoundedConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		writePrice(w, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer srv.Close()
+
+	src := newTestRunsOnSource(srv.URL)
+	src.workers = 3
+	p := newAWSProvider(&fakeEC2{}, "us-east-1", "", WithPriceSource(src))
+	templates := make([]model.NodeTemplate, 12)
+	for i := range templates {
+		templates[i].InstanceType = "m5." + strings.Repeat("x", i) + "large"
+	}
+
+	summary, err := p.EnrichWithPricing(context.Background(), templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Priced != len(templates) {
+		t.Errorf("priced %d of %d", summary.Priced, len(templates))
+	}
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", peak)
+	}
+}
+
+func TestAWSProvider_PricingSummaryClearsRecoveredFailures(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		writePrice(w, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer srv.Close()
+
+	p := newTestPricingProvider(srv)
+	if _, err := p.EnrichWithPricing(context.Background(), []model.NodeTemplate{{InstanceType: "m5.large"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(p.PricingSummary().Failures); got != 1 {
+		t.Fatalf("expected 1 failure, got %d", got)
+	}
+
+	fail.Store(false)
+	if _, err := p.EnrichWithPricing(context.Background(), []model.NodeTemplate{{InstanceType: "m5.large"}}); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.PricingSummary(); len(s.Failures) != 0 || s.Priced != 1 || s.Warnings() != nil {
+		t.Errorf("summary after recovery = %+v, want no failures", s)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := map[string]time.Duration{
+		"3":                             3 * time.Second,
+		" 0 ":                           0,
+		"":                              0,
+		"-1":                            0,
+		"Wed, 21 Oct 2015 07:28:00 GMT": 0,
+	}
+	for in, want := range tests {
+		if got := parseRetryAfter(in); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// errUnsupportedSheet is returned by decodeSheet for a file extension that
+// is neither CSV nor YAML.
+var errUnsupportedSheet = errors.New("unsupported sheet format")
+
+// sheetRecord is one CSV data row whose fields are looked up by header name.
+type sheetRecord struct {
+	col map[string]int
+	rec []string
+}
+
+// field returns the trimmed value of the named column, or empty when the
+// column is absent or left off the row.
+func (r sheetRecord) field(name string) string {
+	if i, ok := r.col[name]; ok && i < len(r.rec) {
+		return strings.TrimSpace(r.rec[i])
+	}
+	return ""
+}
+
+// decodeSheet reads the rows of a sheet file with the given extension: a
+// CSV file with a header row, or a YAML list decoded into T. CSV columns are
+// located by header name so that their order does not matter, the required
+// columns must be present, and each data row is converted by row.
+func decodeSheet[T any](r io.Reader, ext string, required []string, row func(sheetRecord) (T, error)) ([]T, error) {
+	switch ext {
+	case ".csv":
+		return decodeSheetCSV(r, required, row)
+	case ".yaml", ".yml":
+		var rows []T
+		err := yaml.NewDecoder(r).Decode(&rows)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return rows, err
+	default:
+		return nil, errUnsupportedSheet
+	}
+}
+
+func decodeSheetCSV[T any](r io.Reader, required []string, row func(sheetRecord) (T, error)) ([]T, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1 // optional trailing columns may be left off
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	var rows []T
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := row(sheetRecord{col: col, rec: rec})
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, v)
+	}
+}
+
+// regionalRows picks, per instance type, the index of the sheet row that
+// applies in a region among n rows. A row for that region takes precedence
+// over a row without a region; rows for other regions are ignored. key
+// returns the instance type and region of row i.
+func regionalRows(n int, region string, key func(i int) (instanceType, rowRegion string)) map[string]int {
+	picked := make(map[string]int)
+	regional := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		it, rowRegion := key(i)
+		switch {
+		case rowRegion == region:
+			picked[it] = i
+			regional[it] = true
+		case rowRegion == "" && !regional[it]:
+			picked[it] = i
+		}
+	}
+	return picked
+}
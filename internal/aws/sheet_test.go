@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSheetCSV(t *testing.T) {
+	type row struct{ A, B string }
+	conv := func(rec sheetRecord) (row, error) {
+		if rec.field("a") == "bad" {
+			return row{}, errors.New("bad value")
+		}
+		return row{A: rec.field("a"), B: rec.field("b")}, nil
+	}
+
+	rows, err := decodeSheet(strings.NewReader("B, A\n# comment\n2, 1\n,3\n"), ".csv", []string{"a"}, conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0] != (row{A: "1", B: "2"}) || rows[1] != (row{A: "3"}) {
+		t.Errorf("rows = %+v", rows)
+	}
+
+	if _, err := decodeSheet(strings.NewReader("b\n1\n"), ".csv", []string{"a"}, conv); err == nil || !strings.Contains(err.Error(), `"a"`) {
+		t.Errorf("missing column err = %v", err)
+	}
+	if _, err := decodeSheet(strings.NewReader("a\nok\nbad\n"), ".csv", nil, conv); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("row err = %v, want line 3", err)
+	}
+	if _, err := decodeSheet(strings.NewReader(""), ".txt", nil, conv); !errors.Is(err, errUnsupportedSheet) {
+		t.Errorf("unsupported err = %v", err)
+	}
+}
+
+func TestRegionalRows(t *testing.T) {
+	rows := []struct{ it, region string }{
+		{"m5.large", "us-east-1"},
+		{"m5.large", ""},
+		{"c5.large", ""},
+		{"c5.large", "eu-west-1"},
+		{"r5.large", "eu-west-1"},
+	}
+	got := regionalRows(len(rows), "us-east-1", func(i int) (string, string) { return rows[i].it, rows[i].region })
+	want := map[string]int{"m5.large": 0, "c5.large": 2}
+	if len(got) != len(want) {
+		t.Fatalf("picked = %v, want %v", got, want)
+	}
+	for it, i := range want {
+		if got[it] != i {
+			t.Errorf("%s picked row %d, want %d", it, got[it], i)
+		}
+	}
+}
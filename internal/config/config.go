@@ -8,14 +8,20 @@ import (
 
 // Config is the top-level configuration for ClusterFit.
 type Config struct {
-	Cluster    ClusterConfig    `yaml:"cluster"`
-	Prometheus PrometheusConfig `yaml:"prometheus"`
-	Kubernetes KubernetesConfig `yaml:"kubernetes"`
-	Metrics    MetricsConfig    `yaml:"metrics"`
-	Instances  InstancesConfig  `yaml:"instances"`
-	Simulation SimulationConfig `yaml:"simulation"`
-	Scoring    ScoringConfig    `yaml:"scoring"`
-	Output     OutputConfig     `yaml:"output"`
+	Cluster     ClusterConfig     `yaml:"cluster"`
+	Prometheus  PrometheusConfig  `yaml:"prometheus"`
+	Kubernetes  KubernetesConfig  `yaml:"kubernetes"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Instances   InstancesConfig   `yaml:"instances"`
+	Simulation  SimulationConfig  `yaml:"simulation"`
+	Scoring     ScoringConfig     `yaml:"scoring"`
+	Output      OutputConfig      `yaml:"output"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Pricing     PricingConfig     `yaml:"pricing"`
+	Commitments CommitmentsConfig `yaml:"commitments"`
+	Karpenter   KarpenterConfig   `yaml:"karpenter"`
+	Replay      ReplayConfig      `yaml:"replay"`
+	Rightsize   RightsizeConfig   `yaml:"rightsize"`
 }
 
 type KubernetesConfig struct {
@@ -23,6 +29,7 @@ type KubernetesConfig struct {
 	Kubeconfig         string `yaml:"kubeconfig"`
 	Context            string `yaml:"context"`
 	DiscoveryNamespace string `yaml:"discovery_namespace"` // empty = all namespaces
+	CollectConstraints bool   `yaml:"collect_constraints"` // read pod node selectors/tolerations from the API
 }
 
 type ClusterConfig struct {
@@ -38,8 +45,33 @@ type PrometheusConfig struct {
 type MetricsConfig struct {
 	Window            time.Duration `yaml:"window"`
 	Step              time.Duration `yaml:"step"`
-	Percentile        float64       `yaml:"percentile"`
+	Percentile        float64       `yaml:"percentile"`        // sizing percentile; 1.0 = max over the window
+	ExtraPercentiles  []float64     `yaml:"extra_percentiles"` // further percentiles stored with each pod's usage
 	ExcludeNamespaces []string      `yaml:"exclude_namespaces"`
+	Series            bool          `yaml:"series"`      // store per-pod usage series in inspect snapshots
+	SeriesStep        time.Duration `yaml:"series_step"` // resolution of the usage series
+
+	// Per-resource sizing policies; unset fields size by the larger of the
+	// request and usage at Percentile
+	Sizing SizingConfig `yaml:"sizing"`
+}
+
+// SizingConfig holds the CPU and memory sizing policies.
+type SizingConfig struct {
+	CPU    SizingConf `yaml:"cpu"`
+	Memory SizingConf `yaml:"memory"`
+}
+
+// IsZero reports whether neither policy is configured.
+func (s SizingConfig) IsZero() bool {
+	return s.CPU == SizingConf{} && s.Memory == SizingConf{}
+}
+
+// SizingConf sets how a pod's effective size in one dimension is derived.
+type SizingConf struct {
+	Source     string  `yaml:"source"`     // request, usage, max (default) or limit
+	Percentile float64 `yaml:"percentile"` // usage percentile; 0 = metrics percentile, 1.0 = max
+	Headroom   float64 `yaml:"headroom"`   // multiplier on usage; 0 = 1
 }
 
 type InstancesConfig struct {
@@ -58,6 +90,51 @@ type SimulationConfig struct {
 	SystemReserved SystemReservedConf `yaml:"system_reserved"`
 	MaxNodes       int                `yaml:"max_nodes"`
 	MinNodes       int                `yaml:"min_nodes"`
+	Zones          []string           `yaml:"zones"`      // explicit availability zones, e.g. us-east-1a
+	ZoneCount      int                `yaml:"zone_count"` // derive zones <region>a, <region>b, ... when Zones is empty
+	Algorithm      string             `yaml:"algorithm"`  // bin-packing algorithm, e.g. best-fit-decreasing
+	ComparePackers bool               `yaml:"compare_packers"`
+
+	// Branch-and-bound searches for the cheapest packing for this long, on
+	// clusters of up to ExactMaxPods pods
+	ExactTimeBudget time.Duration `yaml:"exact_time_budget"`
+	ExactMaxPods    int           `yaml:"exact_max_pods"`
+
+	// Mixed pools across instance families, in addition to the strategy's scenarios
+	CrossFamily CrossFamilyConf `yaml:"cross_family"`
+
+	// Peak-aware packing checks a node's combined usage at every timestep of
+	// the usage series instead of summing each pod's percentile
+	PeakAware            bool    `yaml:"peak_aware"`
+	OvercommitPercentile float64 `yaml:"overcommit_percentile"` // share of timesteps a node's usage must fit its capacity
+
+	// Replica count controllers are packed at: current, min, typical (p95)
+	// or peak over the metrics window
+	Replicas string `yaml:"replicas"`
+}
+
+// ZoneNames returns the availability zones nodes are spread across, or nil
+// when the simulation is zone-agnostic. Explicit Zones take precedence over
+// ZoneCount, which derives names from the region.
+func (s SimulationConfig) ZoneNames(region string) []string {
+	if len(s.Zones) > 0 {
+		return s.Zones
+	}
+	if s.ZoneCount <= 0 {
+		return nil
+	}
+	zones := make([]string, s.ZoneCount)
+	for i := range zones {
+		zones[i] = fmt.Sprintf("%s%c", region, 'a'+i)
+	}
+	return zones
+}
+
+// CrossFamilyConf configures the mixed pools built across instance families.
+type CrossFamilyConf struct {
+	Enabled         bool `yaml:"enabled"`
+	MaxFamilies     int  `yaml:"max_families"`     // families per pool, 2 to 5
+	MaxCombinations int  `yaml:"max_combinations"` // pools simulated, best-priced first
 }
 
 type SystemReservedConf struct {
@@ -81,6 +158,86 @@ type OutputConfig struct {
 	TopN   int    `yaml:"top_n"`
 }
 
+type CacheConfig struct {
+	Dir              string        `yaml:"dir"`                // empty = ~/.cache/clusterfit
+	InstanceTypesTTL time.Duration `yaml:"instance_types_ttl"` // EC2 instance type catalogs
+	PricingTTL       time.Duration `yaml:"pricing_ttl"`        // on-demand and spot prices
+}
+
+// Pricing sources selectable with pricing.source.
+const (
+	PricingSourceRunsOn     = "runs-on"
+	PricingSourceOfferFile  = "offer-file"
+	PricingSourcePriceSheet = "price-sheet"
+)
+
+type PricingConfig struct {
+	Source     string `yaml:"source"`      // runs-on, offer-file or price-sheet
+	OfferFile  string `yaml:"offer_file"`  // AWS Price List EC2 offer file (index.json, optionally .gz)
+	PriceSheet string `yaml:"price_sheet"` // static CSV or YAML price sheet
+
+	// Spot Instance Advisor data (JSON), or a CSV or YAML sheet, giving the
+	// spot interruption frequency of each instance type
+	SpotInterruptions string `yaml:"spot_interruptions"`
+}
+
+// CommitmentsConfig describes existing Reserved Instances and Savings Plans.
+type CommitmentsConfig struct {
+	ReservedInstances []ReservedInstanceConf `yaml:"reserved_instances"`
+	SavingsPlans      []SavingsPlanConf      `yaml:"savings_plans"`
+}
+
+type ReservedInstanceConf struct {
+	InstanceType string  `yaml:"instance_type"`
+	Count        int     `yaml:"count"`
+	HourlyRate   float64 `yaml:"hourly_rate"` // effective $/hour per instance, upfront amortized
+	Discount     float64 `yaml:"discount"`    // fraction off on-demand, when hourly_rate is unknown
+}
+
+type SavingsPlanConf struct {
+	Type             string  `yaml:"type"`   // compute or ec2-instance
+	Family           string  `yaml:"family"` // ec2-instance plans only
+	HourlyCommitment float64 `yaml:"hourly_commitment"`
+	Discount         float64 `yaml:"discount"` // average fraction off on-demand
+}
+
+// KarpenterConfig enables Karpenter mode, which simulates Karpenter
+// provisioning from the given NodePools instead of fixed scenarios.
+type KarpenterConfig struct {
+	Manifests []string `yaml:"manifests"` // NodePool and EC2NodeClass YAML files or directories
+}
+
+// Enabled returns true when NodePool manifests are configured.
+func (k KarpenterConfig) Enabled() bool {
+	return len(k.Manifests) > 0
+}
+
+// ReplayConfig configures the replay of autoscaling over the metrics window,
+// which reports node-hours and cost integrated over time for the top
+// recommendations.
+type ReplayConfig struct {
+	Enabled              bool          `yaml:"enabled"`
+	Step                 time.Duration `yaml:"step"`                   // resolution of the usage timeline
+	RepackEvery          int           `yaml:"repack_every"`           // steps between scale-down passes
+	Policy               string        `yaml:"policy"`                 // cluster-autoscaler or karpenter
+	ScaleDownUtilization float64       `yaml:"scale_down_utilization"` // cluster-autoscaler scale-down threshold
+	Top                  int           `yaml:"top"`                    // recommendations replayed
+}
+
+// RightsizeConfig configures the requests and limits proposed by rightsize.
+type RightsizeConfig struct {
+	CPU    RightsizeConf `yaml:"cpu"`
+	Memory RightsizeConf `yaml:"memory"`
+	Top    int           `yaml:"top"` // workloads listed
+}
+
+// RightsizeConf sets how one resource's request and limit are derived.
+type RightsizeConf struct {
+	Percentile float64 `yaml:"percentile"`  // usage percentile the request covers; 1.0 = max
+	Headroom   float64 `yaml:"headroom"`    // multiplier on usage
+	LimitRatio float64 `yaml:"limit_ratio"` // limit as a multiple of the request; 0 = no limit
+}
+
 // Default returns a Config with sensible defaults.
 func Default() Config {
 	return Config{
@@ -91,9 +248,11 @@ func Default() Config {
 			Timeout: 60 * time.Second,
 		},
 		Metrics: MetricsConfig{
-			Window:     7 * 24 * time.Hour,
-			Step:       5 * time.Minute,
-			Percentile: 0.95,
+			Window:           7 * 24 * time.Hour,
+			Step:             5 * time.Minute,
+			Percentile:       0.95,
+			ExtraPercentiles: []float64{0.50, 0.99},
+			SeriesStep:       15 * time.Minute,
 			ExcludeNamespaces: []string{
 				"kube-system",
 				"kube-node-lease",
@@ -116,8 +275,17 @@ func Default() Config {
 				CPUMillis: 100,
 				MemoryMiB: 256,
 			},
-			MaxNodes: 500,
-			MinNodes: 3,
+			MaxNodes:             500,
+			MinNodes:             3,
+			Algorithm:            "best-fit-decreasing",
+			ExactTimeBudget:      5 * time.Second,
+			ExactMaxPods:         200,
+			OvercommitPercentile: 0.99,
+			Replicas:             "current",
+			CrossFamily: CrossFamilyConf{
+				MaxFamilies:     3,
+				MaxCombinations: 10,
+			},
 		},
 		Scoring: ScoringConfig{
 			Weights: ScoringWeightsConf{
@@ -131,6 +299,25 @@ func Default() Config {
 			Format: "table",
 			TopN:   5,
 		},
+		Cache: CacheConfig{
+			InstanceTypesTTL: 7 * 24 * time.Hour,
+			PricingTTL:       24 * time.Hour,
+		},
+		Pricing: PricingConfig{
+			Source: PricingSourceRunsOn,
+		},
+		Replay: ReplayConfig{
+			Step:                 15 * time.Minute,
+			RepackEvery:          4,
+			Policy:               "cluster-autoscaler",
+			ScaleDownUtilization: 0.5,
+			Top:                  3,
+		},
+		Rightsize: RightsizeConfig{
+			CPU:    RightsizeConf{Percentile: 0.95, Headroom: 1.0},
+			Memory: RightsizeConf{Percentile: 1.0, Headroom: 1.15, LimitRatio: 1.0},
+			Top:    20,
+		},
 	}
 }
 
@@ -139,19 +326,111 @@ func (c *Config) Validate() error {
 	if c.Metrics.Percentile < 0 || c.Metrics.Percentile > 1.0 {
 		return fmt.Errorf("percentile must be between 0 and 1.0, got %v", c.Metrics.Percentile)
 	}
+	for _, p := range c.Metrics.ExtraPercentiles {
+		if p <= 0 || p > 1.0 {
+			return fmt.Errorf("extra_percentiles must be in (0, 1.0], got %v", p)
+		}
+	}
+	for _, r := range []struct {
+		name string
+		sc   SizingConf
+	}{{"cpu", c.Metrics.Sizing.CPU}, {"memory", c.Metrics.Sizing.Memory}} {
+		name, sc := r.name, r.sc
+		switch sc.Source {
+		case "", "request", "usage", "max", "limit":
+		default:
+			return fmt.Errorf("sizing %s source must be request, usage, max, or limit, got %q", name, sc.Source)
+		}
+		if sc.Percentile < 0 || sc.Percentile > 1.0 {
+			return fmt.Errorf("sizing %s percentile must be between 0 and 1.0, got %v", name, sc.Percentile)
+		}
+		if sc.Headroom < 0 {
+			return fmt.Errorf("sizing %s headroom must be non-negative, got %v", name, sc.Headroom)
+		}
+	}
 	if c.Metrics.Window <= 0 {
 		return fmt.Errorf("metrics window must be positive, got %v", c.Metrics.Window)
 	}
+	if (c.Metrics.Series || c.Simulation.PeakAware) && c.Metrics.SeriesStep <= 0 {
+		return fmt.Errorf("metrics series_step must be positive, got %v", c.Metrics.SeriesStep)
+	}
 	if c.Simulation.SpotRatio < 0 || c.Simulation.SpotRatio > 1.0 {
 		return fmt.Errorf("spot_ratio must be between 0 and 1.0, got %v", c.Simulation.SpotRatio)
 	}
+	if c.Simulation.PeakAware && (c.Simulation.OvercommitPercentile <= 0 || c.Simulation.OvercommitPercentile > 1.0) {
+		return fmt.Errorf("overcommit_percentile must be in (0, 1.0], got %v", c.Simulation.OvercommitPercentile)
+	}
 	if c.Simulation.MinNodes < 0 {
 		return fmt.Errorf("min_nodes must be non-negative, got %d", c.Simulation.MinNodes)
 	}
+	if c.Simulation.ZoneCount < 0 || c.Simulation.ZoneCount > 26 {
+		return fmt.Errorf("zone_count must be between 0 and 26, got %d", c.Simulation.ZoneCount)
+	}
+	if len(c.Simulation.Zones) > 0 && c.Simulation.ZoneCount > 0 && len(c.Simulation.Zones) != c.Simulation.ZoneCount {
+		return fmt.Errorf("zone_count %d does not match %d zones listed", c.Simulation.ZoneCount, len(c.Simulation.Zones))
+	}
+	seenZones := make(map[string]bool, len(c.Simulation.Zones))
+	for _, z := range c.Simulation.Zones {
+		if z == "" || seenZones[z] {
+			return fmt.Errorf("zones must be unique and non-empty, got %v", c.Simulation.Zones)
+		}
+		seenZones[z] = true
+	}
 	validStrats := map[string]bool{"homogeneous": true, "mixed": true, "both": true}
 	if !validStrats[c.Simulation.Strategy] {
 		return fmt.Errorf("strategy must be homogeneous, mixed, or both, got %q", c.Simulation.Strategy)
 	}
+	if cf := c.Simulation.CrossFamily; cf.Enabled {
+		if cf.MaxFamilies < 2 || cf.MaxFamilies > 5 {
+			return fmt.Errorf("cross_family max_families must be between 2 and 5, got %d", cf.MaxFamilies)
+		}
+		if cf.MaxCombinations < 1 {
+			return fmt.Errorf("cross_family max_combinations must be positive, got %d", cf.MaxCombinations)
+		}
+	}
+	validAlgorithms := map[string]bool{
+		"best-fit-decreasing": true, "first-fit-decreasing": true, "dot-product": true, "local-search": true,
+		"branch-and-bound": true,
+	}
+	if !validAlgorithms[c.Simulation.Algorithm] {
+		return fmt.Errorf("algorithm must be best-fit-decreasing, first-fit-decreasing, dot-product, local-search, or branch-and-bound, got %q", c.Simulation.Algorithm)
+	}
+	if c.Simulation.ExactTimeBudget < 0 || c.Simulation.ExactMaxPods < 0 {
+		return fmt.Errorf("exact_time_budget and exact_max_pods must be non-negative")
+	}
+	if c.Simulation.PeakAware && c.Simulation.Algorithm != "best-fit-decreasing" {
+		return fmt.Errorf("peak_aware packing replaces algorithm %q; set one or the other", c.Simulation.Algorithm)
+	}
+	switch c.Simulation.Replicas {
+	case "current", "min", "typical", "peak":
+	default:
+		return fmt.Errorf("replicas must be current, min, typical, or peak, got %q", c.Simulation.Replicas)
+	}
+	if c.Cache.InstanceTypesTTL < 0 || c.Cache.PricingTTL < 0 {
+		return fmt.Errorf("cache TTLs must be non-negative")
+	}
+	switch c.Pricing.Source {
+	case PricingSourceRunsOn:
+	case PricingSourceOfferFile:
+		if c.Pricing.OfferFile == "" {
+			return fmt.Errorf("pricing.offer_file is required when pricing.source is %s", PricingSourceOfferFile)
+		}
+	case PricingSourcePriceSheet:
+		if c.Pricing.PriceSheet == "" {
+			return fmt.Errorf("pricing.price_sheet is required when pricing.source is %s", PricingSourcePriceSheet)
+		}
+	default:
+		return fmt.Errorf("pricing source must be runs-on, offer-file, or price-sheet, got %q", c.Pricing.Source)
+	}
+	if err := c.Commitments.validate(); err != nil {
+		return err
+	}
+	if err := c.Replay.validate(); err != nil {
+		return err
+	}
+	if err := c.Rightsize.validate(); err != nil {
+		return err
+	}
 	validFormats := map[string]bool{"table": true, "json": true, "markdown": true, "csv": true}
 	if !validFormats[c.Output.Format] {
 		return fmt.Errorf("output format must be table, json, markdown, or csv, got %q", c.Output.Format)
@@ -172,3 +451,78 @@ func detectRegion() string {
 	}
 	return "us-east-1"
 }
+
+func (c CommitmentsConfig) validate() error {
+	for i, ri := range c.ReservedInstances {
+		if ri.InstanceType == "" || ri.Count <= 0 {
+			return fmt.Errorf("reserved_instances[%d]: instance_type and a positive count are required", i)
+		}
+		if ri.HourlyRate < 0 || ri.Discount < 0 || ri.Discount >= 1 {
+			return fmt.Errorf("reserved_instances[%d]: hourly_rate must be non-negative and discount in [0, 1)", i)
+		}
+		if ri.HourlyRate == 0 && ri.Discount == 0 {
+			return fmt.Errorf("reserved_instances[%d]: one of hourly_rate or discount is required", i)
+		}
+	}
+	for i, sp := range c.SavingsPlans {
+		switch sp.Type {
+		case "compute":
+		case "ec2-instance":
+			if sp.Family == "" {
+				return fmt.Errorf("savings_plans[%d]: family is required for ec2-instance plans", i)
+			}
+		default:
+			return fmt.Errorf("savings_plans[%d]: type must be compute or ec2-instance, got %q", i, sp.Type)
+		}
+		if sp.HourlyCommitment <= 0 {
+			return fmt.Errorf("savings_plans[%d]: hourly_commitment must be positive", i)
+		}
+		if sp.Discount <= 0 || sp.Discount >= 1 {
+			return fmt.Errorf("savings_plans[%d]: discount must be between 0 and 1, got %v", i, sp.Discount)
+		}
+	}
+	return nil
+}
+
+func (r ReplayConfig) validate() error {
+	if !r.Enabled {
+		return nil
+	}
+	if r.Step <= 0 {
+		return fmt.Errorf("replay.step must be positive, got %v", r.Step)
+	}
+	if r.RepackEvery < 0 {
+		return fmt.Errorf("replay.repack_every must be non-negative, got %d", r.RepackEvery)
+	}
+	if r.Policy != "cluster-autoscaler" && r.Policy != "karpenter" {
+		return fmt.Errorf("replay.policy must be cluster-autoscaler or karpenter, got %q", r.Policy)
+	}
+	if r.ScaleDownUtilization < 0 || r.ScaleDownUtilization > 1.0 {
+		return fmt.Errorf("replay.scale_down_utilization must be between 0 and 1.0, got %v", r.ScaleDownUtilization)
+	}
+	if r.Top <= 0 {
+		return fmt.Errorf("replay.top must be positive, got %d", r.Top)
+	}
+	return nil
+}
+
+func (r RightsizeConfig) validate() error {
+	for _, t := range []struct {
+		name string
+		conf RightsizeConf
+	}{{"cpu", r.CPU}, {"memory", r.Memory}} {
+		if t.conf.Percentile <= 0 || t.conf.Percentile > 1.0 {
+			return fmt.Errorf("rightsize.%s.percentile must be in (0, 1.0], got %v", t.name, t.conf.Percentile)
+		}
+		if t.conf.Headroom < 1 {
+			return fmt.Errorf("rightsize.%s.headroom must be at least 1, got %v", t.name, t.conf.Headroom)
+		}
+		if t.conf.LimitRatio != 0 && t.conf.LimitRatio < 1 {
+			return fmt.Errorf("rightsize.%s.limit_ratio must be 0 (no limit) or at least 1, got %v", t.name, t.conf.LimitRatio)
+		}
+	}
+	if r.Top < 0 {
+		return fmt.Errorf("rightsize.top must be non-negative, got %d", r.Top)
+	}
+	return nil
+}
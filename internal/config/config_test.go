@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 func TestDefault_Valid(t *testing.T) {
@@ -22,6 +23,58 @@ func TestValidate_InvalidPercentile(t *testing.T) {
 	if err := cfg.Validate(); err == nil {
 		t.Error("expected error for negative percentile")
 	}
+
+	cfg = Default()
+	cfg.Metrics.ExtraPercentiles = []float64{0.5, 0}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a zero extra percentile")
+	}
+	cfg.Metrics.ExtraPercentiles = []float64{0.999, 1.0}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("extra percentiles up to 1.0 should be valid: %v", err)
+	}
+}
+
+func TestValidate_Sizing(t *testing.T) {
+	cfg := Default()
+	cfg.Metrics.Sizing.CPU = SizingConf{Source: "usage", Percentile: 0.9, Headroom: 1.2}
+	cfg.Metrics.Sizing.Memory = SizingConf{Source: "max", Percentile: 1.0}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("sizing policies should be valid: %v", err)
+	}
+
+	cfg.Metrics.Sizing.Memory.Source = "peak"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an unknown sizing source")
+	}
+	cfg.Metrics.Sizing.Memory.Source = "limit"
+	cfg.Metrics.Sizing.CPU.Percentile = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a sizing percentile > 1.0")
+	}
+	cfg.Metrics.Sizing.CPU.Percentile = 0
+	cfg.Metrics.Sizing.CPU.Headroom = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative headroom")
+	}
+}
+
+func TestValidate_Rightsize(t *testing.T) {
+	cfg := Default()
+	cfg.Rightsize.Memory.Headroom = 0.9
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a headroom below 1")
+	}
+	cfg = Default()
+	cfg.Rightsize.CPU.LimitRatio = 0.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a limit below the request")
+	}
+	cfg = Default()
+	cfg.Rightsize.CPU.Percentile = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a zero rightsize percentile")
+	}
 }
 
 func TestValidate_InvalidWindow(t *testing.T) {
@@ -66,3 +119,182 @@ func TestValidate_TopN_FixesZero(t *testing.T) {
 		t.Errorf("expected TopN to be fixed to 5, got %d", cfg.Output.TopN)
 	}
 }
+
+func TestValidate_DuplicateZones(t *testing.T) {
+	cfg := Default()
+	cfg.Simulation.Zones = []string{"us-east-1a", "us-east-1a"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for duplicate zones")
+	}
+}
+
+func TestValidate_PricingSource(t *testing.T) {
+	cfg := Default()
+	cfg.Pricing.Source = "ec2-api"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown pricing source")
+	}
+
+	cfg.Pricing.Source = PricingSourceOfferFile
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for offer-file source without offer_file")
+	}
+	cfg.Pricing.OfferFile = "/mirror/AmazonEC2/us-east-1/index.json"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_Commitments(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       CommitmentsConfig
+		wantErr bool
+	}{
+		{"valid", CommitmentsConfig{
+			ReservedInstances: []ReservedInstanceConf{{InstanceType: "m5.xlarge", Count: 4, Discount: 0.4}},
+			SavingsPlans:      []SavingsPlanConf{{Type: "compute", HourlyCommitment: 12, Discount: 0.28}},
+		}, false},
+		{"RI without rate", CommitmentsConfig{
+			ReservedInstances: []ReservedInstanceConf{{InstanceType: "m5.xlarge", Count: 4}},
+		}, true},
+		{"EC2 plan without family", CommitmentsConfig{
+			SavingsPlans: []SavingsPlanConf{{Type: "ec2-instance", HourlyCommitment: 5, Discount: 0.4}},
+		}, true},
+		{"unknown plan type", CommitmentsConfig{
+			SavingsPlans: []SavingsPlanConf{{Type: "sagemaker", HourlyCommitment: 5, Discount: 0.4}},
+		}, true},
+	}
+	for _, tt := range tests {
+		cfg := Default()
+		cfg.Commitments = tt.c
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_Replay(t *testing.T) {
+	cfg := Default()
+	cfg.Replay.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Replay.Policy = "keda"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown replay policy")
+	}
+	cfg.Replay.Policy = "karpenter"
+	cfg.Replay.Step = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero replay step")
+	}
+
+	// Settings are only checked when the replay is enabled
+	cfg.Replay.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_Algorithm(t *testing.T) {
+	cfg := Default()
+	cfg.Simulation.Algorithm = "local-search"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Simulation.PeakAware = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for peak-aware packing with another algorithm")
+	}
+	cfg.Simulation.PeakAware = false
+	cfg.Simulation.Algorithm = "worst-fit"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+
+	cfg.Simulation.Algorithm = "branch-and-bound"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.Simulation.ExactTimeBudget = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative time budget")
+	}
+}
+
+func TestValidate_PeakAware(t *testing.T) {
+	cfg := Default()
+	cfg.Simulation.PeakAware = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Simulation.OvercommitPercentile = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for overcommit percentile above 1")
+	}
+	cfg.Simulation.OvercommitPercentile = 1
+	cfg.Metrics.SeriesStep = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero series step")
+	}
+}
+
+func TestValidate_Replicas(t *testing.T) {
+	cfg := Default()
+	if cfg.Simulation.Replicas != "current" {
+		t.Errorf("default replicas = %q, want current", cfg.Simulation.Replicas)
+	}
+	cfg.Simulation.Replicas = "peak"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Simulation.Replicas = "max"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown replica level")
+	}
+}
+
+func TestZoneNames(t *testing.T) {
+	sim := SimulationConfig{ZoneCount: 3}
+	got := sim.ZoneNames("eu-west-1")
+	want := []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("zone[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	sim.Zones = []string{"use1-az1", "use1-az2", "use1-az4"}
+	if got := sim.ZoneNames("us-east-1"); got[2] != "use1-az4" {
+		t.Errorf("explicit zones should take precedence, got %v", got)
+	}
+	if got := (SimulationConfig{}).ZoneNames("us-east-1"); got != nil {
+		t.Errorf("expected no zones by default, got %v", got)
+	}
+}
+
+func TestValidate_CrossFamily(t *testing.T) {
+	cfg := Default()
+	cfg.Simulation.CrossFamily.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Simulation.CrossFamily.MaxFamilies = 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for pools of one family")
+	}
+	cfg.Simulation.CrossFamily.MaxFamilies = 3
+	cfg.Simulation.CrossFamily.MaxCombinations = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero combinations")
+	}
+}
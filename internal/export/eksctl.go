@@ -0,0 +1,59 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// eksctlConfig is the subset of an eksctl ClusterConfig holding node groups.
+type eksctlConfig struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name   string `yaml:"name"`
+		Region string `yaml:"region,omitempty"`
+	} `yaml:"metadata"`
+	ManagedNodeGroups []eksctlNodeGroup `yaml:"managedNodeGroups"`
+}
+
+type eksctlNodeGroup struct {
+	Name              string   `yaml:"name"`
+	InstanceTypes     []string `yaml:"instanceTypes,flow"`
+	AMIFamily         string   `yaml:"amiFamily"`
+	Spot              bool     `yaml:"spot,omitempty"`
+	MinSize           int      `yaml:"minSize"`
+	MaxSize           int      `yaml:"maxSize"`
+	DesiredCapacity   int      `yaml:"desiredCapacity"`
+	AvailabilityZones []string `yaml:"availabilityZones,omitempty,flow"`
+}
+
+// writeEksctl renders the node groups as an eksctl ClusterConfig, to merge
+// into the cluster's config or apply with `eksctl create nodegroup -f`.
+func writeEksctl(w io.Writer, groups []NodeGroup, opts Options) error {
+	c := eksctlConfig{APIVersion: "eksctl.io/v1alpha5", Kind: "ClusterConfig"}
+	c.Metadata.Name = opts.ClusterName
+	c.Metadata.Region = opts.Region
+	for _, g := range groups {
+		c.ManagedNodeGroups = append(c.ManagedNodeGroups, eksctlNodeGroup{
+			Name:              g.Name,
+			InstanceTypes:     g.InstanceTypes,
+			AMIFamily:         "AmazonLinux2023",
+			Spot:              g.CapacityType == model.CapacitySpot,
+			MinSize:           g.MinSize,
+			MaxSize:           g.MaxSize,
+			DesiredCapacity:   g.DesiredSize,
+			AvailabilityZones: g.Zones,
+		})
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("encoding eksctl config: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,191 @@
+// Package export turns a recommendation into infrastructure configuration:
+// eksctl managed node groups, Terraform aws_eks_node_group resources, or a
+// Karpenter NodePool.
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// Output formats.
+const (
+	FormatEksctl    = "eksctl"
+	FormatTerraform = "terraform"
+	FormatKarpenter = "karpenter"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatEksctl, FormatTerraform, FormatKarpenter}
+
+// Options controls how a recommendation is exported.
+type Options struct {
+	ClusterName string
+	Region      string
+	Name        string  // node group or NodePool name; derived from the instance types when empty
+	MinNodes    int     // HA floor for the minimum size
+	Headroom    float64 // fraction of the desired size added to the maximum size
+	NodeClass   string  // EC2NodeClass referenced by the Karpenter NodePool
+}
+
+// NodeGroup is one node group of an exported recommendation. Managed node
+// groups have a single architecture and capacity type, so a recommendation
+// mixing spot and on-demand nodes, or Graviton and x86 families, becomes
+// several groups.
+type NodeGroup struct {
+	Name          string
+	InstanceTypes []string // by node count, most used first
+	Architecture  model.Architecture
+	CapacityType  model.CapacityType
+	Zones         []string
+
+	MinSize     int
+	MaxSize     int
+	DesiredSize int
+
+	// Largest instance type capacity, for Karpenter limits
+	MaxVCPUs     int32
+	MaxMemoryMiB int64
+}
+
+// Write renders the recommendation in the given format.
+func Write(w io.Writer, format string, rec model.Recommendation, opts Options) error {
+	groups, err := NodeGroups(rec, opts)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatEksctl:
+		return writeEksctl(w, groups, opts)
+	case FormatTerraform:
+		return writeTerraform(w, groups, opts)
+	case FormatKarpenter:
+		name := opts.Name
+		if name == "" {
+			name = defaultName(rec.SimulationResult)
+		}
+		return writeKarpenter(w, groups, name, opts)
+	}
+	return fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(Formats, ", "))
+}
+
+// NodeGroups splits the nodes of a recommendation into node groups and sizes
+// them. The desired size is the simulated node count, which covers the
+// sizing percentile of the workloads. The minimum is the estimated trough
+// node count when the metrics recorded the cluster scaling, and never less
+// than MinNodes. The maximum adds Headroom on top of the desired size for
+// peaks above the sizing percentile. Sizes are shared out between groups in
+// proportion to their node counts.
+func NodeGroups(rec model.Recommendation, opts Options) ([]NodeGroup, error) {
+	sr := rec.SimulationResult
+	if len(sr.Nodes) == 0 {
+		return nil, fmt.Errorf("recommendation #%d has no nodes", rec.Rank)
+	}
+
+	type key struct {
+		arch     model.Architecture
+		capacity model.CapacityType
+	}
+	byKey := make(map[key]*NodeGroup)
+	counts := make(map[key]map[string]int)
+	var keys []key
+	for i := range sr.Nodes {
+		t := sr.Nodes[i].Template
+		k := key{t.Architecture, t.CapacityType}
+		if k.capacity == "" {
+			k.capacity = model.CapacityOnDemand
+		}
+		g, ok := byKey[k]
+		if !ok {
+			g = &NodeGroup{Architecture: k.arch, CapacityType: k.capacity, Zones: sr.InstanceConfig.Zones}
+			byKey[k] = g
+			counts[k] = make(map[string]int)
+			keys = append(keys, k)
+		}
+		g.DesiredSize++
+		counts[k][t.InstanceType]++
+		g.MaxVCPUs = max(g.MaxVCPUs, t.VCPUs)
+		g.MaxMemoryMiB = max(g.MaxMemoryMiB, t.MemoryMiB)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].arch != keys[j].arch {
+			return keys[i].arch < keys[j].arch
+		}
+		return keys[i].capacity < keys[j].capacity
+	})
+
+	total := len(sr.Nodes)
+	minTotal := opts.MinNodes
+	if se := sr.ScalingEfficiency; se != nil {
+		minTotal = max(minTotal, se.EstTroughNodes)
+	}
+	minTotal = min(minTotal, total)
+
+	base := opts.Name
+	if base == "" {
+		base = defaultName(sr)
+	}
+	archs := make(map[model.Architecture]bool)
+	for _, k := range keys {
+		archs[k.arch] = true
+	}
+
+	groups := make([]NodeGroup, 0, len(keys))
+	for _, k := range keys {
+		g := byKey[k]
+		for it := range counts[k] {
+			g.InstanceTypes = append(g.InstanceTypes, it)
+		}
+		c := counts[k]
+		sort.Slice(g.InstanceTypes, func(i, j int) bool {
+			a, b := g.InstanceTypes[i], g.InstanceTypes[j]
+			if c[a] != c[b] {
+				return c[a] > c[b]
+			}
+			return a < b
+		})
+
+		share := float64(g.DesiredSize) / float64(total)
+		g.MinSize = min(int(math.Ceil(float64(minTotal)*share)), g.DesiredSize)
+		g.MaxSize = g.DesiredSize + int(math.Ceil(float64(g.DesiredSize)*opts.Headroom))
+
+		g.Name = base
+		if len(archs) > 1 {
+			g.Name += "-" + string(k.arch)
+		}
+		if len(keys) > 1 && k.capacity == model.CapacitySpot {
+			g.Name += "-spot"
+		}
+		groups = append(groups, *g)
+	}
+	return groups, nil
+}
+
+// defaultName names node groups after the recommendation: the instance type
+// of a homogeneous cluster, e.g. "m7i-xlarge", or the family of a mixed one.
+func defaultName(sr model.SimulationResult) string {
+	types := make(map[string]bool)
+	families := make(map[string]bool)
+	for i := range sr.Nodes {
+		types[sr.Nodes[i].Template.InstanceType] = true
+		families[sr.Nodes[i].Template.InstanceFamily] = true
+	}
+	switch {
+	case len(types) == 1:
+		for it := range types {
+			return strings.ReplaceAll(it, ".", "-")
+		}
+	case len(families) == 1:
+		for f := range families {
+			if f != "" {
+				return f
+			}
+		}
+	}
+	return "clusterfit"
+}
@@ -0,0 +1,188 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func makeNode(instanceType string, arch model.Architecture, capacity model.CapacityType) model.NodeAllocation {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return model.NodeAllocation{Template: model.NodeTemplate{
+		InstanceType:   instanceType,
+		InstanceFamily: family,
+		Architecture:   arch,
+		VCPUs:          4,
+		MemoryMiB:      16384,
+		CapacityType:   capacity,
+	}}
+}
+
+func makeRec(nodes ...model.NodeAllocation) model.Recommendation {
+	return model.Recommendation{
+		Rank: 1,
+		SimulationResult: model.SimulationResult{
+			InstanceConfig: model.InstanceConfig{Zones: []string{"us-east-1a", "us-east-1b"}},
+			Nodes:          nodes,
+			TotalNodes:     len(nodes),
+		},
+	}
+}
+
+func repeat(n int, node model.NodeAllocation) []model.NodeAllocation {
+	nodes := make([]model.NodeAllocation, n)
+	for i := range nodes {
+		nodes[i] = node
+	}
+	return nodes
+}
+
+func TestNodeGroups_Sizes(t *testing.T) {
+	rec := makeRec(repeat(8, makeNode("m7i.xlarge", model.ArchAMD64, model.CapacityOnDemand))...)
+
+	groups, err := NodeGroups(rec, Options{MinNodes: 3, Headroom: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	g := groups[0]
+	if g.Name != "m7i-xlarge" || g.MinSize != 3 || g.DesiredSize != 8 || g.MaxSize != 10 {
+		t.Errorf("group = %s min %d desired %d max %d, want m7i-xlarge 3/8/10", g.Name, g.MinSize, g.DesiredSize, g.MaxSize)
+	}
+
+	// The estimated trough raises the minimum above the HA floor
+	rec.SimulationResult.ScalingEfficiency = &model.ScalingEfficiency{EstTroughNodes: 5}
+	groups, _ = NodeGroups(rec, Options{MinNodes: 3})
+	if groups[0].MinSize != 5 || groups[0].MaxSize != 8 {
+		t.Errorf("min %d max %d, want trough 5 and no headroom 8", groups[0].MinSize, groups[0].MaxSize)
+	}
+
+	// The minimum never exceeds the desired size
+	groups, _ = NodeGroups(rec, Options{MinNodes: 12})
+	if groups[0].MinSize != 8 {
+		t.Errorf("min %d, want capped at 8", groups[0].MinSize)
+	}
+}
+
+func TestNodeGroups_Split(t *testing.T) {
+	nodes := repeat(2, makeNode("m7i.xlarge", model.ArchAMD64, model.CapacityOnDemand))
+	nodes = append(nodes, repeat(3, makeNode("m7i.xlarge", model.ArchAMD64, model.CapacitySpot))...)
+	nodes = append(nodes, makeNode("m7i.2xlarge", model.ArchAMD64, model.CapacitySpot))
+
+	groups, err := NodeGroups(makeRec(nodes...), Options{MinNodes: 3, Name: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want on-demand and spot", len(groups))
+	}
+	od, spot := groups[0], groups[1]
+	if od.Name != "app" || od.CapacityType != model.CapacityOnDemand || od.DesiredSize != 2 || od.MinSize != 1 {
+		t.Errorf("on-demand group = %+v", od)
+	}
+	if spot.Name != "app-spot" || spot.DesiredSize != 4 || spot.MinSize != 2 {
+		t.Errorf("spot group = %+v", spot)
+	}
+	if strings.Join(spot.InstanceTypes, ",") != "m7i.xlarge,m7i.2xlarge" {
+		t.Errorf("spot instance types = %v, want most used first", spot.InstanceTypes)
+	}
+
+	mixedArch := makeRec(makeNode("m7i.xlarge", model.ArchAMD64, model.CapacityOnDemand), makeNode("m7g.xlarge", model.ArchARM64, model.CapacityOnDemand))
+	groups, _ = NodeGroups(mixedArch, Options{})
+	if len(groups) != 2 || groups[0].Name != "clusterfit-amd64" || groups[1].Name != "clusterfit-arm64" {
+		t.Errorf("groups = %+v, want one per architecture", groups)
+	}
+}
+
+func TestNodeGroups_NoNodes(t *testing.T) {
+	if _, err := NodeGroups(makeRec(), Options{}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestWrite_Eksctl(t *testing.T) {
+	rec := makeRec(repeat(4, makeNode("m7g.xlarge", model.ArchARM64, model.CapacitySpot))...)
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatEksctl, rec, Options{ClusterName: "prod", Region: "us-east-1", MinNodes: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	var c eksctlConfig
+	if err := yaml.Unmarshal(buf.Bytes(), &c); err != nil {
+		t.Fatalf("invalid YAML: %v\n%s", err, buf.String())
+	}
+	if c.Metadata.Name != "prod" || c.Metadata.Region != "us-east-1" || len(c.ManagedNodeGroups) != 1 {
+		t.Fatalf("config = %+v", c)
+	}
+	ng := c.ManagedNodeGroups[0]
+	if !ng.Spot || ng.MinSize != 3 || ng.DesiredCapacity != 4 || len(ng.AvailabilityZones) != 2 {
+		t.Errorf("node group = %+v", ng)
+	}
+}
+
+func TestWrite_Terraform(t *testing.T) {
+	rec := makeRec(repeat(4, makeNode("m7g.xlarge", model.ArchARM64, model.CapacityOnDemand))...)
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatTerraform, rec, Options{ClusterName: "prod", MinNodes: 3, Headroom: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`resource "aws_eks_node_group" "m7g_xlarge" {`,
+		`cluster_name    = "prod"`,
+		`instance_types  = ["m7g.xlarge"]`,
+		`capacity_type   = "ON_DEMAND"`,
+		`ami_type        = "AL2023_ARM_64_STANDARD"`,
+		"min_size     = 3",
+		"max_size     = 6",
+		"desired_size = 4",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWrite_Karpenter(t *testing.T) {
+	nodes := repeat(3, makeNode("m7g.xlarge", model.ArchARM64, model.CapacityOnDemand))
+	nodes = append(nodes, makeNode("m7g.xlarge", model.ArchARM64, model.CapacitySpot))
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatKarpenter, makeRec(nodes...), Options{NodeClass: "default"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var np karpenterNodePool
+	if err := yaml.Unmarshal(buf.Bytes(), &np); err != nil {
+		t.Fatalf("invalid YAML: %v\n%s", err, buf.String())
+	}
+	if np.Kind != "NodePool" || np.Metadata.Name != "m7g-xlarge" || np.Spec.Template.Spec.NodeClassRef.Name != "default" {
+		t.Errorf("NodePool = %+v", np)
+	}
+	reqs := make(map[string][]string)
+	for _, r := range np.Spec.Template.Spec.Requirements {
+		reqs[r.Key] = r.Values
+	}
+	if strings.Join(reqs[model.LabelCapacityType], ",") != "on-demand,spot" {
+		t.Errorf("capacity types = %v", reqs[model.LabelCapacityType])
+	}
+	if strings.Join(reqs[model.TopologyZone], ",") != "us-east-1a,us-east-1b" {
+		t.Errorf("zones = %v", reqs[model.TopologyZone])
+	}
+	// 4 nodes of 4 vCPUs and 16 GiB, without headroom
+	if np.Spec.Limits["cpu"] != "16" || np.Spec.Limits["memory"] != "64Gi" {
+		t.Errorf("limits = %v", np.Spec.Limits)
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	rec := makeRec(makeNode("m7i.xlarge", model.ArchAMD64, model.CapacityOnDemand))
+	if err := Write(&bytes.Buffer{}, "cloudformation", rec, Options{}); err == nil {
+		t.Error("expected an error")
+	}
+}
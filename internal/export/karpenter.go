@@ -0,0 +1,100 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/guimove/clusterfit/internal/karpenter"
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// karpenterNodePool is a karpenter.sh/v1 NodePool.
+type karpenterNodePool struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Template struct {
+			Spec struct {
+				NodeClassRef struct {
+					Group string `yaml:"group"`
+					Kind  string `yaml:"kind"`
+					Name  string `yaml:"name"`
+				} `yaml:"nodeClassRef"`
+				Requirements []model.NodeRequirement `yaml:"requirements"`
+			} `yaml:"spec"`
+		} `yaml:"template"`
+		Limits     map[string]string `yaml:"limits"`
+		Disruption struct {
+			ConsolidationPolicy string `yaml:"consolidationPolicy"`
+			ConsolidateAfter    string `yaml:"consolidateAfter"`
+		} `yaml:"disruption"`
+	} `yaml:"spec"`
+}
+
+// writeKarpenter renders the node groups as a single NodePool restricted to
+// the recommended instance types. Karpenter has no minimum size, and spot
+// ratios cannot be expressed: when both capacity types are recommended,
+// Karpenter launches spot whenever it is available. The maximum sizes become
+// the NodePool's CPU and memory limits.
+func writeKarpenter(w io.Writer, groups []NodeGroup, name string, opts Options) error {
+	var types, archs, capacities []string
+	var cpu, memMiB int64
+	for _, g := range groups {
+		types = append(types, g.InstanceTypes...)
+		if g.Architecture != "" {
+			archs = append(archs, string(g.Architecture))
+		}
+		capacities = append(capacities, string(g.CapacityType))
+		cpu += int64(g.MaxSize) * int64(g.MaxVCPUs)
+		memMiB += int64(g.MaxSize) * g.MaxMemoryMiB
+	}
+
+	np := karpenterNodePool{APIVersion: "karpenter.sh/v1", Kind: karpenter.KindNodePool}
+	np.Metadata.Name = name
+	ref := &np.Spec.Template.Spec.NodeClassRef
+	ref.Group, ref.Kind, ref.Name = "karpenter.k8s.aws", karpenter.KindEC2NodeClass, opts.NodeClass
+	reqs := []model.NodeRequirement{{Key: model.LabelInstanceType, Operator: model.OpIn, Values: sorted(types)}}
+	if len(archs) > 0 {
+		reqs = append(reqs, model.NodeRequirement{Key: model.LabelArch, Operator: model.OpIn, Values: sorted(archs)})
+	}
+	reqs = append(reqs, model.NodeRequirement{Key: model.LabelCapacityType, Operator: model.OpIn, Values: sorted(capacities)})
+	if zones := groups[0].Zones; len(zones) > 0 {
+		reqs = append(reqs, model.NodeRequirement{Key: model.TopologyZone, Operator: model.OpIn, Values: zones})
+	}
+	np.Spec.Template.Spec.Requirements = reqs
+	np.Spec.Limits = map[string]string{
+		"cpu":    strconv.FormatInt(cpu, 10),
+		"memory": formatMiB(memMiB),
+	}
+	np.Spec.Disruption.ConsolidationPolicy = model.ConsolidateWhenEmptyOrUnderutilized
+	np.Spec.Disruption.ConsolidateAfter = "1m"
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(np); err != nil {
+		return fmt.Errorf("encoding Karpenter NodePool: %w", err)
+	}
+	return enc.Close()
+}
+
+// sorted returns the distinct values in order.
+func sorted(values []string) []string {
+	sort.Strings(values)
+	return slices.Compact(values)
+}
+
+// formatMiB renders a memory quantity in Gi when it is a whole number of GiB.
+func formatMiB(mib int64) string {
+	if mib%1024 == 0 {
+		return fmt.Sprintf("%dGi", mib/1024)
+	}
+	return fmt.Sprintf("%dMi", mib)
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// writeTerraform renders the node groups as aws_eks_node_group resources.
+// The node role and subnets are left to variables: the subnets decide which
+// zones the nodes run in.
+func writeTerraform(w io.Writer, groups []NodeGroup, opts Options) error {
+	ew := &errWriter{w: w}
+	for i, g := range groups {
+		if i > 0 {
+			ew.printf("\n")
+		}
+		capacity := "ON_DEMAND"
+		if g.CapacityType == model.CapacitySpot {
+			capacity = "SPOT"
+		}
+		amiType := "AL2023_x86_64_STANDARD"
+		if g.Architecture == model.ArchARM64 {
+			amiType = "AL2023_ARM_64_STANDARD"
+		}
+
+		ew.printf("resource \"aws_eks_node_group\" %q {\n", strings.ReplaceAll(g.Name, "-", "_"))
+		ew.printf("  cluster_name    = %q\n", opts.ClusterName)
+		ew.printf("  node_group_name = %q\n", g.Name)
+		ew.printf("  node_role_arn   = var.node_role_arn\n")
+		if len(g.Zones) > 0 {
+			ew.printf("  subnet_ids      = var.subnet_ids # subnets in %s\n", strings.Join(g.Zones, ", "))
+		} else {
+			ew.printf("  subnet_ids      = var.subnet_ids\n")
+		}
+		ew.printf("  instance_types  = [%s]\n", quoteList(g.InstanceTypes))
+		ew.printf("  capacity_type   = %q\n", capacity)
+		ew.printf("  ami_type        = %q\n", amiType)
+		ew.printf("\n")
+		ew.printf("  scaling_config {\n")
+		ew.printf("    min_size     = %d\n", g.MinSize)
+		ew.printf("    max_size     = %d\n", g.MaxSize)
+		ew.printf("    desired_size = %d\n", g.DesiredSize)
+		ew.printf("  }\n")
+		ew.printf("\n")
+		ew.printf("  # Let the cluster autoscaler own the node count after creation\n")
+		ew.printf("  lifecycle {\n")
+		ew.printf("    ignore_changes = [scaling_config[0].desired_size]\n")
+		ew.printf("  }\n")
+		ew.printf("}\n")
+	}
+	return ew.err
+}
+
+// quoteList renders strings as a comma-separated list of quoted values.
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// errWriter records the first write error, so a template can be written
+// without checking every line.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
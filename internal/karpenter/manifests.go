@@ -0,0 +1,288 @@
+// Package karpenter reads Karpenter NodePool and EC2NodeClass manifests.
+package karpenter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// Manifest kinds understood by LoadNodePools; other kinds are skipped.
+const (
+	KindNodePool     = "NodePool"
+	KindEC2NodeClass = "EC2NodeClass"
+)
+
+// manifest is the envelope of a Kubernetes object.
+type manifest struct {
+	Kind     string    `yaml:"kind"`
+	Metadata metadata  `yaml:"metadata"`
+	Spec     yaml.Node `yaml:"spec"`
+}
+
+type metadata struct {
+	Name string `yaml:"name"`
+}
+
+// nodePoolSpec is the subset of the karpenter.sh NodePool spec that affects
+// provisioning. The kubelet block is only present in v1beta1 NodePools; v1
+// moved it to the EC2NodeClass.
+type nodePoolSpec struct {
+	Template struct {
+		Metadata struct {
+			Labels map[string]string `yaml:"labels"`
+		} `yaml:"metadata"`
+		Spec struct {
+			NodeClassRef struct {
+				Name string `yaml:"name"`
+			} `yaml:"nodeClassRef"`
+			Requirements []model.NodeRequirement `yaml:"requirements"`
+			Taints       []taint                 `yaml:"taints"`
+			Kubelet      *kubeletSpec            `yaml:"kubelet"`
+		} `yaml:"spec"`
+	} `yaml:"template"`
+	Disruption struct {
+		ConsolidationPolicy string `yaml:"consolidationPolicy"`
+		ConsolidateAfter    string `yaml:"consolidateAfter"`
+	} `yaml:"disruption"`
+	Limits map[string]string `yaml:"limits"`
+	Weight int32             `yaml:"weight"`
+}
+
+type taint struct {
+	Key    string `yaml:"key"`
+	Value  string `yaml:"value"`
+	Effect string `yaml:"effect"`
+}
+
+// ec2NodeClassSpec is the subset of the karpenter.k8s.aws EC2NodeClass spec
+// that affects node capacity.
+type ec2NodeClassSpec struct {
+	Kubelet *kubeletSpec `yaml:"kubelet"`
+}
+
+type kubeletSpec struct {
+	MaxPods        int32             `yaml:"maxPods"`
+	PodsPerCore    int32             `yaml:"podsPerCore"`
+	SystemReserved map[string]string `yaml:"systemReserved"`
+	KubeReserved   map[string]string `yaml:"kubeReserved"`
+}
+
+// LoadNodePools reads NodePools from YAML files, or from every .yaml and .yml
+// file of a directory, resolving the kubelet settings of the EC2NodeClass
+// each NodePool references. Files may hold several documents.
+func LoadNodePools(paths []string) ([]model.NodePool, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading Karpenter manifests: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(p, pattern))
+			files = append(files, matches...)
+		}
+	}
+	sort.Strings(files)
+
+	var pools []model.NodePool
+	var poolClasses []string
+	classes := make(map[string]model.NodeClassKubelet)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading Karpenter manifests: %w", err)
+		}
+		ps, refs, cs, err := parseManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", f, err)
+		}
+		pools = append(pools, ps...)
+		poolClasses = append(poolClasses, refs...)
+		for name, k := range cs {
+			classes[name] = k
+		}
+	}
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("no NodePool found in %s", strings.Join(paths, ", "))
+	}
+
+	seen := make(map[string]bool, len(pools))
+	for i := range pools {
+		if seen[pools[i].Name] {
+			return nil, fmt.Errorf("duplicate NodePool %q", pools[i].Name)
+		}
+		seen[pools[i].Name] = true
+		// A NodePool's own (v1beta1) kubelet block takes precedence
+		if k, ok := classes[poolClasses[i]]; ok && pools[i].Kubelet == (model.NodeClassKubelet{}) {
+			pools[i].Kubelet = k
+		}
+	}
+	return pools, nil
+}
+
+// parseManifests decodes a multi-document YAML stream, returning its
+// NodePools with the name of their EC2NodeClass, and the kubelet settings of
+// its EC2NodeClasses by name.
+func parseManifests(data []byte) ([]model.NodePool, []string, map[string]model.NodeClassKubelet, error) {
+	var pools []model.NodePool
+	var refs []string
+	classes := make(map[string]model.NodeClassKubelet)
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var m manifest
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		switch m.Kind {
+		case KindNodePool:
+			var spec nodePoolSpec
+			if err := m.Spec.Decode(&spec); err != nil {
+				return nil, nil, nil, fmt.Errorf("NodePool %s: %w", m.Metadata.Name, err)
+			}
+			pool, err := convertNodePool(m.Metadata.Name, spec)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("NodePool %s: %w", m.Metadata.Name, err)
+			}
+			pools = append(pools, pool)
+			refs = append(refs, spec.Template.Spec.NodeClassRef.Name)
+		case KindEC2NodeClass:
+			var spec ec2NodeClassSpec
+			if err := m.Spec.Decode(&spec); err != nil {
+				return nil, nil, nil, fmt.Errorf("EC2NodeClass %s: %w", m.Metadata.Name, err)
+			}
+			k, err := convertKubelet(spec.Kubelet)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("EC2NodeClass %s: %w", m.Metadata.Name, err)
+			}
+			classes[m.Metadata.Name] = k
+		}
+	}
+	return pools, refs, classes, nil
+}
+
+// convertNodePool maps a NodePool spec to the model.
+func convertNodePool(name string, spec nodePoolSpec) (model.NodePool, error) {
+	if name == "" {
+		return model.NodePool{}, fmt.Errorf("metadata.name is required")
+	}
+	tmpl := spec.Template
+	pool := model.NodePool{
+		Name:                name,
+		Weight:              spec.Weight,
+		Requirements:        tmpl.Spec.Requirements,
+		Labels:              tmpl.Metadata.Labels,
+		NodeClass:           tmpl.Spec.NodeClassRef.Name,
+		ConsolidationPolicy: spec.Disruption.ConsolidationPolicy,
+	}
+
+	for i, r := range pool.Requirements {
+		switch r.Operator {
+		case model.OpIn, model.OpNotIn, model.OpExists, model.OpDoesNotExist:
+		case model.OpGt, model.OpLt:
+			if len(r.Values) != 1 {
+				return pool, fmt.Errorf("requirements[%d]: %s takes exactly one value", i, r.Operator)
+			}
+		default:
+			return pool, fmt.Errorf("requirements[%d]: unknown operator %q", i, r.Operator)
+		}
+	}
+
+	for _, t := range tmpl.Spec.Taints {
+		pool.Taints = append(pool.Taints, model.Taint{Key: t.Key, Value: t.Value, Effect: model.TaintEffect(t.Effect)})
+	}
+
+	switch after := spec.Disruption.ConsolidateAfter; after {
+	case "":
+	case "Never":
+		pool.ConsolidateAfter = -1
+	default:
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return pool, fmt.Errorf("disruption.consolidateAfter: %w", err)
+		}
+		pool.ConsolidateAfter = d
+	}
+
+	if v, ok := spec.Limits["cpu"]; ok {
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return pool, fmt.Errorf("limits.cpu: %w", err)
+		}
+		pool.LimitCPUMillis = q.MilliValue()
+	}
+	if v, ok := spec.Limits["memory"]; ok {
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return pool, fmt.Errorf("limits.memory: %w", err)
+		}
+		pool.LimitMemoryBytes = q.Value()
+	}
+
+	k, err := convertKubelet(tmpl.Spec.Kubelet)
+	if err != nil {
+		return pool, err
+	}
+	pool.Kubelet = k
+	return pool, nil
+}
+
+// convertKubelet maps a kubelet configuration block to the model.
+func convertKubelet(spec *kubeletSpec) (model.NodeClassKubelet, error) {
+	var k model.NodeClassKubelet
+	if spec == nil {
+		return k, nil
+	}
+	k.MaxPods = spec.MaxPods
+	k.PodsPerCore = spec.PodsPerCore
+
+	var err error
+	if k.KubeReserved, err = parseReserved(spec.KubeReserved); err != nil {
+		return k, fmt.Errorf("kubelet.kubeReserved: %w", err)
+	}
+	if k.SystemReserved, err = parseReserved(spec.SystemReserved); err != nil {
+		return k, fmt.Errorf("kubelet.systemReserved: %w", err)
+	}
+	return k, nil
+}
+
+// parseReserved converts a {cpu, memory} resource list.
+func parseReserved(list map[string]string) (model.ResourceQuantity, error) {
+	var q model.ResourceQuantity
+	if v, ok := list["cpu"]; ok {
+		cpu, err := resource.ParseQuantity(v)
+		if err != nil {
+			return q, err
+		}
+		q.CPUMillis = cpu.MilliValue()
+	}
+	if v, ok := list["memory"]; ok {
+		mem, err := resource.ParseQuantity(v)
+		if err != nil {
+			return q, err
+		}
+		q.MemoryBytes = mem.Value()
+	}
+	return q, nil
+}
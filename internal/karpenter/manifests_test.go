@@ -0,0 +1,178 @@
+package karpenter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+const manifests = `
+apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: general
+spec:
+  weight: 10
+  template:
+    metadata:
+      labels:
+        team: platform
+    spec:
+      nodeClassRef:
+        group: karpenter.k8s.aws
+        kind: EC2NodeClass
+        name: default
+      requirements:
+        - key: kubernetes.io/arch
+          operator: In
+          values: ["arm64"]
+        - key: karpenter.k8s.aws/instance-category
+          operator: In
+          values: ["c", "m", "r"]
+        - key: karpenter.k8s.aws/instance-generation
+          operator: Gt
+          values: ["5"]
+      taints:
+        - key: dedicated
+          value: platform
+          effect: NoSchedule
+  limits:
+    cpu: "1000"
+    memory: 1000Gi
+  disruption:
+    consolidationPolicy: WhenEmptyOrUnderutilized
+    consolidateAfter: 30s
+---
+apiVersion: karpenter.k8s.aws/v1
+kind: EC2NodeClass
+metadata:
+  name: default
+spec:
+  amiSelectorTerms:
+    - alias: al2023@latest
+  kubelet:
+    maxPods: 110
+    systemReserved:
+      cpu: 100m
+      memory: 100Mi
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+`
+
+func writeManifest(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadNodePools(t *testing.T) {
+	path := writeManifest(t, t.TempDir(), "karpenter.yaml", manifests)
+
+	pools, err := LoadNodePools([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 1 {
+		t.Fatalf("got %d pools, want 1", len(pools))
+	}
+	p := pools[0]
+	if p.Name != "general" || p.Weight != 10 || p.NodeClass != "default" {
+		t.Errorf("pool = %+v", p)
+	}
+	if len(p.Requirements) != 3 || p.Requirements[2].Operator != model.OpGt {
+		t.Errorf("requirements = %v", p.Requirements)
+	}
+	if p.Labels["team"] != "platform" {
+		t.Errorf("labels = %v", p.Labels)
+	}
+	if len(p.Taints) != 1 || p.Taints[0].Effect != model.TaintNoSchedule {
+		t.Errorf("taints = %v", p.Taints)
+	}
+	if p.LimitCPUMillis != 1_000_000 || p.LimitMemoryBytes != 1000<<30 {
+		t.Errorf("limits = %d millis, %d bytes", p.LimitCPUMillis, p.LimitMemoryBytes)
+	}
+	if p.ConsolidationPolicy != model.ConsolidateWhenEmptyOrUnderutilized || p.ConsolidateAfter != 30*time.Second {
+		t.Errorf("disruption = %s after %s", p.ConsolidationPolicy, p.ConsolidateAfter)
+	}
+	want := model.NodeClassKubelet{
+		MaxPods:        110,
+		SystemReserved: model.ResourceQuantity{CPUMillis: 100, MemoryBytes: 100 << 20},
+	}
+	if p.Kubelet != want {
+		t.Errorf("kubelet = %+v, want %+v from the EC2NodeClass", p.Kubelet, want)
+	}
+}
+
+func TestLoadNodePools_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "a.yaml", strings.Replace(manifests, "name: general", "name: a", 1))
+	writeManifest(t, dir, "b.yml", `
+kind: NodePool
+metadata:
+  name: b
+spec:
+  template:
+    spec:
+      kubelet:
+        maxPods: 20
+      nodeClassRef:
+        name: default
+  disruption:
+    consolidateAfter: Never
+`)
+	writeManifest(t, dir, "notes.txt", "kind: NodePool")
+
+	pools, err := LoadNodePools([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 || pools[0].Name != "a" || pools[1].Name != "b" {
+		t.Fatalf("pools = %v", pools)
+	}
+	// A v1beta1 kubelet block on the NodePool wins over the EC2NodeClass
+	if pools[1].Kubelet.MaxPods != 20 || !pools[1].Kubelet.SystemReserved.IsZero() {
+		t.Errorf("kubelet = %+v, want the NodePool's own", pools[1].Kubelet)
+	}
+	if pools[1].ConsolidateAfter >= 0 {
+		t.Errorf("consolidateAfter Never = %s, want negative", pools[1].ConsolidateAfter)
+	}
+}
+
+func TestLoadNodePools_Invalid(t *testing.T) {
+	pool := func(name, body string) string {
+		return "kind: NodePool\nmetadata:\n  name: " + name + "\nspec:\n" + body
+	}
+	tests := map[string]string{
+		"none":      "kind: EC2NodeClass\nmetadata:\n  name: default\n",
+		"operator":  pool("p", "  template:\n    spec:\n      requirements:\n        - {key: karpenter.k8s.aws/instance-cpu, operator: Above, values: [\"4\"]}\n"),
+		"gt":        pool("p", "  template:\n    spec:\n      requirements:\n        - {key: karpenter.k8s.aws/instance-cpu, operator: Gt, values: [\"4\", \"8\"]}\n"),
+		"limits":    pool("p", "  limits:\n    cpu: lots\n"),
+		"after":     pool("p", "  disruption:\n    consolidateAfter: soon\n"),
+		"reserved":  pool("p", "  template:\n    spec:\n      kubelet:\n        kubeReserved: {memory: plenty}\n"),
+		"duplicate": pool("p", "  weight: 1\n") + "---\n" + pool("p", "  weight: 2\n"),
+		"unnamed":   "kind: NodePool\nspec: {}\n",
+		"malformed": "kind: [NodePool\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeManifest(t, t.TempDir(), name+".yaml", content)
+			if _, err := LoadNodePools([]string{path}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadNodePools([]string{filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
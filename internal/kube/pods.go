@@ -0,0 +1,187 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// ListPodConstraints lists running pods and returns their scheduling constraints
+// (node selector, tolerations, required architecture, pod affinity and
+// topology spread rules between replicas, and PodDisruptionBudget coverage)
+// keyed by "namespace/pod". An empty namespace lists pods in all namespaces.
+// PodDisruptionBudgets are optional: when they cannot be listed (typically a
+// missing policy/v1 RBAC permission) a warning is printed and the pods are
+// returned without DisruptionBudget set.
+func ListPodConstraints(ctx context.Context, client kubernetes.Interface, namespace string) (map[string]model.PodConstraints, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	var pdbs []policyv1.PodDisruptionBudget
+	pdbList, err := client.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: could not list pod disruption budgets, assuming none: %v\n", err)
+	} else {
+		pdbs = pdbList.Items
+	}
+
+	result := make(map[string]model.PodConstraints, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		c := podConstraints(pod)
+		c.DisruptionBudget = coveredByPDB(pod, pdbs)
+		result[pod.Namespace+"/"+pod.Name] = c
+	}
+	return result, nil
+}
+
+// coveredByPDB reports whether a PodDisruptionBudget in the pod's namespace
+// selects it. An empty selector selects every pod of the namespace; a
+// missing one selects none.
+func coveredByPDB(pod *corev1.Pod, pdbs []policyv1.PodDisruptionBudget) bool {
+	for i := range pdbs {
+		pdb := &pdbs[i]
+		if pdb.Namespace != pod.Namespace || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err == nil && selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// podConstraints extracts the scheduling constraints the simulation understands.
+func podConstraints(pod *corev1.Pod) model.PodConstraints {
+	c := model.PodConstraints{}
+
+	if len(pod.Spec.NodeSelector) > 0 {
+		c.NodeSelector = make(map[string]string, len(pod.Spec.NodeSelector))
+		for k, v := range pod.Spec.NodeSelector {
+			c.NodeSelector[k] = v
+		}
+	}
+
+	for _, t := range pod.Spec.Tolerations {
+		c.Tolerations = append(c.Tolerations, formatToleration(t))
+	}
+	sort.Strings(c.Tolerations)
+
+	c.Architecture = model.ArchitectureFromSelector(c.NodeSelector)
+	if c.Architecture == "" {
+		c.Architecture = requiredAffinityArch(pod.Spec.Affinity)
+	}
+
+	if aff := pod.Spec.Affinity; aff != nil {
+		if aff.PodAffinity != nil {
+			c.PodAffinity = selfAffinityTerms(pod, aff.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		}
+		if aff.PodAntiAffinity != nil {
+			c.PodAntiAffinity = selfAffinityTerms(pod, aff.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		}
+	}
+
+	for _, tsc := range pod.Spec.TopologySpreadConstraints {
+		if !selectsSelf(pod, tsc.LabelSelector) {
+			continue
+		}
+		c.TopologySpread = append(c.TopologySpread, model.TopologySpreadConstraint{
+			TopologyKey: tsc.TopologyKey,
+			MaxSkew:     tsc.MaxSkew,
+			Hard:        tsc.WhenUnsatisfiable == corev1.DoNotSchedule,
+		})
+	}
+	return c
+}
+
+// selfAffinityTerms converts required pod (anti-)affinity terms whose label
+// selector matches the pod itself, i.e. rules between replicas of the same
+// controller. Terms targeting other pods cannot be mapped to a replica group
+// from the selector alone and are skipped.
+func selfAffinityTerms(pod *corev1.Pod, terms []corev1.PodAffinityTerm) []model.PodAffinityTerm {
+	var result []model.PodAffinityTerm
+	for _, t := range terms {
+		if len(t.Namespaces) > 0 && !contains(t.Namespaces, pod.Namespace) {
+			continue
+		}
+		if !selectsSelf(pod, t.LabelSelector) {
+			continue
+		}
+		result = append(result, model.PodAffinityTerm{TopologyKey: t.TopologyKey})
+	}
+	return result
+}
+
+// selectsSelf reports whether a label selector matches the pod's own labels.
+func selectsSelf(pod *corev1.Pod, sel *metav1.LabelSelector) bool {
+	if sel == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sel)
+	if err != nil || selector.Empty() {
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+func contains(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredAffinityArch returns the architecture when required node affinity
+// pins kubernetes.io/arch to a single value in every term, or empty otherwise.
+func requiredAffinityArch(aff *corev1.Affinity) model.Architecture {
+	if aff == nil || aff.NodeAffinity == nil || aff.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+
+	var arch string
+	for _, term := range aff.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		termArch := ""
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == model.LabelArch && expr.Operator == corev1.NodeSelectorOpIn && len(expr.Values) == 1 {
+				termArch = expr.Values[0]
+			}
+		}
+		// Terms are ORed: any term without an arch pin allows every architecture
+		if termArch == "" || (arch != "" && termArch != arch) {
+			return ""
+		}
+		arch = termArch
+	}
+	return model.Architecture(arch)
+}
+
+// formatToleration renders a Kubernetes toleration in kubectl notation
+// (see model.WorkloadProfile.Tolerations).
+func formatToleration(t corev1.Toleration) string {
+	if t.Key == "" && t.Operator == corev1.TolerationOpExists && t.Effect == "" {
+		return "*"
+	}
+	s := t.Key
+	if t.Operator != corev1.TolerationOpExists {
+		s += "=" + t.Value
+	}
+	if t.Effect != "" {
+		s += ":" + string(t.Effect)
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestListPodConstraints(t *testing.T) {
+	gpu := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "trainer-0", Namespace: "ml"},
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"kubernetes.io/arch": "amd64", "nvidia.com/gpu.present": "true"},
+			Tolerations: []corev1.Toleration{
+				{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ml", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	arm := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "prod"},
+		Spec: corev1.PodSpec{
+			Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"},
+						}},
+					}},
+				},
+			}},
+			Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	client := fake.NewSimpleClientset(gpu, arm) //nolint:staticcheck // NewClientset requires generated apply configs
+
+	got, err := ListPodConstraints(context.Background(), client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := got["ml/trainer-0"]
+	if g.Architecture != model.ArchAMD64 {
+		t.Errorf("trainer arch = %q, want amd64", g.Architecture)
+	}
+	if g.NodeSelector["nvidia.com/gpu.present"] != "true" {
+		t.Errorf("trainer selector = %v", g.NodeSelector)
+	}
+	wantTols := []string{"dedicated=ml:NoSchedule", "nvidia.com/gpu:NoSchedule"}
+	if len(g.Tolerations) != len(wantTols) {
+		t.Fatalf("trainer tolerations = %v, want %v", g.Tolerations, wantTols)
+	}
+	for i := range wantTols {
+		if g.Tolerations[i] != wantTols[i] {
+			t.Errorf("toleration[%d] = %q, want %q", i, g.Tolerations[i], wantTols[i])
+		}
+	}
+
+	a := got["prod/api-1"]
+	if a.Architecture != model.ArchARM64 {
+		t.Errorf("api arch = %q, want arm64 (from required affinity)", a.Architecture)
+	}
+	if len(a.Tolerations) != 1 || a.Tolerations[0] != "*" {
+		t.Errorf("api tolerations = %v, want [*]", a.Tolerations)
+	}
+}
+
+func TestListPodConstraints_DisruptionBudget(t *testing.T) {
+	pod := func(name, ns, app string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{"app": app}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	pdb := func(name, ns string, sel *metav1.LabelSelector) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: sel},
+		}
+	}
+
+	client := fake.NewSimpleClientset( //nolint:staticcheck // NewClientset requires generated apply configs
+		pod("db-0", "prod", "db"), pod("web-1", "prod", "web"),
+		pod("db-0", "dev", "db"), pod("worker-1", "batch", "worker"),
+		pdb("db", "prod", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}),
+		pdb("all", "batch", &metav1.LabelSelector{}),
+		pdb("none", "dev", nil),
+	)
+
+	got, err := ListPodConstraints(context.Background(), client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"prod/db-0": true, "prod/web-1": false, "dev/db-0": false, "batch/worker-1": true}
+	for key, covered := range want {
+		if got[key].DisruptionBudget != covered {
+			t.Errorf("%s DisruptionBudget = %v, want %v", key, got[key].DisruptionBudget, covered)
+		}
+	}
+}
+
+func TestListPodConstraints_PDBListForbidden(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "prod"},
+		Spec:       corev1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/arch": "arm64"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	client := fake.NewSimpleClientset(pod) //nolint:staticcheck // NewClientset requires generated apply configs
+	client.PrependReactor("list", "poddisruptionbudgets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "policy", Resource: "poddisruptionbudgets"}, "", errors.New("rbac"))
+	})
+
+	got, err := ListPodConstraints(context.Background(), client, "")
+	if err != nil {
+		t.Fatalf("PDB list failure should not be fatal: %v", err)
+	}
+	c, ok := got["prod/api-0"]
+	if !ok {
+		t.Fatal("pod constraints missing")
+	}
+	if c.Architecture != model.ArchARM64 {
+		t.Errorf("arch = %q, want arm64", c.Architecture)
+	}
+	if c.DisruptionBudget {
+		t.Error("DisruptionBudget should be unset when PDBs cannot be listed")
+	}
+}
+
+func TestRequiredAffinityArch_MultipleTerms(t *testing.T) {
+	term := func(values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+			Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: values,
+		}}}
+	}
+	aff := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+
+	if got := requiredAffinityArch(aff(term("arm64"), term("arm64"))); got != model.ArchARM64 {
+		t.Errorf("same arch in every term: got %q", got)
+	}
+	if got := requiredAffinityArch(aff(term("arm64"), term("amd64"))); got != "" {
+		t.Errorf("different arch per term: got %q, want empty", got)
+	}
+	if got := requiredAffinityArch(aff(term("arm64", "amd64"))); got != "" {
+		t.Errorf("multi-value In: got %q, want empty", got)
+	}
+	if got := requiredAffinityArch(nil); got != "" {
+		t.Errorf("nil affinity: got %q, want empty", got)
+	}
+}
+
+func TestPodConstraints_SelfAffinityAndSpread(t *testing.T) {
+	self := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}
+	other := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "prod", Labels: map[string]string{"app": "api"}},
+		Spec: corev1.PodSpec{
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+						{LabelSelector: self, TopologyKey: "kubernetes.io/hostname"},
+						{LabelSelector: other, TopologyKey: "kubernetes.io/hostname"},
+					},
+				},
+			},
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: self},
+				{MaxSkew: 2, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.ScheduleAnyway, LabelSelector: self},
+			},
+		},
+	}
+
+	c := podConstraints(pod)
+	if len(c.PodAntiAffinity) != 1 || c.PodAntiAffinity[0].TopologyKey != model.TopologyHostname || c.PodAntiAffinity[0].Group != "" {
+		t.Errorf("anti-affinity = %+v, want one self term on hostname", c.PodAntiAffinity)
+	}
+	if len(c.TopologySpread) != 2 {
+		t.Fatalf("spread = %+v, want 2 constraints", c.TopologySpread)
+	}
+	if !c.TopologySpread[0].Hard || c.TopologySpread[1].Hard {
+		t.Errorf("spread hardness = %v/%v, want true/false", c.TopologySpread[0].Hard, c.TopologySpread[1].Hard)
+	}
+}
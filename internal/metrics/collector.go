@@ -28,9 +28,13 @@ type MetricsCollector interface {
 // CollectOptions configures metrics collection.
 type CollectOptions struct {
 	Window            model.TimeWindow
-	Namespaces        []string      // Empty = all namespaces
-	ExcludeNamespaces []string      // Namespaces to exclude
-	LabelSelector     string        // Optional label filter
-	Percentile        float64       // Which percentile for effective sizing (default 0.95)
-	StepInterval      time.Duration // PromQL step interval
+	Namespaces        []string           // Empty = all namespaces
+	ExcludeNamespaces []string           // Namespaces to exclude
+	LabelSelector     string             // Optional label filter
+	Percentile        float64            // Which percentile for effective sizing (default 0.95); 1.0 = max
+	ExtraPercentiles  []float64          // Further percentiles stored with each pod's usage
+	CPUSizing         model.SizingPolicy // CPU sizing; a zero Percentile uses Percentile
+	MemorySizing      model.SizingPolicy // Memory sizing; a zero Percentile uses Percentile
+	StepInterval      time.Duration      // PromQL step interval
+	SeriesStep        time.Duration      // When set, also store per-pod usage series at this resolution
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,12 +23,6 @@ const (
 
 	// defaultStep is the PromQL step interval when none is configured.
 	defaultStep = "5m"
-
-	// minEffectiveCPUMillis is the floor for workload CPU sizing (prevents zero-sized pods).
-	minEffectiveCPUMillis = 10
-
-	// minEffectiveMemoryBytes is the floor for workload memory sizing.
-	minEffectiveMemoryBytes = 64 * 1024 * 1024 // 64 MiB
 )
 
 // PrometheusCollector collects metrics from Prometheus, Thanos, or Cortex.
@@ -34,8 +31,15 @@ type PrometheusCollector struct {
 	endpoint string
 	backend  string
 	timeout  time.Duration
+
+	// Optional Kubernetes API source for pod scheduling constraints
+	constraintLister PodConstraintLister
 }
 
+// PodConstraintLister returns scheduling constraints for running pods, keyed
+// by "namespace/pod". Implemented by kube.ListPodConstraints.
+type PodConstraintLister func(ctx context.Context) (map[string]model.PodConstraints, error)
+
 // PrometheusOption configures the Prometheus collector.
 type PrometheusOption func(*PrometheusCollector)
 
@@ -44,6 +48,13 @@ func WithTimeout(d time.Duration) PrometheusOption {
 	return func(c *PrometheusCollector) { c.timeout = d }
 }
 
+// WithConstraintLister reads pod scheduling constraints from the Kubernetes API
+// instead of kube-state-metrics. The API preserves original label keys and
+// required node affinity, which the sanitized metric labels cannot.
+func WithConstraintLister(l PodConstraintLister) PrometheusOption {
+	return func(c *PrometheusCollector) { c.constraintLister = l }
+}
+
 // NewPrometheusCollector creates a collector connected to the given endpoint.
 func NewPrometheusCollector(endpoint string, opts ...PrometheusOption) (*PrometheusCollector, error) {
 	client, err := promapi.NewClient(promapi.Config{
@@ -120,23 +131,31 @@ func (c *PrometheusCollector) Collect(ctx context.Context, opts CollectOptions)
 	}
 
 	queries := map[string]string{
-		"cpu_p50":        queryCPUPercentile(0.50, windowStr, stepStr),
-		"cpu_p95":        queryCPUPercentile(0.95, windowStr, stepStr),
-		"cpu_p99":        queryCPUPercentile(0.99, windowStr, stepStr),
-		"mem_p50":        queryMemoryPercentile(0.50, windowStr, stepStr),
-		"mem_p95":        queryMemoryPercentile(0.95, windowStr, stepStr),
-		"mem_p99":        queryMemoryPercentile(0.99, windowStr, stepStr),
+		"cpu_max":        queryCPUMax(windowStr, stepStr),
+		"mem_max":        queryMemoryMax(windowStr, stepStr),
 		"cpu_requests":   queryPodResourceRequests("cpu"),
 		"mem_requests":   queryPodResourceRequests("memory"),
 		"cpu_limits":     queryPodResourceLimits("cpu"),
 		"mem_limits":     queryPodResourceLimits("memory"),
 		"pod_owner":           queryPodOwner(),
+		"replicaset_owner":    queryReplicaSetOwner(),
+		"replicas_min":        queryReplicasMin(windowStr, stepStr),
+		"replicas_p95":        queryReplicasPercentile(0.95, windowStr, stepStr),
+		"replicas_max":        queryReplicasMax(windowStr, stepStr),
+		"hpa_min_replicas":    queryHPAReplicas("min_replicas"),
+		"hpa_max_replicas":    queryHPAReplicas("max_replicas"),
+		"pod_nodeselectors":   queryPodNodeSelectors(),
+		"pod_tolerations":     queryPodTolerations(),
 		"running_pods":        queryRunningPods(),
 		"cluster_cpu_p95":     queryClusterCPUPercentile(0.95, windowStr, stepStr),
 		"cluster_mem_p95":     queryClusterMemoryPercentile(0.95, windowStr, stepStr),
 		"min_node_count":      queryMinNodeCount(windowStr, stepStr),
 		"max_node_count":      queryMaxNodeCount(windowStr, stepStr),
 	}
+	for _, pct := range collectedPercentiles(opts) {
+		queries[usageKey("cpu", pct)] = queryCPUPercentile(pct, windowStr, stepStr)
+		queries[usageKey("mem", pct)] = queryMemoryPercentile(pct, windowStr, stepStr)
+	}
 
 	results := make(chan queryResult, len(queries))
 	queryCtx, cancel := context.WithTimeout(ctx, c.timeout)
@@ -161,7 +180,65 @@ func (c *PrometheusCollector) Collect(ctx context.Context, opts CollectOptions)
 	}
 
 	// Build workload profiles from collected data
-	return c.buildClusterState(collected, opts, errs)
+	state, err := c.buildClusterState(collected, opts, errs)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SeriesStep > 0 {
+		if err := c.collectSeries(ctx, opts, state); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.constraintLister != nil {
+		byPod, err := c.constraintLister(ctx)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: could not list pod constraints from Kubernetes API: %v\n", err)
+		} else {
+			state.ApplyConstraints(byPod, model.ConstraintsFromKubernetesAPI)
+		}
+	}
+
+	// One profile per controller, once per-pod series and constraints are in
+	state.AggregateReplicas(extractReplicaSetOwners(collected["replicaset_owner"]))
+	state.ApplyReplicaRanges(replicaRanges(collected))
+
+	return state, nil
+}
+
+// sizingPolicies returns the CPU and memory sizing policies, with the
+// sizing percentile filled in where a policy sets none.
+func sizingPolicies(opts CollectOptions) (cpu, mem model.SizingPolicy) {
+	cpu, mem = opts.CPUSizing, opts.MemorySizing
+	if cpu.Percentile == 0 {
+		cpu.Percentile = opts.Percentile
+	}
+	if mem.Percentile == 0 {
+		mem.Percentile = opts.Percentile
+	}
+	return cpu, mem
+}
+
+// collectedPercentiles returns the percentiles queried for each pod: the
+// sizing percentiles of both policies and the extra ones, ascending. 1.0 is
+// left out, as the max comes from max_over_time.
+func collectedPercentiles(opts CollectOptions) []float64 {
+	cpu, mem := sizingPolicies(opts)
+	var pcts []float64
+	for _, p := range append([]float64{cpu.Percentile, mem.Percentile}, opts.ExtraPercentiles...) {
+		if p > 0 && p < 1 && !slices.Contains(pcts, p) {
+			pcts = append(pcts, p)
+		}
+	}
+	slices.Sort(pcts)
+	return pcts
+}
+
+// usageKey names the query for a resource's usage at a percentile, e.g.
+// "cpu_p95" or "mem_p99.9".
+func usageKey(resource string, pct float64) string {
+	return resource + "_" + strings.ToLower(model.PercentileLabel(pct))
 }
 
 // podKey creates a unique key for a pod.
@@ -177,17 +254,23 @@ func (c *PrometheusCollector) buildClusterState(
 	queryErrors []string,
 ) (*model.ClusterState, error) {
 	// Index all metrics by (namespace, pod)
-	cpuP50 := extractVector(data["cpu_p50"])
-	cpuP95 := extractVector(data["cpu_p95"])
-	cpuP99 := extractVector(data["cpu_p99"])
-	memP50 := extractVector(data["mem_p50"])
-	memP95 := extractVector(data["mem_p95"])
-	memP99 := extractVector(data["mem_p99"])
+	cpuPolicy, memPolicy := sizingPolicies(opts)
+	percentiles := collectedPercentiles(opts)
+	cpuAt := make(map[float64]map[podKey]float64, len(percentiles))
+	memAt := make(map[float64]map[podKey]float64, len(percentiles))
+	for _, pct := range percentiles {
+		cpuAt[pct] = extractVector(data[usageKey("cpu", pct)])
+		memAt[pct] = extractVector(data[usageKey("mem", pct)])
+	}
+	cpuMax := extractVector(data["cpu_max"])
+	memMax := extractVector(data["mem_max"])
 	cpuReq := extractVector(data["cpu_requests"])
 	memReq := extractVector(data["mem_requests"])
 	cpuLim := extractVector(data["cpu_limits"])
 	memLim := extractVector(data["mem_limits"])
 	owners := extractOwnerInfo(data["pod_owner"])
+	selectors := extractNodeSelectors(data["pod_nodeselectors"])
+	tolerations := extractTolerations(data["pod_tolerations"])
 
 	// Use running pods as the anchor to avoid counting ghost pods from the
 	// historical quantile_over_time window (completed Jobs, old ReplicaSet
@@ -218,6 +301,7 @@ func (c *PrometheusCollector) buildClusterState(
 	}
 
 	var workloads, daemonSets []model.WorkloadProfile
+	var constrained int
 
 	for pk := range allPods {
 		if excludeNS[pk.Namespace] {
@@ -227,18 +311,6 @@ func (c *PrometheusCollector) buildClusterState(
 		wp := model.WorkloadProfile{
 			Namespace: pk.Namespace,
 			Name:      pk.Pod,
-			CPUUsage: model.PercentileValues{
-				P50: cpuP50[pk],
-				P95: cpuP95[pk],
-				P99: cpuP99[pk],
-				Max: cpuP99[pk], // Use P99 as max approximation
-			},
-			MemoryUsage: model.PercentileValues{
-				P50: memP50[pk],
-				P95: memP95[pk],
-				P99: memP99[pk],
-				Max: memP99[pk],
-			},
 			Requested: model.ResourceQuantity{
 				CPUMillis:   int64(cpuReq[pk] * 1000),
 				MemoryBytes: int64(memReq[pk]),
@@ -249,28 +321,16 @@ func (c *PrometheusCollector) buildClusterState(
 			},
 		}
 
-		// Determine effective sizing based on configured percentile
-		cpuAtPct := wp.CPUUsage.AtPercentile(opts.Percentile)
-		memAtPct := wp.MemoryUsage.AtPercentile(opts.Percentile)
-
-		// Use the max of (request, observed usage at percentile) for bin-packing
-		wp.EffectiveCPUMillis = int64(math.Max(float64(wp.Requested.CPUMillis), cpuAtPct*1000))
-		wp.EffectiveMemoryBytes = int64(math.Max(float64(wp.Requested.MemoryBytes), memAtPct))
-
-		// If no metrics at all, mark and use requests
-		if cpuP95[pk] == 0 && memP95[pk] == 0 {
-			wp.NoMetrics = true
-			wp.EffectiveCPUMillis = wp.Requested.CPUMillis
-			wp.EffectiveMemoryBytes = wp.Requested.MemoryBytes
+		// Observed usage at each collected percentile, and the max
+		for _, pct := range percentiles {
+			wp.CPUUsage.Set(pct, cpuAt[pct][pk])
+			wp.MemoryUsage.Set(pct, memAt[pct][pk])
 		}
+		wp.CPUUsage.Max = cpuMax[pk]
+		wp.MemoryUsage.Max = memMax[pk]
 
-		// Minimum effective values to prevent zero-sized pods
-		if wp.EffectiveCPUMillis < minEffectiveCPUMillis {
-			wp.EffectiveCPUMillis = minEffectiveCPUMillis
-		}
-		if wp.EffectiveMemoryBytes < minEffectiveMemoryBytes {
-			wp.EffectiveMemoryBytes = minEffectiveMemoryBytes
-		}
+		// Effective sizing under the CPU and memory policies
+		wp.Resize(cpuPolicy, memPolicy)
 
 		// Check owner info for DaemonSet
 		if owner, ok := owners[pk]; ok {
@@ -281,6 +341,17 @@ func (c *PrometheusCollector) buildClusterState(
 			}
 		}
 
+		// Scheduling constraints from kube-state-metrics
+		if sel, ok := selectors[pk]; ok {
+			wp.NodeSelector = sel
+			wp.Architecture = model.ArchitectureFromSelector(sel)
+			constrained++
+		}
+		if tols, ok := tolerations[pk]; ok {
+			wp.Tolerations = tols
+			constrained++
+		}
+
 		if wp.IsDaemonSet {
 			daemonSets = append(daemonSets, wp)
 		} else {
@@ -294,6 +365,9 @@ func (c *PrometheusCollector) buildClusterState(
 		Workloads:     workloads,
 		DaemonSets:    daemonSets,
 	}
+	if constrained > 0 {
+		state.ConstraintsSource = model.ConstraintsFromKubeStateMetrics
+	}
 
 	// Populate cluster-wide aggregate metrics if available
 	clusterCPU := extractScalar(data["cluster_cpu_p95"])
@@ -367,6 +441,185 @@ func extractOwnerInfo(v prommodel.Value) map[podKey]ownerInfo {
 	return result
 }
 
+// extractReplicaSetOwners parses the kube_replicaset_owner metric into the
+// Deployment owning each ReplicaSet, keyed by "namespace/replicaset".
+func extractReplicaSetOwners(v prommodel.Value) map[string]string {
+	result := make(map[string]string)
+	vec, ok := v.(prommodel.Vector)
+	if !ok {
+		return result
+	}
+
+	for _, sample := range vec {
+		ns := string(sample.Metric["namespace"])
+		rs := string(sample.Metric["replicaset"])
+		name := string(sample.Metric["owner_name"])
+		if ns == "" || rs == "" || name == "" || sample.Metric["owner_kind"] != "Deployment" {
+			continue
+		}
+		result[ns+"/"+rs] = name
+	}
+	return result
+}
+
+// replicaRanges builds the replica range of each controller, keyed by
+// ControllerKey, from the replica history and HPA queries.
+func replicaRanges(data map[string]prommodel.Value) map[string]model.ReplicaRange {
+	ranges := make(map[string]model.ReplicaRange)
+	set := func(name string, apply func(r *model.ReplicaRange, n int32)) {
+		for key, v := range extractOwnerVector(data[name]) {
+			r := ranges[key]
+			apply(&r, int32(math.Ceil(v)))
+			ranges[key] = r
+		}
+	}
+	set("replicas_min", func(r *model.ReplicaRange, n int32) { r.Min = n })
+	set("replicas_p95", func(r *model.ReplicaRange, n int32) { r.P95 = n })
+	set("replicas_max", func(r *model.ReplicaRange, n int32) { r.Max = n })
+	set("hpa_min_replicas", func(r *model.ReplicaRange, n int32) { r.HPAMin = n })
+	set("hpa_max_replicas", func(r *model.ReplicaRange, n int32) { r.HPAMax = n })
+	return ranges
+}
+
+// extractOwnerVector converts a Prometheus Value labeled by (namespace,
+// owner_kind, owner_name) to a map keyed like WorkloadProfile.ControllerKey.
+func extractOwnerVector(v prommodel.Value) map[string]float64 {
+	result := make(map[string]float64)
+	vec, ok := v.(prommodel.Vector)
+	if !ok {
+		return result
+	}
+
+	for _, sample := range vec {
+		ns := string(sample.Metric["namespace"])
+		kind := string(sample.Metric["owner_kind"])
+		name := string(sample.Metric["owner_name"])
+		if ns == "" || kind == "" || name == "" {
+			continue
+		}
+		result[ns+"/"+kind+"/"+name] = float64(sample.Value)
+	}
+	return result
+}
+
+// nodeSelectorLabelPrefix is the label prefix kube-state-metrics uses for
+// kube_pod_nodeselectors.
+const nodeSelectorLabelPrefix = "nodeselector_"
+
+// wellKnownSelectorKeys maps sanitized Prometheus label names back to the
+// original node label keys. kube-state-metrics replaces every character outside
+// [a-zA-Z0-9_] with "_", so arbitrary keys cannot be recovered; these are the
+// keys a NodeTemplate can actually satisfy.
+var wellKnownSelectorKeys = func() map[string]string {
+	keys := []string{
+		model.LabelArch,
+		model.LabelOS,
+		model.LabelInstanceType,
+		model.LabelInstanceFamily,
+		model.LabelCapacityType,
+		model.LabelRegion,
+		"beta.kubernetes.io/arch",
+		"beta.kubernetes.io/instance-type",
+		"eks.amazonaws.com/capacityType",
+		"eks.amazonaws.com/nodegroup",
+		"karpenter.sh/nodepool",
+		"topology.kubernetes.io/zone",
+	}
+	m := make(map[string]string, len(keys))
+	for _, k := range keys {
+		m[sanitizeLabelName(k)] = k
+	}
+	return m
+}()
+
+// sanitizeLabelName mirrors kube-state-metrics label-name sanitization.
+func sanitizeLabelName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// extractNodeSelectors parses pod node selectors from the kube_pod_nodeselectors metric.
+func extractNodeSelectors(v prommodel.Value) map[podKey]map[string]string {
+	result := make(map[podKey]map[string]string)
+	vec, ok := v.(prommodel.Vector)
+	if !ok {
+		return result
+	}
+
+	for _, sample := range vec {
+		ns := string(sample.Metric["namespace"])
+		pod := string(sample.Metric["pod"])
+		if ns == "" || pod == "" {
+			continue
+		}
+		sel := make(map[string]string)
+		for name, value := range sample.Metric {
+			key, found := strings.CutPrefix(string(name), nodeSelectorLabelPrefix)
+			if !found || value == "" {
+				continue
+			}
+			if original, ok := wellKnownSelectorKeys[key]; ok {
+				key = original
+			}
+			sel[key] = string(value)
+		}
+		if len(sel) > 0 {
+			result[podKey{ns, pod}] = sel
+		}
+	}
+	return result
+}
+
+// extractTolerations parses pod tolerations from the kube_pod_tolerations metric
+// into kubectl notation (see model.WorkloadProfile.Tolerations).
+func extractTolerations(v prommodel.Value) map[podKey][]string {
+	result := make(map[podKey][]string)
+	vec, ok := v.(prommodel.Vector)
+	if !ok {
+		return result
+	}
+
+	for _, sample := range vec {
+		ns := string(sample.Metric["namespace"])
+		pod := string(sample.Metric["pod"])
+		if ns == "" || pod == "" {
+			continue
+		}
+		tol := formatToleration(
+			string(sample.Metric["key"]),
+			string(sample.Metric["operator"]),
+			string(sample.Metric["value"]),
+			string(sample.Metric["effect"]),
+		)
+		pk := podKey{ns, pod}
+		result[pk] = append(result[pk], tol)
+	}
+	for pk := range result {
+		sort.Strings(result[pk])
+	}
+	return result
+}
+
+// formatToleration renders a Kubernetes toleration in kubectl notation.
+func formatToleration(key, operator, value, effect string) string {
+	if key == "" && operator == "Exists" && effect == "" {
+		return "*"
+	}
+	s := key
+	if operator != "Exists" {
+		s += "=" + value
+	}
+	if effect != "" {
+		s += ":" + effect
+	}
+	return s
+}
+
 // extractScalar extracts a single float64 value from a Prometheus query result.
 // Works with both Vector (single-element) and Scalar result types.
 func extractScalar(v prommodel.Value) float64 {
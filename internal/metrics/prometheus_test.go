@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"slices"
+	"testing"
+
+	prommodel "github.com/prometheus/common/model"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestExtractNodeSelectors(t *testing.T) {
+	vec := prommodel.Vector{
+		&prommodel.Sample{Metric: prommodel.Metric{
+			"namespace":                          "prod",
+			"pod":                                "api-1",
+			"nodeselector_kubernetes_io_arch":    "arm64",
+			"nodeselector_team":                  "payments",
+			"nodeselector_unknown_example_com_x": "",
+		}, Value: 1},
+		&prommodel.Sample{Metric: prommodel.Metric{"namespace": "prod", "pod": "web-1"}, Value: 1},
+	}
+
+	got := extractNodeSelectors(vec)
+	sel, ok := got[podKey{"prod", "api-1"}]
+	if !ok {
+		t.Fatal("missing selector for prod/api-1")
+	}
+	if sel["kubernetes.io/arch"] != "arm64" {
+		t.Errorf("well-known key not restored: %v", sel)
+	}
+	if sel["team"] != "payments" {
+		t.Errorf("custom key missing: %v", sel)
+	}
+	if len(sel) != 2 {
+		t.Errorf("expected 2 selector entries, got %v", sel)
+	}
+	if _, ok := got[podKey{"prod", "web-1"}]; ok {
+		t.Error("pod without selectors should be absent")
+	}
+}
+
+func TestExtractReplicaSetOwners(t *testing.T) {
+	vec := prommodel.Vector{
+		&prommodel.Sample{Metric: prommodel.Metric{
+			"namespace": "prod", "replicaset": "api-7d4b9c8f6d", "owner_kind": "Deployment", "owner_name": "api",
+		}, Value: 1},
+		&prommodel.Sample{Metric: prommodel.Metric{
+			"namespace": "prod", "replicaset": "orphan-5f6b7c9d8", "owner_kind": "<none>", "owner_name": "<none>",
+		}, Value: 1},
+	}
+
+	got := extractReplicaSetOwners(vec)
+	if len(got) != 1 || got["prod/api-7d4b9c8f6d"] != "api" {
+		t.Errorf("got %v, want only prod/api-7d4b9c8f6d owned by api", got)
+	}
+	if got := extractReplicaSetOwners(nil); len(got) != 0 {
+		t.Errorf("got %v for no data", got)
+	}
+}
+
+func TestReplicaRanges(t *testing.T) {
+	api := prommodel.Metric{"namespace": "prod", "owner_kind": "Deployment", "owner_name": "api"}
+	data := map[string]prommodel.Value{
+		"replicas_min":     prommodel.Vector{&prommodel.Sample{Metric: api, Value: 2}},
+		"replicas_p95":     prommodel.Vector{&prommodel.Sample{Metric: api, Value: 5.4}},
+		"replicas_max":     prommodel.Vector{&prommodel.Sample{Metric: api, Value: 8}},
+		"hpa_min_replicas": prommodel.Vector{&prommodel.Sample{Metric: api, Value: 2}},
+		"hpa_max_replicas": prommodel.Vector{
+			&prommodel.Sample{Metric: api, Value: 10},
+			&prommodel.Sample{Metric: prommodel.Metric{"namespace": "prod", "owner_kind": "Deployment"}, Value: 4},
+		},
+	}
+
+	got := replicaRanges(data)
+	want := model.ReplicaRange{Min: 2, P95: 6, Max: 8, HPAMin: 2, HPAMax: 10}
+	if len(got) != 1 || got["prod/Deployment/api"] != want {
+		t.Errorf("got %+v, want only prod/Deployment/api with %+v", got, want)
+	}
+	if got := replicaRanges(nil); len(got) != 0 {
+		t.Errorf("got %v for no data", got)
+	}
+}
+
+func TestExtractTolerations(t *testing.T) {
+	vec := prommodel.Vector{
+		&prommodel.Sample{Metric: prommodel.Metric{
+			"namespace": "ml", "pod": "trainer-0",
+			"key": "nvidia.com/gpu", "operator": "Exists", "effect": "NoSchedule",
+		}, Value: 1},
+		&prommodel.Sample{Metric: prommodel.Metric{
+			"namespace": "ml", "pod": "trainer-0",
+			"key": "dedicated", "operator": "Equal", "value": "ml", "effect": "NoSchedule",
+		}, Value: 1},
+		&prommodel.Sample{Metric: prommodel.Metric{
+			"namespace": "ml", "pod": "agent", "operator": "Exists",
+		}, Value: 1},
+	}
+
+	got := extractTolerations(vec)
+	tols := got[podKey{"ml", "trainer-0"}]
+	want := []string{"dedicated=ml:NoSchedule", "nvidia.com/gpu:NoSchedule"}
+	if len(tols) != len(want) {
+		t.Fatalf("tolerations = %v, want %v", tols, want)
+	}
+	for i := range want {
+		if tols[i] != want[i] {
+			t.Errorf("toleration[%d] = %q, want %q", i, tols[i], want[i])
+		}
+	}
+	if agent := got[podKey{"ml", "agent"}]; len(agent) != 1 || agent[0] != "*" {
+		t.Errorf("tolerate-all = %v, want [*]", agent)
+	}
+}
+
+func TestBuildClusterState_Percentiles(t *testing.T) {
+	pod := func(v float64) prommodel.Vector {
+		return prommodel.Vector{&prommodel.Sample{
+			Metric: prommodel.Metric{"namespace": "prod", "pod": "api-1"},
+			Value:  prommodel.SampleValue(v),
+		}}
+	}
+	opts := CollectOptions{Percentile: 0.90, ExtraPercentiles: []float64{0.5, 0.999, 0.90, 1.0}}
+	if got := collectedPercentiles(opts); !slices.Equal(got, []float64{0.5, 0.90, 0.999}) {
+		t.Fatalf("collected percentiles = %v, want [0.5 0.9 0.999]", got)
+	}
+
+	data := map[string]prommodel.Value{
+		"running_pods": pod(1),
+		"cpu_p50":      pod(0.2),
+		"cpu_p90":      pod(0.6),
+		"cpu_p99.9":    pod(0.9),
+		"cpu_max":      pod(1.5),
+		"mem_p90":      pod(512 * 1024 * 1024),
+		"mem_max":      pod(1024 * 1024 * 1024),
+		"cpu_requests": pod(0.1),
+		"mem_requests": pod(128 * 1024 * 1024),
+	}
+	state, err := (&PrometheusCollector{}).buildClusterState(data, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := state.Workloads[0]
+	if w.EffectiveCPUMillis != 600 || w.EffectiveMemoryBytes != 512*1024*1024 {
+		t.Errorf("effective = %dm, %d bytes, want the exact p90 (600m, 512 MiB)", w.EffectiveCPUMillis, w.EffectiveMemoryBytes)
+	}
+	if w.CPUUsage.AtPercentile(0.999) != 0.9 || w.CPUUsage.Max != 1.5 {
+		t.Errorf("cpu usage = %+v, want p99.9 0.9 and a real max of 1.5", w.CPUUsage)
+	}
+
+	opts.Percentile = 1.0
+	state, err = (&PrometheusCollector{}).buildClusterState(data, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := state.Workloads[0]; w.EffectiveCPUMillis != 1500 {
+		t.Errorf("effective CPU at percentile 1.0 = %dm, want the max (1500m)", w.EffectiveCPUMillis)
+	}
+}
+
+func TestBuildClusterState_SizingPolicies(t *testing.T) {
+	pod := func(v float64) prommodel.Vector {
+		return prommodel.Vector{&prommodel.Sample{
+			Metric: prommodel.Metric{"namespace": "prod", "pod": "api-1"},
+			Value:  prommodel.SampleValue(v),
+		}}
+	}
+	opts := CollectOptions{
+		Percentile:   0.95,
+		CPUSizing:    model.SizingPolicy{Source: model.SizeByUsage, Percentile: 0.90},
+		MemorySizing: model.SizingPolicy{Source: model.SizeByMax, Percentile: 1.0, Headroom: 1.25},
+	}
+	if got := collectedPercentiles(opts); !slices.Equal(got, []float64{0.90}) {
+		t.Fatalf("collected percentiles = %v, want [0.9]", got)
+	}
+
+	data := map[string]prommodel.Value{
+		"running_pods": pod(1),
+		"cpu_p90":      pod(0.3),
+		"mem_p90":      pod(256 * 1024 * 1024),
+		"mem_max":      pod(512 * 1024 * 1024),
+		"cpu_requests": pod(0.5),
+		"mem_requests": pod(128 * 1024 * 1024),
+	}
+	state, err := (&PrometheusCollector{}).buildClusterState(data, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := state.Workloads[0]
+	if w.EffectiveCPUMillis != 300 || w.EffectiveMemoryBytes != 640*1024*1024 {
+		t.Errorf("effective = %dm, %d bytes, want p90 CPU usage (300m) and max memory x1.25 (640 MiB)",
+			w.EffectiveCPUMillis, w.EffectiveMemoryBytes)
+	}
+}
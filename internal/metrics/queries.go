@@ -37,6 +37,34 @@ func queryMemoryPercentile(percentile float64, window, step string) string {
 )`, percentile, window, step)
 }
 
+// queryCPUMax returns PromQL for the highest CPU usage over a time range,
+// in cores per (namespace, pod).
+func queryCPUMax(window, step string) string {
+	return fmt.Sprintf(`max_over_time(
+  sum by (namespace, pod) (
+    rate(container_cpu_usage_seconds_total{
+      container!="",
+      container!="POD",
+      image!=""
+    }[5m])
+  )[%s:%s]
+)`, window, step)
+}
+
+// queryMemoryMax returns PromQL for the highest memory usage over a time
+// range, in bytes per (namespace, pod).
+func queryMemoryMax(window, step string) string {
+	return fmt.Sprintf(`max_over_time(
+  sum by (namespace, pod) (
+    container_memory_working_set_bytes{
+      container!="",
+      container!="POD",
+      image!=""
+    }
+  )[%s:%s]
+)`, window, step)
+}
+
 // queryPodResourceRequests returns PromQL for pod resource requests.
 func queryPodResourceRequests(resource string) string {
 	return fmt.Sprintf(`sum by (namespace, pod) (
@@ -52,8 +80,8 @@ func queryPodResourceLimits(resource string) string {
 }
 
 // queryRunningPods returns PromQL for currently running pods.
-// The pod inventory is an instant snapshot, but per-pod P95/P99 CPU and memory
-// metrics still cover the full quantile_over_time window (e.g. 7 days).
+// The pod inventory is an instant snapshot, but per-pod percentile and max CPU
+// and memory metrics still cover the full quantile_over_time window (e.g. 7 days).
 func queryRunningPods() string {
 	return `kube_pod_status_phase{phase="Running"} == 1`
 }
@@ -63,6 +91,70 @@ func queryPodOwner() string {
 	return `kube_pod_owner{}`
 }
 
+// queryReplicaSetOwner returns PromQL for the Deployments that own
+// ReplicaSets.
+func queryReplicaSetOwner() string {
+	return `kube_replicaset_owner{owner_kind="Deployment"}`
+}
+
+// queryHPAReplicas returns PromQL for an HPA replica bound ("min_replicas"
+// or "max_replicas") of each (namespace, owner) an HPA scales.
+func queryHPAReplicas(bound string) string {
+	return fmt.Sprintf(`max by (namespace, owner_kind, owner_name) (
+  label_replace(label_replace(
+    kube_horizontalpodautoscaler_spec_%s
+    * on (namespace, horizontalpodautoscaler) group_left (scaletargetref_kind, scaletargetref_name)
+    kube_horizontalpodautoscaler_info,
+  "owner_kind", "$1", "scaletargetref_kind", "(.+)"),
+  "owner_name", "$1", "scaletargetref_name", "(.+)")
+)`, bound)
+}
+
+// controllerReplicas is the replica count of every Deployment and
+// StatefulSet, labeled by (namespace, owner_kind, owner_name).
+const controllerReplicas = `max by (namespace, owner_kind, owner_name) (
+    label_replace(label_replace(kube_deployment_status_replicas,
+      "owner_kind", "Deployment", "", ""), "owner_name", "$1", "deployment", "(.+)")
+    or
+    label_replace(label_replace(kube_statefulset_status_replicas,
+      "owner_kind", "StatefulSet", "", ""), "owner_name", "$1", "statefulset", "(.+)")
+  )`
+
+// queryReplicasPercentile returns PromQL for the replica count of each
+// controller at a percentile over a time range.
+func queryReplicasPercentile(percentile float64, window, step string) string {
+	return fmt.Sprintf(`quantile_over_time(%g,
+  %s[%s:%s]
+)`, percentile, controllerReplicas, window, step)
+}
+
+// queryReplicasMin returns PromQL for the fewest replicas of each controller
+// over a time range.
+func queryReplicasMin(window, step string) string {
+	return fmt.Sprintf(`min_over_time(
+  %s[%s:%s]
+)`, controllerReplicas, window, step)
+}
+
+// queryReplicasMax returns PromQL for the most replicas of each controller
+// over a time range.
+func queryReplicasMax(window, step string) string {
+	return fmt.Sprintf(`max_over_time(
+  %s[%s:%s]
+)`, controllerReplicas, window, step)
+}
+
+// queryPodNodeSelectors returns PromQL for pod node selectors.
+// kube-state-metrics exposes each selector as a sanitized "nodeselector_<key>" label.
+func queryPodNodeSelectors() string {
+	return `kube_pod_nodeselectors{}`
+}
+
+// queryPodTolerations returns PromQL for pod tolerations (one series per toleration).
+func queryPodTolerations() string {
+	return `kube_pod_tolerations{}`
+}
+
 // queryClusterCPUPercentile returns PromQL for cluster-wide aggregate CPU usage
 // at a given percentile over the full window. Captures scaling peaks that
 // per-pod instant snapshots miss.
@@ -101,3 +193,82 @@ func queryMinNodeCount(window, step string) string {
 func queryMaxNodeCount(window, step string) string {
 	return fmt.Sprintf(`max_over_time(count(kube_node_info)[%s:%s])`, window, step)
 }
+
+// querySeriesCPU returns PromQL for per-pod CPU usage, in cores, for a range
+// query at the given step. Each point is the peak over the step, sampled at
+// the resolution, so downsampling keeps short bursts.
+func querySeriesCPU(step, resolution string) string {
+	return fmt.Sprintf(`max_over_time(
+  sum by (namespace, pod) (
+    rate(container_cpu_usage_seconds_total{
+      container!="",
+      container!="POD",
+      image!=""
+    }[5m])
+  )[%s:%s]
+)`, step, resolution)
+}
+
+// querySeriesMemory returns PromQL for per-pod memory usage, in bytes, for a
+// range query at the given step, keeping the peak over each step.
+func querySeriesMemory(step, resolution string) string {
+	return fmt.Sprintf(`max_over_time(
+  sum by (namespace, pod) (
+    container_memory_working_set_bytes{
+      container!="",
+      container!="POD",
+      image!=""
+    }
+  )[%s:%s]
+)`, step, resolution)
+}
+
+// podOwnerJoin attaches each pod's owner to a per-pod expression. topk keeps
+// a single owner per pod so the join stays one-to-one.
+const podOwnerJoin = `* on (namespace, pod) group_left (owner_kind, owner_name)
+  topk by (namespace, pod) (1, kube_pod_owner)`
+
+// queryOwnerCPU returns PromQL for the CPU usage, in cores, of the pods of
+// each (namespace, owner) for a range query at the given step, keeping the
+// peak over each step as sampled at the resolution.
+func queryOwnerCPU(step, resolution string) string {
+	return fmt.Sprintf(`max_over_time(
+  sum by (namespace, owner_kind, owner_name) (
+    sum by (namespace, pod) (
+      rate(container_cpu_usage_seconds_total{
+        container!="",
+        container!="POD",
+        image!=""
+      }[5m])
+    )
+    %s
+  )[%s:%s]
+)`, podOwnerJoin, step, resolution)
+}
+
+// queryOwnerMemory returns PromQL for the memory working set, in bytes, of
+// the pods of each (namespace, owner) for a range query at the given step,
+// keeping the peak over each step.
+func queryOwnerMemory(step, resolution string) string {
+	return fmt.Sprintf(`max_over_time(
+  sum by (namespace, owner_kind, owner_name) (
+    sum by (namespace, pod) (
+      container_memory_working_set_bytes{
+        container!="",
+        container!="POD",
+        image!=""
+      }
+    )
+    %s
+  )[%s:%s]
+)`, podOwnerJoin, step, resolution)
+}
+
+// queryOwnerPods returns PromQL for the number of running pods of each
+// (namespace, owner) at every step of a range query.
+func queryOwnerPods() string {
+	return `count by (namespace, owner_kind, owner_name) (
+  (kube_pod_status_phase{phase="Running"} == 1)
+  ` + podOwnerJoin + `
+)`
+}
@@ -65,3 +65,17 @@ func (s *StaticCollector) Collect(ctx context.Context, opts CollectOptions) (*mo
 
 	return &state, nil
 }
+
+// CollectTimeline builds the timeline from the usage series stored in the
+// snapshot by 'clusterfit inspect --series'.
+func (s *StaticCollector) CollectTimeline(ctx context.Context, opts CollectOptions) (*model.UsageTimeline, error) {
+	state, err := s.Collect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	tl := state.Timeline()
+	if tl == nil {
+		return nil, fmt.Errorf("%w: the snapshot has no usage series", ErrNoMetricsFound)
+	}
+	return tl, nil
+}
@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// TimelineCollector is implemented by collectors that can report workload
+// usage at every step of the metrics window, not only its percentiles.
+type TimelineCollector interface {
+	// CollectTimeline returns the usage of every controller's pods at each
+	// opts.Window.Step of opts.Window, sampled at opts.StepInterval.
+	CollectTimeline(ctx context.Context, opts CollectOptions) (*model.UsageTimeline, error)
+}
+
+// rangeChunkPoints caps the points per series of a single range query.
+// Longer windows are split into consecutive queries, which keeps responses
+// small and well below the 11,000 points per series Prometheus allows.
+const rangeChunkPoints = 720
+
+// queryRangeChunked runs a range query in chunks of at most rangeChunkPoints
+// steps, each with the collector's timeout, and joins the series of every
+// chunk.
+func (c *PrometheusCollector) queryRangeChunked(ctx context.Context, query string, r promv1.Range) (prommodel.Matrix, error) {
+	byMetric := make(map[prommodel.Fingerprint]*prommodel.SampleStream)
+	var order []prommodel.Fingerprint
+
+	span := time.Duration(rangeChunkPoints-1) * r.Step
+	for from := r.Start; !from.After(r.End); from = from.Add(span + r.Step) {
+		chunk := promv1.Range{Start: from, End: from.Add(span), Step: r.Step}
+		if chunk.End.After(r.End) {
+			chunk.End = r.End
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		v, _, err := c.api.QueryRange(queryCtx, query, chunk)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		matrix, ok := v.(prommodel.Matrix)
+		if !ok {
+			continue
+		}
+		for _, stream := range matrix {
+			fp := stream.Metric.Fingerprint()
+			if s, ok := byMetric[fp]; ok {
+				s.Values = append(s.Values, stream.Values...)
+				continue
+			}
+			byMetric[fp] = stream
+			order = append(order, fp)
+		}
+	}
+
+	matrix := make(prommodel.Matrix, 0, len(order))
+	for _, fp := range order {
+		matrix = append(matrix, byMetric[fp])
+	}
+	return matrix, nil
+}
+
+// collectSeries attaches a usage series at opts.SeriesStep to every
+// workload of the state.
+func (c *PrometheusCollector) collectSeries(ctx context.Context, opts CollectOptions, state *model.ClusterState) error {
+	step := opts.SeriesStep
+	resolution := formatDuration(opts.StepInterval)
+	if resolution == "" {
+		resolution = defaultStep
+	}
+	r := promv1.Range{Start: opts.Window.Start, End: opts.Window.End, Step: step}
+
+	cpu, err := c.queryRangeChunked(ctx, querySeriesCPU(formatDuration(step), resolution), r)
+	if err != nil {
+		return fmt.Errorf("querying CPU series: %w", err)
+	}
+	mem, err := c.queryRangeChunked(ctx, querySeriesMemory(formatDuration(step), resolution), r)
+	if err != nil {
+		return fmt.Errorf("querying memory series: %w", err)
+	}
+
+	series := buildSeries(cpu, mem, r.Start, step, int(r.End.Sub(r.Start)/step)+1)
+	for i := range state.Workloads {
+		w := &state.Workloads[i]
+		w.Series = series[podKey{w.Namespace, w.Name}]
+	}
+	return nil
+}
+
+// buildSeries indexes per-pod CPU and memory range results by pod. Each
+// series starts at the pod's first sample in the window.
+func buildSeries(cpu, mem prommodel.Matrix, start time.Time, step time.Duration, steps int) map[podKey]*model.UsageSeries {
+	type values struct {
+		cpu, mem []int64
+		first    int
+	}
+	byPod := make(map[podKey]*values)
+	add := func(matrix prommodel.Matrix, isCPU bool) {
+		for _, stream := range matrix {
+			pk := podKey{string(stream.Metric["namespace"]), string(stream.Metric["pod"])}
+			if pk.Namespace == "" || pk.Pod == "" {
+				continue
+			}
+			v, ok := byPod[pk]
+			if !ok {
+				v = &values{cpu: make([]int64, steps), mem: make([]int64, steps), first: steps}
+				byPod[pk] = v
+			}
+			for _, p := range stream.Values {
+				i := int(math.Round(float64(p.Timestamp.Time().Sub(start)) / float64(step)))
+				if i < 0 || i >= steps {
+					continue
+				}
+				v.first = min(v.first, i)
+				if isCPU {
+					v.cpu[i] = int64(float64(p.Value) * 1000)
+				} else {
+					v.mem[i] = int64(p.Value)
+				}
+			}
+		}
+	}
+	add(cpu, true)
+	add(mem, false)
+
+	series := make(map[podKey]*model.UsageSeries, len(byPod))
+	for pk, v := range byPod {
+		if v.first >= steps {
+			continue
+		}
+		series[pk] = &model.UsageSeries{
+			Start:       start.Add(time.Duration(v.first) * step),
+			Step:        step,
+			CPUMillis:   v.cpu[v.first:],
+			MemoryBytes: v.mem[v.first:],
+		}
+	}
+	return series
+}
+
+// ownerKey identifies the controller of a series in a range query result.
+type ownerKey struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// CollectTimeline runs range queries for the CPU, memory and pod count of
+// every controller over the window. CPU and memory are the peak within each
+// step, so short bursts survive a coarse step. DaemonSets are left out: their
+// pods follow the node count rather than drive it.
+func (c *PrometheusCollector) CollectTimeline(ctx context.Context, opts CollectOptions) (*model.UsageTimeline, error) {
+	step := opts.Window.Step
+	if step <= 0 {
+		step = 5 * time.Minute
+	}
+	resolution := formatDuration(opts.StepInterval)
+	if resolution == "" {
+		resolution = defaultStep
+	}
+	r := promv1.Range{Start: opts.Window.Start, End: opts.Window.End, Step: step}
+
+	queries := map[string]string{
+		"cpu":  queryOwnerCPU(formatDuration(step), resolution),
+		"mem":  queryOwnerMemory(formatDuration(step), resolution),
+		"pods": queryOwnerPods(),
+	}
+
+	data := make(map[string]prommodel.Value, len(queries))
+	for name, q := range queries {
+		v, err := c.queryRangeChunked(ctx, q, r)
+		if err != nil {
+			return nil, fmt.Errorf("querying %s timeline: %w", name, err)
+		}
+		data[name] = v
+	}
+
+	steps := int(r.End.Sub(r.Start)/step) + 1
+	tl := buildTimeline(data, r.Start, step, steps, opts.ExcludeNamespaces)
+	if len(tl.Series) == 0 {
+		return nil, fmt.Errorf("%w: the timeline queries returned no series", ErrNoMetricsFound)
+	}
+	return tl, nil
+}
+
+// buildTimeline assembles the timeline from the range query results. Series
+// whose owners resolve to the same controller, such as the ReplicaSets of a
+// Deployment across a rollout, are summed.
+func buildTimeline(data map[string]prommodel.Value, start time.Time, step time.Duration, steps int, excludeNamespaces []string) *model.UsageTimeline {
+	excluded := make(map[string]bool, len(excludeNamespaces))
+	for _, ns := range excludeNamespaces {
+		excluded[ns] = true
+	}
+
+	byOwner := make(map[ownerKey]*model.WorkloadSeries)
+	series := func(m prommodel.Metric) *model.WorkloadSeries {
+		ns := string(m["namespace"])
+		kind, name := model.ControllerOf(string(m["owner_kind"]), string(m["owner_name"]))
+		if ns == "" || excluded[ns] || kind == "DaemonSet" {
+			return nil
+		}
+		k := ownerKey{ns, kind, name}
+		s, ok := byOwner[k]
+		if !ok {
+			s = &model.WorkloadSeries{
+				Namespace:   ns,
+				OwnerKind:   kind,
+				OwnerName:   name,
+				Pods:        make([]int32, steps),
+				CPUMillis:   make([]int64, steps),
+				MemoryBytes: make([]int64, steps),
+			}
+			byOwner[k] = s
+		}
+		return s
+	}
+
+	for name, v := range data {
+		matrix, ok := v.(prommodel.Matrix)
+		if !ok {
+			continue
+		}
+		for _, stream := range matrix {
+			s := series(stream.Metric)
+			if s == nil {
+				continue
+			}
+			for _, p := range stream.Values {
+				i := int(math.Round(float64(p.Timestamp.Time().Sub(start)) / float64(step)))
+				if i < 0 || i >= steps {
+					continue
+				}
+				switch name {
+				case "cpu":
+					s.CPUMillis[i] += int64(float64(p.Value) * 1000)
+				case "mem":
+					s.MemoryBytes[i] += int64(p.Value)
+				case "pods":
+					s.Pods[i] += int32(p.Value)
+				}
+			}
+		}
+	}
+
+	tl := &model.UsageTimeline{Start: start, Step: step}
+	for _, s := range byOwner {
+		tl.Series = append(tl.Series, *s)
+	}
+	sort.Slice(tl.Series, func(i, j int) bool {
+		return tl.Series[i].Key() < tl.Series[j].Key()
+	})
+	return tl
+}
@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// rangeAPI answers range queries with one sample per step for a single pod,
+// recording the queries and ranges it was asked for.
+type rangeAPI struct {
+	promv1.API
+	queries []string
+	ranges  []promv1.Range
+}
+
+func (a *rangeAPI) QueryRange(_ context.Context, query string, r promv1.Range, _ ...promv1.Option) (prommodel.Value, promv1.Warnings, error) {
+	a.queries = append(a.queries, query)
+	a.ranges = append(a.ranges, r)
+	s := &prommodel.SampleStream{Metric: prommodel.Metric{
+		"namespace": "prod", "pod": "api-1", "owner_kind": "StatefulSet", "owner_name": "api",
+	}}
+	for t := r.Start; !t.After(r.End); t = t.Add(r.Step) {
+		s.Values = append(s.Values, prommodel.SamplePair{
+			Timestamp: prommodel.TimeFromUnixNano(t.UnixNano()),
+			Value:     prommodel.SampleValue(t.Sub(r.Start).Hours()),
+		})
+	}
+	return prommodel.Matrix{s}, nil, nil
+}
+
+func TestBuildTimeline(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	step := 15 * time.Minute
+	at := func(i int) prommodel.Time {
+		return prommodel.TimeFromUnixNano(start.Add(time.Duration(i) * step).UnixNano())
+	}
+	stream := func(kind, name string, values ...float64) *prommodel.SampleStream {
+		s := &prommodel.SampleStream{Metric: prommodel.Metric{
+			"namespace": "prod", "owner_kind": prommodel.LabelValue(kind), "owner_name": prommodel.LabelValue(name),
+		}}
+		for i, v := range values {
+			s.Values = append(s.Values, prommodel.SamplePair{Timestamp: at(i), Value: prommodel.SampleValue(v)})
+		}
+		return s
+	}
+
+	// A rollout of the api Deployment moves pods from one ReplicaSet to another
+	data := map[string]prommodel.Value{
+		"pods": prommodel.Matrix{
+			stream("ReplicaSet", "api-7d4b9c8f6d", 2, 1, 0),
+			stream("ReplicaSet", "api-5f6b7c9d8", 0, 1, 2),
+			stream("DaemonSet", "node-exporter", 3, 3, 3),
+		},
+		"cpu": prommodel.Matrix{
+			stream("ReplicaSet", "api-7d4b9c8f6d", 0.5, 0.25, 0),
+			stream("ReplicaSet", "api-5f6b7c9d8", 0, 0.25, 0.75),
+		},
+		"mem": prommodel.Matrix{
+			stream("ReplicaSet", "api-7d4b9c8f6d", 1e9, 5e8, 0),
+		},
+	}
+
+	tl := buildTimeline(data, start, step, 3, nil)
+	if len(tl.Series) != 1 {
+		t.Fatalf("got %d series, want the api Deployment only: %+v", len(tl.Series), tl.Series)
+	}
+	s := tl.Series[0]
+	if s.Key() != "prod/Deployment/api" {
+		t.Errorf("key = %s, want prod/Deployment/api", s.Key())
+	}
+	for i, want := range []int32{2, 2, 2} {
+		if s.Pods[i] != want {
+			t.Errorf("pods[%d] = %d, want %d", i, s.Pods[i], want)
+		}
+	}
+	if s.CPUMillis[0] != 500 || s.CPUMillis[1] != 500 || s.CPUMillis[2] != 750 {
+		t.Errorf("cpu = %v, want [500 500 750]", s.CPUMillis)
+	}
+	if s.MemoryBytes[1] != 5e8 || s.MemoryBytes[2] != 0 {
+		t.Errorf("memory = %v", s.MemoryBytes)
+	}
+	if tl.Steps() != 3 || tl.Duration() != 45*time.Minute {
+		t.Errorf("steps %d duration %v, want 3 and 45m", tl.Steps(), tl.Duration())
+	}
+
+	if tl := buildTimeline(data, start, step, 3, []string{"prod"}); len(tl.Series) != 0 {
+		t.Errorf("excluded namespace kept: %+v", tl.Series)
+	}
+}
+
+func TestCollectTimeline_PeakPerStep(t *testing.T) {
+	api := &rangeAPI{}
+	c := &PrometheusCollector{api: api, timeout: time.Minute}
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	opts := CollectOptions{
+		Window:       model.TimeWindow{Start: start, End: start.Add(time.Hour), Step: 15 * time.Minute},
+		StepInterval: time.Minute,
+	}
+
+	tl, err := c.CollectTimeline(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Step != 15*time.Minute || tl.Steps() != 5 {
+		t.Errorf("step %v, %d steps, want 15m and 5", tl.Step, tl.Steps())
+	}
+	var peaks int
+	for _, q := range api.queries {
+		if strings.HasPrefix(q, "max_over_time(") && strings.Contains(q, ")[15m:1m]") {
+			peaks++
+		}
+	}
+	if peaks != 2 {
+		t.Errorf("queries = %q, want the CPU and memory peak over each 15m step at 1m", api.queries)
+	}
+}
+
+func TestQueryRangeChunked(t *testing.T) {
+	api := &rangeAPI{}
+	c := &PrometheusCollector{api: api, timeout: time.Minute}
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	step := 5 * time.Minute
+
+	// 7 days at 5m is 2017 points: three chunks of at most 720
+	r := promv1.Range{Start: start, End: start.Add(7 * 24 * time.Hour), Step: step}
+	matrix, err := c.queryRangeChunked(context.Background(), "q", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.ranges) != 3 {
+		t.Fatalf("got %d queries, want 3", len(api.ranges))
+	}
+	for i := 1; i < len(api.ranges); i++ {
+		if got := api.ranges[i].Start.Sub(api.ranges[i-1].End); got != step {
+			t.Errorf("chunk %d starts %v after the previous one ends, want one step", i, got)
+		}
+	}
+	if !api.ranges[2].End.Equal(r.End) {
+		t.Errorf("last chunk ends at %v, want %v", api.ranges[2].End, r.End)
+	}
+	if len(matrix) != 1 || len(matrix[0].Values) != 2017 {
+		t.Fatalf("got %d series, want one series of 2017 points", len(matrix))
+	}
+}
+
+func TestBuildSeries(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	step := 15 * time.Minute
+	at := func(i int) prommodel.Time {
+		return prommodel.TimeFromUnixNano(start.Add(time.Duration(i) * step).UnixNano())
+	}
+	stream := func(pod string, first int, values ...float64) *prommodel.SampleStream {
+		s := &prommodel.SampleStream{Metric: prommodel.Metric{"namespace": "prod", "pod": prommodel.LabelValue(pod)}}
+		for i, v := range values {
+			s.Values = append(s.Values, prommodel.SamplePair{Timestamp: at(first + i), Value: prommodel.SampleValue(v)})
+		}
+		return s
+	}
+
+	// The web pod started at the second step
+	cpu := prommodel.Matrix{stream("api-1", 0, 0.25, 0.5, 1), stream("web-1", 1, 0.1, 0.2)}
+	mem := prommodel.Matrix{stream("api-1", 0, 1e9, 1e9, 2e9), stream("web-1", 1, 5e8, 5e8)}
+
+	series := buildSeries(cpu, mem, start, step, 3)
+	api := series[podKey{"prod", "api-1"}]
+	if api == nil || !api.Start.Equal(start) || len(api.CPUMillis) != 3 || api.CPUMillis[2] != 1000 || api.MemoryBytes[2] != 2e9 {
+		t.Errorf("api series = %+v", api)
+	}
+	web := series[podKey{"prod", "web-1"}]
+	if web == nil || !web.Start.Equal(start.Add(step)) || len(web.CPUMillis) != 2 || web.CPUMillis[0] != 100 {
+		t.Errorf("web series = %+v, want two steps from the second", web)
+	}
+
+	state := &model.ClusterState{Workloads: []model.WorkloadProfile{{Namespace: "prod", Name: "web-1"}}}
+	state.Workloads[0].Series = web
+	if tl := state.Timeline(); tl == nil || tl.Steps() != 2 {
+		t.Errorf("timeline from series = %+v", tl)
+	}
+}
@@ -53,6 +53,10 @@ type ClusterState struct {
 	// Cluster-wide aggregate metrics (P95 CPU/mem, node count range)
 	AggregateMetrics *ClusterAggregateMetrics `json:"aggregate_metrics,omitempty"`
 
+	// Where pod scheduling constraints came from: "kube-state-metrics",
+	// "kubernetes-api", or empty when none were collected
+	ConstraintsSource string `json:"constraints_source,omitempty"`
+
 	// Cluster metadata
 	ClusterName string `json:"cluster_name"`
 	Region      string `json:"region"`
@@ -63,7 +67,7 @@ type ClusterState struct {
 func (cs ClusterState) TotalEffectiveCPU() int64 {
 	var total int64
 	for i := range cs.Workloads {
-		total += cs.Workloads[i].EffectiveCPUMillis
+		total += cs.Workloads[i].TotalEffective().CPUMillis
 	}
 	return total
 }
@@ -72,14 +76,19 @@ func (cs ClusterState) TotalEffectiveCPU() int64 {
 func (cs ClusterState) TotalEffectiveMemory() int64 {
 	var total int64
 	for i := range cs.Workloads {
-		total += cs.Workloads[i].EffectiveMemoryBytes
+		total += cs.Workloads[i].TotalEffective().MemoryBytes
 	}
 	return total
 }
 
-// WorkloadCount returns the number of non-DaemonSet workloads.
+// WorkloadCount returns the number of non-DaemonSet pods, counting every
+// replica of a controller's representative profile.
 func (cs ClusterState) WorkloadCount() int {
-	return len(cs.Workloads)
+	var n int
+	for i := range cs.Workloads {
+		n += cs.Workloads[i].ReplicaCount()
+	}
+	return n
 }
 
 // DaemonSetOverhead returns the total resources consumed by DaemonSets per node.
@@ -87,6 +96,52 @@ func (cs ClusterState) DaemonSetOverhead() ResourceQuantity {
 	return SumEffectiveResources(cs.DaemonSets)
 }
 
+// Sources of pod scheduling constraints recorded in ClusterState.ConstraintsSource.
+const (
+	ConstraintsFromKubeStateMetrics = "kube-state-metrics"
+	ConstraintsFromKubernetesAPI    = "kubernetes-api"
+)
+
+// PinnedWorkloads returns the number of pods restricted to specific nodes.
+func (cs ClusterState) PinnedWorkloads() int {
+	var n int
+	for i := range cs.Workloads {
+		if cs.Workloads[i].IsPinned() {
+			n += cs.Workloads[i].ReplicaCount()
+		}
+	}
+	return n
+}
+
+// ApplyConstraints overlays per-pod scheduling constraints, keyed by
+// "namespace/pod", onto workloads and DaemonSets, and records the source.
+// It returns the number of pods that were matched.
+func (cs *ClusterState) ApplyConstraints(byPod map[string]PodConstraints, source string) int {
+	matched := 0
+	apply := func(wps []WorkloadProfile) {
+		for i := range wps {
+			c, ok := byPod[wps[i].Namespace+"/"+wps[i].Name]
+			if !ok {
+				continue
+			}
+			wps[i].NodeSelector = c.NodeSelector
+			wps[i].Tolerations = c.Tolerations
+			wps[i].Architecture = c.Architecture
+			wps[i].PodAffinity = c.PodAffinity
+			wps[i].PodAntiAffinity = c.PodAntiAffinity
+			wps[i].TopologySpread = c.TopologySpread
+			wps[i].DisruptionBudget = c.DisruptionBudget
+			matched++
+		}
+	}
+	apply(cs.Workloads)
+	apply(cs.DaemonSets)
+	if matched > 0 {
+		cs.ConstraintsSource = source
+	}
+	return matched
+}
+
 // SumEffectiveResources returns the total effective CPU and memory across a slice of workloads.
 func SumEffectiveResources(wps []WorkloadProfile) ResourceQuantity {
 	var total ResourceQuantity
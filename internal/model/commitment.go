@@ -0,0 +1,257 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Savings Plan types.
+const (
+	SavingsPlanCompute     = "compute"      // any family, size, region and OS
+	SavingsPlanEC2Instance = "ec2-instance" // one instance family in a region
+)
+
+// ReservedInstance is a standard or convertible RI purchase. Regional Linux
+// RIs are size-flexible: their capacity is shared across every size of the
+// family using EC2 normalization factors.
+type ReservedInstance struct {
+	InstanceType string  `json:"instance_type"`
+	Count        int     `json:"count"`
+	HourlyRate   float64 `json:"hourly_rate,omitempty"` // effective (amortized) $/hour per instance
+	Discount     float64 `json:"discount,omitempty"`    // fraction off on-demand; see ResolveRates
+}
+
+// SavingsPlan is a $/hour spend commitment that buys on-demand usage at a
+// discount.
+type SavingsPlan struct {
+	Type             string  `json:"type"`             // SavingsPlanCompute or SavingsPlanEC2Instance
+	Family           string  `json:"family,omitempty"` // required for EC2 Instance Savings Plans
+	HourlyCommitment float64 `json:"hourly_commitment"`
+	Discount         float64 `json:"discount"` // average fraction off on-demand
+}
+
+// Commitments describes the RIs and Savings Plans available to the cluster.
+type Commitments struct {
+	ReservedInstances []ReservedInstance `json:"reserved_instances,omitempty"`
+	SavingsPlans      []SavingsPlan      `json:"savings_plans,omitempty"`
+}
+
+// IsZero returns true when no commitments are configured.
+func (c Commitments) IsZero() bool {
+	return len(c.ReservedInstances) == 0 && len(c.SavingsPlans) == 0
+}
+
+// CommittedCost is the monthly cost of a set of nodes once commitments are
+// applied. Commitments are paid whether or not they are used, so the
+// effective cost includes unused RI and Savings Plan spend and can exceed
+// the list price.
+type CommittedCost struct {
+	EffectiveMonthlyCost    float64 `json:"effective_monthly_cost"`
+	ReservedNodes           float64 `json:"reserved_nodes"`               // node-equivalents covered by RIs
+	SavingsPlanCovered      float64 `json:"savings_plan_covered_monthly"` // on-demand spend covered by Savings Plans
+	OnDemandMonthlyCost     float64 `json:"on_demand_monthly_cost"`       // uncovered on-demand spend
+	UnusedCommitmentMonthly float64 `json:"unused_commitment_monthly"`    // RI and Savings Plan spend with no matching usage
+}
+
+// NormalizationFactor returns the EC2 size normalization factor of an
+// instance type (large = 4, xlarge = 8, 2xlarge = 16, ...), or 0 for sizes
+// that are not size-flexible, such as metal.
+func NormalizationFactor(instanceType string) float64 {
+	_, size, ok := strings.Cut(instanceType, ".")
+	if !ok {
+		return 0
+	}
+	switch size {
+	case "nano":
+		return 0.25
+	case "micro":
+		return 0.5
+	case "small":
+		return 1
+	case "medium":
+		return 2
+	case "large":
+		return 4
+	case "xlarge":
+		return 8
+	}
+	if n, found := strings.CutSuffix(size, "xlarge"); found {
+		if mult, err := strconv.Atoi(n); err == nil && mult > 0 {
+			return 8 * float64(mult)
+		}
+	}
+	return 0
+}
+
+// billedNode tracks how much of one on-demand node is still billed at list price.
+type billedNode struct {
+	template  NodeTemplate
+	family    string
+	factor    float64
+	uncovered float64 // fraction of the node not yet covered, 0..1
+}
+
+// Apply computes the committed cost of running the given nodes for a month.
+// Commitments are applied in the order AWS bills them: Reserved Instances
+// first, then EC2 Instance Savings Plans, then Compute Savings Plans, each
+// group highest discount first. Spot capacity is never covered.
+func (c Commitments) Apply(nodes []NodeTemplate) CommittedCost {
+	var hourly, spot float64
+	var onDemand []*billedNode
+	for _, n := range nodes {
+		if n.CapacityType == CapacitySpot {
+			spot += n.EffectivePricePerHour()
+			continue
+		}
+		onDemand = append(onDemand, &billedNode{
+			template:  n,
+			family:    instanceFamily(n),
+			factor:    NormalizationFactor(n.InstanceType),
+			uncovered: 1,
+		})
+	}
+	// Size-flexible RIs apply to the smallest sizes first
+	sort.SliceStable(onDemand, func(i, j int) bool { return onDemand[i].factor < onDemand[j].factor })
+
+	var reservedNodes, spCovered, unused float64
+
+	for _, ri := range c.ReservedInstances {
+		rate := ri.HourlyRate
+		hourly += rate * float64(ri.Count)
+		remaining := float64(ri.Count)
+		riFactor := NormalizationFactor(ri.InstanceType)
+		riFamily, _, _ := strings.Cut(ri.InstanceType, ".")
+
+		for _, n := range onDemand {
+			if remaining <= 0 {
+				break
+			}
+			if n.uncovered <= 0 {
+				continue
+			}
+			// RI-equivalents this node needs, and what share of them is available
+			var need float64
+			switch {
+			case n.template.InstanceType == ri.InstanceType:
+				need = n.uncovered
+			case riFactor > 0 && n.factor > 0 && n.family == riFamily:
+				need = n.uncovered * n.factor / riFactor
+			default:
+				continue
+			}
+			take := min(need, remaining)
+			remaining -= take
+			covered := n.uncovered * take / need
+			n.uncovered -= covered
+			reservedNodes += covered
+		}
+		unused += remaining * rate
+	}
+
+	plans := make([]SavingsPlan, len(c.SavingsPlans))
+	copy(plans, c.SavingsPlans)
+	sort.SliceStable(plans, func(i, j int) bool {
+		if (plans[i].Type == SavingsPlanEC2Instance) != (plans[j].Type == SavingsPlanEC2Instance) {
+			return plans[i].Type == SavingsPlanEC2Instance
+		}
+		return plans[i].Discount > plans[j].Discount
+	})
+	for _, sp := range plans {
+		hourly += sp.HourlyCommitment
+		if sp.Discount >= 1 {
+			continue
+		}
+		// On-demand spend the commitment can buy
+		capacity := sp.HourlyCommitment / (1 - sp.Discount)
+		for _, n := range onDemand {
+			if capacity <= 0 {
+				break
+			}
+			if n.uncovered <= 0 || (sp.Type == SavingsPlanEC2Instance && n.family != sp.Family) {
+				continue
+			}
+			price := n.template.OnDemandPricePerHour
+			if price <= 0 {
+				continue
+			}
+			take := min(n.uncovered*price, capacity)
+			capacity -= take
+			n.uncovered -= take / price
+			spCovered += take
+		}
+		unused += capacity * (1 - sp.Discount)
+	}
+
+	var uncovered float64
+	for _, n := range onDemand {
+		if n.uncovered > 0 {
+			uncovered += n.uncovered * n.template.OnDemandPricePerHour
+		}
+	}
+	hourly += uncovered + spot
+
+	return CommittedCost{
+		EffectiveMonthlyCost:    hourly * HoursPerMonth,
+		ReservedNodes:           reservedNodes,
+		SavingsPlanCovered:      spCovered * HoursPerMonth,
+		OnDemandMonthlyCost:     uncovered * HoursPerMonth,
+		UnusedCommitmentMonthly: unused * HoursPerMonth,
+	}
+}
+
+// ResolveRates returns a copy of the commitments in which every discount-only
+// RI has an hourly rate derived from the catalog's on-demand prices, using a
+// same-family size through normalization factors when the exact type is
+// missing. Instance types that cannot be priced are returned; their RIs keep
+// a zero rate, so their cost is left out of effective prices.
+func (c Commitments) ResolveRates(catalog []NodeTemplate) (Commitments, []string) {
+	resolved := Commitments{
+		ReservedInstances: make([]ReservedInstance, len(c.ReservedInstances)),
+		SavingsPlans:      c.SavingsPlans,
+	}
+	copy(resolved.ReservedInstances, c.ReservedInstances)
+
+	var unpriced []string
+	for i := range resolved.ReservedInstances {
+		ri := &resolved.ReservedInstances[i]
+		if ri.HourlyRate > 0 {
+			continue
+		}
+		if od := onDemandPrice(catalog, ri.InstanceType); od > 0 {
+			ri.HourlyRate = od * (1 - ri.Discount)
+		} else {
+			unpriced = append(unpriced, ri.InstanceType)
+		}
+	}
+	return resolved, unpriced
+}
+
+// onDemandPrice finds the on-demand price of an instance type in a catalog,
+// scaling a same-family size by normalization factors if needed.
+func onDemandPrice(catalog []NodeTemplate, instanceType string) float64 {
+	for _, t := range catalog {
+		if t.InstanceType == instanceType && t.OnDemandPricePerHour > 0 {
+			return t.OnDemandPricePerHour
+		}
+	}
+	factor := NormalizationFactor(instanceType)
+	family, _, _ := strings.Cut(instanceType, ".")
+	for _, t := range catalog {
+		f := NormalizationFactor(t.InstanceType)
+		if factor > 0 && f > 0 && instanceFamily(t) == family && t.OnDemandPricePerHour > 0 {
+			return t.OnDemandPricePerHour * factor / f
+		}
+	}
+	return 0
+}
+
+// instanceFamily returns the template's family, deriving it from the
+// instance type ("m5.large" → "m5") when unset.
+func instanceFamily(t NodeTemplate) string {
+	if t.InstanceFamily != "" {
+		return t.InstanceFamily
+	}
+	family, _, _ := strings.Cut(t.InstanceType, ".")
+	return family
+}
@@ -1,7 +1,13 @@
 package model
 
 import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestResourceQuantity_Add(t *testing.T) {
@@ -67,17 +73,24 @@ func TestResourceQuantity_IsZero(t *testing.T) {
 }
 
 func TestPercentileValues_AtPercentile(t *testing.T) {
-	pv := PercentileValues{P50: 10, P95: 20, P99: 30, Max: 50}
+	var pv PercentileValues
+	pv.Set(0.50, 10)
+	pv.Set(0.90, 15)
+	pv.Set(0.95, 20)
+	pv.Set(0.999, 30)
+	pv.Set(1.0, 50)
 
 	tests := []struct {
 		pct  float64
 		want float64
 	}{
 		{0.50, 10},
-		{0.75, 20},
+		{0.75, 15},
+		{0.90, 15},
 		{0.95, 20},
 		{0.97, 30},
-		{0.99, 30},
+		{0.999, 30},
+		{0.9995, 50},
 		{1.0, 50},
 	}
 
@@ -89,6 +102,208 @@ func TestPercentileValues_AtPercentile(t *testing.T) {
 	}
 }
 
+func TestPercentileValues_JSON(t *testing.T) {
+	var pv PercentileValues
+	pv.Set(0.90, 0.4)
+	pv.Set(0.999, 0.7)
+	pv.Max = 0.9
+
+	data, err := json.Marshal(pv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Max":0.9,"P90":0.4,"P99.9":0.7}` {
+		t.Errorf("JSON = %s", data)
+	}
+	var back PercentileValues
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.AtPercentile(0.90) != 0.4 || back.AtPercentile(0.999) != 0.7 || back.Max != 0.9 {
+		t.Errorf("round trip = %+v", back)
+	}
+
+	// Snapshots written before percentiles were configurable
+	var legacy PercentileValues
+	if err := json.Unmarshal([]byte(`{"P50":1,"P95":2,"P99":3,"Max":3}`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if legacy.AtPercentile(0.95) != 2 || legacy.AtPercentile(0.5) != 1 || legacy.Max != 3 {
+		t.Errorf("legacy = %+v", legacy)
+	}
+
+	if err := json.Unmarshal([]byte(`{"p95x":1}`), &legacy); err == nil {
+		t.Error("expected an error for an invalid percentile key")
+	}
+}
+
+func TestWorkloadProfile_Resize(t *testing.T) {
+	const mib = 1024 * 1024
+	w := WorkloadProfile{
+		Requested: ResourceQuantity{CPUMillis: 500, MemoryBytes: 256 * mib},
+		Limits:    ResourceQuantity{CPUMillis: 2000, MemoryBytes: 1024 * mib},
+	}
+	w.CPUUsage.Set(0.90, 0.3)
+	w.CPUUsage.Set(0.99, 0.8)
+	w.CPUUsage.Max = 1.2
+	w.MemoryUsage.Set(0.90, 200*mib)
+	w.MemoryUsage.Max = 600 * mib
+
+	tests := []struct {
+		name     string
+		cpu, mem SizingPolicy
+		wantCPU  int64
+		wantMem  int64
+	}{
+		{"default max at p90", SizingPolicy{Percentile: 0.90}, SizingPolicy{Percentile: 0.90}, 500, 256 * mib},
+		{"cpu usage, memory max", SizingPolicy{Source: SizeByUsage, Percentile: 0.90}, SizingPolicy{Source: SizeByMax, Percentile: 1.0}, 300, 600 * mib},
+		{"headroom", SizingPolicy{Source: SizeByUsage, Percentile: 0.99, Headroom: 1.5}, SizingPolicy{Percentile: 1.0, Headroom: 1.5}, 1200, 900 * mib},
+		{"request and limit", SizingPolicy{Source: SizeByRequest, Percentile: 1.0}, SizingPolicy{Source: SizeByLimit, Percentile: 1.0}, 500, 1024 * mib},
+		{"floors", SizingPolicy{Source: SizeByUsage, Percentile: 0.90, Headroom: 0.01}, SizingPolicy{Source: SizeByUsage, Percentile: 0.90, Headroom: 0.01}, minEffectiveCPUMillis, minEffectiveMemoryBytes},
+	}
+	for _, tt := range tests {
+		w.Resize(tt.cpu, tt.mem)
+		if w.EffectiveCPUMillis != tt.wantCPU || w.EffectiveMemoryBytes != tt.wantMem {
+			t.Errorf("%s: effective = %dm, %d MiB, want %dm, %d MiB", tt.name,
+				w.EffectiveCPUMillis, w.EffectiveMemoryBytes/mib, tt.wantCPU, tt.wantMem/mib)
+		}
+		if w.NoMetrics {
+			t.Errorf("%s: NoMetrics set with usage stored", tt.name)
+		}
+	}
+
+	idle := WorkloadProfile{Requested: ResourceQuantity{CPUMillis: 250, MemoryBytes: 128 * mib}}
+	idle.Resize(SizingPolicy{Source: SizeByUsage, Percentile: 0.9}, SizingPolicy{Source: SizeByUsage, Percentile: 0.9})
+	if !idle.NoMetrics || idle.EffectiveCPUMillis != 250 || idle.EffectiveMemoryBytes != 128*mib {
+		t.Errorf("pod without usage = %+v, want NoMetrics sized by its requests", idle)
+	}
+}
+
+func TestClusterState_Resize(t *testing.T) {
+	measured := WorkloadProfile{Name: "api", EffectiveCPUMillis: 100}
+	measured.CPUUsage.Set(0.95, 0.4)
+	handWritten := WorkloadProfile{Name: "legacy", EffectiveCPUMillis: 700}
+	cs := ClusterState{Workloads: []WorkloadProfile{measured, handWritten}}
+
+	if kept := cs.Resize(SizingPolicy{Percentile: 0.95}, SizingPolicy{Percentile: 0.95}); kept != 1 {
+		t.Errorf("kept = %d, want 1", kept)
+	}
+	if cs.Workloads[0].EffectiveCPUMillis != 400 || cs.Workloads[1].EffectiveCPUMillis != 700 {
+		t.Errorf("effective CPU = %d/%d, want 400 re-derived and 700 kept",
+			cs.Workloads[0].EffectiveCPUMillis, cs.Workloads[1].EffectiveCPUMillis)
+	}
+}
+
+func TestClusterState_MissingPercentiles(t *testing.T) {
+	api := WorkloadProfile{Name: "api"}
+	for _, pct := range []float64{0.50, 0.95, 0.99} {
+		api.CPUUsage.Set(pct, pct)
+		api.MemoryUsage.Set(pct, pct)
+	}
+	api.CPUUsage.Max, api.MemoryUsage.Max = 1, 1
+	cs := ClusterState{Workloads: []WorkloadProfile{api, {Name: "legacy"}}}
+
+	if subs := cs.MissingPercentiles(0.95, 1.0); len(subs) != 0 {
+		t.Errorf("stored percentiles reported missing: %+v", subs)
+	}
+	subs := cs.MissingPercentiles(0.90, 0.995)
+	want := []PercentileSubstitution{{"cpu", 0.90, 0.95}, {"memory", 0.995, 1}}
+	if len(subs) != len(want) {
+		t.Fatalf("substitutions = %+v, want %+v", subs, want)
+	}
+	for i := range want {
+		if subs[i] != want[i] {
+			t.Errorf("substitution[%d] = %+v, want %+v", i, subs[i], want[i])
+		}
+	}
+}
+
+func TestParseSizingPolicy(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    SizingPolicy
+		wantStr string
+	}{
+		{"request", SizingPolicy{Source: SizeByRequest}, "request"},
+		{"usage:0.9", SizingPolicy{Source: SizeByUsage, Percentile: 0.9}, "p90 usage"},
+		{"max:1.0:1.2", SizingPolicy{Source: SizeByMax, Percentile: 1.0, Headroom: 1.2}, "max(request, max usage x1.2)"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSizingPolicy(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if got != tt.want || got.String() != tt.wantStr {
+			t.Errorf("%s = %+v (%q), want %+v (%q)", tt.spec, got, got.String(), tt.want, tt.wantStr)
+		}
+	}
+
+	for _, spec := range []string{"", "peak", "usage:1.5", "usage:0.9:0", "max:0.9:1.1:2"} {
+		if _, err := ParseSizingPolicy(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestRightsize(t *testing.T) {
+	const mib = 1024 * 1024
+	pod := func(name, kind, owner string, reqCPU, reqMem int64, cpu, mem float64) WorkloadProfile {
+		w := WorkloadProfile{
+			Name: name, Namespace: "prod", OwnerKind: kind, OwnerName: owner,
+			Requested: ResourceQuantity{CPUMillis: reqCPU, MemoryBytes: reqMem * mib},
+		}
+		w.CPUUsage.Set(0.95, cpu)
+		w.MemoryUsage.Set(1.0, mem*mib)
+		return w
+	}
+	workloads := []WorkloadProfile{
+		pod("api-7d9f8c6b5-a", "ReplicaSet", "api-7d9f8c6b5", 2000, 4096, 0.3, 500),
+		pod("api-7d9f8c6b5-b", "ReplicaSet", "api-7d9f8c6b5", 2000, 4096, 0.4001, 600),
+		pod("worker-0", "StatefulSet", "worker", 500, 1024, 1.2, 2000),
+		{Name: "legacy", Namespace: "prod", Requested: ResourceQuantity{CPUMillis: 100, MemoryBytes: 128 * mib}, NoMetrics: true},
+	}
+	policy := RightsizePolicy{
+		CPU:    RightsizeTarget{Percentile: 0.95},
+		Memory: RightsizeTarget{Percentile: 1.0, Headroom: 1.5, LimitRatio: 1.0},
+	}
+
+	recs := Rightsize(workloads, policy)
+	if len(recs) != 3 {
+		t.Fatalf("got %d recommendations, want 3: %+v", len(recs), recs)
+	}
+	api := recs[0]
+	if api.Key() != "prod/Deployment/api" || api.Pods != 2 {
+		t.Fatalf("first = %s with %d pods, want prod/Deployment/api with 2 (most freed first)", api.Key(), api.Pods)
+	}
+	if api.RecommendedRequests != (ResourceQuantity{CPUMillis: 401, MemoryBytes: 900 * mib}) {
+		t.Errorf("api requests = %+v, want the busiest pod: 401m, 900 MiB", api.RecommendedRequests)
+	}
+	if api.RecommendedLimits != (ResourceQuantity{MemoryBytes: 900 * mib}) {
+		t.Errorf("api limits = %+v, want no CPU limit and memory = request", api.RecommendedLimits)
+	}
+	if d := api.RequestChange(); d.CPUMillis != -2*1599 {
+		t.Errorf("api CPU change = %dm, want %dm", d.CPUMillis, -2*1599)
+	}
+	if worker := recs[2]; worker.RecommendedRequests.CPUMillis != 1200 || worker.RecommendedRequests.MemoryBytes != 3000*mib {
+		t.Errorf("worker requests = %+v, want raised to 1200m, 3000 MiB and listed last", worker.RecommendedRequests)
+	}
+	if legacy := recs[1]; !legacy.NoMetrics || legacy.RecommendedRequests != legacy.Requested {
+		t.Errorf("legacy = %+v, want its requests kept for lack of metrics", legacy)
+	}
+
+	cs := ClusterState{Workloads: workloads}
+	rs := cs.Rightsized(recs, SizingPolicy{Percentile: 0.95}, SizingPolicy{Percentile: 1.0})
+	if got := rs.Workloads[0]; got.Requested.CPUMillis != 401 || got.EffectiveCPUMillis != 401 || got.EffectiveMemoryBytes != 900*mib {
+		t.Errorf("right-sized api pod = %+v, want the recommended requests as its size", got)
+	}
+	if cs.Workloads[0].Requested.CPUMillis != 2000 {
+		t.Error("Rightsized modified the original state")
+	}
+	if rs.Workloads[3].Requested.CPUMillis != 100 {
+		t.Errorf("pod without metrics = %+v, want it unchanged", rs.Workloads[3])
+	}
+}
+
 func TestNodeTemplate_EffectivePricePerHour(t *testing.T) {
 	n := NodeTemplate{
 		OnDemandPricePerHour: 0.10,
@@ -379,3 +594,656 @@ func TestFamiliesForClass_UnknownArch(t *testing.T) {
 		t.Errorf("expected nil for unknown arch, got %v", got)
 	}
 }
+
+func TestWorkloadProfile_Tolerates(t *testing.T) {
+	taint := Taint{Key: "nvidia.com/gpu", Value: "true", Effect: TaintNoSchedule}
+
+	tests := []struct {
+		name        string
+		tolerations []string
+		want        bool
+	}{
+		{"none", nil, false},
+		{"exact", []string{"nvidia.com/gpu=true:NoSchedule"}, true},
+		{"key and effect", []string{"nvidia.com/gpu:NoSchedule"}, true},
+		{"key only", []string{"nvidia.com/gpu"}, true},
+		{"wildcard", []string{"*"}, true},
+		{"wrong value", []string{"nvidia.com/gpu=false:NoSchedule"}, false},
+		{"wrong effect", []string{"nvidia.com/gpu:NoExecute"}, false},
+		{"other key", []string{"dedicated=batch:NoSchedule"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := WorkloadProfile{Tolerations: tt.tolerations}
+			if got := w.Tolerates(taint); got != tt.want {
+				t.Errorf("Tolerates(%v) = %v, want %v", tt.tolerations, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeTemplate_NodeLabels(t *testing.T) {
+	n := NodeTemplate{
+		InstanceType:   "m7g.xlarge",
+		InstanceFamily: "m7g",
+		Architecture:   ArchARM64,
+		CapacityType:   CapacityOnDemand,
+		Labels:         map[string]string{"team": "data", LabelOS: "linux"},
+	}
+
+	labels := n.NodeLabels()
+	want := map[string]string{
+		LabelArch:           "arm64",
+		LabelInstanceType:   "m7g.xlarge",
+		LabelInstanceFamily: "m7g",
+		LabelCapacityType:   "on-demand",
+		LabelOS:             "linux",
+		"team":              "data",
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, labels[k], v)
+		}
+	}
+}
+
+func TestNodeTemplate_NodeLabels_Karpenter(t *testing.T) {
+	n := NodeTemplate{
+		InstanceType:   "c6gn.2xlarge",
+		InstanceFamily: "c6gn",
+		Generation:     6,
+		Size:           "2xlarge",
+		VCPUs:          8,
+		MemoryMiB:      16384,
+	}
+
+	labels := n.NodeLabels()
+	want := map[string]string{
+		LabelInstanceCategory:   "c",
+		LabelInstanceGeneration: "6",
+		LabelInstanceSize:       "2xlarge",
+		LabelInstanceCPU:        "8",
+		LabelInstanceMemory:     "16384",
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, labels[k], v)
+		}
+	}
+}
+
+func TestNodeRequirement_Matches(t *testing.T) {
+	labels := map[string]string{LabelInstanceCPU: "8", LabelInstanceFamily: "m7g"}
+	tests := []struct {
+		req  NodeRequirement
+		want bool
+	}{
+		{NodeRequirement{Key: LabelInstanceFamily, Operator: OpIn, Values: []string{"m7g", "c7g"}}, true},
+		{NodeRequirement{Key: LabelInstanceFamily, Operator: OpIn, Values: []string{"c7g"}}, false},
+		{NodeRequirement{Key: LabelInstanceFamily, Operator: OpNotIn, Values: []string{"m7g"}}, false},
+		{NodeRequirement{Key: LabelInstanceSize, Operator: OpNotIn, Values: []string{"metal"}}, true},
+		{NodeRequirement{Key: LabelInstanceFamily, Operator: OpExists}, true},
+		{NodeRequirement{Key: LabelInstanceSize, Operator: OpExists}, false},
+		{NodeRequirement{Key: LabelInstanceSize, Operator: OpDoesNotExist}, true},
+		{NodeRequirement{Key: LabelInstanceCPU, Operator: OpGt, Values: []string{"4"}}, true},
+		{NodeRequirement{Key: LabelInstanceCPU, Operator: OpGt, Values: []string{"8"}}, false},
+		{NodeRequirement{Key: LabelInstanceCPU, Operator: OpLt, Values: []string{"9"}}, true},
+		{NodeRequirement{Key: LabelInstanceFamily, Operator: OpLt, Values: []string{"9"}}, false},
+		{NodeRequirement{Key: LabelInstanceCPU, Operator: "Sometimes"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.req.Matches(labels); got != tt.want {
+			t.Errorf("%s %s %v = %v, want %v", tt.req.Key, tt.req.Operator, tt.req.Values, got, tt.want)
+		}
+	}
+}
+
+func TestNodePool_Templates(t *testing.T) {
+	catalog := []NodeTemplate{
+		{InstanceType: "m7g.large", InstanceFamily: "m7g", Architecture: ArchARM64, VCPUs: 2, MemoryMiB: 8192,
+			MaxPods: 29, AllocatableCPUMillis: 1930, AllocatableMemoryBytes: 7 << 30, CapacityType: CapacityOnDemand},
+		{InstanceType: "m7g.2xlarge", InstanceFamily: "m7g", Architecture: ArchARM64, VCPUs: 8, MemoryMiB: 32768,
+			MaxPods: 58, AllocatableCPUMillis: 7910, AllocatableMemoryBytes: 29 << 30, CapacityType: CapacityOnDemand},
+		{InstanceType: "m7i.large", InstanceFamily: "m7i", Architecture: ArchAMD64, VCPUs: 2, MemoryMiB: 8192,
+			MaxPods: 29, AllocatableCPUMillis: 1930, AllocatableMemoryBytes: 7 << 30, CapacityType: CapacityOnDemand},
+	}
+	pool := NodePool{
+		Name: "arm",
+		Requirements: []NodeRequirement{
+			{Key: LabelArch, Operator: OpIn, Values: []string{"arm64"}},
+			{Key: LabelCapacityType, Operator: OpIn, Values: []string{"spot", "on-demand"}},
+			{Key: LabelInstanceCPU, Operator: OpGt, Values: []string{"2"}},
+			{Key: "team", Operator: OpIn, Values: []string{"data"}},
+		},
+		Taints:  []Taint{{Key: "dedicated", Value: "data", Effect: TaintNoSchedule}},
+		Kubelet: NodeClassKubelet{MaxPods: 110, PodsPerCore: 10, SystemReserved: ResourceQuantity{CPUMillis: 100}},
+	}
+
+	got := pool.Templates(catalog)
+	if len(got) != 1 || got[0].InstanceType != "m7g.2xlarge" {
+		t.Fatalf("templates = %v, want only m7g.2xlarge", got)
+	}
+	n := got[0]
+	if n.CapacityType != CapacitySpot {
+		t.Errorf("capacity type = %s, want spot", n.CapacityType)
+	}
+	if n.Labels["team"] != "data" || n.Labels[LabelNodePool] != "arm" {
+		t.Errorf("labels = %v", n.Labels)
+	}
+	if len(n.Taints) != 1 || n.Taints[0].Key != "dedicated" {
+		t.Errorf("taints = %v", n.Taints)
+	}
+	if n.MaxPods != 80 {
+		t.Errorf("max pods = %d, want 80 (podsPerCore caps maxPods)", n.MaxPods)
+	}
+	if n.AllocatableCPUMillis != 7810 {
+		t.Errorf("allocatable CPU = %d, want 7810", n.AllocatableCPUMillis)
+	}
+	if catalog[1].CapacityType != CapacityOnDemand || catalog[1].Labels != nil {
+		t.Error("Templates modified the catalog")
+	}
+
+	if got := pool.Families(); got != nil {
+		t.Errorf("families = %v, want nil for an unrestricted pool", got)
+	}
+	if got := pool.Architectures(); len(got) != 1 || got[0] != ArchARM64 {
+		t.Errorf("architectures = %v, want [arm64]", got)
+	}
+}
+
+func TestNodePool_KubeReserved(t *testing.T) {
+	tmpl := NodeTemplate{VCPUs: 4, MemoryMiB: 16384, AllocatableCPUMillis: 3920, AllocatableMemoryBytes: 15 << 30}
+	k := NodeClassKubelet{KubeReserved: ResourceQuantity{CPUMillis: 200, MemoryBytes: 1 << 30}}
+	k.apply(&tmpl)
+
+	if tmpl.AllocatableCPUMillis != 3800 {
+		t.Errorf("allocatable CPU = %d, want 3800", tmpl.AllocatableCPUMillis)
+	}
+	if want := int64(15<<30) - evictionHardMemoryBytes; tmpl.AllocatableMemoryBytes != want {
+		t.Errorf("allocatable memory = %d, want %d", tmpl.AllocatableMemoryBytes, want)
+	}
+}
+
+func TestNodePool_CapacityTypeAndFamilies(t *testing.T) {
+	tests := []struct {
+		name     string
+		reqs     []NodeRequirement
+		capacity CapacityType
+		families []string
+	}{
+		{"unconstrained", nil, CapacityOnDemand, nil},
+		{"spot only", []NodeRequirement{{Key: LabelCapacityType, Operator: OpIn, Values: []string{"spot"}}}, CapacitySpot, nil},
+		{"no spot", []NodeRequirement{{Key: LabelCapacityType, Operator: OpNotIn, Values: []string{"spot"}}}, CapacityOnDemand, nil},
+		{"families", []NodeRequirement{
+			{Key: LabelInstanceFamily, Operator: OpIn, Values: []string{"m7g", "c7g"}},
+			{Key: LabelInstanceType, Operator: OpIn, Values: []string{"r7g.large", "m7g.xlarge"}},
+		}, CapacityOnDemand, []string{"c7g", "m7g", "r7g"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NodePool{Requirements: tt.reqs}
+			if got := p.CapacityType(); got != tt.capacity {
+				t.Errorf("capacity type = %s, want %s", got, tt.capacity)
+			}
+			if got := p.Families(); !slices.Equal(got, tt.families) {
+				t.Errorf("families = %v, want %v", got, tt.families)
+			}
+		})
+	}
+}
+
+func TestNodePool_SuggestRequirements(t *testing.T) {
+	p := NodePool{Requirements: []NodeRequirement{
+		{Key: LabelArch, Operator: OpIn, Values: []string{"arm64"}},
+		{Key: LabelInstanceCategory, Operator: OpIn, Values: []string{"c", "m", "r"}},
+		{Key: LabelInstanceGeneration, Operator: OpGt, Values: []string{"5"}},
+	}}
+
+	got := p.SuggestRequirements([]string{"m7g"}, 4, 8)
+	want := []NodeRequirement{
+		{Key: LabelArch, Operator: OpIn, Values: []string{"arm64"}},
+		{Key: LabelInstanceFamily, Operator: OpIn, Values: []string{"m7g"}},
+		{Key: LabelInstanceCPU, Operator: OpGt, Values: []string{"3"}},
+		{Key: LabelInstanceCPU, Operator: OpLt, Values: []string{"9"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("requirements = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Key != want[i].Key || got[i].Operator != want[i].Operator || !slices.Equal(got[i].Values, want[i].Values) {
+			t.Errorf("requirement %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClusterState_ApplyConstraints(t *testing.T) {
+	cs := ClusterState{
+		Workloads: []WorkloadProfile{
+			{Namespace: "prod", Name: "api-1"},
+			{Namespace: "prod", Name: "web-1"},
+		},
+	}
+
+	matched := cs.ApplyConstraints(map[string]PodConstraints{
+		"prod/api-1": {
+			NodeSelector: map[string]string{LabelArch: "arm64"},
+			Architecture: ArchARM64,
+		},
+	}, ConstraintsFromKubernetesAPI)
+
+	if matched != 1 {
+		t.Errorf("matched = %d, want 1", matched)
+	}
+	if cs.ConstraintsSource != ConstraintsFromKubernetesAPI {
+		t.Errorf("source = %q, want %q", cs.ConstraintsSource, ConstraintsFromKubernetesAPI)
+	}
+	if cs.Workloads[0].Architecture != ArchARM64 {
+		t.Errorf("api-1 arch = %q, want arm64", cs.Workloads[0].Architecture)
+	}
+	if got := cs.PinnedWorkloads(); got != 1 {
+		t.Errorf("PinnedWorkloads() = %d, want 1", got)
+	}
+}
+
+func TestNormalizationFactor(t *testing.T) {
+	tests := map[string]float64{
+		"t3.nano":      0.25,
+		"m5.large":     4,
+		"m5.xlarge":    8,
+		"m5.2xlarge":   16,
+		"m6i.24xlarge": 192,
+		"m5.metal":     0,
+		"invalid":      0,
+	}
+	for it, want := range tests {
+		if got := NormalizationFactor(it); got != want {
+			t.Errorf("NormalizationFactor(%q) = %v, want %v", it, got, want)
+		}
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestCommitments_Apply(t *testing.T) {
+	node := func(it string, price float64) NodeTemplate {
+		return NodeTemplate{InstanceType: it, OnDemandPricePerHour: price, CapacityType: CapacityOnDemand}
+	}
+	xlarge := node("m5.xlarge", 0.192)
+	twoXL := node("m5.2xlarge", 0.384)
+	c6i := node("c6i.xlarge", 0.17)
+	spot := NodeTemplate{InstanceType: "m5.xlarge", OnDemandPricePerHour: 0.192, SpotPricePerHour: 0.07, CapacityType: CapacitySpot}
+
+	t.Run("no commitments", func(t *testing.T) {
+		cc := Commitments{}.Apply([]NodeTemplate{xlarge, spot})
+		if !approxEqual(cc.EffectiveMonthlyCost, (0.192+0.07)*HoursPerMonth) {
+			t.Errorf("effective = %v, want the list price", cc.EffectiveMonthlyCost)
+		}
+	})
+
+	t.Run("size-flexible RI", func(t *testing.T) {
+		// Two m5.xlarge RIs cover one m5.2xlarge; the third is unused
+		c := Commitments{ReservedInstances: []ReservedInstance{{InstanceType: "m5.xlarge", Count: 3, HourlyRate: 0.12}}}
+		cc := c.Apply([]NodeTemplate{twoXL})
+		if !approxEqual(cc.ReservedNodes, 1) {
+			t.Errorf("reserved nodes = %v, want 1", cc.ReservedNodes)
+		}
+		if !approxEqual(cc.EffectiveMonthlyCost, 3*0.12*HoursPerMonth) {
+			t.Errorf("effective = %v, want all three RIs and no on-demand", cc.EffectiveMonthlyCost)
+		}
+		if !approxEqual(cc.UnusedCommitmentMonthly, 0.12*HoursPerMonth) {
+			t.Errorf("unused = %v, want one RI", cc.UnusedCommitmentMonthly)
+		}
+	})
+
+	t.Run("application order", func(t *testing.T) {
+		c := Commitments{
+			ReservedInstances: []ReservedInstance{{InstanceType: "m5.xlarge", Count: 1, HourlyRate: 0.12}},
+			SavingsPlans: []SavingsPlan{
+				// Listed first but applied last: Compute plans follow EC2 Instance plans
+				{Type: SavingsPlanCompute, HourlyCommitment: 0.14, Discount: 0.3},
+				{Type: SavingsPlanEC2Instance, Family: "m5", HourlyCommitment: 0.096, Discount: 0.5},
+			},
+		}
+		cc := c.Apply([]NodeTemplate{xlarge, xlarge, c6i, spot})
+
+		// RI covers one m5.xlarge, the EC2 plan ($0.096 → $0.192 of usage) the
+		// other, the Compute plan ($0.14 → $0.20) the c6i with $0.03 to spare.
+		if !approxEqual(cc.ReservedNodes, 1) {
+			t.Errorf("reserved nodes = %v, want 1", cc.ReservedNodes)
+		}
+		if !approxEqual(cc.SavingsPlanCovered, (0.192+0.17)*HoursPerMonth) {
+			t.Errorf("savings plan coverage = %v", cc.SavingsPlanCovered/HoursPerMonth)
+		}
+		if !approxEqual(cc.OnDemandMonthlyCost, 0) {
+			t.Errorf("uncovered on-demand = %v, want 0", cc.OnDemandMonthlyCost)
+		}
+		wantHourly := 0.12 + 0.096 + 0.14 + 0.07 // RI + both plans + spot
+		if !approxEqual(cc.EffectiveMonthlyCost, wantHourly*HoursPerMonth) {
+			t.Errorf("effective = %v/h, want %v/h", cc.EffectiveMonthlyCost/HoursPerMonth, wantHourly)
+		}
+		if !approxEqual(cc.UnusedCommitmentMonthly, 0.03*0.7*HoursPerMonth) {
+			t.Errorf("unused = %v/h, want 0.021/h", cc.UnusedCommitmentMonthly/HoursPerMonth)
+		}
+	})
+}
+
+func TestCommitments_ResolveRates(t *testing.T) {
+	c := Commitments{ReservedInstances: []ReservedInstance{
+		{InstanceType: "m5.2xlarge", Count: 1, Discount: 0.4},
+		{InstanceType: "r6g.large", Count: 2, Discount: 0.3},
+		{InstanceType: "c5.large", Count: 1, HourlyRate: 0.05},
+	}}
+	catalog := []NodeTemplate{{InstanceType: "m5.xlarge", InstanceFamily: "m5", OnDemandPricePerHour: 0.192}}
+
+	resolved, unpriced := c.ResolveRates(catalog)
+	if !approxEqual(resolved.ReservedInstances[0].HourlyRate, 0.384*0.6) {
+		t.Errorf("m5.2xlarge rate = %v, want scaled from m5.xlarge", resolved.ReservedInstances[0].HourlyRate)
+	}
+	if resolved.ReservedInstances[2].HourlyRate != 0.05 {
+		t.Error("explicit hourly rate should be kept")
+	}
+	if len(unpriced) != 1 || unpriced[0] != "r6g.large" {
+		t.Errorf("unpriced = %v, want [r6g.large]", unpriced)
+	}
+	if c.ReservedInstances[0].HourlyRate != 0 {
+		t.Error("ResolveRates must not modify the receiver")
+	}
+}
+
+func TestControllerOf(t *testing.T) {
+	tests := []struct {
+		kind, name         string
+		wantKind, wantName string
+	}{
+		{"ReplicaSet", "api-7d4b9c8f6d", "Deployment", "api"},
+		{"ReplicaSet", "payments-api-5f6b7c9d8", "Deployment", "payments-api"},
+		{"ReplicaSet", "standalone", "ReplicaSet", "standalone"},
+		{"ReplicaSet", "api-v2", "ReplicaSet", "api-v2"},
+		{"Job", "backup-29012345", "CronJob", "backup"},
+		{"Job", "migrate-42", "Job", "migrate-42"},
+		{"StatefulSet", "db-0abcdef", "StatefulSet", "db-0abcdef"},
+	}
+	for _, tt := range tests {
+		kind, name := ControllerOf(tt.kind, tt.name)
+		if kind != tt.wantKind || name != tt.wantName {
+			t.Errorf("ControllerOf(%s, %s) = %s/%s, want %s/%s", tt.kind, tt.name, kind, name, tt.wantKind, tt.wantName)
+		}
+	}
+}
+
+func TestWorkloadProfile_ControllerKey(t *testing.T) {
+	w := WorkloadProfile{Namespace: "prod", Name: "api-7d4b9c8f6d-x2k4p", OwnerKind: "ReplicaSet", OwnerName: "api-7d4b9c8f6d"}
+	if got := w.ControllerKey(); got != "prod/Deployment/api" {
+		t.Errorf("ControllerKey() = %s, want prod/Deployment/api", got)
+	}
+	bare := WorkloadProfile{Namespace: "prod", Name: "debug"}
+	if got := bare.ControllerKey(); got != "prod/Pod/debug" {
+		t.Errorf("ControllerKey() = %s, want prod/Pod/debug", got)
+	}
+}
+
+func TestClusterState_Timeline(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	step := 15 * time.Minute
+	pod := func(name, owner string, offset int, cpu ...int64) WorkloadProfile {
+		return WorkloadProfile{
+			Namespace: "prod", Name: name, OwnerKind: "ReplicaSet", OwnerName: owner,
+			Series: &UsageSeries{
+				Start:       start.Add(time.Duration(offset) * step),
+				Step:        step,
+				CPUMillis:   cpu,
+				MemoryBytes: make([]int64, len(cpu)),
+			},
+		}
+	}
+	cs := ClusterState{Workloads: []WorkloadProfile{
+		pod("api-7d4b9c8f6d-a", "api-7d4b9c8f6d", 0, 100, 200, 300),
+		pod("api-7d4b9c8f6d-b", "api-7d4b9c8f6d", 1, 50, 50),
+		pod("worker-5f6b7c9d8-a", "worker-5f6b7c9d8", 2, 700),
+		{Namespace: "prod", Name: "debug"}, // no series
+	}}
+
+	tl := cs.Timeline()
+	if tl == nil {
+		t.Fatal("expected a timeline")
+	}
+	if !tl.Start.Equal(start) || tl.Step != step || tl.Steps() != 3 {
+		t.Fatalf("timeline start %v step %v steps %d", tl.Start, tl.Step, tl.Steps())
+	}
+	if len(tl.Series) != 2 {
+		t.Fatalf("got %d series, want api and worker", len(tl.Series))
+	}
+	api := tl.Series[0]
+	if api.Key() != "prod/Deployment/api" {
+		t.Errorf("key = %s", api.Key())
+	}
+	if !slices.Equal(api.Pods, []int32{1, 2, 2}) || !slices.Equal(api.CPUMillis, []int64{100, 250, 350}) {
+		t.Errorf("api pods %v cpu %v, want [1 2 2] and [100 250 350]", api.Pods, api.CPUMillis)
+	}
+
+	if (&ClusterState{Workloads: []WorkloadProfile{{Name: "debug"}}}).Timeline() != nil {
+		t.Error("expected no timeline without series")
+	}
+}
+
+func TestWorkloadProfile_SeriesJSON(t *testing.T) {
+	// Snapshots without series keep their shape, and older snapshots load
+	data, err := json.Marshal(WorkloadProfile{Name: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Series") {
+		t.Errorf("empty series serialized: %s", data)
+	}
+	var w WorkloadProfile
+	if err := json.Unmarshal([]byte(`{"Name":"api","EffectiveCPUMillis":100}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Series != nil || w.EffectiveCPUMillis != 100 {
+		t.Errorf("decoded %+v", w)
+	}
+}
+
+func TestClusterState_AggregateReplicas(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	step := 15 * time.Minute
+	pod := func(name, owner string, cpu int64, offset int, series ...int64) WorkloadProfile {
+		w := WorkloadProfile{
+			Namespace: "prod", Name: name, OwnerKind: "ReplicaSet", OwnerName: owner,
+			Requested:          ResourceQuantity{CPUMillis: 250, MemoryBytes: 512 << 20},
+			EffectiveCPUMillis: cpu, EffectiveMemoryBytes: 512 << 20,
+			CPUUsage: PercentileValues{Percentiles: map[float64]float64{0.95: float64(cpu) / 1000}, Max: float64(cpu) / 1000},
+		}
+		if series != nil {
+			w.Series = &UsageSeries{
+				Start: start.Add(time.Duration(offset) * step), Step: step,
+				CPUMillis: series, MemoryBytes: make([]int64, len(series)),
+			}
+		}
+		return w
+	}
+	cs := ClusterState{Workloads: []WorkloadProfile{
+		pod("api-7d4b9c8f6d-a", "api-7d4b9c8f6d", 300, 0, 100, 200, 300),
+		pod("api-7d4b9c8f6d-b", "api-7d4b9c8f6d", 250, 1, 50, 50),
+		pod("api-7d4b9c8f6d-c", "api-7d4b9c8f6d", 400, 1, 60, 70),
+		pod("worker-a", "worker", 700, 2, 700),
+		pod("worker-b", "worker", 700, 2, 700),
+		{Namespace: "prod", Name: "debug", EffectiveCPUMillis: 10, EffectiveMemoryBytes: 64 << 20},
+	}}
+	cs.Workloads[4].NodeSelector = map[string]string{LabelArch: "arm64"} // another template, mid-rollout
+	before := cs.Timeline()
+	totalCPU := cs.TotalEffectiveCPU()
+
+	cs.AggregateReplicas(map[string]string{"prod/api-7d4b9c8f6d": "api"})
+	if len(cs.Workloads) != 4 {
+		t.Fatalf("got %d workloads, want api, two worker groups and debug", len(cs.Workloads))
+	}
+	api := cs.Workloads[0]
+	if api.OwnerKind != "Deployment" || api.OwnerName != "api" || api.Name != "api" || api.Replicas != 3 {
+		t.Errorf("api = %s/%s %q x%d, want Deployment/api x3", api.OwnerKind, api.OwnerName, api.Name, api.Replicas)
+	}
+	if api.EffectiveCPUMillis != 400 || api.CPUUsage.AtPercentile(0.95) != 0.4 {
+		t.Errorf("api sized %dm, p95 %v, want the largest replica", api.EffectiveCPUMillis, api.CPUUsage.AtPercentile(0.95))
+	}
+	if len(api.ReplicaSizes) != 3 || api.ReplicaSizes[0].CPUMillis != 400 || api.ReplicaSizes[2].CPUMillis != 250 {
+		t.Errorf("replica sizes %v, want 400, 300, 250", api.ReplicaSizes)
+	}
+	if w := cs.Workloads[1]; w.Replicas != 1 || w.ReplicaSizes != nil {
+		t.Errorf("worker replicas %d sizes %v, want one without sizes", w.Replicas, w.ReplicaSizes)
+	}
+	if cs.Workloads[3].Replicas != 0 {
+		t.Error("a bare pod should stay a single pod")
+	}
+	if cs.WorkloadCount() != 6 || cs.TotalEffectiveCPU() != totalCPU {
+		t.Errorf("count %d cpu %d, want 6 and %d", cs.WorkloadCount(), cs.TotalEffectiveCPU(), totalCPU)
+	}
+
+	// The timeline is the same from the summed series
+	after := cs.Timeline()
+	if after == nil || len(after.Series) != len(before.Series) {
+		t.Fatalf("got timeline %+v, want %d series", after, len(before.Series))
+	}
+	for i := range before.Series {
+		b, a := before.Series[i], after.Series[i]
+		if a.Key() != b.Key() || !slices.Equal(a.Pods, b.Pods) || !slices.Equal(a.CPUMillis, b.CPUMillis) {
+			t.Errorf("series %s pods %v cpu %v, want %s %v %v", a.Key(), a.Pods, a.CPUMillis, b.Key(), b.Pods, b.CPUMillis)
+		}
+	}
+
+	// Expanding restores one pod per replica with its own size
+	pods := ExpandReplicas(cs.Workloads)
+	if len(pods) != 6 || pods[0].Name != "api-0" || pods[2].EffectiveCPUMillis != 250 || pods[0].Replicas != 0 {
+		t.Fatalf("expanded %d pods, first %q, third %dm", len(pods), pods[0].Name, pods[2].EffectiveCPUMillis)
+	}
+	if s := pods[0].Series; s.Pods != nil || !slices.Equal(s.CPUMillis, []int64{100, 104, 140}) {
+		t.Errorf("per-pod series %v, want the total split across running replicas", s.CPUMillis)
+	}
+	if again := ExpandReplicas(pods); len(again) != len(pods) {
+		t.Error("expanding twice should change nothing")
+	}
+}
+
+func TestClusterState_ResizeAggregatedMatchesLive(t *testing.T) {
+	const mib = 1 << 20
+	pods := func(cpu, mem SizingPolicy) ClusterState {
+		var workloads []WorkloadProfile
+		for i, usage := range []float64{0.1, 0.6, 0.3, 0} {
+			w := WorkloadProfile{
+				Namespace: "prod", Name: "api-" + strconv.Itoa(i), OwnerKind: "StatefulSet", OwnerName: "api",
+				Requested: ResourceQuantity{CPUMillis: 200, MemoryBytes: 256 * mib},
+			}
+			if usage > 0 {
+				w.CPUUsage.Set(0.95, usage)
+				w.CPUUsage.Max = 2 * usage
+				w.MemoryUsage.Set(0.95, usage*1024*mib)
+				w.MemoryUsage.Max = usage * 1024 * mib
+			}
+			w.Resize(cpu, mem)
+			workloads = append(workloads, w)
+		}
+		return ClusterState{Workloads: workloads}
+	}
+	collected := SizingPolicy{Percentile: 0.95}
+	resized := SizingPolicy{Source: SizeByUsage, Percentile: 1.0}
+
+	live := pods(resized, resized)
+	live.AggregateReplicas(nil)
+	offline := pods(collected, collected)
+	offline.AggregateReplicas(nil)
+	offline.Resize(resized, resized)
+
+	l, o := live.Workloads[0], offline.Workloads[0]
+	if l.Replicas != 4 || o.Replicas != 4 {
+		t.Fatalf("replicas = %d live, %d offline, want 4", l.Replicas, o.Replicas)
+	}
+	if l.EffectiveCPUMillis != o.EffectiveCPUMillis || l.EffectiveMemoryBytes != o.EffectiveMemoryBytes {
+		t.Errorf("effective = %dm/%d MiB offline, want %dm/%d MiB as live", o.EffectiveCPUMillis,
+			o.EffectiveMemoryBytes/mib, l.EffectiveCPUMillis, l.EffectiveMemoryBytes/mib)
+	}
+	if !slices.Equal(l.ReplicaSizes, o.ReplicaSizes) || len(o.ReplicaSizes) != 4 {
+		t.Errorf("replica sizes = %v offline, want %v as live", o.ReplicaSizes, l.ReplicaSizes)
+	}
+	if !slices.Contains(o.ReplicaSizes, ResourceQuantity{CPUMillis: 200, MemoryBytes: 256 * mib}) {
+		t.Errorf("replica sizes = %v, want the replica without usage sized by its requests", o.ReplicaSizes)
+	}
+	if live.TotalEffectiveCPU() != offline.TotalEffectiveCPU() {
+		t.Errorf("total cpu = %d offline, want %d", offline.TotalEffectiveCPU(), live.TotalEffectiveCPU())
+	}
+}
+
+func TestWorkloadProfile_WithReplicas(t *testing.T) {
+	w := WorkloadProfile{Name: "api", Replicas: 2, EffectiveCPUMillis: 400, ReplicaSizes: []ResourceQuantity{
+		{CPUMillis: 400}, {CPUMillis: 200},
+	}}
+	scaled := w.WithReplicas(4)
+	if scaled.ReplicaCount() != 4 || scaled.TotalEffective().CPUMillis != 1200 {
+		t.Errorf("scaled to %d replicas of %dm, want 4 of 1200m in total", scaled.ReplicaCount(), scaled.TotalEffective().CPUMillis)
+	}
+	if len(w.ReplicaSizes) != 2 {
+		t.Error("WithReplicas should not change the original")
+	}
+}
+
+func TestReplicaRange_At(t *testing.T) {
+	tests := []struct {
+		name  string
+		r     ReplicaRange
+		level string
+		want  int
+	}{
+		{"observed min", ReplicaRange{Min: 2, P95: 6, Max: 9}, ReplicasMin, 2},
+		{"observed p95", ReplicaRange{Min: 2, P95: 6, Max: 9}, ReplicasTypical, 6},
+		{"observed peak", ReplicaRange{Min: 2, P95: 6, Max: 9}, ReplicasPeak, 9},
+		{"current", ReplicaRange{Min: 2, P95: 6, Max: 9}, ReplicasCurrent, 4},
+		{"HPA bounds without history", ReplicaRange{HPAMin: 3, HPAMax: 20}, ReplicasPeak, 20},
+		{"typical without history", ReplicaRange{HPAMin: 3, HPAMax: 20}, ReplicasTypical, 4},
+		{"clamped to the HPA maximum", ReplicaRange{Min: 2, P95: 6, Max: 12, HPAMax: 10}, ReplicasPeak, 10},
+		{"clamped to the HPA minimum", ReplicaRange{Min: 1, P95: 6, Max: 9, HPAMin: 3}, ReplicasMin, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.At(tt.level, 4); got != tt.want {
+				t.Errorf("At(%s) = %d, want %d", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterState_AtReplicas(t *testing.T) {
+	cs := ClusterState{Workloads: []WorkloadProfile{
+		{Namespace: "prod", Name: "api", OwnerKind: "Deployment", OwnerName: "api", Replicas: 3,
+			EffectiveCPUMillis: 500, ReplicaRange: &ReplicaRange{Min: 2, P95: 6, Max: 12, HPAMax: 10}},
+		{Namespace: "prod", Name: "db", OwnerKind: "StatefulSet", OwnerName: "db", Replicas: 3, EffectiveCPUMillis: 1000},
+		{Namespace: "prod", Name: "debug", EffectiveCPUMillis: 100},
+	}}
+	cs.Workloads[0].NodeSelector = map[string]string{LabelArch: "arm64"}
+	cs.Workloads = append(cs.Workloads, cs.Workloads[0]) // the same Deployment mid-rollout
+	cs.Workloads[3].NodeSelector = nil
+	cs.Workloads[3].Replicas = 1
+
+	peak := cs.AtReplicas(ReplicasPeak)
+	if got := peak.Workloads[0].Replicas + peak.Workloads[3].Replicas; got != 10 {
+		t.Errorf("api at peak has %d replicas, want the HPA maximum of 10", got)
+	}
+	if peak.Workloads[0].Replicas != 8 {
+		t.Errorf("the larger rollout group has %d replicas, want 8", peak.Workloads[0].Replicas)
+	}
+	if peak.Workloads[1].Replicas != 3 || peak.WorkloadCount() != 14 {
+		t.Errorf("db replicas %d, pods %d, want db unchanged and 14 pods", peak.Workloads[1].Replicas, peak.WorkloadCount())
+	}
+	if cs.Workloads[0].Replicas != 3 {
+		t.Error("AtReplicas should not change the original")
+	}
+
+	// The smaller rollout group rounds away at the minimum
+	low := cs.AtReplicas(ReplicasMin)
+	if len(low.Workloads) != 3 || low.Workloads[0].Replicas != 2 {
+		t.Errorf("got %d workloads, api x%d, want 3 with api x2", len(low.Workloads), low.Workloads[0].Replicas)
+	}
+	if cur := cs.AtReplicas(ReplicasCurrent); cur.WorkloadCount() != cs.WorkloadCount() {
+		t.Errorf("current has %d pods, want %d", cur.WorkloadCount(), cs.WorkloadCount())
+	}
+}
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
 // CapacityType represents the EC2 purchasing option.
 type CapacityType string
 
@@ -16,6 +22,48 @@ const (
 	ArchARM64 Architecture = "arm64"
 )
 
+// Well-known node labels derived from a NodeTemplate, matching what EKS and
+// Karpenter set on provisioned nodes.
+const (
+	LabelArch           = "kubernetes.io/arch"
+	LabelOS             = "kubernetes.io/os"
+	LabelInstanceType   = "node.kubernetes.io/instance-type"
+	LabelInstanceFamily = "karpenter.k8s.aws/instance-family"
+	LabelCapacityType   = "karpenter.sh/capacity-type"
+	LabelRegion         = "topology.kubernetes.io/region"
+)
+
+// TaintEffect is the Kubernetes taint effect.
+type TaintEffect string
+
+const (
+	TaintNoSchedule       TaintEffect = "NoSchedule"
+	TaintPreferNoSchedule TaintEffect = "PreferNoSchedule"
+	TaintNoExecute        TaintEffect = "NoExecute"
+)
+
+// Taint is a node taint that repels pods without a matching toleration.
+type Taint struct {
+	Key    string
+	Value  string
+	Effect TaintEffect
+}
+
+// String returns the taint in kubectl notation: key=value:Effect.
+func (t Taint) String() string {
+	s := t.Key
+	if t.Value != "" {
+		s += "=" + t.Value
+	}
+	return s + ":" + string(t.Effect)
+}
+
+// Blocks returns true if the taint prevents scheduling of non-tolerating pods.
+// PreferNoSchedule is a soft preference and never blocks placement.
+func (t Taint) Blocks() bool {
+	return t.Effect == TaintNoSchedule || t.Effect == TaintNoExecute
+}
+
 // NodeTemplate represents a candidate EC2 instance type for bin-packing simulation.
 type NodeTemplate struct {
 	// EC2 identity
@@ -43,6 +91,14 @@ type NodeTemplate struct {
 	SpotPricePerHour     float64
 	CapacityType         CapacityType
 
+	// Share of spot instances of this type interrupted per month, e.g. from
+	// the Spot Instance Advisor (0 = unknown)
+	SpotInterruptionRate float64
+
+	// Scheduling attributes
+	Labels map[string]string // Extra node labels (well-known labels are derived)
+	Taints []Taint
+
 	// Metadata
 	CurrentGeneration bool
 	Region            string
@@ -56,6 +112,57 @@ func (n NodeTemplate) EffectivePricePerHour() float64 {
 	return n.OnDemandPricePerHour
 }
 
+// NodeLabels returns the full label set a node of this template would carry:
+// the well-known labels derived from the template, overlaid with Labels.
+func (n NodeTemplate) NodeLabels() map[string]string {
+	labels := map[string]string{
+		LabelOS: "linux",
+	}
+	if n.Architecture != "" {
+		labels[LabelArch] = string(n.Architecture)
+	}
+	if n.InstanceType != "" {
+		labels[LabelInstanceType] = n.InstanceType
+	}
+	if n.InstanceFamily != "" {
+		labels[LabelInstanceFamily] = n.InstanceFamily
+		if category := instanceCategory(n.InstanceFamily); category != "" {
+			labels[LabelInstanceCategory] = category
+		}
+	}
+	if n.Generation > 0 {
+		labels[LabelInstanceGeneration] = strconv.Itoa(n.Generation)
+	}
+	if n.Size != "" {
+		labels[LabelInstanceSize] = n.Size
+	}
+	if n.VCPUs > 0 {
+		labels[LabelInstanceCPU] = strconv.Itoa(int(n.VCPUs))
+	}
+	if n.MemoryMiB > 0 {
+		labels[LabelInstanceMemory] = strconv.FormatInt(n.MemoryMiB, 10)
+	}
+	if n.CapacityType != "" {
+		labels[LabelCapacityType] = string(n.CapacityType)
+	}
+	if n.Region != "" {
+		labels[LabelRegion] = n.Region
+	}
+	for k, v := range n.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// instanceCategory returns the letters of a family before its generation,
+// e.g. "m" for "m7g" and "inf" for "inf2".
+func instanceCategory(family string) string {
+	if i := strings.IndexFunc(family, unicode.IsDigit); i >= 0 {
+		return family[:i]
+	}
+	return family
+}
+
 // AllocatableResources returns the allocatable capacity as a ResourceQuantity.
 func (n NodeTemplate) AllocatableResources() ResourceQuantity {
 	return ResourceQuantity{
@@ -0,0 +1,309 @@
+package model
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Karpenter well-known labels, set on every node Karpenter provisions and
+// usable as NodePool requirement keys.
+const (
+	LabelInstanceCategory   = "karpenter.k8s.aws/instance-category"
+	LabelInstanceGeneration = "karpenter.k8s.aws/instance-generation"
+	LabelInstanceSize       = "karpenter.k8s.aws/instance-size"
+	LabelInstanceCPU        = "karpenter.k8s.aws/instance-cpu"
+	LabelInstanceMemory     = "karpenter.k8s.aws/instance-memory" // MiB
+	LabelNodePool           = "karpenter.sh/nodepool"
+)
+
+// Node requirement operators, as in NodePool spec.template.spec.requirements.
+const (
+	OpIn           = "In"
+	OpNotIn        = "NotIn"
+	OpExists       = "Exists"
+	OpDoesNotExist = "DoesNotExist"
+	OpGt           = "Gt"
+	OpLt           = "Lt"
+)
+
+// Karpenter consolidation policies.
+const (
+	ConsolidateWhenEmpty                = "WhenEmpty"
+	ConsolidateWhenEmptyOrUnderutilized = "WhenEmptyOrUnderutilized"
+)
+
+// instanceKeys are the requirement keys describing the instance type itself.
+// Requirements on other keys (zone and capacity type aside) become node labels.
+var instanceKeys = map[string]bool{
+	LabelArch:               true,
+	LabelOS:                 true,
+	LabelInstanceType:       true,
+	LabelInstanceFamily:     true,
+	LabelInstanceCategory:   true,
+	LabelInstanceGeneration: true,
+	LabelInstanceSize:       true,
+	LabelInstanceCPU:        true,
+	LabelInstanceMemory:     true,
+}
+
+// shapeKeys are the instance keys a NodePool suggestion replaces with the
+// families and sizes the simulation actually used.
+var shapeKeys = map[string]bool{
+	LabelInstanceType:       true,
+	LabelInstanceFamily:     true,
+	LabelInstanceCategory:   true,
+	LabelInstanceGeneration: true,
+	LabelInstanceSize:       true,
+	LabelInstanceCPU:        true,
+	LabelInstanceMemory:     true,
+}
+
+// NodeRequirement is a Karpenter node selector requirement.
+type NodeRequirement struct {
+	Key       string   `json:"key" yaml:"key"`
+	Operator  string   `json:"operator" yaml:"operator"`
+	Values    []string `json:"values,omitempty" yaml:"values,omitempty,flow"`
+	MinValues int      `json:"minValues,omitempty" yaml:"minValues,omitempty"`
+}
+
+// Matches reports whether a label set satisfies the requirement.
+func (r NodeRequirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+	switch r.Operator {
+	case OpIn:
+		return ok && slices.Contains(r.Values, v)
+	case OpNotIn:
+		return !ok || !slices.Contains(r.Values, v)
+	case OpExists:
+		return ok
+	case OpDoesNotExist:
+		return !ok
+	case OpGt, OpLt:
+		if !ok || len(r.Values) == 0 {
+			return false
+		}
+		have, err1 := strconv.ParseInt(v, 10, 64)
+		bound, err2 := strconv.ParseInt(r.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if r.Operator == OpGt {
+			return have > bound
+		}
+		return have < bound
+	}
+	return false
+}
+
+// NodeClassKubelet holds the EC2NodeClass kubelet settings that change node
+// capacity. Zero values keep the EKS defaults.
+type NodeClassKubelet struct {
+	MaxPods        int32            `json:"max_pods,omitempty"`
+	PodsPerCore    int32            `json:"pods_per_core,omitempty"`
+	KubeReserved   ResourceQuantity `json:"kube_reserved"`   // replaces the EKS kube-reserved formula when set
+	SystemReserved ResourceQuantity `json:"system_reserved"` // subtracted from allocatable
+}
+
+// NodePool is a Karpenter NodePool together with the kubelet settings of its
+// EC2NodeClass.
+type NodePool struct {
+	Name         string            `json:"name"`
+	Weight       int32             `json:"weight,omitempty"` // higher weight pools are tried first
+	Requirements []NodeRequirement `json:"requirements"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Taints       []Taint           `json:"taints,omitempty"`
+	NodeClass    string            `json:"node_class,omitempty"`
+	Kubelet      NodeClassKubelet  `json:"kubelet"`
+
+	// Limits on the total capacity of the pool's nodes; zero = unlimited
+	LimitCPUMillis   int64 `json:"limit_cpu_millis,omitempty"`
+	LimitMemoryBytes int64 `json:"limit_memory_bytes,omitempty"`
+
+	// Disruption settings
+	ConsolidationPolicy string        `json:"consolidation_policy,omitempty"`
+	ConsolidateAfter    time.Duration `json:"consolidate_after,omitempty"` // negative = Never
+}
+
+// Allows reports whether the pool may launch the given instance type,
+// evaluating the requirements on instance labels. Zone and capacity type
+// requirements are not properties of the instance type and are checked by
+// AllowsZone and CapacityType.
+func (p NodePool) Allows(t NodeTemplate) bool {
+	labels := t.NodeLabels()
+	for _, r := range p.Requirements {
+		if instanceKeys[r.Key] && !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsZone reports whether the pool may launch nodes in the zone.
+func (p NodePool) AllowsZone(zone string) bool {
+	return p.allowsValue(TopologyZone, zone)
+}
+
+// CapacityType returns the capacity type Karpenter launches for the pool:
+// spot when the pool allows it, since Karpenter prefers spot over
+// on-demand, and on-demand otherwise. A pool without a capacity type
+// requirement launches on-demand nodes.
+func (p NodePool) CapacityType() CapacityType {
+	if p.constrains(LabelCapacityType) && p.allowsValue(LabelCapacityType, string(CapacitySpot)) {
+		return CapacitySpot
+	}
+	return CapacityOnDemand
+}
+
+// Families returns the instance families the pool is restricted to by
+// instance-family or instance-type In requirements, or nil when any family
+// is allowed.
+func (p NodePool) Families() []string {
+	var families []string
+	restricted := false
+	for _, r := range p.Requirements {
+		if r.Operator != OpIn {
+			continue
+		}
+		switch r.Key {
+		case LabelInstanceFamily:
+			restricted = true
+			families = append(families, r.Values...)
+		case LabelInstanceType:
+			restricted = true
+			for _, it := range r.Values {
+				family, _, _ := strings.Cut(it, ".")
+				families = append(families, family)
+			}
+		}
+	}
+	if !restricted {
+		return nil
+	}
+	sort.Strings(families)
+	return slices.Compact(families)
+}
+
+// Architectures returns the architectures the pool allows.
+func (p NodePool) Architectures() []Architecture {
+	var archs []Architecture
+	for _, a := range []Architecture{ArchAMD64, ArchARM64} {
+		if p.allowsValue(LabelArch, string(a)) {
+			archs = append(archs, a)
+		}
+	}
+	return archs
+}
+
+// allowsValue reports whether every requirement on key accepts value.
+func (p NodePool) allowsValue(key, value string) bool {
+	labels := map[string]string{key: value}
+	for _, r := range p.Requirements {
+		if r.Key == key && !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// constrains reports whether the pool has a requirement on key.
+func (p NodePool) constrains(key string) bool {
+	for _, r := range p.Requirements {
+		if r.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Templates returns the catalog entries the pool may launch, as nodes of the
+// pool: with its capacity type, labels, taints and kubelet settings applied.
+func (p NodePool) Templates(catalog []NodeTemplate) []NodeTemplate {
+	capacity := p.CapacityType()
+	var templates []NodeTemplate
+	for _, t := range catalog {
+		if !p.Allows(t) {
+			continue
+		}
+		t.CapacityType = capacity
+		t.Labels = p.nodeLabels(t.Labels)
+		t.Taints = append(slices.Clip(t.Taints), p.Taints...)
+		p.Kubelet.apply(&t)
+		templates = append(templates, t)
+	}
+	return templates
+}
+
+// nodeLabels returns the labels the pool adds to its nodes on top of base:
+// the template labels, single-value In requirements on custom keys, and the
+// NodePool name.
+func (p NodePool) nodeLabels(base map[string]string) map[string]string {
+	labels := make(map[string]string, len(base)+len(p.Labels)+1)
+	for k, v := range base {
+		labels[k] = v
+	}
+	for k, v := range p.Labels {
+		labels[k] = v
+	}
+	for _, r := range p.Requirements {
+		if instanceKeys[r.Key] || r.Key == TopologyZone || r.Key == LabelCapacityType {
+			continue
+		}
+		if r.Operator == OpIn && len(r.Values) == 1 {
+			labels[r.Key] = r.Values[0]
+		}
+	}
+	labels[LabelNodePool] = p.Name
+	return labels
+}
+
+// apply overrides the template's pod density and allocatable capacity.
+func (k NodeClassKubelet) apply(t *NodeTemplate) {
+	if k.MaxPods > 0 {
+		t.MaxPods = k.MaxPods
+	}
+	if k.PodsPerCore > 0 && t.VCPUs > 0 {
+		t.MaxPods = min(t.MaxPods, k.PodsPerCore*t.VCPUs)
+	}
+	if !k.KubeReserved.IsZero() {
+		t.AllocatableCPUMillis = int64(t.VCPUs)*1000 - k.KubeReserved.CPUMillis
+		t.AllocatableMemoryBytes = t.MemoryMiB*1024*1024 - k.KubeReserved.MemoryBytes - evictionHardMemoryBytes
+	}
+	t.AllocatableCPUMillis = max(0, t.AllocatableCPUMillis-k.SystemReserved.CPUMillis)
+	t.AllocatableMemoryBytes = max(0, t.AllocatableMemoryBytes-k.SystemReserved.MemoryBytes)
+}
+
+// evictionHardMemoryBytes is the kubelet's default memory.available eviction threshold.
+const evictionHardMemoryBytes = 100 * 1024 * 1024
+
+// NodePoolSuggestion is the requirements block recommended for a NodePool,
+// narrowed to the instance families and sizes the simulation provisioned.
+type NodePoolSuggestion struct {
+	NodePool      string            `json:"nodepool"`
+	Nodes         int               `json:"nodes"`
+	MonthlyCost   float64           `json:"monthly_cost"`
+	InstanceTypes []string          `json:"instance_types"` // types launched, cheapest first
+	Requirements  []NodeRequirement `json:"requirements"`
+}
+
+// SuggestRequirements returns the pool's requirements with its instance shape
+// requirements replaced by the given families and vCPU range. Architecture,
+// capacity type, zone and custom requirements are kept.
+func (p NodePool) SuggestRequirements(families []string, minCPU, maxCPU int32) []NodeRequirement {
+	var reqs []NodeRequirement
+	for _, r := range p.Requirements {
+		if !shapeKeys[r.Key] {
+			reqs = append(reqs, r)
+		}
+	}
+	reqs = append(reqs, NodeRequirement{Key: LabelInstanceFamily, Operator: OpIn, Values: families})
+	if minCPU > 0 {
+		reqs = append(reqs,
+			NodeRequirement{Key: LabelInstanceCPU, Operator: OpGt, Values: []string{strconv.Itoa(int(minCPU) - 1)}},
+			NodeRequirement{Key: LabelInstanceCPU, Operator: OpLt, Values: []string{strconv.Itoa(int(maxCPU) + 1)}},
+		)
+	}
+	return reqs
+}
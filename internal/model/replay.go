@@ -0,0 +1,205 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// UsageTimeline is the resource usage of the cluster's workloads at regular
+// steps over the metrics window, grouped by controller. It drives the
+// time-series replay of autoscaling.
+type UsageTimeline struct {
+	Start  time.Time        `json:"start"`
+	Step   time.Duration    `json:"step"`
+	Series []WorkloadSeries `json:"series"`
+}
+
+// WorkloadSeries is the usage of one controller's pods, or of a bare pod,
+// at every step of a timeline. All slices have one entry per step.
+type WorkloadSeries struct {
+	Namespace string `json:"namespace"`
+	OwnerKind string `json:"owner_kind"`
+	OwnerName string `json:"owner_name"`
+
+	Pods        []int32 `json:"pods"`         // running pods
+	CPUMillis   []int64 `json:"cpu_millis"`   // total usage of the running pods
+	MemoryBytes []int64 `json:"memory_bytes"` // total usage of the running pods
+}
+
+// UsageSeries is a pod's usage at regular steps, from its first sample in the
+// metrics window to the end of the window. Each value is the peak within its
+// step. Steps where the pod reported no metrics are zero.
+//
+// The series of a controller's representative profile is the total usage of
+// its replicas, with the replicas running at each step in Pods.
+type UsageSeries struct {
+	Start       time.Time     `json:"start"`
+	Step        time.Duration `json:"step"`
+	CPUMillis   []int64       `json:"cpu_millis"`
+	MemoryBytes []int64       `json:"memory_bytes"`
+	Pods        []int32       `json:"pods,omitempty"`
+}
+
+// Steps returns the number of steps in the timeline.
+func (t *UsageTimeline) Steps() int {
+	n := 0
+	for i := range t.Series {
+		n = max(n, len(t.Series[i].Pods))
+	}
+	return n
+}
+
+// Duration returns the time covered by the timeline.
+func (t *UsageTimeline) Duration() time.Duration {
+	return time.Duration(t.Steps()) * t.Step
+}
+
+// Timeline sums the usage series of the workloads by controller. Only pods
+// running when the snapshot was taken have series, so controllers that
+// scaled down during the window are seen at their current size. It returns
+// nil when no workload has a series.
+func (cs *ClusterState) Timeline() *UsageTimeline {
+	var start, end time.Time
+	var step time.Duration
+	for i := range cs.Workloads {
+		s := cs.Workloads[i].Series
+		if s == nil || s.Step <= 0 {
+			continue
+		}
+		if step == 0 || s.Start.Before(start) {
+			start = s.Start
+		}
+		if e := s.Start.Add(time.Duration(len(s.CPUMillis)) * s.Step); e.After(end) {
+			end = e
+		}
+		step = max(step, s.Step)
+	}
+	if step == 0 {
+		return nil
+	}
+	steps := int(end.Sub(start) / step)
+
+	tl := &UsageTimeline{Start: start, Step: step}
+	index := make(map[string]int)
+	for i := range cs.Workloads {
+		w := &cs.Workloads[i]
+		if w.Series == nil || w.Series.Step != step {
+			continue
+		}
+		key := w.ControllerKey()
+		j, ok := index[key]
+		if !ok {
+			kind, name := w.controller()
+			j = len(tl.Series)
+			index[key] = j
+			tl.Series = append(tl.Series, WorkloadSeries{
+				Namespace:   w.Namespace,
+				OwnerKind:   kind,
+				OwnerName:   name,
+				Pods:        make([]int32, steps),
+				CPUMillis:   make([]int64, steps),
+				MemoryBytes: make([]int64, steps),
+			})
+		}
+		ws := &tl.Series[j]
+		offset := int(w.Series.Start.Sub(start) / step)
+		for k := range w.Series.CPUMillis {
+			t := offset + k
+			if t < 0 || t >= steps {
+				continue
+			}
+			if w.Series.Pods != nil {
+				ws.Pods[t] += w.Series.Pods[k]
+			} else {
+				ws.Pods[t]++
+			}
+			ws.CPUMillis[t] += w.Series.CPUMillis[k]
+			if k < len(w.Series.MemoryBytes) {
+				ws.MemoryBytes[t] += w.Series.MemoryBytes[k]
+			}
+		}
+	}
+	return tl
+}
+
+// Key identifies the controller of the series, in the form of
+// WorkloadProfile.ControllerKey.
+func (s *WorkloadSeries) Key() string {
+	return s.Namespace + "/" + s.OwnerKind + "/" + s.OwnerName
+}
+
+// ControllerKey identifies the controller that manages the workload's pods,
+// resolving a Deployment's ReplicaSets and a CronJob's Jobs, so pods created
+// before and after a rollout share a key. See ControllerOf.
+func (w *WorkloadProfile) ControllerKey() string {
+	kind, name := w.controller()
+	return w.Namespace + "/" + kind + "/" + name
+}
+
+// controller returns the kind and name of the workload's top-level
+// controller, or the pod itself when it has no owner.
+func (w *WorkloadProfile) controller() (string, string) {
+	kind, name := ControllerOf(w.OwnerKind, w.OwnerName)
+	if name == "" {
+		return "Pod", w.Name
+	}
+	return kind, name
+}
+
+// ControllerOf returns the top-level controller of a pod owner: the
+// Deployment of a ReplicaSet named "<deployment>-<pod-template-hash>", and
+// the CronJob of a Job named "<cronjob>-<scheduled minute>". Other owners
+// are returned unchanged.
+func ControllerOf(kind, name string) (string, string) {
+	base, suffix, ok := cutLast(name, "-")
+	if !ok {
+		return kind, name
+	}
+	switch {
+	case kind == "ReplicaSet" && isPodTemplateHash(suffix):
+		return "Deployment", base
+	case kind == "Job" && len(suffix) >= 8 && strings.Trim(suffix, "0123456789") == "":
+		return "CronJob", base
+	}
+	return kind, name
+}
+
+// isPodTemplateHash reports whether s looks like a pod-template-hash: 6 to
+// 10 characters of the alphabet Kubernetes uses for generated names.
+func isPodTemplateHash(s string) bool {
+	return len(s) >= 6 && len(s) <= 10 && strings.Trim(s, "bcdfghjklmnpqrstvwxz2456789") == ""
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i > 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// ReplayResult is the outcome of replaying an instance configuration over a
+// usage timeline, scaling nodes up and down as an autoscaler would.
+type ReplayResult struct {
+	InstanceConfig InstanceConfig `json:"instance_config"`
+	Policy         string         `json:"policy"` // autoscaler replayed, e.g. "cluster-autoscaler"
+	Steps          int            `json:"steps"`
+	Step           time.Duration  `json:"step"`
+
+	NodeHours           float64 `json:"node_hours"`
+	Cost                float64 `json:"cost"`                  // integrated over the timeline
+	MonthlyCost         float64 `json:"monthly_cost"`          // Cost scaled to 730 hours
+	SnapshotMonthlyCost float64 `json:"snapshot_monthly_cost"` // cost of the static bin-packing, for comparison
+
+	MinNodes  int     `json:"min_nodes"`
+	PeakNodes int     `json:"peak_nodes"`
+	AvgNodes  float64 `json:"avg_nodes"`
+
+	AvgCPUUtilization float64 `json:"avg_cpu_utilization"` // usage over allocatable, time-weighted
+	AvgMemUtilization float64 `json:"avg_mem_utilization"`
+
+	ScaleUps          int `json:"scale_ups"`          // steps that launched nodes
+	PeakPendingPods   int `json:"peak_pending_pods"`  // most pods waiting for a new node at one step
+	UnschedulablePods int `json:"unschedulable_pods"` // most pods no instance type could host at one step
+
+	NodeCounts []int `json:"node_counts"` // nodes at every step
+}
@@ -0,0 +1,470 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"time"
+)
+
+// ReplicaUsage is what one replica of a controller is sized from, kept on
+// the representative profile so that each replica can be re-sized under
+// other sizing policies.
+type ReplicaUsage struct {
+	Requested   ResourceQuantity
+	Limits      ResourceQuantity
+	CPUUsage    PercentileValues
+	MemoryUsage PercentileValues
+}
+
+// replicaUsage returns what the profile is sized from.
+func (w *WorkloadProfile) replicaUsage() ReplicaUsage {
+	return ReplicaUsage{Requested: w.Requested, Limits: w.Limits, CPUUsage: w.CPUUsage, MemoryUsage: w.MemoryUsage}
+}
+
+// equal reports whether two replicas are sized from the same values.
+func (r *ReplicaUsage) equal(o *ReplicaUsage) bool {
+	return r.Requested == o.Requested && r.Limits == o.Limits &&
+		r.CPUUsage.equal(o.CPUUsage) && r.MemoryUsage.equal(o.MemoryUsage)
+}
+
+func (p PercentileValues) equal(o PercentileValues) bool {
+	return p.Max == o.Max && maps.Equal(p.Percentiles, o.Percentiles)
+}
+
+// ReplicaCount returns the number of pods the profile stands for: Replicas
+// for a controller's representative profile, 1 for a single pod.
+func (w *WorkloadProfile) ReplicaCount() int {
+	return max(1, int(w.Replicas))
+}
+
+// ReplicaSize returns the effective size of the i-th replica, from
+// ReplicaSizes when the replicas differ, or the profile's effective size.
+func (w *WorkloadProfile) ReplicaSize(i int) ResourceQuantity {
+	if i < len(w.ReplicaSizes) {
+		return w.ReplicaSizes[i]
+	}
+	return ResourceQuantity{CPUMillis: w.EffectiveCPUMillis, MemoryBytes: w.EffectiveMemoryBytes}
+}
+
+// TotalEffective returns the effective size of all the profile's replicas.
+func (w *WorkloadProfile) TotalEffective() ResourceQuantity {
+	var total ResourceQuantity
+	for i := range w.ReplicaCount() {
+		total = total.Add(w.ReplicaSize(i))
+	}
+	return total
+}
+
+// Expand returns one profile per replica, named "<name>-<index>", each with
+// its own effective size and a per-pod usage series. A profile that is not a
+// controller's representative is returned as is.
+func (w *WorkloadProfile) Expand() []WorkloadProfile {
+	if w.Replicas <= 0 {
+		return []WorkloadProfile{*w}
+	}
+	pod := *w
+	pod.Replicas = 0
+	pod.ReplicaSizes = nil
+	pod.ReplicaUsage = nil
+	pod.Series = w.Series.perPod()
+
+	pods := make([]WorkloadProfile, w.Replicas)
+	for i := range pods {
+		pods[i] = pod
+		pods[i].Name = fmt.Sprintf("%s-%d", w.Name, i)
+		size := w.ReplicaSize(i)
+		pods[i].EffectiveCPUMillis, pods[i].EffectiveMemoryBytes = size.CPUMillis, size.MemoryBytes
+		if i < len(w.ReplicaUsage) {
+			r := w.ReplicaUsage[i]
+			pods[i].Requested, pods[i].Limits = r.Requested, r.Limits
+			pods[i].CPUUsage, pods[i].MemoryUsage = r.CPUUsage, r.MemoryUsage
+		}
+	}
+	return pods
+}
+
+// ExpandReplicas returns the workloads with every controller's
+// representative profile expanded into its replicas. Workloads that are all
+// single pods are returned unchanged, so expanding twice is harmless.
+func ExpandReplicas(workloads []WorkloadProfile) []WorkloadProfile {
+	if !hasReplicas(workloads) {
+		return workloads
+	}
+	n := 0
+	for i := range workloads {
+		n += workloads[i].ReplicaCount()
+	}
+	pods := make([]WorkloadProfile, 0, n)
+	for i := range workloads {
+		pods = append(pods, workloads[i].Expand()...)
+	}
+	return pods
+}
+
+func hasReplicas(workloads []WorkloadProfile) bool {
+	for i := range workloads {
+		if workloads[i].Replicas > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WithReplicas returns a copy of the representative profile standing for n
+// replicas, n >= 1. When the replicas differ in size, the sizes and usage
+// are resampled across the new count, keeping their spread.
+func (w *WorkloadProfile) WithReplicas(n int) WorkloadProfile {
+	scaled := *w
+	scaled.Replicas = int32(n)
+	scaled.ReplicaSizes = resample(w.ReplicaSizes, n)
+	scaled.ReplicaUsage = resample(w.ReplicaUsage, n)
+	return scaled
+}
+
+// resample returns n values spread evenly over the given ones, or nil when
+// there are none.
+func resample[T any](values []T, n int) []T {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]T, n)
+	for i := range n {
+		out[i] = values[i*len(values)/n]
+	}
+	return out
+}
+
+// perPod returns the series of one replica: the series itself, or the
+// total divided by the running replicas for a controller's series.
+func (s *UsageSeries) perPod() *UsageSeries {
+	if s == nil || s.Pods == nil {
+		return s
+	}
+	pod := &UsageSeries{
+		Start:       s.Start,
+		Step:        s.Step,
+		CPUMillis:   make([]int64, len(s.CPUMillis)),
+		MemoryBytes: make([]int64, len(s.MemoryBytes)),
+	}
+	for t := range s.Pods {
+		n := int64(s.Pods[t])
+		if n == 0 {
+			continue
+		}
+		if t < len(s.CPUMillis) {
+			pod.CPUMillis[t] = ceilDiv(s.CPUMillis[t], n)
+		}
+		if t < len(s.MemoryBytes) {
+			pod.MemoryBytes[t] = ceilDiv(s.MemoryBytes[t], n)
+		}
+	}
+	return pod
+}
+
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
+
+// AggregateReplicas replaces the pods of each controller with one
+// representative profile carrying Replicas, which keeps snapshots of large
+// clusters small. Pods are grouped by owner, with a ReplicaSet resolved to
+// the Deployment given for "namespace/replicaset" in deployments; pods of
+// one controller with different scheduling constraints, as during a
+// rollout, stay in separate groups. Pods without an owner and DaemonSets
+// are left as they are.
+//
+// The representative has the largest requests, limits and effective size
+// of the replicas, and their highest usage at each percentile. When the
+// replicas differ in effective size, ReplicaSizes keeps each one, largest
+// first. Usage series are summed, with the running replicas per step.
+func (cs *ClusterState) AggregateReplicas(deployments map[string]string) {
+	resolved := make(map[string]string) // ReplicaSet group key → Deployment group key
+	workloads := make([]WorkloadProfile, 0, len(cs.Workloads))
+	index := make(map[string]int)
+	replicas := make(map[int][]sizedReplica)
+
+	for i := range cs.Workloads {
+		w := cs.Workloads[i]
+		if w.OwnerName == "" || w.Replicas > 0 {
+			workloads = append(workloads, w)
+			continue
+		}
+		if w.OwnerKind == "ReplicaSet" {
+			if d, ok := deployments[w.Namespace+"/"+w.OwnerName]; ok {
+				from := w.GroupKey()
+				w.OwnerKind, w.OwnerName = "Deployment", d
+				resolved[from] = w.GroupKey()
+			}
+		}
+		key := w.GroupKey() + "|" + constraintsSignature(&w)
+		j, ok := index[key]
+		if !ok {
+			j = len(workloads)
+			index[key] = j
+			rep := w
+			rep.Name = w.OwnerName
+			rep.Series = nil
+			workloads = append(workloads, rep)
+		}
+		rep := &workloads[j]
+		if ok {
+			rep.merge(&w)
+		}
+		rep.Replicas++
+		rep.Series = rep.Series.addReplica(w.Series)
+		replicas[j] = append(replicas[j], sizedReplica{
+			size:  ResourceQuantity{CPUMillis: w.EffectiveCPUMillis, MemoryBytes: w.EffectiveMemoryBytes},
+			usage: w.replicaUsage(),
+		})
+	}
+
+	for j, r := range replicas {
+		workloads[j].setReplicas(r)
+	}
+
+	// Affinity terms naming a resolved ReplicaSet now name its Deployment
+	for i := range workloads {
+		remapGroups(workloads[i].PodAffinity, resolved)
+		remapGroups(workloads[i].PodAntiAffinity, resolved)
+	}
+	cs.Workloads = workloads
+}
+
+// sizedReplica is the effective size of one replica and what it is sized
+// from.
+type sizedReplica struct {
+	size  ResourceQuantity
+	usage ReplicaUsage
+}
+
+// setReplicas sorts the replicas largest first and keeps their sizes in
+// ReplicaSizes, and what they are sized from in ReplicaUsage, when they
+// differ.
+func (w *WorkloadProfile) setReplicas(r []sizedReplica) {
+	sort.SliceStable(r, func(a, b int) bool {
+		if r[a].size.CPUMillis != r[b].size.CPUMillis {
+			return r[a].size.CPUMillis > r[b].size.CPUMillis
+		}
+		return r[a].size.MemoryBytes > r[b].size.MemoryBytes
+	})
+	w.ReplicaSizes, w.ReplicaUsage = nil, nil
+	for i := range r[1:] {
+		if r[i+1].size != r[0].size {
+			w.ReplicaSizes = make([]ResourceQuantity, len(r))
+			for k := range r {
+				w.ReplicaSizes[k] = r[k].size
+			}
+			break
+		}
+	}
+	for i := range r[1:] {
+		if !r[i+1].usage.equal(&r[0].usage) {
+			w.ReplicaUsage = make([]ReplicaUsage, len(r))
+			for k := range r {
+				w.ReplicaUsage[k] = r[k].usage
+			}
+			break
+		}
+	}
+}
+
+// merge folds another replica of the same controller into the
+// representative profile.
+func (w *WorkloadProfile) merge(o *WorkloadProfile) {
+	w.Requested = maxQuantity(w.Requested, o.Requested)
+	w.Limits = maxQuantity(w.Limits, o.Limits)
+	w.EffectiveCPUMillis = max(w.EffectiveCPUMillis, o.EffectiveCPUMillis)
+	w.EffectiveMemoryBytes = max(w.EffectiveMemoryBytes, o.EffectiveMemoryBytes)
+	w.CPUUsage = w.CPUUsage.envelope(o.CPUUsage)
+	w.MemoryUsage = w.MemoryUsage.envelope(o.MemoryUsage)
+	w.NoMetrics = w.NoMetrics && o.NoMetrics
+}
+
+// envelope returns the higher of two usages at each percentile.
+func (p PercentileValues) envelope(o PercentileValues) PercentileValues {
+	out := PercentileValues{Max: math.Max(p.Max, o.Max)}
+	for q, v := range p.Percentiles {
+		out.Set(q, v)
+	}
+	for q, v := range o.Percentiles {
+		out.Set(q, math.Max(out.Percentiles[q], v))
+	}
+	return out
+}
+
+// addReplica adds a replica's series to the sum of a controller's series,
+// counting the replica as running from its first sample. Series at another
+// step are left out.
+func (s *UsageSeries) addReplica(pod *UsageSeries) *UsageSeries {
+	if pod == nil || pod.Step <= 0 || (s != nil && s.Step != pod.Step) {
+		return s
+	}
+	if s == nil {
+		s = &UsageSeries{Start: pod.Start, Step: pod.Step}
+	}
+	if pod.Start.Before(s.Start) {
+		shift := int(s.Start.Sub(pod.Start) / s.Step)
+		s.Pods = append(make([]int32, shift), s.Pods...)
+		s.CPUMillis = append(make([]int64, shift), s.CPUMillis...)
+		s.MemoryBytes = append(make([]int64, shift), s.MemoryBytes...)
+		s.Start = s.Start.Add(-time.Duration(shift) * s.Step)
+	}
+	offset := int(pod.Start.Sub(s.Start) / s.Step)
+	for k := range pod.CPUMillis {
+		t := offset + k
+		for len(s.Pods) <= t {
+			s.Pods = append(s.Pods, 0)
+			s.CPUMillis = append(s.CPUMillis, 0)
+			s.MemoryBytes = append(s.MemoryBytes, 0)
+		}
+		s.Pods[t]++
+		s.CPUMillis[t] += pod.CPUMillis[k]
+		if k < len(pod.MemoryBytes) {
+			s.MemoryBytes[t] += pod.MemoryBytes[k]
+		}
+	}
+	return s
+}
+
+// constraintsSignature identifies the scheduling constraints of a pod, so
+// only pods that schedule alike share a representative profile.
+func constraintsSignature(w *WorkloadProfile) string {
+	return fmt.Sprintf("%v|%v|%s|%v|%v|%v|%t", w.NodeSelector, w.Tolerations, w.Architecture,
+		w.PodAffinity, w.PodAntiAffinity, w.TopologySpread, w.DisruptionBudget)
+}
+
+func remapGroups(terms []PodAffinityTerm, resolved map[string]string) {
+	for i := range terms {
+		if g, ok := resolved[terms[i].Group]; ok {
+			terms[i].Group = g
+		}
+	}
+}
+
+// Replica levels: the replica count at which controllers are packed.
+const (
+	ReplicasCurrent = "current" // the replicas running when the snapshot was taken
+	ReplicasMin     = "min"     // the fewest observed over the metrics window
+	ReplicasTypical = "typical" // the p95 of the observed count
+	ReplicasPeak    = "peak"    // the most observed over the metrics window
+)
+
+// ReplicaLevels lists the valid replica levels.
+var ReplicaLevels = []string{ReplicasCurrent, ReplicasMin, ReplicasTypical, ReplicasPeak}
+
+// ReplicaRange is the replica count of a controller over the metrics window,
+// and the bounds of the HorizontalPodAutoscaler that scales it, if any.
+type ReplicaRange struct {
+	Min int32 `json:"min"` // observed; 0 = no history
+	P95 int32 `json:"p95"`
+	Max int32 `json:"max"`
+
+	HPAMin int32 `json:"hpa_min,omitempty"` // 0 = no HPA
+	HPAMax int32 `json:"hpa_max,omitempty"`
+}
+
+// At returns the replica count at a level, given the current count. The
+// observed count is used when there is history, or else the HPA bound for
+// min and peak; either way it is kept within the HPA's bounds. Without
+// either, the current count is returned.
+func (r *ReplicaRange) At(level string, current int) int {
+	n := current
+	switch level {
+	case ReplicasMin:
+		n = pick(r.Min, r.HPAMin, current)
+	case ReplicasTypical:
+		n = pick(r.P95, 0, current)
+	case ReplicasPeak:
+		n = pick(r.Max, r.HPAMax, current)
+	}
+	if r.HPAMin > 0 {
+		n = max(n, int(r.HPAMin))
+	}
+	if r.HPAMax > 0 {
+		n = min(n, int(r.HPAMax))
+	}
+	return n
+}
+
+// pick returns the observed count when there is history, else the HPA
+// bound, else the current count.
+func pick(observed, bound int32, current int) int {
+	switch {
+	case observed > 0:
+		return int(observed)
+	case bound > 0:
+		return int(bound)
+	}
+	return current
+}
+
+// HasReplicaRanges reports whether any workload has a replica range.
+func (cs *ClusterState) HasReplicaRanges() bool {
+	for i := range cs.Workloads {
+		if cs.Workloads[i].ReplicaRange != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyReplicaRanges sets the replica range of the representative profiles,
+// keyed by ControllerKey, and returns the number of controllers matched.
+func (cs *ClusterState) ApplyReplicaRanges(byController map[string]ReplicaRange) int {
+	matched := make(map[string]bool)
+	for i := range cs.Workloads {
+		w := &cs.Workloads[i]
+		if w.Replicas <= 0 {
+			continue
+		}
+		key := w.ControllerKey()
+		if r, ok := byController[key]; ok {
+			w.ReplicaRange = &r
+			matched[key] = true
+		}
+	}
+	return len(matched)
+}
+
+// AtReplicas returns a copy of the cluster state with every controller that
+// has a replica range at the level's replica count. A controller split
+// across profiles, as during a rollout, is scaled in proportion to each
+// one's replicas, the last profile taking what rounding leaves. Profiles
+// scaled to zero are left out.
+func (cs *ClusterState) AtReplicas(level string) ClusterState {
+	current := make(map[string]int)
+	target := make(map[string]int)
+	for i := range cs.Workloads {
+		if w := &cs.Workloads[i]; w.ReplicaRange != nil {
+			current[w.ControllerKey()] += w.ReplicaCount()
+		}
+	}
+	for i := range cs.Workloads {
+		w := &cs.Workloads[i]
+		if key := w.ControllerKey(); w.ReplicaRange != nil {
+			if _, ok := target[key]; !ok {
+				target[key] = w.ReplicaRange.At(level, current[key])
+			}
+		}
+	}
+
+	scaled := *cs
+	scaled.Workloads = make([]WorkloadProfile, 0, len(cs.Workloads))
+	for i := range cs.Workloads {
+		w := &cs.Workloads[i]
+		if w.ReplicaRange == nil || level == ReplicasCurrent {
+			scaled.Workloads = append(scaled.Workloads, *w)
+			continue
+		}
+		key := w.ControllerKey()
+		n := int(math.Round(float64(target[key]) * float64(w.ReplicaCount()) / float64(current[key])))
+		target[key] -= n
+		current[key] -= w.ReplicaCount()
+		if n > 0 {
+			scaled.Workloads = append(scaled.Workloads, w.WithReplicas(n))
+		}
+	}
+	return scaled
+}
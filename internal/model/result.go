@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
 
 // NodeAllocation represents one provisioned node and the workloads placed on it.
 type NodeAllocation struct {
@@ -9,6 +15,7 @@ type NodeAllocation struct {
 	UsedCPU   int64             `json:"used_cpu_millis"`
 	UsedMem   int64             `json:"used_memory_bytes"`
 	PodCount  int32             `json:"pod_count"`
+	Zone      string            `json:"zone,omitempty"` // empty when the simulation is zone-agnostic
 
 	// Derived metrics
 	CPUUtilization float64 `json:"cpu_utilization"` // 0.0 - 1.0
@@ -29,7 +36,8 @@ func (na NodeAllocation) MemWaste() int64 {
 type InstanceConfig struct {
 	InstanceTypes []NodeTemplate `json:"instance_types"`
 	SpotRatio     float64        `json:"spot_ratio"`
-	Strategy      string         `json:"strategy"` // "homogeneous" or "mixed"
+	Strategy      string         `json:"strategy"` // "homogeneous", "mixed", "cross-family" or "karpenter"
+	Zones         []string       `json:"zones,omitempty"`
 }
 
 // Label returns a human-readable label for this configuration.
@@ -37,6 +45,12 @@ func (ic InstanceConfig) Label() string {
 	if ic.Strategy == "homogeneous" && len(ic.InstanceTypes) == 1 {
 		return ic.InstanceTypes[0].InstanceType
 	}
+	if ic.Strategy == "karpenter" {
+		return karpenterLabel(ic.InstanceTypes)
+	}
+	if ic.Strategy == "cross-family" {
+		return strings.Join(instanceFamilies(ic.InstanceTypes), "+") + " (cross-family)"
+	}
 	label := ""
 	for i, t := range ic.InstanceTypes {
 		if i > 0 {
@@ -47,6 +61,26 @@ func (ic InstanceConfig) Label() string {
 	return label + " (mixed)"
 }
 
+// karpenterLabel summarizes the instance families a Karpenter scenario may
+// launch, e.g. "karpenter (c7g, m7g)".
+func karpenterLabel(types []NodeTemplate) string {
+	families := instanceFamilies(types)
+	if len(families) > 3 {
+		return fmt.Sprintf("karpenter (%d families)", len(families))
+	}
+	return "karpenter (" + strings.Join(families, ", ") + ")"
+}
+
+// instanceFamilies returns the distinct instance families of the types, sorted.
+func instanceFamilies(types []NodeTemplate) []string {
+	var families []string
+	for _, t := range types {
+		families = append(families, t.InstanceFamily)
+	}
+	sort.Strings(families)
+	return slices.Compact(families)
+}
+
 // FragmentationReport details resource waste patterns.
 type FragmentationReport struct {
 	// Stranded: one dimension nearly full, the other underused
@@ -70,6 +104,39 @@ type ScalingEfficiency struct {
 	EstTroughCPUUtil float64 `json:"est_trough_cpu_util"` // 0.0–1.0
 }
 
+// ConstraintOverhead quantifies the cost of pod affinity, anti-affinity and
+// topology spread rules, by comparison with a run that ignores them.
+type ConstraintOverhead struct {
+	UnconstrainedNodes int     `json:"unconstrained_nodes"`
+	ExtraNodes         int     `json:"extra_nodes"`
+	ExtraMonthlyCost   float64 `json:"extra_monthly_cost"`
+}
+
+// NodeFailure is the outcome of losing the most-loaded nodes and re-packing
+// their pods onto the surviving nodes, without provisioning new capacity.
+type NodeFailure struct {
+	FailedNodes   int  `json:"failed_nodes"`
+	DisplacedPods int  `json:"displaced_pods"`
+	StrandedPods  int  `json:"stranded_pods"` // displaced pods no surviving node can host
+	Survives      bool `json:"survives"`
+}
+
+// FailureHeadroom captures N-1 and N-2 node failure tolerance.
+type FailureHeadroom struct {
+	N1 NodeFailure `json:"n_minus_1"`
+	N2 NodeFailure `json:"n_minus_2"`
+}
+
+// ZoneFailure is the outcome of re-packing the cluster with one availability
+// zone removed.
+type ZoneFailure struct {
+	Zone              string  `json:"zone"`
+	Fits              bool    `json:"fits"` // no pods left unschedulable beyond the baseline run
+	Nodes             int     `json:"nodes"`
+	UnschedulablePods int     `json:"unschedulable_pods"`
+	ExtraMonthlyCost  float64 `json:"extra_monthly_cost"` // vs. the all-zones cluster
+}
+
 // SimulationResult captures the outcome of a single bin-packing run.
 type SimulationResult struct {
 	// The instance configuration used
@@ -94,11 +161,110 @@ type SimulationResult struct {
 	// Scaling efficiency (nil if no aggregate metrics available)
 	ScalingEfficiency *ScalingEfficiency `json:"scaling_efficiency,omitempty"`
 
+	// Extra nodes required by affinity/spread rules (nil if the workloads have none)
+	ConstraintOverhead *ConstraintOverhead `json:"constraint_overhead,omitempty"`
+
+	// Tolerance to losing the most-loaded nodes
+	FailureHeadroom *FailureHeadroom `json:"failure_headroom,omitempty"`
+
+	// Outcome of losing each zone (nil when fewer than two zones are simulated)
+	ZoneFailures []ZoneFailure `json:"zone_failures,omitempty"`
+
+	// Cost after Reserved Instances and Savings Plans (nil without commitments)
+	CommittedCost *CommittedCost `json:"committed_cost,omitempty"`
+
 	// Pods that could not be placed
 	UnschedulablePods []WorkloadProfile `json:"unschedulable_pods,omitempty"`
 
 	// Duration of the simulation
 	SimulationDuration time.Duration `json:"simulation_duration"`
+
+	// Bin-packing algorithm that produced the result, e.g. "best-fit-decreasing"
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Lower bound on the nodes and cost any packing of the placed pods needs
+	LowerBound *LowerBound `json:"lower_bound,omitempty"`
+
+	// Whether an exact packer proved the packing optimal (empty for heuristics)
+	Optimality string `json:"optimality,omitempty"`
+
+	// Expected spot interruptions and the pods they evict (nil without spot nodes)
+	SpotDisruption *SpotDisruption `json:"spot_disruption,omitempty"`
+}
+
+// Optimality of a packing found by an exact packer.
+const (
+	OptimalityOptimal   = "optimal"    // the search completed: no packing costs less on-demand
+	OptimalityBestFound = "best-found" // the search stopped early; the best packing found
+)
+
+// LowerBound is a floor on the node count and cost of a scenario, and how far
+// the packing found is from it. The true optimum lies between the two, so a
+// small gap proves the packing close to optimal; a large one means a better
+// packing may exist, or that the bound is loose.
+type LowerBound struct {
+	Nodes       int     `json:"nodes"`
+	MonthlyCost float64 `json:"monthly_cost"`
+	Binding     string  `json:"binding"` // bound that set Nodes: "cpu", "memory", "pods", "large-items" or "min-nodes"
+
+	NodeGap int     `json:"node_gap"`     // nodes above the bound
+	CostGap float64 `json:"cost_gap_pct"` // cost above the bound, in percent
+}
+
+// SpotDisruption estimates the disruption spot interruptions cause each
+// month: every spot node is interrupted at its instance type's rate, evicting
+// the pods it hosts. Instance types without interruption data are assumed to
+// be interrupted at the default rate and listed in EstimatedTypes.
+type SpotDisruption struct {
+	SpotNodes             int      `json:"spot_nodes"`
+	InterruptionsPerMonth float64  `json:"interruptions_per_month"`
+	EvictionsPerMonth     float64  `json:"pod_evictions_per_month"`
+	EvictedFraction       float64  `json:"evicted_fraction"` // evictions per month over pods placed
+	EstimatedTypes        []string `json:"estimated_types,omitempty"`
+
+	// Pods kept off spot: StatefulSet replicas, pods with a
+	// PodDisruptionBudget and pods selecting on-demand capacity
+	ProtectedPods int `json:"protected_pods"`
+	// Spot nodes the spot ratio asked for but that host protected pods
+	SpotShortfall int `json:"spot_shortfall"`
+}
+
+// EffectiveCost returns the monthly cost actually paid: the committed cost
+// when commitments are modeled, the list price otherwise.
+func (sr SimulationResult) EffectiveCost() float64 {
+	if sr.CommittedCost != nil {
+		return sr.CommittedCost.EffectiveMonthlyCost
+	}
+	return sr.TotalCost
+}
+
+// ZoneCounts returns the number of nodes per zone, or nil when zones are not modeled.
+func (sr SimulationResult) ZoneCounts() map[string]int {
+	if len(sr.InstanceConfig.Zones) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(sr.InstanceConfig.Zones))
+	for _, z := range sr.InstanceConfig.Zones {
+		counts[z] = 0
+	}
+	for i := range sr.Nodes {
+		counts[sr.Nodes[i].Zone]++
+	}
+	return counts
+}
+
+// WorstZoneFailure returns the zone loss with the most unschedulable pods,
+// breaking ties by extra cost, or nil when no zone failures were simulated.
+func (sr SimulationResult) WorstZoneFailure() *ZoneFailure {
+	var worst *ZoneFailure
+	for i := range sr.ZoneFailures {
+		zf := &sr.ZoneFailures[i]
+		if worst == nil || zf.UnschedulablePods > worst.UnschedulablePods ||
+			(zf.UnschedulablePods == worst.UnschedulablePods && zf.ExtraMonthlyCost > worst.ExtraMonthlyCost) {
+			worst = zf
+		}
+	}
+	return worst
 }
 
 // ScoringWeights configures the relative importance of scoring dimensions.
@@ -125,7 +291,8 @@ type Recommendation struct {
 	SimulationResult SimulationResult `json:"simulation_result"`
 
 	// Cost analysis
-	MonthlyCost   float64 `json:"monthly_cost"`
+	MonthlyCost   float64 `json:"monthly_cost"`      // effective cost, after commitments
+	ListMonthlyCost float64 `json:"list_monthly_cost"` // on-demand/spot list price
 	CostVsBaseline float64 `json:"cost_vs_baseline_pct"` // Negative = savings
 	AnnualSavings float64 `json:"annual_savings"`
 
@@ -147,3 +314,36 @@ type AlternativeArch struct {
 	TopPick      Recommendation `json:"top_pick"`
 	Savings      float64        `json:"savings_pct"` // % cheaper vs primary top pick (positive = cheaper)
 }
+
+// PackerComparison is the outcome of packing one instance configuration with
+// every bin-packing algorithm.
+type PackerComparison struct {
+	InstanceConfig InstanceConfig `json:"instance_config"`
+	Runs           []PackerRun    `json:"runs"` // the configured algorithm first
+}
+
+// ReplicaLevelComparison is the outcome of packing one instance
+// configuration with controllers at each replica level.
+type ReplicaLevelComparison struct {
+	InstanceConfig InstanceConfig    `json:"instance_config"`
+	Runs           []ReplicaLevelRun `json:"runs"`
+}
+
+// ReplicaLevelRun is the outcome of packing at one replica level.
+type ReplicaLevelRun struct {
+	Level             string  `json:"level"` // ReplicasCurrent, ReplicasMin, ...
+	Pods              int     `json:"pods"`
+	Nodes             int     `json:"nodes"`
+	MonthlyCost       float64 `json:"monthly_cost"`
+	UnschedulablePods int     `json:"unschedulable_pods"`
+}
+
+// PackerRun is the outcome of one bin-packing algorithm.
+type PackerRun struct {
+	Algorithm         string        `json:"algorithm"`
+	Nodes             int           `json:"nodes"`
+	NodeDelta         int           `json:"node_delta"` // vs the first run
+	MonthlyCost       float64       `json:"monthly_cost"`
+	UnschedulablePods int           `json:"unschedulable_pods"`
+	Duration          time.Duration `json:"duration"`
+}
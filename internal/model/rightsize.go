@@ -0,0 +1,232 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// RightsizeTarget sets how the request and limit of one resource are
+// derived from the observed usage of a controller's pods.
+type RightsizeTarget struct {
+	Percentile float64 // usage percentile the request covers (0.0 to 1.0; 1.0 = max)
+	Headroom   float64 // multiplier on usage; 0 = 1
+	LimitRatio float64 // limit as a multiple of the request; 0 = no limit
+}
+
+// RightsizePolicy sets how right-sized requests and limits are derived.
+type RightsizePolicy struct {
+	CPU    RightsizeTarget
+	Memory RightsizeTarget
+}
+
+// RightsizeRecommendation proposes the per-pod requests and limits of one
+// controller, from the usage of its busiest pod.
+type RightsizeRecommendation struct {
+	Namespace string `json:"namespace"`
+	OwnerKind string `json:"owner_kind"` // top-level controller, e.g. "Deployment"; "Pod" for bare pods
+	OwnerName string `json:"owner_name"`
+	Pods      int    `json:"pods"`
+
+	// Per pod, the largest across the controller's pods
+	Requested ResourceQuantity `json:"requested"`
+	Limits    ResourceQuantity `json:"limits"`
+	Usage     ResourceQuantity `json:"usage"` // at the policy percentiles
+
+	RecommendedRequests ResourceQuantity `json:"recommended_requests"`
+	RecommendedLimits   ResourceQuantity `json:"recommended_limits"` // zero = no limit
+
+	// No usage was observed, so the requests and limits are kept
+	NoMetrics bool `json:"no_metrics,omitempty"`
+}
+
+// Key identifies the controller, in the form of WorkloadProfile.ControllerKey.
+func (r *RightsizeRecommendation) Key() string {
+	return r.Namespace + "/" + r.OwnerKind + "/" + r.OwnerName
+}
+
+// RequestChange returns the change in total requests across the
+// controller's pods; negative values free capacity.
+func (r *RightsizeRecommendation) RequestChange() ResourceQuantity {
+	d := r.RecommendedRequests.Sub(r.Requested)
+	return ResourceQuantity{
+		CPUMillis:   d.CPUMillis * int64(r.Pods),
+		MemoryBytes: d.MemoryBytes * int64(r.Pods),
+	}
+}
+
+// Rightsize proposes requests and limits for the controllers of the given
+// pods, grouped by ControllerKey. Requests cover the usage of the busiest
+// pod at the policy percentiles, times the headroom, rounded up to whole
+// millicores and MiB and floored like effective sizes. Controllers with no
+// observed usage keep their requests and limits. The recommendations are
+// sorted by the share of the cluster's requests they free, largest first.
+func Rightsize(workloads []WorkloadProfile, p RightsizePolicy) []RightsizeRecommendation {
+	index := make(map[string]int)
+	var recs []RightsizeRecommendation
+	measured := make(map[string]bool)
+	var total ResourceQuantity
+	for i := range workloads {
+		w := &workloads[i]
+		replicas := int64(w.ReplicaCount())
+		total = total.Add(ResourceQuantity{
+			CPUMillis:   w.Requested.CPUMillis * replicas,
+			MemoryBytes: w.Requested.MemoryBytes * replicas,
+		})
+		key := w.ControllerKey()
+		j, ok := index[key]
+		if !ok {
+			kind, name := w.controller()
+			j = len(recs)
+			index[key] = j
+			recs = append(recs, RightsizeRecommendation{Namespace: w.Namespace, OwnerKind: kind, OwnerName: name})
+		}
+		r := &recs[j]
+		r.Pods += int(replicas)
+		r.Requested = maxQuantity(r.Requested, w.Requested)
+		r.Limits = maxQuantity(r.Limits, w.Limits)
+		if w.NoMetrics || (w.CPUUsage.IsZero() && w.MemoryUsage.IsZero()) {
+			continue
+		}
+		measured[key] = true
+		r.Usage = maxQuantity(r.Usage, ResourceQuantity{
+			CPUMillis:   int64(math.Ceil(w.CPUUsage.AtPercentile(p.CPU.Percentile) * 1000)),
+			MemoryBytes: int64(math.Ceil(w.MemoryUsage.AtPercentile(p.Memory.Percentile))),
+		})
+	}
+
+	const mib = 1024 * 1024
+	for i := range recs {
+		r := &recs[i]
+		if !measured[r.Key()] {
+			r.NoMetrics = true
+			r.RecommendedRequests, r.RecommendedLimits = r.Requested, r.Limits
+			continue
+		}
+		cpu := max(minEffectiveCPUMillis, p.CPU.request(float64(r.Usage.CPUMillis), 1))
+		mem := max(minEffectiveMemoryBytes, p.Memory.request(float64(r.Usage.MemoryBytes), mib))
+		r.RecommendedRequests = ResourceQuantity{CPUMillis: cpu, MemoryBytes: mem}
+		r.RecommendedLimits = ResourceQuantity{
+			CPUMillis:   p.CPU.limit(cpu, 1),
+			MemoryBytes: p.Memory.limit(mem, mib),
+		}
+	}
+
+	freed := func(r *RightsizeRecommendation) float64 {
+		d := r.RequestChange()
+		var share float64
+		if total.CPUMillis > 0 {
+			share -= float64(d.CPUMillis) / float64(total.CPUMillis)
+		}
+		if total.MemoryBytes > 0 {
+			share -= float64(d.MemoryBytes) / float64(total.MemoryBytes)
+		}
+		return share
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		fi, fj := freed(&recs[i]), freed(&recs[j])
+		if fi != fj {
+			return fi > fj
+		}
+		return recs[i].Key() < recs[j].Key()
+	})
+	return recs
+}
+
+// request returns the request covering the usage, rounded up to a multiple
+// of unit.
+func (t RightsizeTarget) request(usage float64, unit int64) int64 {
+	if t.Headroom > 0 {
+		usage *= t.Headroom
+	}
+	return roundUp(usage, unit)
+}
+
+// limit returns the limit for the request, rounded up to a multiple of
+// unit, or 0 for no limit.
+func (t RightsizeTarget) limit(request, unit int64) int64 {
+	if t.LimitRatio <= 0 {
+		return 0
+	}
+	return roundUp(float64(request)*t.LimitRatio, unit)
+}
+
+func roundUp(v float64, unit int64) int64 {
+	return int64(math.Ceil(v/float64(unit))) * unit
+}
+
+func maxQuantity(a, b ResourceQuantity) ResourceQuantity {
+	return ResourceQuantity{
+		CPUMillis:   max(a.CPUMillis, b.CPUMillis),
+		MemoryBytes: max(a.MemoryBytes, b.MemoryBytes),
+	}
+}
+
+// Rightsized returns a copy of the cluster state with the recommended
+// requests and limits applied to the pods of each controller, and their
+// effective sizes re-derived under the given sizing policies. Controllers
+// without metrics are left as they are.
+func (cs *ClusterState) Rightsized(recs []RightsizeRecommendation, cpu, mem SizingPolicy) ClusterState {
+	byKey := make(map[string]*RightsizeRecommendation, len(recs))
+	for i := range recs {
+		if !recs[i].NoMetrics {
+			byKey[recs[i].Key()] = &recs[i]
+		}
+	}
+	apply := func(wps []WorkloadProfile) []WorkloadProfile {
+		out := make([]WorkloadProfile, len(wps))
+		copy(out, wps)
+		for i := range out {
+			w := &out[i]
+			r, ok := byKey[w.ControllerKey()]
+			if !ok || w.NoMetrics || (w.CPUUsage.IsZero() && w.MemoryUsage.IsZero()) {
+				continue
+			}
+			w.Requested, w.Limits = r.RecommendedRequests, r.RecommendedLimits
+			w.ReplicaUsage = slices.Clone(w.ReplicaUsage)
+			for k := range w.ReplicaUsage {
+				w.ReplicaUsage[k].Requested, w.ReplicaUsage[k].Limits = r.RecommendedRequests, r.RecommendedLimits
+			}
+			w.Resize(cpu, mem)
+		}
+		return out
+	}
+	rs := *cs
+	rs.Workloads = apply(cs.Workloads)
+	rs.DaemonSets = apply(cs.DaemonSets)
+	return rs
+}
+
+// RightsizeReport pairs the request recommendations with the node
+// simulations that price them: the current instance configuration and the
+// best one, each packed with the current and the right-sized requests.
+type RightsizeReport struct {
+	Workloads []RightsizeRecommendation `json:"workloads"`
+
+	Current         Recommendation `json:"current"`          // current instances, current requests
+	RequestsFixed   Recommendation `json:"requests_fixed"`   // current instances, right-sized requests
+	InstanceChanged Recommendation `json:"instance_changed"` // best instances for the current requests
+	Both            Recommendation `json:"both"`             // best instances for the right-sized requests
+
+	// The current instance configuration was given rather than taken from
+	// the best recommendation for the current requests
+	BaselineGiven bool `json:"baseline_given"`
+}
+
+// RequestSavings returns the monthly savings of right-sizing requests on
+// the current instances.
+func (r *RightsizeReport) RequestSavings() float64 {
+	return r.Current.MonthlyCost - r.RequestsFixed.MonthlyCost
+}
+
+// InstanceSavings returns the monthly savings of changing instances while
+// keeping the current requests. Without a given baseline the current
+// instances are the changed ones, so it is 0 and not worth reporting.
+func (r *RightsizeReport) InstanceSavings() float64 {
+	return r.Current.MonthlyCost - r.InstanceChanged.MonthlyCost
+}
+
+// TotalSavings returns the monthly savings of doing both.
+func (r *RightsizeReport) TotalSavings() float64 {
+	return r.Current.MonthlyCost - r.Both.MonthlyCost
+}
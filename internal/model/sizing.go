@@ -0,0 +1,214 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Sizing sources: what a pod's effective size in one dimension is derived from.
+const (
+	SizeByRequest = "request" // the request, ignoring usage
+	SizeByUsage   = "usage"   // observed usage, ignoring the request
+	SizeByMax     = "max"     // the larger of the request and observed usage
+	SizeByLimit   = "limit"   // the limit; the larger of request and usage without one
+)
+
+// SizingSources lists the valid SizingPolicy sources.
+var SizingSources = []string{SizeByRequest, SizeByUsage, SizeByMax, SizeByLimit}
+
+const (
+	// minEffectiveCPUMillis is the floor for workload CPU sizing (prevents zero-sized pods).
+	minEffectiveCPUMillis = 10
+
+	// minEffectiveMemoryBytes is the floor for workload memory sizing.
+	minEffectiveMemoryBytes = 64 * 1024 * 1024 // 64 MiB
+)
+
+// SizingPolicy sets how a pod's effective size in one dimension, CPU or
+// memory, is derived for bin-packing. Memory is usually sized at a high
+// percentile or the max, since running out is fatal, while CPU can be sized
+// lower, since a busy node only throttles.
+type SizingPolicy struct {
+	Source     string  // SizeBy*; empty = SizeByMax
+	Percentile float64 // usage percentile (0.0 to 1.0; 1.0 = max over the window)
+	Headroom   float64 // multiplier on observed usage; 0 = 1
+}
+
+// String describes the policy, e.g. "max(request, p95 usage)" or
+// "max usage x1.2".
+func (p SizingPolicy) String() string {
+	usage := strings.ToLower(PercentileLabel(p.Percentile)) + " usage"
+	if p.Headroom > 0 && p.Headroom != 1 {
+		usage += " x" + strconv.FormatFloat(p.Headroom, 'f', -1, 64)
+	}
+	switch p.Source {
+	case SizeByRequest:
+		return "request"
+	case SizeByUsage:
+		return usage
+	case SizeByLimit:
+		return "limit"
+	}
+	return "max(request, " + usage + ")"
+}
+
+// size returns the effective size from a request, a limit and the observed
+// usage, in the same unit. Pods without metrics use their request.
+func (p SizingPolicy) size(request, limit int64, usage float64, noMetrics bool) int64 {
+	headroom := p.Headroom
+	if headroom <= 0 {
+		headroom = 1
+	}
+	observed := int64(usage * headroom)
+
+	switch {
+	case p.Source == SizeByRequest:
+		return request
+	case p.Source == SizeByLimit && limit > 0:
+		return limit
+	case noMetrics:
+		return request
+	case p.Source == SizeByUsage:
+		return observed
+	}
+	return max(request, observed)
+}
+
+// Resize derives the effective CPU and memory sizes from the pod's stored
+// usage, requests and limits under the given policies, flooring them so no
+// pod is zero-sized. A pod with no usage at either policy's percentile is
+// marked NoMetrics and sized by its requests. A controller's replicas are
+// each sized from their own usage when it was kept in ReplicaUsage, as
+// during collection, or else all from its representative usage, the
+// highest of the replicas'.
+func (w *WorkloadProfile) Resize(cpu, mem SizingPolicy) {
+	if len(w.ReplicaUsage) == 0 {
+		w.ReplicaSizes = nil
+		size, noMetrics := w.replicaUsage().size(cpu, mem)
+		w.EffectiveCPUMillis, w.EffectiveMemoryBytes = size.CPUMillis, size.MemoryBytes
+		w.NoMetrics = noMetrics
+		return
+	}
+
+	replicas := make([]sizedReplica, len(w.ReplicaUsage))
+	w.EffectiveCPUMillis, w.EffectiveMemoryBytes = 0, 0
+	w.NoMetrics = true
+	for i, r := range w.ReplicaUsage {
+		size, noMetrics := r.size(cpu, mem)
+		replicas[i] = sizedReplica{size: size, usage: r}
+		w.EffectiveCPUMillis = max(w.EffectiveCPUMillis, size.CPUMillis)
+		w.EffectiveMemoryBytes = max(w.EffectiveMemoryBytes, size.MemoryBytes)
+		w.NoMetrics = w.NoMetrics && noMetrics
+	}
+	w.setReplicas(replicas)
+}
+
+// size returns the replica's effective size under the given policies, and
+// whether it has no usage at either policy's percentile.
+func (r ReplicaUsage) size(cpu, mem SizingPolicy) (ResourceQuantity, bool) {
+	cpuUsage := r.CPUUsage.AtPercentile(cpu.Percentile)
+	memUsage := r.MemoryUsage.AtPercentile(mem.Percentile)
+	noMetrics := cpuUsage == 0 && memUsage == 0
+	return ResourceQuantity{
+		CPUMillis: max(minEffectiveCPUMillis,
+			cpu.size(r.Requested.CPUMillis, r.Limits.CPUMillis, cpuUsage*1000, noMetrics)),
+		MemoryBytes: max(minEffectiveMemoryBytes,
+			mem.size(r.Requested.MemoryBytes, r.Limits.MemoryBytes, memUsage, noMetrics)),
+	}, noMetrics
+}
+
+// Resize re-derives the effective sizes of the workloads and DaemonSets from
+// their stored usage under the given policies, as for a snapshot collected
+// with other policies. Pods with no stored usage at all, such as those of
+// hand-written snapshots, keep their sizes; their count is returned.
+func (cs *ClusterState) Resize(cpu, mem SizingPolicy) int {
+	kept := 0
+	resize := func(wps []WorkloadProfile) {
+		for i := range wps {
+			w := &wps[i]
+			if !w.NoMetrics && w.CPUUsage.IsZero() && w.MemoryUsage.IsZero() {
+				kept++
+				continue
+			}
+			w.Resize(cpu, mem)
+		}
+	}
+	resize(cs.Workloads)
+	resize(cs.DaemonSets)
+	return kept
+}
+
+// PercentileSubstitution is a usage percentile missing from a snapshot and
+// the stored percentile read in its place.
+type PercentileSubstitution struct {
+	Resource string  // "cpu" or "memory"
+	Wanted   float64 // percentile asked for
+	Used     float64 // nearest stored percentile above it; 1.0 = Max
+}
+
+// MissingPercentiles returns the CPU and memory usage percentiles that the
+// pods' stored usage lacks, with the percentile read instead. Pods without
+// stored usage are ignored.
+func (cs *ClusterState) MissingPercentiles(cpu, mem float64) []PercentileSubstitution {
+	var subs []PercentileSubstitution
+	seen := make(map[PercentileSubstitution]bool)
+	check := func(resource string, usage PercentileValues, pct float64) {
+		if usage.IsZero() {
+			return
+		}
+		used := usage.StoredPercentile(pct)
+		if pct >= 1 || math.Abs(used-pct) < percentileEpsilon {
+			return
+		}
+		sub := PercentileSubstitution{Resource: resource, Wanted: pct, Used: used}
+		if !seen[sub] {
+			seen[sub] = true
+			subs = append(subs, sub)
+		}
+	}
+	for _, wps := range [][]WorkloadProfile{cs.Workloads, cs.DaemonSets} {
+		for i := range wps {
+			check("cpu", wps[i].CPUUsage, cpu)
+			check("memory", wps[i].MemoryUsage, mem)
+		}
+	}
+	return subs
+}
+
+// ParseSizingPolicy parses a policy written as SOURCE[:PERCENTILE[:HEADROOM]],
+// e.g. "max", "usage:0.9" or "max:1.0:1.2". Omitted fields are left zero.
+func ParseSizingPolicy(spec string) (SizingPolicy, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		return SizingPolicy{}, fmt.Errorf("sizing policy %q: want SOURCE[:PERCENTILE[:HEADROOM]]", spec)
+	}
+	p := SizingPolicy{Source: parts[0]}
+	if !validSizingSource(p.Source) {
+		return SizingPolicy{}, fmt.Errorf("sizing policy %q: source must be one of %s", spec, strings.Join(SizingSources, ", "))
+	}
+	var err error
+	if len(parts) > 1 {
+		p.Percentile, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil || p.Percentile <= 0 || p.Percentile > 1 {
+			return SizingPolicy{}, fmt.Errorf("sizing policy %q: percentile must be in (0, 1.0]", spec)
+		}
+	}
+	if len(parts) > 2 {
+		p.Headroom, err = strconv.ParseFloat(parts[2], 64)
+		if err != nil || p.Headroom <= 0 || math.IsInf(p.Headroom, 0) {
+			return SizingPolicy{}, fmt.Errorf("sizing policy %q: headroom must be a positive multiplier", spec)
+		}
+	}
+	return p, nil
+}
+
+func validSizingSource(s string) bool {
+	for _, v := range SizingSources {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
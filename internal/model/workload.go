@@ -1,5 +1,13 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
 // ResourceQuantity represents a CPU/memory quantity with millicpu and bytes precision.
 type ResourceQuantity struct {
 	CPUMillis   int64 // CPU in millicores (1000 = 1 vCPU)
@@ -32,27 +40,112 @@ func (r ResourceQuantity) IsZero() bool {
 	return r.CPUMillis == 0 && r.MemoryBytes == 0
 }
 
-// PercentileValues holds observed resource usage at multiple percentiles.
+// PercentileValues holds observed resource usage at the collected
+// percentiles, and the highest usage over the window.
 type PercentileValues struct {
-	P50 float64
-	P95 float64
-	P99 float64
-	Max float64
+	Percentiles map[float64]float64 // keyed by percentile (0.0 to 1.0)
+	Max         float64
+}
+
+// IsZero reports whether no usage was recorded, not even a zero.
+func (p PercentileValues) IsZero() bool {
+	return len(p.Percentiles) == 0 && p.Max == 0
+}
+
+// Set records the usage at a percentile; 1.0 and above set Max.
+func (p *PercentileValues) Set(pct, value float64) {
+	if pct >= 1 {
+		p.Max = value
+		return
+	}
+	if p.Percentiles == nil {
+		p.Percentiles = make(map[float64]float64)
+	}
+	p.Percentiles[pct] = value
 }
 
-// AtPercentile returns the value at the given percentile (0.0 to 1.0).
-// Falls back to the nearest available percentile.
+// AtPercentile returns the value at the given percentile (0.0 to 1.0): the
+// value collected at that percentile, Max at 1.0, or otherwise the value at
+// the nearest collected percentile above it, falling back to Max.
 func (p PercentileValues) AtPercentile(pct float64) float64 {
-	switch {
-	case pct <= 0.50:
-		return p.P50
-	case pct <= 0.95:
-		return p.P95
-	case pct <= 0.99:
-		return p.P99
-	default:
+	if pct >= 1 {
 		return p.Max
 	}
+	best, value := math.Inf(1), p.Max
+	for q, v := range p.Percentiles {
+		if math.Abs(q-pct) < percentileEpsilon {
+			return v
+		}
+		if q > pct && q < best {
+			best, value = q, v
+		}
+	}
+	return value
+}
+
+// StoredPercentile returns the percentile AtPercentile reads for pct: pct
+// itself when collected, otherwise the nearest collected percentile above
+// it, or 1.0 for Max.
+func (p PercentileValues) StoredPercentile(pct float64) float64 {
+	if pct >= 1 {
+		return 1
+	}
+	best := 1.0
+	for q := range p.Percentiles {
+		if math.Abs(q-pct) < percentileEpsilon {
+			return q
+		}
+		if q > pct && q < best {
+			best = q
+		}
+	}
+	return best
+}
+
+// percentileEpsilon is the tolerance when matching percentiles, which may
+// have been parsed from text.
+const percentileEpsilon = 1e-9
+
+// PercentileLabel formats a percentile (0.0 to 1.0) as in "P95" or "P99.9",
+// or "Max" for 1.0.
+func PercentileLabel(pct float64) string {
+	if pct >= 1 {
+		return "Max"
+	}
+	return "P" + strconv.FormatFloat(math.Round(pct*100*1e6)/1e6, 'f', -1, 64)
+}
+
+// MarshalJSON encodes the values as an object keyed by PercentileLabel,
+// e.g. {"P50": 0.1, "P95": 0.4, "Max": 0.9}.
+func (p PercentileValues) MarshalJSON() ([]byte, error) {
+	m := make(map[string]float64, len(p.Percentiles)+1)
+	for q, v := range p.Percentiles {
+		m[PercentileLabel(q)] = v
+	}
+	m[PercentileLabel(1)] = p.Max
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the object written by MarshalJSON, which also reads
+// snapshots from releases that stored a fixed P50, P95, P99 and Max.
+func (p *PercentileValues) UnmarshalJSON(data []byte) error {
+	var m map[string]float64
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*p = PercentileValues{}
+	for label, v := range m {
+		if label == PercentileLabel(1) {
+			p.Max = v
+			continue
+		}
+		pct, err := strconv.ParseFloat(strings.TrimPrefix(label, "P"), 64)
+		if err != nil || !strings.HasPrefix(label, "P") || pct <= 0 || pct > 100 {
+			return fmt.Errorf("invalid percentile %q", label)
+		}
+		p.Set(pct/100, v)
+	}
+	return nil
 }
 
 // WorkloadProfile represents the resource footprint of a single pod or replica group,
@@ -76,17 +169,153 @@ type WorkloadProfile struct {
 	EffectiveCPUMillis   int64
 	EffectiveMemoryBytes int64
 
-	// Replica count (for controller-managed workloads)
+	// Replica count, for the representative profile of a controller's pods
+	// (see ClusterState.AggregateReplicas); 0 for a single pod
 	Replicas int32
 
+	// Effective size of each replica, largest first, when they differ.
+	// The effective size above is the largest.
+	ReplicaSizes []ResourceQuantity `json:",omitempty"`
+
+	// Requests, limits and usage of each replica, in the order of
+	// ReplicaSizes, when they differ; Resize re-derives each replica's size
+	// from them
+	ReplicaUsage []ReplicaUsage `json:",omitempty"`
+
+	// Replica count over the metrics window and HPA bounds, for the
+	// representative profile of a Deployment or StatefulSet
+	ReplicaRange *ReplicaRange `json:",omitempty"`
+
 	// Scheduling constraints
 	NodeSelector map[string]string
-	Tolerations  []string
+	Tolerations  []string     // kubectl notation: "key=value:Effect", "key:Effect", "key", ":Effect", or "*"
 	Architecture Architecture // Required architecture (empty = any)
 
+	// Inter-pod placement rules (required terms only)
+	PodAffinity     []PodAffinityTerm
+	PodAntiAffinity []PodAffinityTerm
+	TopologySpread  []TopologySpreadConstraint
+
+	// Whether a PodDisruptionBudget selects this pod
+	DisruptionBudget bool
+
 	// Whether this is a DaemonSet pod (runs on every node)
 	IsDaemonSet bool
 
 	// Whether this pod had no observed metrics (used request values)
 	NoMetrics bool
+
+	// Why the simulation could not place this pod (set only on unschedulable pods)
+	UnschedulableReason string
+
+	// Usage over the metrics window, when collected with range queries.
+	// Omitted from snapshots collected without them.
+	Series *UsageSeries `json:",omitempty"`
+}
+
+// Tolerates returns true if one of the workload's tolerations matches the taint.
+// A toleration with no value matches any value (operator Exists), a toleration
+// with no effect matches every effect, a toleration with no key matches every
+// key, and "*" tolerates everything.
+func (w *WorkloadProfile) Tolerates(t Taint) bool {
+	for _, tol := range w.Tolerations {
+		if tol == "*" {
+			return true
+		}
+		keyValue, effect, hasEffect := strings.Cut(tol, ":")
+		if hasEffect && effect != "" && TaintEffect(effect) != t.Effect {
+			continue
+		}
+		key, value, hasValue := strings.Cut(keyValue, "=")
+		if key != "" && key != t.Key {
+			continue
+		}
+		if hasValue && value != t.Value {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// GroupKey identifies the replica group a pod belongs to ("namespace/kind/name").
+// Returns empty for pods without an owner.
+func (w *WorkloadProfile) GroupKey() string {
+	if w.OwnerName == "" {
+		return ""
+	}
+	return w.Namespace + "/" + w.OwnerKind + "/" + w.OwnerName
+}
+
+// HasTopologyConstraints returns true if the workload carries pod affinity,
+// anti-affinity, or hard topology spread rules.
+func (w *WorkloadProfile) HasTopologyConstraints() bool {
+	if len(w.PodAffinity) > 0 || len(w.PodAntiAffinity) > 0 {
+		return true
+	}
+	for _, sc := range w.TopologySpread {
+		if sc.Hard {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPinned returns true if the workload restricts which nodes it may run on.
+func (w *WorkloadProfile) IsPinned() bool {
+	return len(w.NodeSelector) > 0 || w.Architecture != ""
+}
+
+// ToleratesSpot reports whether the pod may run on spot capacity: it does not
+// select on-demand capacity, belong to a StatefulSet, or have a
+// PodDisruptionBudget. StatefulSet replicas keep state and identity that an
+// interruption disrupts, and a PDB declares that evictions must be limited.
+func (w *WorkloadProfile) ToleratesSpot() bool {
+	return w.NodeSelector[LabelCapacityType] != string(CapacityOnDemand) &&
+		w.OwnerKind != "StatefulSet" && !w.DisruptionBudget
+}
+
+// Topology keys understood by the simulation.
+const (
+	TopologyHostname = "kubernetes.io/hostname"
+	TopologyZone     = "topology.kubernetes.io/zone"
+)
+
+// PodAffinityTerm requires (affinity) or forbids (anti-affinity) co-location
+// with pods of a replica group within the same topology domain.
+type PodAffinityTerm struct {
+	TopologyKey string
+	Group       string // GroupKey of the target group; empty = the workload's own group
+}
+
+// TopologySpreadConstraint limits the replica count difference between topology domains.
+type TopologySpreadConstraint struct {
+	TopologyKey string
+	MaxSkew     int32
+	Hard        bool // DoNotSchedule; ScheduleAnyway constraints are not enforced
+}
+
+// PodConstraints holds the scheduling constraints of a single pod, as read
+// from kube-state-metrics or the Kubernetes API.
+type PodConstraints struct {
+	NodeSelector    map[string]string
+	Tolerations     []string
+	Architecture    Architecture
+	PodAffinity     []PodAffinityTerm
+	PodAntiAffinity []PodAffinityTerm
+	TopologySpread  []TopologySpreadConstraint
+
+	DisruptionBudget bool // selected by a PodDisruptionBudget
+}
+
+// ArchitectureFromSelector returns the architecture required by a node selector,
+// or empty if the selector does not pin one.
+func ArchitectureFromSelector(sel map[string]string) Architecture {
+	if arch, ok := sel[LabelArch]; ok {
+		return Architecture(arch)
+	}
+	if arch, ok := sel["beta.kubernetes.io/arch"]; ok {
+		return Architecture(arch)
+	}
+	return ""
 }
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/guimove/clusterfit/internal/aws"
@@ -21,6 +24,9 @@ type Orchestrator struct {
 	Provider  aws.PricingProvider
 	Config    config.Config
 	Writer    io.Writer
+
+	// NodePools switches to Karpenter mode when non-empty
+	NodePools []model.NodePool
 }
 
 // New creates an orchestrator with the given dependencies.
@@ -49,8 +55,13 @@ func (o *Orchestrator) Recommend(ctx context.Context) ([]model.Recommendation, e
 		},
 		ExcludeNamespaces: cfg.Metrics.ExcludeNamespaces,
 		Percentile:        cfg.Metrics.Percentile,
+		ExtraPercentiles:  cfg.Metrics.ExtraPercentiles,
 		StepInterval:      cfg.Metrics.Step,
 	}
+	opts.CPUSizing, opts.MemorySizing = SizingFromConfig(cfg.Metrics)
+	if cfg.Metrics.Series || cfg.Simulation.PeakAware {
+		opts.SeriesStep = cfg.Metrics.SeriesStep
+	}
 
 	state, err := o.Collector.Collect(ctx, opts)
 	if err != nil {
@@ -67,8 +78,18 @@ func (o *Orchestrator) Recommend(ctx context.Context) ([]model.Recommendation, e
 	_, _ = fmt.Fprintf(o.Writer, "Found %d workloads and %d DaemonSets\n",
 		state.WorkloadCount(), len(state.DaemonSets))
 
-	// Step 2: Auto-classify workloads if families are not explicitly set
-	autoClassified := len(cfg.Instances.Families) == 0
+	// Pack controllers at the configured replica level; the observed state
+	// is kept for the per-level comparison.
+	observed := *state
+	if r := cfg.Simulation.Replicas; r != "" && r != model.ReplicasCurrent {
+		*state = state.AtReplicas(cfg.Simulation.Replicas)
+		_, _ = fmt.Fprintf(o.Writer, "Packing controllers at %s replicas: %d pods\n",
+			cfg.Simulation.Replicas, state.WorkloadCount())
+	}
+
+	// Step 2: Auto-classify workloads if families are not explicitly set.
+	// In Karpenter mode the NodePools decide the instance types.
+	autoClassified := len(cfg.Instances.Families) == 0 && len(o.NodePools) == 0
 	var workloadClass model.WorkloadClass
 	var gibPerVCPU float64
 
@@ -96,7 +117,16 @@ func (o *Orchestrator) Recommend(ctx context.Context) ([]model.Recommendation, e
 		Resilience:    cfg.Scoring.Weights.Resilience,
 	}
 
-	recs, err := o.runSimulation(ctx, cfg, state, weights, cfg.Instances.Families, []model.Architecture{model.ArchAMD64})
+	commitments, commitmentWarnings := o.resolveCommitments(ctx, cfg)
+
+	var recs []model.Recommendation
+	if len(o.NodePools) > 0 {
+		recs, err = o.runKarpenter(ctx, cfg, state, weights, commitments)
+	} else {
+		primary := instanceSelection{families: cfg.Instances.Families, archs: []model.Architecture{model.ArchAMD64}}
+		cross := crossFamilySelection(cfg, primary, autoClassified, workloadClass)
+		recs, err = o.runSimulation(ctx, cfg, state, weights, commitments, primary, cross)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +153,8 @@ func (o *Orchestrator) Recommend(ctx context.Context) ([]model.Recommendation, e
 		}
 
 		for _, ad := range altDefs {
-			altRecs, altErr := o.runSimulation(ctx, cfg, state, weights, ad.families, []model.Architecture{ad.arch})
+			alt := instanceSelection{families: ad.families, archs: []model.Architecture{ad.arch}}
+			altRecs, altErr := o.runSimulation(ctx, cfg, state, weights, commitments, alt, nil)
 			if altErr != nil || len(altRecs) == 0 {
 				continue
 			}
@@ -139,21 +170,76 @@ func (o *Orchestrator) Recommend(ctx context.Context) ([]model.Recommendation, e
 		}
 	}
 
-	// Step 5: Report
+	// Step 5: Replay autoscaling over the window for the top recommendations
+	var replays []model.ReplayResult
+	var replayWarnings []string
+	if cfg.Replay.Enabled && len(recs) > 0 {
+		replays, err = o.replay(ctx, cfg, opts, state, recs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			replayWarnings = append(replayWarnings, fmt.Sprintf("Autoscaling replay skipped: %v", err))
+		}
+	}
+
+	// Step 6: Pack the top recommendations with every algorithm
+	var comparisons []model.PackerComparison
+	var compareWarnings []string
+	if cfg.Simulation.ComparePackers && len(recs) > 0 {
+		_, _ = fmt.Fprintf(o.Writer, "Comparing %d packing algorithms...\n", len(simulation.Algorithms))
+		comparisons, err = o.comparePackers(ctx, cfg, state, recs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			compareWarnings = append(compareWarnings, fmt.Sprintf("Packer comparison skipped: %v", err))
+		}
+	}
+
+	// Step 7: Pack the top recommendations at each replica level
+	var levels []model.ReplicaLevelComparison
+	var levelWarnings []string
+	if observed.HasReplicaRanges() && len(recs) > 0 {
+		levels, err = o.replicaLevels(ctx, cfg, &observed, recs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			levelWarnings = append(levelWarnings, fmt.Sprintf("Replica level comparison skipped: %v", err))
+		}
+	} else if r := cfg.Simulation.Replicas; r != "" && r != model.ReplicasCurrent {
+		levelWarnings = append(levelWarnings, "No replica history or HPAs were found; controllers are packed at current replicas")
+	}
+
+	// Step 8: Report
 	reporter := report.NewReporter(cfg.Output.Format, o.Writer)
 	meta := report.ReportMeta{
-		ClusterName:      state.ClusterName,
-		Region:           state.Region,
-		CollectedAt:      state.CollectedAt,
-		WindowStart:      opts.Window.Start,
-		WindowEnd:        opts.Window.End,
-		Percentile:       cfg.Metrics.Percentile,
-		TotalPods:        state.WorkloadCount(),
-		TotalDaemons:     len(state.DaemonSets),
-		Strategy:         cfg.Simulation.Strategy,
-		MinNodes:         cfg.Simulation.MinNodes,
-		AggregateMetrics: state.AggregateMetrics,
+		ClusterName:       state.ClusterName,
+		Region:            state.Region,
+		CollectedAt:       state.CollectedAt,
+		WindowStart:       opts.Window.Start,
+		WindowEnd:         opts.Window.End,
+		Percentile:        cfg.Metrics.Percentile,
+		Sizing:            SizingDescription(cfg.Metrics),
+		Replicas:          cfg.Simulation.Replicas,
+		TotalPods:         state.WorkloadCount(),
+		TotalDaemons:      len(state.DaemonSets),
+		Strategy:          cfg.Simulation.Strategy,
+		MinNodes:          cfg.Simulation.MinNodes,
+		Zones:             cfg.Simulation.ZoneNames(cfg.Cluster.Region),
+		Warnings:          append(o.Provider.PricingSummary().Warnings(), commitmentWarnings...),
+		AggregateMetrics:  state.AggregateMetrics,
+		NodePools:         o.NodePoolSuggestions(recs),
+		Replays:           replays,
+		PackerComparisons: comparisons,
+		ReplicaLevels:     levels,
 	}
+	meta.Warnings = append(meta.Warnings, simulation.KarpenterWarnings(o.NodePools)...)
+	meta.Warnings = append(meta.Warnings, replayWarnings...)
+	meta.Warnings = append(meta.Warnings, compareWarnings...)
+	meta.Warnings = append(meta.Warnings, levelWarnings...)
+	meta.Warnings = append(meta.Warnings, o.PackerWarnings(state)...)
 	if autoClassified {
 		meta.WorkloadClass = string(workloadClass)
 		meta.GiBPerVCPU = gibPerVCPU
@@ -167,8 +253,115 @@ func (o *Orchestrator) Recommend(ctx context.Context) ([]model.Recommendation, e
 	return recs, nil
 }
 
+// instanceSelection is the instance families and architectures a
+// simulation run draws its instance types from.
+type instanceSelection struct {
+	families []string
+	archs    []model.Architecture
+}
+
+// crossFamilySelection returns the selection the primary run's cross-family
+// pools draw from: every configured architecture rather than amd64 only, so
+// that families of different architectures can share a pool. When families
+// were auto-classified, the Graviton families of the class are added for
+// arm64. It returns nil when the pools draw from the primary selection.
+func crossFamilySelection(cfg config.Config, primary instanceSelection, autoClassified bool, class model.WorkloadClass) *instanceSelection {
+	sel := instanceSelection{families: slices.Clone(primary.families)}
+	for _, a := range cfg.Instances.Architectures {
+		arch := model.Architecture(a)
+		if slices.Contains(sel.archs, arch) {
+			continue
+		}
+		sel.archs = append(sel.archs, arch)
+		if autoClassified && arch == model.ArchARM64 {
+			sel.families = append(sel.families, model.FamiliesForClass(class, "graviton")...)
+			if class != model.WorkloadClassGeneral {
+				sel.families = append(sel.families, model.FamiliesForClass(model.WorkloadClassGeneral, "graviton")...)
+			}
+		}
+	}
+	if len(sel.archs) == 0 || slices.Equal(sel.archs, primary.archs) {
+		return nil
+	}
+	return &sel
+}
+
 // runSimulation fetches instance types for the given families/architectures and runs the simulation pipeline.
-func (o *Orchestrator) runSimulation(ctx context.Context, cfg config.Config, state *model.ClusterState, weights model.ScoringWeights, families []string, archs []model.Architecture) ([]model.Recommendation, error) {
+// Cross-family pools draw from the cross selection when given, or from the
+// same instance types otherwise.
+func (o *Orchestrator) runSimulation(ctx context.Context, cfg config.Config, state *model.ClusterState, weights model.ScoringWeights, commitments model.Commitments, sel instanceSelection, cross *instanceSelection) ([]model.Recommendation, error) {
+	templates, err := o.fetchTemplates(ctx, cfg, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := cfg.Simulation
+	scenarios := simulation.GenerateScenarios(templates, sim.Strategy, sim.SpotRatio, sim.MinNodes)
+	if sim.CrossFamily.Enabled {
+		crossTemplates := templates
+		if cross != nil {
+			crossTemplates, err = o.fetchTemplates(ctx, cfg, *cross)
+			if err != nil {
+				return nil, err
+			}
+		}
+		scenarios = append(scenarios, crossFamilyScenarios(cfg, crossTemplates)...)
+	}
+	setZones(scenarios, cfg.Simulation.ZoneNames(cfg.Cluster.Region))
+
+	_, _ = fmt.Fprintf(o.Writer, "Simulating %d scenarios across %d instance types...\n",
+		len(scenarios), len(templates))
+
+	return o.rank(ctx, cfg, state, newScorer(weights, state), commitments, o.packer(cfg), scenarios)
+}
+
+// fetchTemplates fetches the priced instance types of a selection.
+func (o *Orchestrator) fetchTemplates(ctx context.Context, cfg config.Config, sel instanceSelection) ([]model.NodeTemplate, error) {
+	filter := aws.InstanceFilter{
+		Families:              sel.families,
+		MinVCPUs:              cfg.Instances.MinVCPUs,
+		MaxVCPUs:              cfg.Instances.MaxVCPUs,
+		Architectures:         sel.archs,
+		CurrentGenerationOnly: cfg.Instances.CurrentGenerationOnly,
+		ExcludeBareMetal:      cfg.Instances.ExcludeBareMetal,
+		ExcludeBurstable:      cfg.Instances.ExcludeBurstable,
+	}
+
+	templates, err := o.Provider.GetInstanceTypes(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("fetching instance types: %w", err)
+	}
+	if err := ApplySpotInterruptions(cfg, cfg.Cluster.Region, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// generateScenarios builds the scenarios of the configured strategy, plus
+// the cross-family pools when enabled.
+func generateScenarios(cfg config.Config, templates []model.NodeTemplate) []simulation.Scenario {
+	sim := cfg.Simulation
+	scenarios := simulation.GenerateScenarios(templates, sim.Strategy, sim.SpotRatio, sim.MinNodes)
+	if sim.CrossFamily.Enabled {
+		scenarios = append(scenarios, crossFamilyScenarios(cfg, templates)...)
+	}
+	return scenarios
+}
+
+// crossFamilyScenarios builds the configured cross-family pools.
+func crossFamilyScenarios(cfg config.Config, templates []model.NodeTemplate) []simulation.Scenario {
+	sim := cfg.Simulation
+	opts := simulation.CrossFamilyOptions{
+		MaxFamilies:     sim.CrossFamily.MaxFamilies,
+		MaxCombinations: sim.CrossFamily.MaxCombinations,
+	}
+	return simulation.GenerateCrossFamilyScenarios(templates, opts, sim.SpotRatio, sim.MinNodes)
+}
+
+// runKarpenter fetches the instance types the NodePools may launch and
+// simulates Karpenter provisioning with them.
+func (o *Orchestrator) runKarpenter(ctx context.Context, cfg config.Config, state *model.ClusterState, weights model.ScoringWeights, commitments model.Commitments) ([]model.Recommendation, error) {
+	families, archs := nodePoolFilter(o.NodePools, cfg.Instances.Families)
 	filter := aws.InstanceFilter{
 		Families:              families,
 		MinVCPUs:              cfg.Instances.MinVCPUs,
@@ -183,17 +376,35 @@ func (o *Orchestrator) runSimulation(ctx context.Context, cfg config.Config, sta
 	if err != nil {
 		return nil, fmt.Errorf("fetching instance types: %w", err)
 	}
+	if err := ApplySpotInterruptions(cfg, cfg.Cluster.Region, templates); err != nil {
+		return nil, err
+	}
 
-	scenarios := simulation.GenerateScenarios(templates, cfg.Simulation.Strategy, cfg.Simulation.SpotRatio, cfg.Simulation.MinNodes)
+	scenarios := simulation.GenerateKarpenterScenarios(templates, o.NodePools)
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("no instance type matches the requirements of any NodePool")
+	}
+	setZones(scenarios, cfg.Simulation.ZoneNames(cfg.Cluster.Region))
 
-	_, _ = fmt.Fprintf(o.Writer, "Simulating %d scenarios across %d instance types...\n",
-		len(scenarios), len(templates))
+	_, _ = fmt.Fprintf(o.Writer, "Simulating Karpenter provisioning for %d NodePools (%d scenarios across %d instance types)...\n",
+		len(o.NodePools), len(scenarios), len(scenarios[0].InstanceTypes))
+
+	return o.rank(ctx, cfg, state, newScorer(weights, state), commitments, &simulation.KarpenterProvisioner{NodePools: o.NodePools}, scenarios)
+}
 
-	packer := &simulation.BestFitDecreasing{}
+// newScorer creates the scorer used by the live pipeline.
+func newScorer(weights model.ScoringWeights, state *model.ClusterState) *simulation.Scorer {
 	scorer := simulation.NewScorer(weights)
 	scorer.DaemonSetCount = len(state.DaemonSets)
 	scorer.AggregateMetrics = state.AggregateMetrics
+	return scorer
+}
+
+// rank runs the scenarios with the given packer and returns the top
+// recommendations.
+func (o *Orchestrator) rank(ctx context.Context, cfg config.Config, state *model.ClusterState, scorer *simulation.Scorer, commitments model.Commitments, packer simulation.BinPacker, scenarios []simulation.Scenario) ([]model.Recommendation, error) {
 	engine := simulation.NewEngine(packer, scorer)
+	engine.Commitments = commitments
 
 	recs, err := engine.RunAll(ctx, scenarios, *state)
 	if err != nil {
@@ -207,12 +418,329 @@ func (o *Orchestrator) runSimulation(ctx context.Context, cfg config.Config, sta
 	return recs, nil
 }
 
+// replay fetches the usage timeline of the window and replays autoscaling
+// for the top recommendations.
+func (o *Orchestrator) replay(ctx context.Context, cfg config.Config, opts metrics.CollectOptions, state *model.ClusterState, recs []model.Recommendation) ([]model.ReplayResult, error) {
+	tc, ok := o.Collector.(metrics.TimelineCollector)
+	if !ok {
+		return nil, fmt.Errorf("the %s metrics backend cannot report usage over time", o.Collector.BackendType())
+	}
+	opts.Window.Step = cfg.Replay.Step
+	timeline, err := tc.CollectTimeline(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("collecting usage timeline: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(o.Writer, "Replaying %s over %d steps for %d configurations...\n",
+		cfg.Replay.Policy, timeline.Steps(), min(len(recs), cfg.Replay.Top))
+	return o.replayTimeline(ctx, cfg, state, timeline, recs)
+}
+
+// ReplayState replays autoscaling for the top recommendations over the usage
+// series stored in a cluster snapshot.
+func (o *Orchestrator) ReplayState(ctx context.Context, state *model.ClusterState, recs []model.Recommendation) ([]model.ReplayResult, error) {
+	timeline := state.Timeline()
+	if timeline == nil {
+		return nil, fmt.Errorf("the cluster state has no usage series; collect it with 'clusterfit inspect --series'")
+	}
+	return o.replayTimeline(ctx, o.Config, state, timeline, recs)
+}
+
+// replayTimeline replays the configured number of top recommendations.
+func (o *Orchestrator) replayTimeline(ctx context.Context, cfg config.Config, state *model.ClusterState, timeline *model.UsageTimeline, recs []model.Recommendation) ([]model.ReplayResult, error) {
+	recs = recs[:min(len(recs), cfg.Replay.Top)]
+	return ReplayRecommendations(ctx, o.replayer(cfg), state, timeline, recs, cfg.Simulation.MinNodes)
+}
+
+// replayer returns the replayer for the configured policy, packing new
+// nodes the way the simulation did.
+func (o *Orchestrator) replayer(cfg config.Config) *simulation.Replayer {
+	return &simulation.Replayer{
+		Packer: o.packer(cfg),
+		Options: simulation.ReplayOptions{
+			Policy:               cfg.Replay.Policy,
+			RepackEvery:          cfg.Replay.RepackEvery,
+			ScaleDownUtilization: cfg.Replay.ScaleDownUtilization,
+		},
+	}
+}
+
+// packer returns the bin-packer for the mode: Karpenter provisioning when
+// NodePools are set, the configured packer otherwise.
+func (o *Orchestrator) packer(cfg config.Config) simulation.BinPacker {
+	if len(o.NodePools) > 0 {
+		return &simulation.KarpenterProvisioner{NodePools: o.NodePools}
+	}
+	return PackerFor(cfg.Simulation)
+}
+
+// PackerFor returns the configured bin-packer: peak-aware best-fit when
+// enabled, the configured algorithm otherwise.
+func PackerFor(sim config.SimulationConfig) simulation.BinPacker {
+	if sim.PeakAware {
+		return &simulation.PeakAwareBestFit{Percentile: sim.OvercommitPercentile}
+	}
+	if sim.Algorithm == simulation.AlgorithmBranchAndBound {
+		return &simulation.BranchAndBound{TimeBudget: sim.ExactTimeBudget, MaxPods: sim.ExactMaxPods}
+	}
+	return simulation.NewPacker(sim.Algorithm)
+}
+
+// ComparePackers packs each recommendation's instance configuration with
+// the configured packer and every other algorithm.
+func (o *Orchestrator) ComparePackers(ctx context.Context, state *model.ClusterState, recs []model.Recommendation) ([]model.PackerComparison, error) {
+	return o.comparePackers(ctx, o.Config, state, recs)
+}
+
+func (o *Orchestrator) comparePackers(ctx context.Context, cfg config.Config, state *model.ClusterState, recs []model.Recommendation) ([]model.PackerComparison, error) {
+	if len(o.NodePools) > 0 {
+		return nil, fmt.Errorf("packer comparison does not apply in Karpenter mode")
+	}
+	packers := []simulation.BinPacker{o.packer(cfg)}
+	for _, name := range simulation.Algorithms {
+		if name == packers[0].Name() {
+			continue
+		}
+		alt := cfg.Simulation
+		alt.PeakAware, alt.Algorithm = false, name
+		packers = append(packers, PackerFor(alt))
+	}
+
+	comparisons := make([]model.PackerComparison, 0, len(recs))
+	for _, rec := range recs {
+		ic := rec.SimulationResult.InstanceConfig
+		runs, err := simulation.ComparePackers(ctx, packers, scenarioFor(ic, cfg.Simulation.MinNodes), *state)
+		if err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, model.PackerComparison{InstanceConfig: ic, Runs: runs})
+	}
+	return comparisons, nil
+}
+
+// ReplicaLevels packs each recommendation's instance configuration with
+// controllers at every replica level. It returns nil when the cluster state
+// has no replica ranges.
+func (o *Orchestrator) ReplicaLevels(ctx context.Context, state *model.ClusterState, recs []model.Recommendation) ([]model.ReplicaLevelComparison, error) {
+	if !state.HasReplicaRanges() {
+		return nil, nil
+	}
+	return o.replicaLevels(ctx, o.Config, state, recs)
+}
+
+func (o *Orchestrator) replicaLevels(ctx context.Context, cfg config.Config, state *model.ClusterState, recs []model.Recommendation) ([]model.ReplicaLevelComparison, error) {
+	packer := o.packer(cfg)
+	comparisons := make([]model.ReplicaLevelComparison, 0, len(recs))
+	for _, rec := range recs {
+		ic := rec.SimulationResult.InstanceConfig
+		runs, err := simulation.CompareReplicaLevels(ctx, packer, scenarioFor(ic, cfg.Simulation.MinNodes), *state, model.ReplicaLevels)
+		if err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, model.ReplicaLevelComparison{InstanceConfig: ic, Runs: runs})
+	}
+	return comparisons, nil
+}
+
+// scenarioFor rebuilds the scenario of an instance configuration. The HA
+// minimum applies to every configuration except Karpenter's, which has none.
+func scenarioFor(ic model.InstanceConfig, minNodes int) simulation.Scenario {
+	scenario := simulation.Scenario{
+		Name:          ic.Label(),
+		InstanceTypes: ic.InstanceTypes,
+		Strategy:      ic.Strategy,
+		SpotRatio:     ic.SpotRatio,
+		Zones:         ic.Zones,
+	}
+	if ic.Strategy != simulation.StrategyKarpenter {
+		scenario.MinNodes = minNodes
+	}
+	return scenario
+}
+
+// PackerWarnings flags packer settings the cluster state cannot honor.
+func (o *Orchestrator) PackerWarnings(state *model.ClusterState) []string {
+	if !o.Config.Simulation.PeakAware {
+		return nil
+	}
+	if len(o.NodePools) > 0 {
+		return []string{"Peak-aware packing does not apply in Karpenter mode; pods are sized at their percentile"}
+	}
+	if state.Timeline() == nil {
+		return []string{"Peak-aware packing needs usage series; none were collected, so pods are sized at their percentile"}
+	}
+	return nil
+}
+
+// ReplayRecommendations replays each recommendation's instance configuration
+// over the timeline.
+func ReplayRecommendations(ctx context.Context, replayer *simulation.Replayer, state *model.ClusterState, timeline *model.UsageTimeline, recs []model.Recommendation, minNodes int) ([]model.ReplayResult, error) {
+	results := make([]model.ReplayResult, 0, len(recs))
+	for _, rec := range recs {
+		ic := rec.SimulationResult.InstanceConfig
+		r, err := replayer.Replay(ctx, scenarioFor(ic, minNodes), *state, timeline)
+		if err != nil {
+			return nil, err
+		}
+		r.InstanceConfig = ic
+		r.SnapshotMonthlyCost = rec.MonthlyCost
+		results = append(results, *r)
+	}
+	return results, nil
+}
+
+// nodePoolFilter returns the instance families and architectures to fetch
+// for the NodePools. Families are nil, i.e. all families, unless every pool
+// restricts them; the configured families then apply, if any.
+func nodePoolFilter(pools []model.NodePool, configured []string) ([]string, []model.Architecture) {
+	var families []string
+	var archs []model.Architecture
+	restricted := true
+	for _, p := range pools {
+		f := p.Families()
+		if f == nil {
+			restricted = false
+		}
+		families = append(families, f...)
+		for _, a := range p.Architectures() {
+			if !slices.Contains(archs, a) {
+				archs = append(archs, a)
+			}
+		}
+	}
+	if !restricted {
+		return configured, archs
+	}
+	sort.Strings(families)
+	return slices.Compact(families), archs
+}
+
+// NodePoolSuggestions returns the NodePool requirements suggested by the top
+// recommendation, or nil outside Karpenter mode.
+func (o *Orchestrator) NodePoolSuggestions(recs []model.Recommendation) []model.NodePoolSuggestion {
+	if len(o.NodePools) == 0 || len(recs) == 0 {
+		return nil
+	}
+	return simulation.SuggestNodePools(o.NodePools, recs[0].SimulationResult)
+}
+
+// resolveCommitments converts the configured commitments, pricing
+// discount-only Reserved Instances from their families' catalog. It returns
+// report warnings for RIs that could not be priced.
+func (o *Orchestrator) resolveCommitments(ctx context.Context, cfg config.Config) (model.Commitments, []string) {
+	commitments := CommitmentsFromConfig(cfg.Commitments)
+
+	var families []string
+	seen := make(map[string]bool)
+	for _, ri := range commitments.ReservedInstances {
+		family, _, _ := strings.Cut(ri.InstanceType, ".")
+		if ri.HourlyRate == 0 && !seen[family] {
+			seen[family] = true
+			families = append(families, family)
+		}
+	}
+
+	var catalog []model.NodeTemplate
+	if len(families) > 0 {
+		// Errors leave the RIs unpriced, which is reported below
+		catalog, _ = o.Provider.GetInstanceTypes(ctx, aws.InstanceFilter{Families: families})
+	}
+	return ResolveCommitments(commitments, catalog)
+}
+
+// SizingFromConfig converts the configured CPU and memory sizing policies to
+// the model, with the metrics percentile where a policy sets none.
+func SizingFromConfig(m config.MetricsConfig) (cpu, mem model.SizingPolicy) {
+	convert := func(c config.SizingConf) model.SizingPolicy {
+		p := model.SizingPolicy{Source: c.Source, Percentile: c.Percentile, Headroom: c.Headroom}
+		if p.Percentile == 0 {
+			p.Percentile = m.Percentile
+		}
+		return p
+	}
+	return convert(m.Sizing.CPU), convert(m.Sizing.Memory)
+}
+
+// SizingDescription describes the configured sizing policies for reports,
+// e.g. "cpu p90 usage, memory max(request, max usage)", or returns "" when
+// both are the default.
+func SizingDescription(m config.MetricsConfig) string {
+	if m.Sizing.IsZero() {
+		return ""
+	}
+	cpu, mem := SizingFromConfig(m)
+	return "cpu " + cpu.String() + ", memory " + mem.String()
+}
+
+// CommitmentsFromConfig converts configured RIs and Savings Plans to the model.
+func CommitmentsFromConfig(c config.CommitmentsConfig) model.Commitments {
+	var m model.Commitments
+	for _, ri := range c.ReservedInstances {
+		m.ReservedInstances = append(m.ReservedInstances, model.ReservedInstance{
+			InstanceType: ri.InstanceType,
+			Count:        ri.Count,
+			HourlyRate:   ri.HourlyRate,
+			Discount:     ri.Discount,
+		})
+	}
+	for _, sp := range c.SavingsPlans {
+		m.SavingsPlans = append(m.SavingsPlans, model.SavingsPlan{
+			Type:             sp.Type,
+			Family:           sp.Family,
+			HourlyCommitment: sp.HourlyCommitment,
+			Discount:         sp.Discount,
+		})
+	}
+	return m
+}
+
+// ResolveCommitments prices discount-only RIs from the catalog and returns
+// a report warning for each instance type that could not be priced.
+func ResolveCommitments(c model.Commitments, catalog []model.NodeTemplate) (model.Commitments, []string) {
+	resolved, unpriced := c.ResolveRates(catalog)
+	warnings := make([]string, 0, len(unpriced))
+	for _, it := range unpriced {
+		warnings = append(warnings, fmt.Sprintf(
+			"No on-demand price for Reserved Instance type %s; set hourly_rate to include it in effective costs", it))
+	}
+	return resolved, warnings
+}
+
 // Simulate runs simulations on a pre-collected cluster state.
 func (o *Orchestrator) Simulate(ctx context.Context, state *model.ClusterState, instanceTypes []model.NodeTemplate) ([]model.Recommendation, error) {
 	cfg := o.Config
 
-	scenarios := simulation.GenerateScenarios(instanceTypes, cfg.Simulation.Strategy, cfg.Simulation.SpotRatio, cfg.Simulation.MinNodes)
+	var scenarios []simulation.Scenario
+	if len(o.NodePools) > 0 {
+		scenarios = simulation.GenerateKarpenterScenarios(instanceTypes, o.NodePools)
+		if len(scenarios) == 0 {
+			return nil, fmt.Errorf("no instance type matches the requirements of any NodePool")
+		}
+	} else {
+		scenarios = generateScenarios(cfg, instanceTypes)
+	}
+	region := state.Region
+	if region == "" {
+		region = cfg.Cluster.Region
+	}
+	setZones(scenarios, cfg.Simulation.ZoneNames(region))
+
+	return o.simulateScenarios(ctx, state, o.offlineCommitments(instanceTypes), scenarios)
+}
+
+// offlineCommitments resolves the configured commitments against the given
+// instance types, printing a warning for each one that cannot be priced.
+func (o *Orchestrator) offlineCommitments(instanceTypes []model.NodeTemplate) model.Commitments {
+	commitments, warnings := ResolveCommitments(CommitmentsFromConfig(o.Config.Commitments), instanceTypes)
+	for _, w := range warnings {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	return commitments
+}
 
+// simulateScenarios ranks the scenarios for a pre-collected cluster state.
+func (o *Orchestrator) simulateScenarios(ctx context.Context, state *model.ClusterState, commitments model.Commitments, scenarios []simulation.Scenario) ([]model.Recommendation, error) {
+	cfg := o.Config
 	weights := model.ScoringWeights{
 		Cost:          cfg.Scoring.Weights.Cost,
 		Utilization:   cfg.Scoring.Weights.Utilization,
@@ -220,19 +748,108 @@ func (o *Orchestrator) Simulate(ctx context.Context, state *model.ClusterState,
 		Resilience:    cfg.Scoring.Weights.Resilience,
 	}
 
-	packer := &simulation.BestFitDecreasing{}
 	scorer := simulation.NewScorer(weights)
 	scorer.AggregateMetrics = state.AggregateMetrics
-	engine := simulation.NewEngine(packer, scorer)
+	return o.rank(ctx, cfg, state, scorer, commitments, o.packer(cfg), scenarios)
+}
 
-	recs, err := engine.RunAll(ctx, scenarios, *state)
+// Rightsize proposes requests and limits for the controllers of a
+// pre-collected cluster state and prices them: the current instances and
+// the best ones are each packed with the current and the right-sized
+// requests. The current instances are the baseline template when given, or
+// the best configuration for the current requests otherwise.
+func (o *Orchestrator) Rightsize(ctx context.Context, state *model.ClusterState, instanceTypes []model.NodeTemplate, baseline *model.NodeTemplate) (*model.RightsizeReport, error) {
+	cfg := o.Config
+	if len(o.NodePools) > 0 {
+		return nil, fmt.Errorf("rightsizing does not apply in Karpenter mode")
+	}
+	rr := &model.RightsizeReport{
+		Workloads:     model.Rightsize(append(slices.Clone(state.Workloads), state.DaemonSets...), RightsizePolicyFromConfig(cfg.Rightsize)),
+		BaselineGiven: baseline != nil,
+	}
+	// Size the current requests under the policies the right-sized ones get,
+	// so that the runs differ only by their requests
+	cpuSizing, memSizing := SizingFromConfig(cfg.Metrics)
+	sized := *state
+	sized.Workloads = slices.Clone(state.Workloads)
+	sized.DaemonSets = slices.Clone(state.DaemonSets)
+	sized.Resize(cpuSizing, memSizing)
+	rightsized := sized.Rightsized(rr.Workloads, cpuSizing, memSizing)
+
+	region := state.Region
+	if region == "" {
+		region = cfg.Cluster.Region
+	}
+	zones := cfg.Simulation.ZoneNames(region)
+	scenarios := generateScenarios(cfg, instanceTypes)
+	setZones(scenarios, zones)
+	commitments := o.offlineCommitments(instanceTypes)
+
+	best, err := o.simulateScenarios(ctx, &sized, commitments, scenarios)
 	if err != nil {
-		return nil, fmt.Errorf("running simulations: %w", err)
+		return nil, err
+	}
+	fixed, err := o.simulateScenarios(ctx, &rightsized, commitments, scenarios)
+	if err != nil {
+		return nil, err
+	}
+	if len(best) == 0 || len(fixed) == 0 {
+		return nil, fmt.Errorf("no instance configuration fits the workloads")
+	}
+	rr.InstanceChanged, rr.Both = best[0], fixed[0]
+
+	current := scenarioFor(best[0].SimulationResult.InstanceConfig, cfg.Simulation.MinNodes)
+	if baseline != nil {
+		current = simulation.Scenario{
+			Name:          baseline.InstanceType,
+			InstanceTypes: []model.NodeTemplate{*baseline},
+			Strategy:      "homogeneous",
+			MinNodes:      cfg.Simulation.MinNodes,
+			Zones:         zones,
+		}
+	}
+	for _, run := range []struct {
+		state *model.ClusterState
+		rec   *model.Recommendation
+	}{{&sized, &rr.Current}, {&rightsized, &rr.RequestsFixed}} {
+		recs, err := o.simulateScenarios(ctx, run.state, commitments, []simulation.Scenario{current})
+		if err != nil {
+			return nil, err
+		}
+		if len(recs) == 0 {
+			return nil, fmt.Errorf("the workloads do not fit %s", current.Name)
+		}
+		*run.rec = recs[0]
 	}
+	return rr, nil
+}
 
-	if cfg.Output.TopN > 0 && len(recs) > cfg.Output.TopN {
-		recs = recs[:cfg.Output.TopN]
+// RightsizePolicyFromConfig converts the configured rightsizing targets to
+// the model.
+func RightsizePolicyFromConfig(c config.RightsizeConfig) model.RightsizePolicy {
+	target := func(t config.RightsizeConf) model.RightsizeTarget {
+		return model.RightsizeTarget{Percentile: t.Percentile, Headroom: t.Headroom, LimitRatio: t.LimitRatio}
 	}
+	return model.RightsizePolicy{CPU: target(c.CPU), Memory: target(c.Memory)}
+}
 
-	return recs, nil
+// ApplySpotInterruptions sets the spot interruption rates read from
+// pricing.spot_interruptions, when configured, on the templates.
+func ApplySpotInterruptions(cfg config.Config, region string, templates []model.NodeTemplate) error {
+	if cfg.Pricing.SpotInterruptions == "" {
+		return nil
+	}
+	rates, err := aws.LoadSpotInterruptions(cfg.Pricing.SpotInterruptions, region)
+	if err != nil {
+		return err
+	}
+	aws.ApplySpotInterruptions(templates, rates)
+	return nil
+}
+
+// setZones spreads the nodes of every scenario across the given availability zones.
+func setZones(scenarios []simulation.Scenario, zones []string) {
+	for i := range scenarios {
+		scenarios[i].Zones = zones
+	}
 }
@@ -3,11 +3,16 @@ package orchestrator
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/guimove/clusterfit/internal/aws"
 	"github.com/guimove/clusterfit/internal/config"
 	"github.com/guimove/clusterfit/internal/metrics"
 	"github.com/guimove/clusterfit/internal/model"
+	"github.com/guimove/clusterfit/internal/simulation"
 )
 
 func TestOrchestrator_Simulate(t *testing.T) {
@@ -68,3 +73,265 @@ func TestOrchestrator_Simulate(t *testing.T) {
 		}
 	}
 }
+
+func TestOrchestrator_ReplayState(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	series := &model.UsageSeries{
+		Start:       start,
+		Step:        time.Hour,
+		CPUMillis:   []int64{500, 1500, 500},
+		MemoryBytes: []int64{1 << 30, 1 << 30, 1 << 30},
+	}
+	state := &model.ClusterState{Workloads: []model.WorkloadProfile{
+		{Name: "app-1", Namespace: "default", EffectiveCPUMillis: 500, EffectiveMemoryBytes: 1 << 30, Series: series},
+		{Name: "app-2", Namespace: "default", EffectiveCPUMillis: 500, EffectiveMemoryBytes: 1 << 30, Series: series},
+	}}
+	templates := []model.NodeTemplate{{
+		InstanceType:           "m5.large",
+		InstanceFamily:         "m5",
+		AllocatableCPUMillis:   1940,
+		AllocatableMemoryBytes: 7 * 1024 * 1024 * 1024,
+		MaxPods:                29,
+		OnDemandPricePerHour:   0.096,
+		CapacityType:           model.CapacityOnDemand,
+	}}
+
+	cfg := config.Default()
+	cfg.Simulation.Strategy = "homogeneous"
+	cfg.Simulation.MinNodes = 0
+	orch := &Orchestrator{Config: cfg, Writer: &bytes.Buffer{}}
+
+	recs, err := orch.Simulate(context.Background(), state, templates)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	replays, err := orch.ReplayState(context.Background(), state, recs)
+	if err != nil {
+		t.Fatalf("ReplayState failed: %v", err)
+	}
+	if len(replays) != 1 || replays[0].Steps != 3 {
+		t.Fatalf("got %+v, want one replay of 3 steps", replays)
+	}
+	if replays[0].SnapshotMonthlyCost != recs[0].MonthlyCost {
+		t.Errorf("snapshot cost = %v, want %v", replays[0].SnapshotMonthlyCost, recs[0].MonthlyCost)
+	}
+	// Both pods peak at 1.5 vCPUs at the second step: one node each
+	if replays[0].PeakNodes != 2 {
+		t.Errorf("peak nodes = %d, want 2", replays[0].PeakNodes)
+	}
+
+	for i := range state.Workloads {
+		state.Workloads[i].Series = nil
+	}
+	if _, err := orch.ReplayState(context.Background(), state, recs); err == nil {
+		t.Error("expected an error for a snapshot without series")
+	}
+}
+
+func TestOrchestrator_ComparePackers(t *testing.T) {
+	state := &model.ClusterState{Workloads: []model.WorkloadProfile{
+		{Name: "app-1", Namespace: "default", EffectiveCPUMillis: 1200, EffectiveMemoryBytes: 1 << 30},
+		{Name: "app-2", Namespace: "default", EffectiveCPUMillis: 900, EffectiveMemoryBytes: 2 << 30},
+		{Name: "app-3", Namespace: "default", EffectiveCPUMillis: 700, EffectiveMemoryBytes: 1 << 30},
+	}}
+	templates := []model.NodeTemplate{{
+		InstanceType:           "m5.large",
+		InstanceFamily:         "m5",
+		AllocatableCPUMillis:   1940,
+		AllocatableMemoryBytes: 7 * 1024 * 1024 * 1024,
+		MaxPods:                29,
+		OnDemandPricePerHour:   0.096,
+		CapacityType:           model.CapacityOnDemand,
+	}}
+
+	cfg := config.Default()
+	cfg.Simulation.Strategy = "homogeneous"
+	cfg.Simulation.Algorithm = "dot-product"
+	orch := &Orchestrator{Config: cfg, Writer: &bytes.Buffer{}}
+
+	recs, err := orch.Simulate(context.Background(), state, templates)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if got := recs[0].SimulationResult.Algorithm; got != "dot-product" {
+		t.Errorf("algorithm = %q, want dot-product", got)
+	}
+
+	comparisons, err := orch.ComparePackers(context.Background(), state, recs)
+	if err != nil {
+		t.Fatalf("ComparePackers failed: %v", err)
+	}
+	if len(comparisons) != len(recs) {
+		t.Fatalf("got %d comparisons, want %d", len(comparisons), len(recs))
+	}
+	runs := comparisons[0].Runs
+	if len(runs) != len(simulation.Algorithms) || runs[0].Algorithm != "dot-product" {
+		t.Errorf("runs = %+v, want the configured dot-product first and every algorithm", runs)
+	}
+}
+
+func TestOrchestrator_Rightsize(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+	var workloads []model.WorkloadProfile
+	for i := range 6 {
+		w := model.WorkloadProfile{
+			Name: fmt.Sprintf("api-%d", i), Namespace: "prod", OwnerKind: "StatefulSet", OwnerName: "api",
+			Requested:          model.ResourceQuantity{CPUMillis: 1500, MemoryBytes: 4 * gib},
+			EffectiveCPUMillis: 1500, EffectiveMemoryBytes: 4 * gib,
+		}
+		w.CPUUsage.Set(0.95, 0.2)
+		w.MemoryUsage.Set(1.0, gib/2)
+		workloads = append(workloads, w)
+	}
+	state := &model.ClusterState{Workloads: workloads}
+	templates := []model.NodeTemplate{
+		{InstanceType: "m5.large", InstanceFamily: "m5", AllocatableCPUMillis: 1940, AllocatableMemoryBytes: 7 * gib,
+			MaxPods: 29, OnDemandPricePerHour: 0.096, CapacityType: model.CapacityOnDemand},
+		{InstanceType: "m5.xlarge", InstanceFamily: "m5", AllocatableCPUMillis: 3920, AllocatableMemoryBytes: 15 * gib,
+			MaxPods: 58, OnDemandPricePerHour: 0.192, CapacityType: model.CapacityOnDemand},
+	}
+
+	cfg := config.Default()
+	cfg.Simulation.Strategy = "homogeneous"
+	cfg.Simulation.MinNodes = 0
+	orch := &Orchestrator{Config: cfg, Writer: &bytes.Buffer{}}
+
+	rr, err := orch.Rightsize(context.Background(), state, templates, &templates[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.Workloads) != 1 || rr.Workloads[0].RecommendedRequests.CPUMillis != 200 {
+		t.Fatalf("workloads = %+v, want api right-sized to 200m", rr.Workloads)
+	}
+	if got := rr.Current.SimulationResult.InstanceConfig.Label(); got != "m5.xlarge" || !rr.BaselineGiven {
+		t.Errorf("current = %s, want the m5.xlarge baseline", got)
+	}
+	if rr.Current.SimulationResult.TotalNodes != 3 || rr.RequestsFixed.SimulationResult.TotalNodes != 1 {
+		t.Errorf("baseline nodes = %d → %d, want 3 → 1 with right-sized requests",
+			rr.Current.SimulationResult.TotalNodes, rr.RequestsFixed.SimulationResult.TotalNodes)
+	}
+	if rr.RequestSavings() <= 0 || rr.TotalSavings() < rr.InstanceSavings() {
+		t.Errorf("savings: requests %.0f, instances %.0f, both %.0f; want right-sizing to save",
+			rr.RequestSavings(), rr.InstanceSavings(), rr.TotalSavings())
+	}
+}
+
+func TestOrchestrator_RightsizeResizesBaseline(t *testing.T) {
+	// The snapshot sized the pods at their max CPU usage; the configured
+	// p95 sizing fits six per node, and the requests are already right
+	const gib = 1024 * 1024 * 1024
+	var workloads []model.WorkloadProfile
+	for i := range 6 {
+		w := model.WorkloadProfile{
+			Name: fmt.Sprintf("api-%d", i), Namespace: "prod", OwnerKind: "Deployment", OwnerName: "api",
+			Requested:          model.ResourceQuantity{CPUMillis: 500, MemoryBytes: gib},
+			Limits:             model.ResourceQuantity{MemoryBytes: gib},
+			EffectiveCPUMillis: 1500, EffectiveMemoryBytes: gib,
+		}
+		w.CPUUsage.Set(0.95, 0.5)
+		w.CPUUsage.Set(1.0, 1.5)
+		w.MemoryUsage.Set(0.95, gib)
+		w.MemoryUsage.Set(1.0, gib)
+		workloads = append(workloads, w)
+	}
+	state := &model.ClusterState{Workloads: workloads}
+	baseline := model.NodeTemplate{InstanceType: "m5.xlarge", InstanceFamily: "m5", AllocatableCPUMillis: 3920,
+		AllocatableMemoryBytes: 15 * gib, MaxPods: 58, OnDemandPricePerHour: 0.192, CapacityType: model.CapacityOnDemand}
+
+	cfg := config.Default()
+	cfg.Simulation.Strategy = "homogeneous"
+	cfg.Simulation.MinNodes = 0
+	cfg.Rightsize.Memory.Headroom = 1.0
+	orch := &Orchestrator{Config: cfg, Writer: &bytes.Buffer{}}
+
+	rr, err := orch.Rightsize(context.Background(), state, []model.NodeTemplate{baseline}, &baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Current.SimulationResult.TotalNodes != 1 || rr.RequestsFixed.SimulationResult.TotalNodes != 1 {
+		t.Errorf("baseline nodes = %d → %d, want 1 → 1 at the configured sizing",
+			rr.Current.SimulationResult.TotalNodes, rr.RequestsFixed.SimulationResult.TotalNodes)
+	}
+	if rr.RequestSavings() != 0 {
+		t.Errorf("request savings = %.2f, want 0 for unchanged requests", rr.RequestSavings())
+	}
+	if state.Workloads[0].EffectiveCPUMillis != 1500 {
+		t.Errorf("state resized in place: effective CPU %dm, want 1500m", state.Workloads[0].EffectiveCPUMillis)
+	}
+}
+
+// catalogProvider serves a fixed catalog filtered by family and architecture.
+type catalogProvider struct {
+	templates []model.NodeTemplate
+}
+
+func (p catalogProvider) GetInstanceTypes(_ context.Context, filter aws.InstanceFilter) ([]model.NodeTemplate, error) {
+	var result []model.NodeTemplate
+	for _, t := range p.templates {
+		if (len(filter.Families) == 0 || slices.Contains(filter.Families, t.InstanceFamily)) &&
+			(len(filter.Architectures) == 0 || slices.Contains(filter.Architectures, t.Architecture)) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (p catalogProvider) Region() string { return "us-east-1" }
+
+func (p catalogProvider) PricingSummary() aws.PricingSummary { return aws.PricingSummary{} }
+
+func TestOrchestrator_RecommendCrossArchitecture(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+	var workloads []model.WorkloadProfile
+	for i := range 4 {
+		for _, arch := range []model.Architecture{model.ArchAMD64, model.ArchARM64} {
+			workloads = append(workloads, model.WorkloadProfile{
+				Name: fmt.Sprintf("%s-%d", arch, i), Namespace: "prod", Architecture: arch,
+				EffectiveCPUMillis: 500, EffectiveMemoryBytes: gib,
+			})
+		}
+	}
+	state := &model.ClusterState{Workloads: workloads}
+
+	provider := catalogProvider{templates: []model.NodeTemplate{
+		{InstanceType: "m7i.large", InstanceFamily: "m7i", Architecture: model.ArchAMD64, AllocatableCPUMillis: 1930,
+			AllocatableMemoryBytes: 7 * gib, MaxPods: 29, OnDemandPricePerHour: 0.1008, CapacityType: model.CapacityOnDemand},
+		{InstanceType: "m7g.large", InstanceFamily: "m7g", Architecture: model.ArchARM64, AllocatableCPUMillis: 1930,
+			AllocatableMemoryBytes: 7 * gib, MaxPods: 29, OnDemandPricePerHour: 0.0816, CapacityType: model.CapacityOnDemand},
+	}}
+
+	cfg := config.Default()
+	cfg.Instances.Families = []string{"m7i", "m7g"}
+	cfg.Instances.Architectures = []string{"amd64", "arm64"}
+	cfg.Simulation.Strategy = "homogeneous"
+	cfg.Simulation.CrossFamily.Enabled = true
+	cfg.Simulation.CrossFamily.MaxFamilies = 2
+	cfg.Output.TopN = 10
+
+	orch := &Orchestrator{
+		Collector: metrics.NewStaticCollectorFromState(state),
+		Provider:  provider,
+		Config:    cfg,
+		Writer:    &bytes.Buffer{},
+	}
+	recs, err := orch.Recommend(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mixed *model.Recommendation
+	for i := range recs {
+		if recs[i].SimulationResult.InstanceConfig.Strategy == simulation.StrategyCrossFamily {
+			mixed = &recs[i]
+		}
+	}
+	if mixed == nil {
+		t.Fatal("no cross-family recommendation")
+	}
+	if got := mixed.SimulationResult.InstanceConfig.Label(); got != "m7g+m7i (cross-family)" && got != "m7i+m7g (cross-family)" {
+		t.Errorf("cross-family pool = %s, want m7i and m7g", got)
+	}
+	if n := len(mixed.SimulationResult.UnschedulablePods); n != 0 {
+		t.Errorf("%d pods left unplaced on the mixed-architecture pool", n)
+	}
+}
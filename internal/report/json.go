@@ -32,3 +32,13 @@ func (r *JSONReporter) Report(ctx context.Context, recs []model.Recommendation,
 	}
 	return nil
 }
+
+// ReadJSON decodes a report written by JSONReporter, e.g. the output of
+// `recommend --output json`.
+func ReadJSON(r io.Reader) ([]model.Recommendation, ReportMeta, error) {
+	var output jsonOutput
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, ReportMeta{}, fmt.Errorf("decoding JSON report: %w", err)
+	}
+	return output.Recommendations, output.Meta, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/guimove/clusterfit/internal/model"
 )
@@ -22,7 +23,13 @@ func (r *MarkdownReporter) Report(_ context.Context, recs []model.Recommendation
 	ew.printf("| Cluster | %s |\n", meta.ClusterName)
 	ew.printf("| Region | %s |\n", meta.Region)
 	ew.printf("| Pods | %d (+ %d DaemonSets) |\n", meta.TotalPods, meta.TotalDaemons)
-	ew.printf("| Percentile | p%.0f |\n", meta.Percentile*100)
+	ew.printf("| Percentile | %s |\n", strings.ToLower(model.PercentileLabel(meta.Percentile)))
+	if meta.Sizing != "" {
+		ew.printf("| Sizing | %s |\n", meta.Sizing)
+	}
+	if meta.Replicas != "" && meta.Replicas != model.ReplicasCurrent {
+		ew.printf("| Replicas | %s |\n", describeReplicaLevel(meta.Replicas))
+	}
 	ew.printf("| Window | %s to %s |\n",
 		meta.WindowStart.Format("2006-01-02"), meta.WindowEnd.Format("2006-01-02"))
 	if meta.AggregateMetrics != nil {
@@ -34,8 +41,18 @@ func (r *MarkdownReporter) Report(_ context.Context, recs []model.Recommendation
 	if meta.MinNodes > 0 {
 		ew.printf("| Min nodes | %d (HA constraint) |\n", meta.MinNodes)
 	}
+	if len(meta.Zones) > 0 {
+		ew.printf("| Zones | %s |\n", strings.Join(meta.Zones, ", "))
+	}
+	if p := packerName(recs); p != "" {
+		ew.printf("| Packer | %s |\n", p)
+	}
 	ew.printf("\n")
 
+	for _, w := range meta.Warnings {
+		ew.printf("> **Warning:** %s\n\n", w)
+	}
+
 	if len(recs) == 0 {
 		ew.printf("No recommendations available.\n")
 		return ew.err
@@ -43,12 +60,16 @@ func (r *MarkdownReporter) Report(_ context.Context, recs []model.Recommendation
 
 	// Recommendations table
 	ew.printf("## Rankings\n\n")
-	ew.printf("| Rank | Configuration | Nodes | CPU%% | Mem%% | Score | $/month |\n")
-	ew.printf("|------|--------------|-------|------|------|-------|--------|\n")
+	ew.printf("| Rank | Configuration | Nodes | CPU%% | Mem%% | Score | $/month | Gap to bound |\n")
+	ew.printf("|------|--------------|-------|------|------|-------|--------|--------------|\n")
 
 	for _, rec := range recs {
 		sr := rec.SimulationResult
-		ew.printf("| %d | %s | %d | %.1f%% | %.1f%% | %.1f | $%.0f |\n",
+		gap := "—"
+		if sr.LowerBound != nil {
+			gap = describeGap(sr.LowerBound)
+		}
+		ew.printf("| %d | %s | %d | %.1f%% | %.1f%% | %.1f | $%.0f | %s |\n",
 			rec.Rank,
 			sr.InstanceConfig.Label(),
 			sr.TotalNodes,
@@ -56,6 +77,7 @@ func (r *MarkdownReporter) Report(_ context.Context, recs []model.Recommendation
 			sr.AvgMemUtilization*100,
 			rec.OverallScore,
 			rec.MonthlyCost,
+			gap,
 		)
 	}
 
@@ -66,9 +88,34 @@ func (r *MarkdownReporter) Report(_ context.Context, recs []model.Recommendation
 	ew.printf("**%s**\n\n", topSR.InstanceConfig.Label())
 	ew.printf("- Nodes: %d\n", topSR.TotalNodes)
 	ew.printf("- Monthly cost: $%.0f\n", top.MonthlyCost)
+	if topSR.CommittedCost != nil {
+		ew.printf("- Commitments: %s\n", describeCommitments(top))
+	}
 	ew.printf("- CPU utilization: %.1f%%\n", topSR.AvgCPUUtilization*100)
 	ew.printf("- Memory utilization: %.1f%%\n", topSR.AvgMemUtilization*100)
 	ew.printf("- Resource balance: %.2f\n", topSR.Fragmentation.ResourceBalanceScore)
+	if co := topSR.ConstraintOverhead; co != nil {
+		ew.printf("- Affinity/spread overhead: %d extra nodes ($%.0f/mo) vs %d unconstrained\n",
+			co.ExtraNodes, co.ExtraMonthlyCost, co.UnconstrainedNodes)
+	}
+	if fh := topSR.FailureHeadroom; fh != nil {
+		ew.printf("- Node failure headroom: %s\n", describeHeadroom(fh))
+	}
+	if zones := formatZoneCounts(topSR); zones != "" {
+		ew.printf("- Nodes per zone: %s\n", zones)
+	}
+	if zf := topSR.WorstZoneFailure(); zf != nil {
+		ew.printf("- Zone loss: %s\n", describeZoneLoss(zf))
+	}
+	if sd := topSR.SpotDisruption; sd != nil {
+		ew.printf("- Spot disruption: %s\n", describeSpotDisruption(sd))
+	}
+	if lb := topSR.LowerBound; lb != nil {
+		ew.printf("- Lower bound: %s\n", describeLowerBound(lb))
+	}
+	if opt := describeOptimality(topSR); opt != "" {
+		ew.printf("- Optimality: %s\n", opt)
+	}
 
 	if top.CostVsBaseline < 0 {
 		ew.printf("- Savings vs baseline: %.1f%%\n", -top.CostVsBaseline)
@@ -84,6 +131,53 @@ func (r *MarkdownReporter) Report(_ context.Context, recs []model.Recommendation
 		}
 	}
 
+	if len(meta.NodePools) > 0 {
+		ew.printf("\n## Suggested NodePool Requirements\n")
+		for _, np := range meta.NodePools {
+			ew.printf("\n```yaml\n%s```\n", formatNodePool(np, ""))
+		}
+	}
+
+	if len(meta.Replays) > 0 {
+		first := meta.Replays[0]
+		ew.printf("\n## Autoscaling Replay\n\n")
+		ew.printf("Replayed with %s over %d steps of %s.\n\n", first.Policy, first.Steps, first.Step)
+		ew.printf("| Configuration | Nodes (min/avg/max) | Node-hours | $/month | vs Static | Peak Pending | Unschedulable |\n")
+		ew.printf("|--------------|---------------------|------------|---------|-----------|--------------|---------------|\n")
+		for _, rp := range meta.Replays {
+			ew.printf("| %s | %s | %.0f | $%.0f | %s | %d | %d |\n",
+				rp.InstanceConfig.Label(), describeReplayNodes(rp), rp.NodeHours, rp.MonthlyCost,
+				describeReplayDelta(rp), rp.PeakPendingPods, rp.UnschedulablePods)
+		}
+	}
+
+	if len(meta.PackerComparisons) > 0 {
+		ew.printf("\n## Packer Comparison\n\n")
+		ew.printf("Node deltas are against %s.\n\n", meta.PackerComparisons[0].Runs[0].Algorithm)
+		ew.printf("| Configuration | Packer | Nodes | Delta | $/month | Unschedulable |\n")
+		ew.printf("|--------------|--------|-------|-------|---------|---------------|\n")
+		for _, pc := range meta.PackerComparisons {
+			for i, run := range pc.Runs {
+				ew.printf("| %s | %s | %d | %s | $%.0f | %d |\n",
+					pc.InstanceConfig.Label(), run.Algorithm, run.Nodes, describeNodeDelta(run, i == 0),
+					run.MonthlyCost, run.UnschedulablePods)
+			}
+		}
+	}
+
+	if len(meta.ReplicaLevels) > 0 {
+		ew.printf("\n## Replica Levels\n\n")
+		ew.printf("| Configuration | Replicas | Pods | Nodes | $/month | Unschedulable |\n")
+		ew.printf("|--------------|----------|------|-------|---------|---------------|\n")
+		for _, rl := range meta.ReplicaLevels {
+			for _, run := range rl.Runs {
+				ew.printf("| %s | %s | %d | %d | $%.0f | %d |\n",
+					rl.InstanceConfig.Label(), describeReplicaLevel(run.Level), run.Pods, run.Nodes,
+					run.MonthlyCost, run.UnschedulablePods)
+			}
+		}
+	}
+
 	// Workload classification and architecture alternatives
 	if meta.WorkloadClass != "" {
 		ew.printf("\n## Workload Profile\n\n")
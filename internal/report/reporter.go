@@ -2,7 +2,10 @@ package report
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/guimove/clusterfit/internal/model"
@@ -21,10 +24,14 @@ type ReportMeta struct {
 	WindowStart  time.Time
 	WindowEnd    time.Time
 	Percentile   float64
+	Sizing       string // CPU and memory sizing policies (empty = max of request and usage at Percentile)
+	Replicas     string // replica level controllers are packed at (empty = current)
 	TotalPods    int
 	TotalDaemons int
 	Strategy     string
 	MinNodes     int
+	Zones        []string // availability zones nodes are spread across (empty = zone-agnostic)
+	Warnings     []string // data-quality issues affecting every recommendation, e.g. missing prices
 
 	// Cluster-wide aggregate metrics (nil if unavailable)
 	AggregateMetrics *model.ClusterAggregateMetrics
@@ -33,6 +40,18 @@ type ReportMeta struct {
 	WorkloadClass string                  // e.g. "general-purpose"
 	GiBPerVCPU    float64                 // aggregate ratio
 	Alternatives  []model.AlternativeArch // architecture alternatives
+
+	// Karpenter mode: NodePool requirements derived from the top recommendation
+	NodePools []model.NodePoolSuggestion
+
+	// Autoscaling replayed over the metrics window for the top recommendations
+	Replays []model.ReplayResult
+
+	// The top recommendations packed with every bin-packing algorithm
+	PackerComparisons []model.PackerComparison
+
+	// The top recommendations packed with controllers at each replica level
+	ReplicaLevels []model.ReplicaLevelComparison
 }
 
 // NewReporter creates a reporter for the given format writing to w.
@@ -46,3 +65,162 @@ func NewReporter(format string, w io.Writer) Reporter {
 		return &TableReporter{w: w}
 	}
 }
+
+// formatZoneCounts renders the per-zone node counts of a result in zone order,
+// e.g. "us-east-1a=3, us-east-1b=3". It returns an empty string without zones.
+func formatZoneCounts(sr model.SimulationResult) string {
+	counts := sr.ZoneCounts()
+	parts := make([]string, 0, len(counts))
+	for _, z := range sr.InstanceConfig.Zones {
+		parts = append(parts, fmt.Sprintf("%s=%d", z, counts[z]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeZoneLoss summarizes the worst single-zone failure of a result.
+func describeZoneLoss(zf *model.ZoneFailure) string {
+	if !zf.Fits {
+		return fmt.Sprintf("%d pods unschedulable without %s", zf.UnschedulablePods, zf.Zone)
+	}
+	sign := "+"
+	if zf.ExtraMonthlyCost < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("survives any single zone (worst: %s$%.0f/mo without %s)",
+		sign, math.Abs(zf.ExtraMonthlyCost), zf.Zone)
+}
+
+// describeCommitments summarizes how RIs and Savings Plans change a result's
+// cost, e.g. "list $5200; RIs cover 8.0 nodes, Savings Plans $1900/mo".
+func describeCommitments(rec model.Recommendation) string {
+	cc := rec.SimulationResult.CommittedCost
+	desc := fmt.Sprintf("list $%.0f; RIs cover %.1f nodes, Savings Plans $%.0f/mo",
+		rec.ListMonthlyCost, cc.ReservedNodes, cc.SavingsPlanCovered)
+	if cc.UnusedCommitmentMonthly >= 1 {
+		desc += fmt.Sprintf("; $%.0f/mo unused", cc.UnusedCommitmentMonthly)
+	}
+	return desc
+}
+
+// formatNodePool renders a NodePool suggestion as a commented
+// spec.template.spec snippet, each line prefixed with indent.
+func formatNodePool(s model.NodePoolSuggestion, indent string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s# NodePool %s: %d nodes, $%.0f/mo (%s)\n",
+		indent, s.NodePool, s.Nodes, s.MonthlyCost, strings.Join(s.InstanceTypes, ", "))
+	fmt.Fprintf(&b, "%srequirements:\n", indent)
+	for _, r := range s.Requirements {
+		fmt.Fprintf(&b, "%s  - key: %s\n", indent, r.Key)
+		fmt.Fprintf(&b, "%s    operator: %s\n", indent, r.Operator)
+		if len(r.Values) > 0 {
+			fmt.Fprintf(&b, "%s    values: [\"%s\"]\n", indent, strings.Join(r.Values, `", "`))
+		}
+		if r.MinValues > 0 {
+			fmt.Fprintf(&b, "%s    minValues: %d\n", indent, r.MinValues)
+		}
+	}
+	return b.String()
+}
+
+// describeHeadroom summarizes N-1 and N-2 node failure tolerance.
+func describeHeadroom(fh *model.FailureHeadroom) string {
+	part := func(label string, f model.NodeFailure) string {
+		if f.Survives {
+			return label + " ok"
+		}
+		return fmt.Sprintf("%s strands %d/%d pods", label, f.StrandedPods, f.DisplacedPods)
+	}
+	return part("N-1", fh.N1) + ", " + part("N-2", fh.N2)
+}
+
+// describeLowerBound summarizes a packing's lower bound and its gap to it,
+// e.g. "5 nodes ($700/mo, memory-bound); packing is +1 node, 12.5% above".
+func describeLowerBound(lb *model.LowerBound) string {
+	return fmt.Sprintf("%d nodes ($%.0f/mo, %s-bound); packing is %s above",
+		lb.Nodes, lb.MonthlyCost, lb.Binding, describeGap(lb))
+}
+
+// describeGap renders the gap of a packing to its lower bound, e.g.
+// "+1 node, 12.5%".
+func describeGap(lb *model.LowerBound) string {
+	unit := "nodes"
+	if lb.NodeGap == 1 {
+		unit = "node"
+	}
+	return fmt.Sprintf("+%d %s, %.1f%%", lb.NodeGap, unit, lb.CostGap)
+}
+
+// describeSpotDisruption summarizes the disruption spot interruptions are
+// expected to cause, e.g. "4 spot nodes: 0.3 interruptions, 2.1 pod
+// evictions/mo (1.5% of pods)".
+func describeSpotDisruption(sd *model.SpotDisruption) string {
+	s := fmt.Sprintf("%d spot nodes: %.1f interruptions, %.1f pod evictions/mo (%.1f%% of pods)",
+		sd.SpotNodes, sd.InterruptionsPerMonth, sd.EvictionsPerMonth, sd.EvictedFraction*100)
+	if sd.ProtectedPods > 0 {
+		s += fmt.Sprintf("; %d StatefulSet/PDB pods kept on-demand", sd.ProtectedPods)
+	}
+	if sd.SpotShortfall > 0 {
+		s += fmt.Sprintf(", %d spot nodes short of the ratio", sd.SpotShortfall)
+	}
+	if len(sd.EstimatedTypes) > 0 {
+		s += fmt.Sprintf("; no interruption data for %s", strings.Join(sd.EstimatedTypes, ", "))
+	}
+	return s
+}
+
+// describeOptimality renders whether an exact packer proved a packing
+// optimal, or an empty string for heuristic packers.
+func describeOptimality(sr model.SimulationResult) string {
+	switch sr.Optimality {
+	case model.OptimalityOptimal:
+		return "optimal (no packing costs less on-demand)"
+	case model.OptimalityBestFound:
+		return "best found (search stopped before proving optimality)"
+	}
+	return ""
+}
+
+// packerName returns the algorithm that produced the recommendations, or an
+// empty string when unknown.
+func packerName(recs []model.Recommendation) string {
+	if len(recs) == 0 {
+		return ""
+	}
+	return recs[0].SimulationResult.Algorithm
+}
+
+// describeNodeDelta renders a packer run's node count against the first run
+// of its comparison, e.g. "+2", or "—" for the first run itself.
+func describeNodeDelta(run model.PackerRun, first bool) string {
+	if first {
+		return "—"
+	}
+	return fmt.Sprintf("%+d", run.NodeDelta)
+}
+
+// describeReplayNodes renders the node count range of a replay, e.g. "3/4.6/9".
+func describeReplayNodes(r model.ReplayResult) string {
+	return fmt.Sprintf("%d/%.1f/%d", r.MinNodes, r.AvgNodes, r.PeakNodes)
+}
+
+// describeReplayDelta renders how a replay's monthly cost compares to the
+// static bin-packing of the same configuration, e.g. "-32%".
+func describeReplayDelta(r model.ReplayResult) string {
+	if r.SnapshotMonthlyCost <= 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%+.0f%%", (r.MonthlyCost-r.SnapshotMonthlyCost)/r.SnapshotMonthlyCost*100)
+}
+
+// describeReplicaLevel describes a replica level, e.g. "typical (p95)".
+func describeReplicaLevel(level string) string {
+	switch level {
+	case model.ReplicasMin:
+		return "min (fewest observed)"
+	case model.ReplicasTypical:
+		return "typical (p95)"
+	case model.ReplicasPeak:
+		return "peak (most observed)"
+	}
+	return "current (snapshot)"
+}
@@ -101,6 +101,237 @@ func TestTableReporter_Empty(t *testing.T) {
 	}
 }
 
+func TestReporters_MetaWarnings(t *testing.T) {
+	meta := sampleMeta()
+	meta.Warnings = []string{"Pricing unavailable for 2 of 40 instance types"}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), sampleRecs(), meta); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "Pricing unavailable for 2 of 40 instance types") {
+			t.Errorf("%s report missing meta warning", format)
+		}
+	}
+}
+
+func TestReporters_NodePools(t *testing.T) {
+	meta := sampleMeta()
+	meta.NodePools = []model.NodePoolSuggestion{{
+		NodePool:      "default",
+		Nodes:         3,
+		MonthlyCost:   350,
+		InstanceTypes: []string{"m7g.xlarge"},
+		Requirements: []model.NodeRequirement{
+			{Key: model.LabelInstanceFamily, Operator: model.OpIn, Values: []string{"m7g"}},
+		},
+	}}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), sampleRecs(), meta); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "NodePool default") || !strings.Contains(out, `values: ["m7g"]`) {
+			t.Errorf("%s report missing NodePool suggestion:\n%s", format, out)
+		}
+	}
+}
+
+func TestReporters_Replays(t *testing.T) {
+	meta := sampleMeta()
+	meta.Replays = []model.ReplayResult{{
+		InstanceConfig:      sampleRecs()[0].SimulationResult.InstanceConfig,
+		Policy:              "cluster-autoscaler",
+		Steps:               672,
+		Step:                15 * time.Minute,
+		NodeHours:           1134,
+		MonthlyCost:         810,
+		SnapshotMonthlyCost: 1200,
+		MinNodes:            4,
+		AvgNodes:            6.75,
+		PeakNodes:           10,
+		PeakPendingPods:     12,
+	}}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), sampleRecs(), meta); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{"cluster-autoscaler", "672 steps of 15m0s", "4/6.8/10", "-32%"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s report missing %q:\n%s", format, want, out)
+			}
+		}
+	}
+}
+
+func TestReporters_PackerComparisons(t *testing.T) {
+	recs := sampleRecs()
+	recs[0].SimulationResult.Algorithm = "local-search"
+	meta := sampleMeta()
+	meta.PackerComparisons = []model.PackerComparison{{
+		InstanceConfig: recs[0].SimulationResult.InstanceConfig,
+		Runs: []model.PackerRun{
+			{Algorithm: "local-search", Nodes: 9, MonthlyCost: 1100},
+			{Algorithm: "first-fit-decreasing", Nodes: 11, NodeDelta: 2, MonthlyCost: 1350},
+		},
+	}}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), recs, meta); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{"local-search", "first-fit-decreasing", "+2"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s report missing %q:\n%s", format, want, out)
+			}
+		}
+	}
+}
+
+func TestReporters_ReplicaLevels(t *testing.T) {
+	recs := sampleRecs()
+	meta := sampleMeta()
+	meta.Replicas = model.ReplicasTypical
+	meta.ReplicaLevels = []model.ReplicaLevelComparison{{
+		InstanceConfig: recs[0].SimulationResult.InstanceConfig,
+		Runs: []model.ReplicaLevelRun{
+			{Level: model.ReplicasMin, Pods: 40, Nodes: 4, MonthlyCost: 560},
+			{Level: model.ReplicasPeak, Pods: 130, Nodes: 13, MonthlyCost: 1820, UnschedulablePods: 2},
+		},
+	}}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), recs, meta); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{"typical (p95)", "min (fewest observed)", "peak (most observed)", "1820"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s report missing %q:\n%s", format, want, out)
+			}
+		}
+	}
+}
+
+func TestReporters_LowerBoundAndOptimality(t *testing.T) {
+	recs := sampleRecs()
+	recs[0].SimulationResult.LowerBound = &model.LowerBound{
+		Nodes: 8, MonthlyCost: 960, Binding: "memory", NodeGap: 2, CostGap: 25,
+	}
+	recs[0].SimulationResult.Optimality = model.OptimalityBestFound
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), recs, sampleMeta()); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{"8 nodes ($960/mo, memory-bound)", "+2 nodes, 25.0%", "best found"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s report missing %q:\n%s", format, want, out)
+			}
+		}
+	}
+}
+
+func TestReporters_SpotDisruption(t *testing.T) {
+	recs := sampleRecs()
+	recs[0].SimulationResult.SpotDisruption = &model.SpotDisruption{
+		SpotNodes: 4, InterruptionsPerMonth: 0.3, EvictionsPerMonth: 2.1, EvictedFraction: 0.015,
+		ProtectedPods: 3, SpotShortfall: 1, EstimatedTypes: []string{"m7i.xlarge"},
+	}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := NewReporter(format, &buf).Report(context.Background(), recs, sampleMeta()); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{"4 spot nodes: 0.3 interruptions, 2.1 pod evictions/mo (1.5% of pods)", "3 StatefulSet/PDB pods kept on-demand", "no interruption data for m7i.xlarge"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s report missing %q:\n%s", format, want, out)
+			}
+		}
+	}
+}
+
+func TestWriteRightsize(t *testing.T) {
+	recs := sampleRecs()
+	rr := &model.RightsizeReport{
+		Workloads: []model.RightsizeRecommendation{{
+			Namespace: "prod", OwnerKind: "Deployment", OwnerName: "api", Pods: 3,
+			Requested:           model.ResourceQuantity{CPUMillis: 2000, MemoryBytes: 4096 * 1024 * 1024},
+			RecommendedRequests: model.ResourceQuantity{CPUMillis: 400, MemoryBytes: 920 * 1024 * 1024},
+			RecommendedLimits:   model.ResourceQuantity{MemoryBytes: 920 * 1024 * 1024},
+		}},
+		Current:         recs[1],
+		RequestsFixed:   recs[1],
+		InstanceChanged: recs[0],
+		Both:            recs[0],
+		BaselineGiven:   true,
+	}
+	rr.RequestsFixed.MonthlyCost = 1000
+	rr.Both.MonthlyCost = 700
+	opts := RightsizeOptions{Policy: model.RightsizePolicy{
+		CPU:    model.RightsizeTarget{Percentile: 0.95},
+		Memory: model.RightsizeTarget{Percentile: 1.0, Headroom: 1.15, LimitRatio: 1},
+	}}
+
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := WriteRightsize(format, &buf, rr, sampleMeta(), opts); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range []string{
+			"prod/Deployment/api", "2000 → 400", "920Mi", "memory: max usage x1.15, limit = request",
+			"cpu -4800m", "$400/month", "$200/month", "$700/month", "Change instances",
+		} {
+			if !strings.Contains(strings.ReplaceAll(out, "`", ""), want) {
+				t.Errorf("%s output missing %q:\n%s", format, want, out)
+			}
+		}
+	}
+
+	// Without a baseline the current instances are the changed ones
+	noBaseline := *rr
+	noBaseline.BaselineGiven = false
+	noBaseline.Current, noBaseline.InstanceChanged = recs[0], recs[0]
+	for _, format := range []string{"table", "markdown"} {
+		var buf bytes.Buffer
+		if err := WriteRightsize(format, &buf, &noBaseline, sampleMeta(), opts); err != nil {
+			t.Fatal(err)
+		}
+		out := strings.ReplaceAll(buf.String(), "`", "")
+		if strings.Contains(out, "Change instances") || !strings.Contains(out, "pass --baseline") {
+			t.Errorf("%s output without baseline should leave out changing instances:\n%s", format, out)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRightsize("json", &buf, rr, sampleMeta(), opts); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Rightsize model.RightsizeReport `json:"rightsize"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Rightsize.Workloads) != 1 || decoded.Rightsize.Both.MonthlyCost != 700 {
+		t.Errorf("decoded = %+v, want the report round-tripped", decoded.Rightsize)
+	}
+}
+
 func TestJSONReporter(t *testing.T) {
 	var buf bytes.Buffer
 	reporter := &JSONReporter{w: &buf}
@@ -124,6 +355,28 @@ func TestJSONReporter(t *testing.T) {
 	}
 }
 
+func TestReadJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&JSONReporter{w: &buf}).Report(context.Background(), sampleRecs(), sampleMeta()); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, meta, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0].SimulationResult.InstanceConfig.InstanceTypes[0].InstanceType != "m5.xlarge" {
+		t.Errorf("recommendations = %+v", recs)
+	}
+	if meta.ClusterName != "test-cluster" || meta.Region != "us-east-1" {
+		t.Errorf("meta = %+v", meta)
+	}
+
+	if _, _, err := ReadJSON(strings.NewReader("not json")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
 func TestMarkdownReporter(t *testing.T) {
 	var buf bytes.Buffer
 	reporter := &MarkdownReporter{w: &buf}
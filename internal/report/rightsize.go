@@ -0,0 +1,243 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// RightsizeOptions sets what a rightsizing report shows.
+type RightsizeOptions struct {
+	Policy model.RightsizePolicy
+	Top    int // workloads listed; 0 = all
+}
+
+// WriteRightsize writes a rightsizing report in the given format: table,
+// json or markdown.
+func WriteRightsize(format string, w io.Writer, rr *model.RightsizeReport, meta ReportMeta, opts RightsizeOptions) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Meta      ReportMeta             `json:"meta"`
+			Rightsize *model.RightsizeReport `json:"rightsize"`
+		}{meta, rr}); err != nil {
+			return fmt.Errorf("encoding JSON output: %w", err)
+		}
+		return nil
+	case "markdown":
+		return writeRightsizeMarkdown(w, rr, meta, opts)
+	default:
+		return writeRightsizeTable(w, rr, meta, opts)
+	}
+}
+
+func writeRightsizeTable(w io.Writer, rr *model.RightsizeReport, meta ReportMeta, opts RightsizeOptions) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("\n")
+	ew.printf("ClusterFit Right-Sizing\n")
+	ew.printf("%s\n", strings.Repeat("=", 60))
+	ew.printf("Cluster:     %s\n", meta.ClusterName)
+	ew.printf("Region:      %s\n", meta.Region)
+	ew.printf("Pods:        %d (+ %d DaemonSets)\n", meta.TotalPods, meta.TotalDaemons)
+	ew.printf("Requests:    %s\n", describeRightsizeTarget("cpu", opts.Policy.CPU))
+	ew.printf("             %s\n", describeRightsizeTarget("memory", opts.Policy.Memory))
+	if meta.Sizing != "" {
+		ew.printf("Sizing:      %s\n", meta.Sizing)
+	}
+	ew.printf("%s\n\n", strings.Repeat("=", 60))
+
+	for _, warning := range meta.Warnings {
+		ew.printf("Warning: %s\n", warning)
+	}
+	if len(meta.Warnings) > 0 {
+		ew.printf("\n")
+	}
+
+	ew.printf("%-40s %4s %15s %19s %8s %8s\n",
+		"Workload", "Pods", "CPU req (m)", "Mem req (MiB)", "CPU lim", "Mem lim")
+	ew.printf("%s\n", strings.Repeat("-", 100))
+	for _, r := range listedWorkloads(rr, opts.Top) {
+		key := r.Key()
+		if len(key) > 40 {
+			key = key[:37] + "..."
+		}
+		note := ""
+		if r.NoMetrics {
+			note = " [no metrics, kept]"
+		}
+		ew.printf("%-40s %4d %15s %19s %8s %8s%s\n",
+			key, r.Pods,
+			fmt.Sprintf("%d → %d", r.Requested.CPUMillis, r.RecommendedRequests.CPUMillis),
+			fmt.Sprintf("%d → %d", r.Requested.MemoryBytes/mib, r.RecommendedRequests.MemoryBytes/mib),
+			formatLimit(r.RecommendedLimits.CPUMillis, 1, "m"),
+			formatLimit(r.RecommendedLimits.MemoryBytes, mib, "Mi"),
+			note,
+		)
+	}
+	ew.printf("%s\n", strings.Repeat("-", 100))
+	ew.printf("%s\n", describeRequestChange(rr))
+
+	ew.printf("\n%-18s %6s %8s %-24s %s\n", "Node simulation", "Nodes", "$/month", "Savings", "Instances")
+	ew.printf("%s\n", strings.Repeat("-", 100))
+	for _, row := range rightsizeRows(rr) {
+		ew.printf("%-18s %6d %8.0f %-24s %s\n", row.label, row.rec.SimulationResult.TotalNodes, row.rec.MonthlyCost,
+			row.savings, row.rec.SimulationResult.InstanceConfig.Label())
+	}
+	ew.printf("%s\n", strings.Repeat("-", 100))
+	if !rr.BaselineGiven {
+		ew.printf("The current instances are taken to be the best ones for the current requests; pass --baseline to compare with yours.\n")
+	}
+	return ew.err
+}
+
+func writeRightsizeMarkdown(w io.Writer, rr *model.RightsizeReport, meta ReportMeta, opts RightsizeOptions) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("# ClusterFit Right-Sizing\n\n")
+	ew.printf("| Property | Value |\n")
+	ew.printf("|----------|-------|\n")
+	ew.printf("| Cluster | %s |\n", meta.ClusterName)
+	ew.printf("| Region | %s |\n", meta.Region)
+	ew.printf("| Pods | %d (+ %d DaemonSets) |\n", meta.TotalPods, meta.TotalDaemons)
+	ew.printf("| Requests | %s; %s |\n",
+		describeRightsizeTarget("cpu", opts.Policy.CPU), describeRightsizeTarget("memory", opts.Policy.Memory))
+	if meta.Sizing != "" {
+		ew.printf("| Sizing | %s |\n", meta.Sizing)
+	}
+	ew.printf("\n")
+
+	for _, warning := range meta.Warnings {
+		ew.printf("> **Warning:** %s\n\n", warning)
+	}
+
+	ew.printf("## Requests\n\n")
+	ew.printf("| Workload | Pods | CPU request (m) | Memory request (MiB) | CPU limit | Memory limit |\n")
+	ew.printf("|----------|-----:|----------------:|---------------------:|----------:|-------------:|\n")
+	for _, r := range listedWorkloads(rr, opts.Top) {
+		cpu := fmt.Sprintf("%d → %d", r.Requested.CPUMillis, r.RecommendedRequests.CPUMillis)
+		if r.NoMetrics {
+			cpu += " (no metrics, kept)"
+		}
+		ew.printf("| %s | %d | %s | %d → %d | %s | %s |\n",
+			r.Key(), r.Pods, cpu,
+			r.Requested.MemoryBytes/mib, r.RecommendedRequests.MemoryBytes/mib,
+			formatLimit(r.RecommendedLimits.CPUMillis, 1, "m"),
+			formatLimit(r.RecommendedLimits.MemoryBytes, mib, "Mi"),
+		)
+	}
+	ew.printf("\n%s\n\n", describeRequestChange(rr))
+
+	ew.printf("## Savings\n\n")
+	ew.printf("| Scenario | Instances | Nodes | $/month | Savings |\n")
+	ew.printf("|----------|-----------|------:|--------:|---------|\n")
+	for _, row := range rightsizeRows(rr) {
+		ew.printf("| %s | %s | %d | %.0f | %s |\n", row.label, row.rec.SimulationResult.InstanceConfig.Label(),
+			row.rec.SimulationResult.TotalNodes, row.rec.MonthlyCost, row.savings)
+	}
+	if !rr.BaselineGiven {
+		ew.printf("\nThe current instances are taken to be the best ones for the current requests; pass `--baseline` to compare with yours.\n")
+	}
+	return ew.err
+}
+
+const mib = 1024 * 1024
+
+// listedWorkloads returns the first top workloads of the report, or all of
+// them when top is 0.
+func listedWorkloads(rr *model.RightsizeReport, top int) []model.RightsizeRecommendation {
+	if top > 0 && len(rr.Workloads) > top {
+		return rr.Workloads[:top]
+	}
+	return rr.Workloads
+}
+
+// rightsizeRow is one line of the savings table.
+type rightsizeRow struct {
+	label   string
+	rec     model.Recommendation
+	savings string
+}
+
+// rightsizeRows returns the savings table: the current instances and the
+// top recommendation, with the current and the right-sized requests.
+// Without a given baseline the current instances are the top
+// recommendation, so changing them is left out.
+func rightsizeRows(rr *model.RightsizeReport) []rightsizeRow {
+	rows := []rightsizeRow{
+		{"Current", rr.Current, "-"},
+		{"Fix requests", rr.RequestsFixed, describeSavings(rr.RequestSavings())},
+	}
+	if rr.BaselineGiven {
+		rows = append(rows, rightsizeRow{"Change instances", rr.InstanceChanged, describeSavings(rr.InstanceSavings())})
+	}
+	return append(rows, rightsizeRow{"Both", rr.Both, describeSavings(rr.TotalSavings())})
+}
+
+// describeSavings formats monthly savings, e.g. "$420/month ($5040/year)".
+func describeSavings(monthly float64) string {
+	switch {
+	case monthly < 0:
+		return fmt.Sprintf("+$%.0f/month", -monthly)
+	case monthly == 0:
+		return "none"
+	}
+	return fmt.Sprintf("$%.0f/month ($%.0f/year)", monthly, monthly*12)
+}
+
+// describeRequestChange summarizes the change in total requests, e.g.
+// "Requests: cpu -2300m, memory -4608 MiB across 12 of 30 workloads".
+func describeRequestChange(rr *model.RightsizeReport) string {
+	var total model.ResourceQuantity
+	var changed, kept int
+	for i := range rr.Workloads {
+		r := &rr.Workloads[i]
+		if r.NoMetrics {
+			kept++
+			continue
+		}
+		d := r.RequestChange()
+		if !d.IsZero() {
+			changed++
+		}
+		total = total.Add(d)
+	}
+	s := fmt.Sprintf("Requests: cpu %+dm, memory %+d MiB across %d of %d workloads",
+		total.CPUMillis, total.MemoryBytes/mib, changed, len(rr.Workloads))
+	if kept > 0 {
+		s += fmt.Sprintf(" (%d without metrics kept)", kept)
+	}
+	return s
+}
+
+// describeRightsizeTarget describes how a request and limit are derived,
+// e.g. "memory: max usage x1.15, limit = request".
+func describeRightsizeTarget(resource string, t model.RightsizeTarget) string {
+	s := resource + ": " + strings.ToLower(model.PercentileLabel(t.Percentile)) + " usage"
+	if t.Headroom > 0 && t.Headroom != 1 {
+		s += " x" + strconv.FormatFloat(t.Headroom, 'f', -1, 64)
+	}
+	switch {
+	case t.LimitRatio <= 0:
+		s += ", no limit"
+	case t.LimitRatio == 1:
+		s += ", limit = request"
+	default:
+		s += ", limit = request x" + strconv.FormatFloat(t.LimitRatio, 'f', -1, 64)
+	}
+	return s
+}
+
+// formatLimit formats a limit in the given unit, or "-" for none.
+func formatLimit(v, unit int64, suffix string) string {
+	if v <= 0 {
+		return "-"
+	}
+	return strconv.FormatInt(v/unit, 10) + suffix
+}
@@ -24,7 +24,13 @@ func (r *TableReporter) Report(_ context.Context, recs []model.Recommendation, m
 	ew.printf("Cluster:     %s\n", meta.ClusterName)
 	ew.printf("Region:      %s\n", meta.Region)
 	ew.printf("Pods:        %d (+ %d DaemonSets)\n", meta.TotalPods, meta.TotalDaemons)
-	ew.printf("Percentile:  p%g\n", meta.Percentile*100)
+	ew.printf("Percentile:  %s\n", strings.ToLower(model.PercentileLabel(meta.Percentile)))
+	if meta.Sizing != "" {
+		ew.printf("Sizing:      %s\n", meta.Sizing)
+	}
+	if meta.Replicas != "" && meta.Replicas != model.ReplicasCurrent {
+		ew.printf("Replicas:    %s\n", describeReplicaLevel(meta.Replicas))
+	}
 	ew.printf("Window:      %s to %s\n",
 		meta.WindowStart.Format("2006-01-02"), meta.WindowEnd.Format("2006-01-02"))
 	if meta.AggregateMetrics != nil {
@@ -36,8 +42,21 @@ func (r *TableReporter) Report(_ context.Context, recs []model.Recommendation, m
 	if meta.MinNodes > 0 {
 		ew.printf("Min nodes:   %d (HA constraint)\n", meta.MinNodes)
 	}
+	if len(meta.Zones) > 0 {
+		ew.printf("Zones:       %s\n", strings.Join(meta.Zones, ", "))
+	}
+	if p := packerName(recs); p != "" {
+		ew.printf("Packer:      %s\n", p)
+	}
 	ew.printf("%s\n\n", strings.Repeat("=", 60))
 
+	for _, w := range meta.Warnings {
+		ew.printf("Warning: %s\n", w)
+	}
+	if len(meta.Warnings) > 0 {
+		ew.printf("\n")
+	}
+
 	if len(recs) == 0 {
 		ew.printf("No recommendations available.\n")
 		return ew.err
@@ -64,9 +83,27 @@ func (r *TableReporter) Report(_ context.Context, recs []model.Recommendation, m
 		if len(sr.UnschedulablePods) > 0 {
 			notes += fmt.Sprintf(" [%d unschedulable]", len(sr.UnschedulablePods))
 		}
+		if sr.ConstraintOverhead != nil && sr.ConstraintOverhead.ExtraNodes > 0 {
+			notes += fmt.Sprintf(" [+%d nodes for spread]", sr.ConstraintOverhead.ExtraNodes)
+		}
+		if sr.FailureHeadroom != nil && !sr.FailureHeadroom.N1.Survives {
+			notes += " [no N+1]"
+		}
+		if zf := sr.WorstZoneFailure(); zf != nil && !zf.Fits {
+			notes += fmt.Sprintf(" [AZ loss: %d unschedulable]", zf.UnschedulablePods)
+		}
 		if sr.ScalingEfficiency != nil && sr.ScalingEfficiency.EstTroughCPUUtil < 0.30 {
 			notes += fmt.Sprintf(" [trough: %.0f%%]", sr.ScalingEfficiency.EstTroughCPUUtil*100)
 		}
+		switch sr.Optimality {
+		case model.OptimalityOptimal:
+			notes += " [optimal]"
+		case model.OptimalityBestFound:
+			notes += " [best found]"
+		}
+		if lb := sr.LowerBound; lb != nil && lb.NodeGap > 0 {
+			notes += fmt.Sprintf(" [+%d vs bound]", lb.NodeGap)
+		}
 
 		ew.printf("#%-3d %-30s %6d %6.1f%% %6.1f%% %6.1f %8.0f %s\n",
 			rec.Rank,
@@ -88,9 +125,34 @@ func (r *TableReporter) Report(_ context.Context, recs []model.Recommendation, m
 	ew.printf("\nRecommended: %s\n", topSR.InstanceConfig.Label())
 	ew.printf("  Nodes:          %d\n", topSR.TotalNodes)
 	ew.printf("  Monthly cost:   $%.0f\n", top.MonthlyCost)
+	if topSR.CommittedCost != nil {
+		ew.printf("  Commitments:    %s\n", describeCommitments(top))
+	}
 	ew.printf("  CPU util:       %.1f%%\n", topSR.AvgCPUUtilization*100)
 	ew.printf("  Memory util:    %.1f%%\n", topSR.AvgMemUtilization*100)
 	ew.printf("  Balance score:  %.2f\n", topSR.Fragmentation.ResourceBalanceScore)
+	if co := topSR.ConstraintOverhead; co != nil {
+		ew.printf("  Spread cost:    %d extra nodes ($%.0f/mo) vs %d unconstrained\n",
+			co.ExtraNodes, co.ExtraMonthlyCost, co.UnconstrainedNodes)
+	}
+	if fh := topSR.FailureHeadroom; fh != nil {
+		ew.printf("  Node failure:   %s\n", describeHeadroom(fh))
+	}
+	if zones := formatZoneCounts(topSR); zones != "" {
+		ew.printf("  Zones:          %s\n", zones)
+	}
+	if zf := topSR.WorstZoneFailure(); zf != nil {
+		ew.printf("  Zone loss:      %s\n", describeZoneLoss(zf))
+	}
+	if sd := topSR.SpotDisruption; sd != nil {
+		ew.printf("  Spot risk:      %s\n", describeSpotDisruption(sd))
+	}
+	if lb := topSR.LowerBound; lb != nil {
+		ew.printf("  Lower bound:    %s\n", describeLowerBound(lb))
+	}
+	if opt := describeOptimality(topSR); opt != "" {
+		ew.printf("  Optimality:     %s\n", opt)
+	}
 
 	if top.AnnualSavings > 0 {
 		ew.printf("  Annual savings: $%.0f\n", top.AnnualSavings)
@@ -103,6 +165,66 @@ func (r *TableReporter) Report(_ context.Context, recs []model.Recommendation, m
 		}
 	}
 
+	if len(meta.NodePools) > 0 {
+		ew.printf("\nSuggested NodePool requirements:\n")
+		for _, np := range meta.NodePools {
+			ew.printf("%s", formatNodePool(np, "  "))
+		}
+	}
+
+	if len(meta.Replays) > 0 {
+		first := meta.Replays[0]
+		ew.printf("\nAutoscaling replay (%s, %d steps of %s):\n", first.Policy, first.Steps, first.Step)
+		ew.printf("  %-30s %17s %9s %8s %9s %7s\n",
+			"Configuration", "Nodes min/avg/max", "Node-hrs", "$/month", "vs static", "Pending")
+		for _, rp := range meta.Replays {
+			label := rp.InstanceConfig.Label()
+			if len(label) > 30 {
+				label = label[:27] + "..."
+			}
+			ew.printf("  %-30s %17s %9.0f %8.0f %9s %7d\n",
+				label, describeReplayNodes(rp), rp.NodeHours, rp.MonthlyCost, describeReplayDelta(rp), rp.PeakPendingPods)
+		}
+	}
+
+	if len(meta.PackerComparisons) > 0 {
+		ew.printf("\nPacker comparison (node delta vs %s):\n", meta.PackerComparisons[0].Runs[0].Algorithm)
+		ew.printf("  %-30s %-22s %6s %6s %8s %7s\n",
+			"Configuration", "Packer", "Nodes", "Delta", "$/month", "Unsched")
+		for _, pc := range meta.PackerComparisons {
+			label := pc.InstanceConfig.Label()
+			if len(label) > 30 {
+				label = label[:27] + "..."
+			}
+			for i, run := range pc.Runs {
+				if i > 0 {
+					label = ""
+				}
+				ew.printf("  %-30s %-22s %6d %6s %8.0f %7d\n",
+					label, run.Algorithm, run.Nodes, describeNodeDelta(run, i == 0), run.MonthlyCost, run.UnschedulablePods)
+			}
+		}
+	}
+
+	if len(meta.ReplicaLevels) > 0 {
+		ew.printf("\nNodes by replica level:\n")
+		ew.printf("  %-30s %-22s %6s %6s %8s %7s\n",
+			"Configuration", "Replicas", "Pods", "Nodes", "$/month", "Unsched")
+		for _, rl := range meta.ReplicaLevels {
+			label := rl.InstanceConfig.Label()
+			if len(label) > 30 {
+				label = label[:27] + "..."
+			}
+			for i, run := range rl.Runs {
+				if i > 0 {
+					label = ""
+				}
+				ew.printf("  %-30s %-22s %6d %6d %8.0f %7d\n",
+					label, describeReplicaLevel(run.Level), run.Pods, run.Nodes, run.MonthlyCost, run.UnschedulablePods)
+			}
+		}
+	}
+
 	// Workload classification and architecture alternatives
 	if meta.WorkloadClass != "" {
 		ew.printf("\nWorkload profile: %s (%.1f GiB/vCPU)\n", meta.WorkloadClass, meta.GiBPerVCPU)
@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"sort"
 
@@ -12,7 +13,7 @@ import (
 type BestFitDecreasing struct{}
 
 // Name returns the strategy name.
-func (b *BestFitDecreasing) Name() string { return "best-fit-decreasing" }
+func (b *BestFitDecreasing) Name() string { return AlgorithmBestFit }
 
 // nodeState tracks the current allocation state of a node during packing.
 type nodeState struct {
@@ -21,14 +22,43 @@ type nodeState struct {
 	remainingCPU int64
 	remainingMem int64
 	podCount     int32
+
+	// Topology bookkeeping for affinity and spread rules
+	labels map[string]string // node labels including a synthetic hostname
+	groups map[string]int    // pods placed per replica group (WorkloadProfile.GroupKey)
+
+	// Spot segregation: with a spot ratio, pods that do not tolerate spot
+	// (model.WorkloadProfile.ToleratesSpot) only share nodes with each other
+	segregateSpot bool
+	intolerant    int32 // pods placed that do not tolerate spot
 }
 
 // Pack places workloads onto nodes using the BFD algorithm.
 func (b *BestFitDecreasing) Pack(ctx context.Context, input PackInput) (*PackResult, error) {
+	return packGreedy(ctx, input, bestFit)
+}
+
+// nodeChooser returns the index of the existing node a workload should go
+// to, or -1 to open a new node for it.
+type nodeChooser func(nodes []nodeState, w *model.WorkloadProfile, zones []string) int
+
+// packGreedy places the workloads largest first, each on the node chosen by
+// choose or on a new node of the cheapest template that fits it.
+func packGreedy(ctx context.Context, input PackInput, choose nodeChooser) (*PackResult, error) {
+	input = input.expanded()
 	if len(input.NodeTemplates) == 0 {
 		return &PackResult{UnschedulablePods: input.Workloads}, nil
 	}
+	nodes, unschedulable, err := placeGreedy(ctx, input, choose)
+	if err != nil {
+		return nil, err
+	}
+	return finishPack(nodes, unschedulable, input), nil
+}
 
+// placeGreedy runs the greedy placement loop and returns the opened nodes
+// and the workloads that could not be placed.
+func placeGreedy(ctx context.Context, input PackInput, choose nodeChooser) ([]nodeState, []model.WorkloadProfile, error) {
 	// Pre-compute DaemonSet overhead (applied to every node)
 	dsOverhead := model.SumEffectiveResources(input.DaemonSets)
 
@@ -42,53 +72,54 @@ func (b *BestFitDecreasing) Pack(ctx context.Context, input PackInput) (*PackRes
 
 	for i := range workloads {
 		if ctx.Err() != nil {
-			return nil, ctx.Err()
+			return nil, nil, ctx.Err()
 		}
 
 		w := &workloads[i]
 
-		// Find the best-fitting existing node
-		bestIdx := -1
-		bestScore := math.MaxFloat64
-
-		for j := range nodes {
-			if !canFit(&nodes[j], w) {
-				continue
-			}
-			score := compositeRemaining(&nodes[j], w)
-			if score < bestScore {
-				bestScore = score
-				bestIdx = j
-			}
-		}
-
-		if bestIdx >= 0 {
-			place(&nodes[bestIdx], w)
+		// Find an existing node that fits
+		if idx := choose(nodes, w, input.Zones); idx >= 0 {
+			place(&nodes[idx], w)
 			continue
 		}
 
 		// No existing node fits — open a new one
 		if input.MaxNodes > 0 && len(nodes) >= input.MaxNodes {
-			unschedulable = append(unschedulable, *w)
+			unschedulable = append(unschedulable, markUnschedulable(*w, reasonMaxNodes))
 			continue
 		}
 
 		tmpl := selectBestTemplate(input.NodeTemplates, w, dsOverhead, input.SystemReserved)
 		if tmpl == nil {
-			unschedulable = append(unschedulable, *w)
+			reason := explainUnschedulable(input.NodeTemplates, w)
+			unschedulable = append(unschedulable, markUnschedulable(*w, reason))
 			continue
 		}
 
-		n := openNode(*tmpl, dsOverhead, input.SystemReserved)
+		n := openPackNode(input, *tmpl, dsOverhead)
+		if reason := assignZone(nodes, &n, w, input.Zones); reason != "" {
+			unschedulable = append(unschedulable, markUnschedulable(*w, reason))
+			continue
+		}
 		place(&n, w)
-		nodes = append(nodes, n)
+		nodes = addNode(nodes, n)
 	}
+	return nodes, unschedulable, nil
+}
 
+// finishPack pads the nodes to MinNodes, applies the spot ratio, and builds
+// the result.
+func finishPack(nodes []nodeState, unschedulable []model.WorkloadProfile, input PackInput) *PackResult {
 	// Pad to MinNodes if needed (HA constraint)
 	if input.MinNodes > 0 && len(nodes) < input.MinNodes {
+		dsOverhead := model.SumEffectiveResources(input.DaemonSets)
 		tmpl := cheapestTemplate(input.NodeTemplates)
 		for len(nodes) < input.MinNodes {
-			nodes = append(nodes, openNode(tmpl, dsOverhead, input.SystemReserved))
+			n := openNode(tmpl, dsOverhead, input.SystemReserved)
+			if len(input.Zones) > 0 {
+				n.labels[model.TopologyZone] = zonesByLoad(nodes, input.Zones)[0]
+			}
+			nodes = addNode(nodes, n)
 		}
 	}
 
@@ -97,7 +128,14 @@ func (b *BestFitDecreasing) Pack(ctx context.Context, input PackInput) (*PackRes
 		applySpotRatio(nodes, input.SpotRatio)
 	}
 
-	// Build result
+	return &PackResult{
+		Nodes:             buildAllocations(nodes),
+		UnschedulablePods: unschedulable,
+	}
+}
+
+// buildAllocations converts the packing state into node allocations.
+func buildAllocations(nodes []nodeState) []model.NodeAllocation {
 	allocations := make([]model.NodeAllocation, len(nodes))
 	for i, n := range nodes {
 		alloc := n.template.AllocatableResources()
@@ -110,6 +148,7 @@ func (b *BestFitDecreasing) Pack(ctx context.Context, input PackInput) (*PackRes
 			UsedCPU:   usedCPU,
 			UsedMem:   usedMem,
 			PodCount:  n.podCount,
+			Zone:      n.labels[model.TopologyZone],
 		}
 		if alloc.CPUMillis > 0 {
 			allocations[i].CPUUtilization = float64(usedCPU) / float64(alloc.CPUMillis)
@@ -118,11 +157,7 @@ func (b *BestFitDecreasing) Pack(ctx context.Context, input PackInput) (*PackRes
 			allocations[i].MemUtilization = float64(usedMem) / float64(alloc.MemoryBytes)
 		}
 	}
-
-	return &PackResult{
-		Nodes:             allocations,
-		UnschedulablePods: unschedulable,
-	}, nil
+	return allocations
 }
 
 // sortByDominance sorts workloads so the most demanding pods come first.
@@ -159,11 +194,45 @@ func largestNodeCapacity(templates []model.NodeTemplate) (int64, int64) {
 	return maxCPU, maxMem
 }
 
-// canFit checks whether workload w fits in node n (CPU, memory, and pod count).
+// bestFit returns the index of the node w fits most tightly, or -1 when no
+// node can take it.
+func bestFit(nodes []nodeState, w *model.WorkloadProfile, zones []string) int {
+	bestIdx := -1
+	bestScore := math.MaxFloat64
+	for j := range nodes {
+		if !canFit(&nodes[j], w) || topologyAllows(nodes, &nodes[j], w, zones) != "" {
+			continue
+		}
+		if score := compositeRemaining(&nodes[j], w); score < bestScore {
+			bestScore = score
+			bestIdx = j
+		}
+	}
+	return bestIdx
+}
+
+// canFit checks whether workload w fits in node n (CPU, memory, pod count,
+// and scheduling constraints).
 func canFit(n *nodeState, w *model.WorkloadProfile) bool {
 	return w.EffectiveCPUMillis <= n.remainingCPU &&
 		w.EffectiveMemoryBytes <= n.remainingMem &&
-		n.podCount < n.template.MaxPods
+		n.podCount < n.template.MaxPods &&
+		admits(&n.template, w) == "" &&
+		zoneMatches(n.labels, w) &&
+		spotCompatible(n, w)
+}
+
+// spotCompatible reports whether w may join the pods of n: on a node that
+// keeps spot-intolerant pods apart, only if it tolerates spot exactly when
+// they do.
+func spotCompatible(n *nodeState, w *model.WorkloadProfile) bool {
+	return !n.segregateSpot || n.podCount == 0 || (n.intolerant > 0) != w.ToleratesSpot()
+}
+
+// markUnschedulable returns a copy of w annotated with the reason it could not be placed.
+func markUnschedulable(w model.WorkloadProfile, reason string) model.WorkloadProfile {
+	w.UnschedulableReason = reason
+	return w
 }
 
 // compositeRemaining returns a scalar measuring how tightly packed a node would be
@@ -179,8 +248,8 @@ func compositeRemaining(n *nodeState, w *model.WorkloadProfile) float64 {
 	return math.Sqrt(cpuAfter*cpuAfter + memAfter*memAfter)
 }
 
-// selectBestTemplate picks the smallest instance type that fits the workload
-// after accounting for DaemonSet overhead and system reserved.
+// selectBestTemplate picks the cheapest instance type that admits the workload
+// and fits it after accounting for DaemonSet overhead and system reserved.
 func selectBestTemplate(
 	templates []model.NodeTemplate,
 	w *model.WorkloadProfile,
@@ -191,6 +260,9 @@ func selectBestTemplate(
 
 	for i := range templates {
 		t := &templates[i]
+		if admits(t, w) != "" {
+			continue
+		}
 		availCPU := t.AllocatableCPUMillis - dsOverhead.CPUMillis - sysReserved.CPUMillis
 		availMem := t.AllocatableMemoryBytes - dsOverhead.MemoryBytes - sysReserved.MemoryBytes
 
@@ -208,13 +280,72 @@ func selectBestTemplate(
 }
 
 // openNode creates a new nodeState with DaemonSet overhead and system reserved subtracted.
+// The node gets a placeholder hostname until it is added to the cluster.
 func openNode(tmpl model.NodeTemplate, dsOverhead, sysReserved model.ResourceQuantity) nodeState {
+	labels := tmpl.NodeLabels()
+	labels[model.TopologyHostname] = "new-node"
 	return nodeState{
 		template:     tmpl,
 		remainingCPU: tmpl.AllocatableCPUMillis - dsOverhead.CPUMillis - sysReserved.CPUMillis,
 		remainingMem: tmpl.AllocatableMemoryBytes - dsOverhead.MemoryBytes - sysReserved.MemoryBytes,
 		podCount:     0,
+		labels:       labels,
+		groups:       make(map[string]int),
+	}
+}
+
+// openPackNode opens a node for the packing of input. With a spot ratio, the
+// node keeps pods that do not tolerate spot apart from those that do, so that
+// the nodes of the tolerant pods can all be converted to spot.
+func openPackNode(input PackInput, tmpl model.NodeTemplate, dsOverhead model.ResourceQuantity) nodeState {
+	n := openNode(tmpl, dsOverhead, input.SystemReserved)
+	n.segregateSpot = input.SpotRatio > 0
+	return n
+}
+
+// addNode appends n to the cluster, assigning it a unique hostname.
+func addNode(nodes []nodeState, n nodeState) []nodeState {
+	n.labels[model.TopologyHostname] = fmt.Sprintf("node-%d", len(nodes))
+	return append(nodes, n)
+}
+
+// assignZone places a node about to be opened in a zone where w may run,
+// preferring the zones with the fewest nodes so the cluster stays balanced.
+// Without zones it only checks the topology rules. It returns an empty string
+// on success, or the reason no zone is eligible.
+func assignZone(nodes []nodeState, n *nodeState, w *model.WorkloadProfile, zones []string) string {
+	if len(zones) == 0 {
+		return topologyAllows(nodes, n, w, zones)
+	}
+
+	reason := reasonZone
+	for _, z := range zonesByLoad(nodes, zones) {
+		n.labels[model.TopologyZone] = z
+		if !zoneMatches(n.labels, w) {
+			continue
+		}
+		if r := topologyAllows(nodes, n, w, zones); r != "" {
+			reason = r
+			continue
+		}
+		return ""
 	}
+	return reason
+}
+
+// zonesByLoad returns the zones ordered by ascending node count, keeping the
+// configured order among zones with the same count.
+func zonesByLoad(nodes []nodeState, zones []string) []string {
+	counts := make(map[string]int, len(zones))
+	for j := range nodes {
+		counts[nodes[j].labels[model.TopologyZone]]++
+	}
+	ordered := make([]string, len(zones))
+	copy(ordered, zones)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return counts[ordered[i]] < counts[ordered[j]]
+	})
+	return ordered
 }
 
 // place puts a workload onto a node, updating remaining resources.
@@ -223,6 +354,12 @@ func place(n *nodeState, w *model.WorkloadProfile) {
 	n.remainingCPU -= w.EffectiveCPUMillis
 	n.remainingMem -= w.EffectiveMemoryBytes
 	n.podCount++
+	if !w.ToleratesSpot() {
+		n.intolerant++
+	}
+	if g := w.GroupKey(); g != "" {
+		n.groups[g]++
+	}
 }
 
 // cheapestTemplate returns the node template with the lowest on-demand price.
@@ -237,7 +374,10 @@ func cheapestTemplate(templates []model.NodeTemplate) model.NodeTemplate {
 }
 
 // applySpotRatio assigns CapacitySpot to the appropriate fraction of nodes.
-// Nodes with fewer, smaller workloads are preferred for spot.
+// Instance types interrupted least often are preferred for spot, then nodes
+// with fewer, smaller workloads. Nodes hosting pods that do not tolerate spot
+// (see model.WorkloadProfile.ToleratesSpot) are never converted; the packers
+// keep those pods on nodes of their own so the ratio can still be met.
 func applySpotRatio(nodes []nodeState, spotRatio float64) {
 	spotCount := int(math.Round(float64(len(nodes)) * spotRatio))
 	if spotCount <= 0 {
@@ -247,7 +387,8 @@ func applySpotRatio(nodes []nodeState, spotRatio float64) {
 		spotCount = len(nodes)
 	}
 
-	// Sort by suitability for spot (lower total resource usage = more suitable)
+	// Sort by suitability for spot (lower interruption rate, then lower
+	// total resource usage = more suitable)
 	indices := make([]int, len(nodes))
 	for i := range indices {
 		indices[i] = i
@@ -255,15 +396,30 @@ func applySpotRatio(nodes []nodeState, spotRatio float64) {
 	sort.SliceStable(indices, func(i, j int) bool {
 		ai := nodes[indices[i]]
 		aj := nodes[indices[j]]
+		if ri, rj := interruptionRate(&ai.template), interruptionRate(&aj.template); ri != rj {
+			return ri < rj
+		}
 		return (ai.template.AllocatableCPUMillis - ai.remainingCPU) <
 			(aj.template.AllocatableCPUMillis - aj.remainingCPU)
 	})
 
-	for i, idx := range indices {
-		if i < spotCount {
+	assigned := 0
+	for _, idx := range indices {
+		if assigned < spotCount && spotTolerant(&nodes[idx]) {
 			nodes[idx].template.CapacityType = model.CapacitySpot
+			assigned++
 		} else {
 			nodes[idx].template.CapacityType = model.CapacityOnDemand
 		}
 	}
 }
+
+// spotTolerant reports whether every workload on the node tolerates spot.
+func spotTolerant(n *nodeState) bool {
+	for i := range n.workloads {
+		if !n.workloads[i].ToleratesSpot() {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/guimove/clusterfit/internal/model"
@@ -498,6 +499,368 @@ func TestBFD_MinNodes_Zero(t *testing.T) {
 	}
 }
 
+func TestBFD_ArchitectureConstraint(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	arm := makeWorkload("arm-only", 500, 1*1024*1024*1024)
+	arm.Architecture = model.ArchARM64
+
+	amdTmpl := makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096)
+	amdTmpl.Architecture = model.ArchAMD64
+	armTmpl := makeTemplate("m7g.large", 2000, 8*1024*1024*1024, 29, 0.0816)
+	armTmpl.Architecture = model.ArchARM64
+
+	// Only amd64 offered → unschedulable with a reason
+	result, err := packer.Pack(context.Background(), PackInput{
+		Workloads:     []model.WorkloadProfile{arm},
+		NodeTemplates: []model.NodeTemplate{amdTmpl},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 1 {
+		t.Fatalf("expected 1 unschedulable, got %d", len(result.UnschedulablePods))
+	}
+	if !strings.Contains(result.UnschedulablePods[0].UnschedulableReason, "architecture") {
+		t.Errorf("unexpected reason %q", result.UnschedulablePods[0].UnschedulableReason)
+	}
+
+	// Mixed pool → lands on the arm64 node even though amd64 is open
+	result, err = packer.Pack(context.Background(), PackInput{
+		Workloads: []model.WorkloadProfile{
+			makeWorkload("any", 1000, 1*1024*1024*1024),
+			arm,
+		},
+		NodeTemplates: []model.NodeTemplate{amdTmpl, armTmpl},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range result.Nodes {
+		for _, w := range n.Workloads {
+			if w.Name == "arm-only" && n.Template.Architecture != model.ArchARM64 {
+				t.Errorf("arm-only pod placed on %s", n.Template.InstanceType)
+			}
+		}
+	}
+}
+
+func TestBFD_NodeSelectorConstraint(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	pinned := makeWorkload("pinned", 500, 1*1024*1024*1024)
+	pinned.NodeSelector = map[string]string{model.LabelInstanceType: "m5.xlarge"}
+
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{pinned, makeWorkload("free", 500, 1*1024*1024*1024)},
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096),
+			makeTemplate("m5.xlarge", 4000, 16*1024*1024*1024, 58, 0.192),
+		},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 0 {
+		t.Fatalf("expected 0 unschedulable, got %d", len(result.UnschedulablePods))
+	}
+	for _, n := range result.Nodes {
+		for _, w := range n.Workloads {
+			if w.Name == "pinned" && n.Template.InstanceType != "m5.xlarge" {
+				t.Errorf("pinned pod placed on %s", n.Template.InstanceType)
+			}
+		}
+	}
+
+	pinned.NodeSelector = map[string]string{"nvidia.com/gpu.present": "true"}
+	input.Workloads = []model.WorkloadProfile{pinned}
+	result, err = packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 1 {
+		t.Fatalf("expected 1 unschedulable, got %d", len(result.UnschedulablePods))
+	}
+	want := "node selector (nvidia.com/gpu.present=true)"
+	if !strings.Contains(result.UnschedulablePods[0].UnschedulableReason, want) {
+		t.Errorf("reason %q does not mention %q", result.UnschedulablePods[0].UnschedulableReason, want)
+	}
+}
+
+func TestBFD_TaintConstraint(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	gpuTmpl := makeTemplate("g5.xlarge", 4000, 16*1024*1024*1024, 58, 1.006)
+	gpuTmpl.Taints = []model.Taint{{Key: "nvidia.com/gpu", Value: "true", Effect: model.TaintNoSchedule}}
+
+	gpuPod := makeWorkload("trainer", 1000, 2*1024*1024*1024)
+	gpuPod.Tolerations = []string{"nvidia.com/gpu:NoSchedule"}
+
+	input := PackInput{
+		Workloads:     []model.WorkloadProfile{gpuPod, makeWorkload("web", 1000, 2*1024*1024*1024)},
+		NodeTemplates: []model.NodeTemplate{gpuTmpl},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(result.Nodes))
+	}
+	if len(result.UnschedulablePods) != 1 || result.UnschedulablePods[0].Name != "web" {
+		t.Fatalf("expected only 'web' to be unschedulable, got %+v", result.UnschedulablePods)
+	}
+	if result.UnschedulablePods[0].UnschedulableReason != reasonTaints {
+		t.Errorf("reason = %q, want %q", result.UnschedulablePods[0].UnschedulableReason, reasonTaints)
+	}
+
+	// PreferNoSchedule is soft and does not block placement
+	gpuTmpl.Taints[0].Effect = model.TaintPreferNoSchedule
+	input.NodeTemplates = []model.NodeTemplate{gpuTmpl}
+	result, err = packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 0 {
+		t.Errorf("expected 0 unschedulable with PreferNoSchedule, got %d", len(result.UnschedulablePods))
+	}
+}
+
+func TestBFD_SpotRatio_RespectsOnDemandSelector(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	pinned := makeWorkload("pinned", 1500, 1*1024*1024*1024)
+	pinned.NodeSelector = map[string]string{model.LabelCapacityType: string(model.CapacityOnDemand)}
+
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{pinned, makeWorkload("free", 1500, 1*1024*1024*1024)},
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096),
+		},
+		SpotRatio: 1.0,
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range result.Nodes {
+		if n.Workloads[0].Name == "pinned" && n.Template.CapacityType == model.CapacitySpot {
+			t.Error("node hosting on-demand-pinned pod was converted to spot")
+		}
+	}
+}
+
+// helper to create replicas of a Deployment
+func makeReplicas(owner string, n int, cpuMillis int64, memBytes int64) []model.WorkloadProfile {
+	replicas := make([]model.WorkloadProfile, n)
+	for i := range replicas {
+		replicas[i] = makeWorkload(owner, cpuMillis, memBytes)
+		replicas[i].OwnerKind = "Deployment"
+		replicas[i].OwnerName = owner
+	}
+	return replicas
+}
+
+func TestBFD_HostnameAntiAffinity(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	replicas := makeReplicas("api", 3, 200, 256*1024*1024)
+	for i := range replicas {
+		replicas[i].PodAntiAffinity = []model.PodAffinityTerm{{TopologyKey: model.TopologyHostname}}
+	}
+
+	input := PackInput{
+		Workloads: replicas,
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.xlarge", 4000, 16*1024*1024*1024, 58, 0.192),
+		},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes (one replica per host), got %d", len(result.Nodes))
+	}
+	for i, n := range result.Nodes {
+		if len(n.Workloads) != 1 {
+			t.Errorf("node %d hosts %d replicas, want 1", i, len(n.Workloads))
+		}
+	}
+
+	// With a node cap the extra replicas cannot be placed
+	input.MaxNodes = 2
+	result, err = packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 1 {
+		t.Fatalf("expected 1 unschedulable replica, got %d", len(result.UnschedulablePods))
+	}
+}
+
+func TestBFD_HostnameSpread(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	replicas := makeReplicas("web", 4, 200, 256*1024*1024)
+	for i := range replicas {
+		replicas[i].TopologySpread = []model.TopologySpreadConstraint{
+			{TopologyKey: model.TopologyHostname, MaxSkew: 1, Hard: true},
+		}
+	}
+
+	// Two large pods open two nodes before the replicas are packed
+	workloads := append([]model.WorkloadProfile{
+		makeWorkload("db-0", 3000, 1*1024*1024*1024),
+		makeWorkload("db-1", 3000, 1*1024*1024*1024),
+	}, replicas...)
+
+	input := PackInput{
+		Workloads: workloads,
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.xlarge", 4000, 16*1024*1024*1024, 58, 0.192),
+		},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(result.Nodes))
+	}
+	for i, n := range result.Nodes {
+		web := 0
+		for _, w := range n.Workloads {
+			if w.Name == "web" {
+				web++
+			}
+		}
+		if web != 2 {
+			t.Errorf("node %d hosts %d replicas, want 2 (maxSkew 1)", i, web)
+		}
+	}
+}
+
+func TestBFD_PodAffinity(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	cache := makeReplicas("cache", 1, 1500, 1*1024*1024*1024)
+	worker := makeReplicas("worker", 1, 1000, 1*1024*1024*1024)
+	worker[0].PodAffinity = []model.PodAffinityTerm{
+		{TopologyKey: model.TopologyHostname, Group: cache[0].GroupKey()},
+	}
+	filler := makeWorkload("filler", 1200, 1*1024*1024*1024)
+
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{cache[0], filler, worker[0]},
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.xlarge", 4000, 16*1024*1024*1024, 58, 0.192),
+		},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range result.Nodes {
+		hasCache, hasWorker := false, false
+		for _, w := range n.Workloads {
+			hasCache = hasCache || w.Name == "cache"
+			hasWorker = hasWorker || w.Name == "worker"
+		}
+		if hasWorker && !hasCache {
+			t.Error("worker placed on a node without its cache")
+		}
+	}
+}
+
+func TestBFD_ZonesBalanced(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	var workloads []model.WorkloadProfile
+	for i := 0; i < 6; i++ {
+		workloads = append(workloads, makeWorkload("app", 1500, 1*1024*1024*1024))
+	}
+
+	input := PackInput{
+		Workloads:     workloads,
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096)},
+		Zones:         []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+		MinNodes:      8,
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, n := range result.Nodes {
+		counts[n.Zone]++
+	}
+	// 8 nodes over 3 zones: 3/3/2
+	if counts["us-east-1a"] != 3 || counts["us-east-1b"] != 3 || counts["us-east-1c"] != 2 {
+		t.Errorf("unbalanced zones: %v", counts)
+	}
+}
+
+func TestBFD_ZoneSpread(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	replicas := makeReplicas("web", 3, 200, 256*1024*1024)
+	for i := range replicas {
+		replicas[i].TopologySpread = []model.TopologySpreadConstraint{
+			{TopologyKey: model.TopologyZone, MaxSkew: 1, Hard: true},
+		}
+	}
+
+	input := PackInput{
+		Workloads:     replicas,
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*1024*1024*1024, 58, 0.192)},
+		Zones:         []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 3 {
+		t.Fatalf("expected one node per zone, got %d nodes", len(result.Nodes))
+	}
+	seen := make(map[string]bool)
+	for _, n := range result.Nodes {
+		if seen[n.Zone] {
+			t.Errorf("zone %s hosts more than one replica", n.Zone)
+		}
+		seen[n.Zone] = true
+	}
+}
+
+func TestBFD_ZoneSelector(t *testing.T) {
+	packer := &BestFitDecreasing{}
+	pinned := makeWorkload("pinned", 500, 1*1024*1024*1024)
+	pinned.NodeSelector = map[string]string{model.TopologyZone: "us-east-1b"}
+
+	input := PackInput{
+		Workloads:     []model.WorkloadProfile{pinned},
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096)},
+		Zones:         []string{"us-east-1a", "us-east-1b"},
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 1 || result.Nodes[0].Zone != "us-east-1b" {
+		t.Fatalf("expected pinned pod on a us-east-1b node, got %+v", result.Nodes)
+	}
+
+	input.Zones = []string{"us-east-1a"}
+	result, err = packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 1 || result.UnschedulablePods[0].UnschedulableReason != reasonZone {
+		t.Errorf("expected pod unschedulable with %q, got %+v", reasonZone, result.UnschedulablePods)
+	}
+}
+
 func BenchmarkBFD_1500Pods(b *testing.B) {
 	var workloads []model.WorkloadProfile
 	for i := 0; i < 1500; i++ {
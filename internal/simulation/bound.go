@@ -0,0 +1,166 @@
+package simulation
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// HighOptimalityGap is the cost gap to the lower bound, as a fraction of the
+// bound, above which a packing is flagged as possibly far from optimal.
+const HighOptimalityGap = 0.15
+
+// Lower bound that sets the node count, as reported in model.LowerBound.
+const (
+	boundCPU        = "cpu"
+	boundMemory     = "memory"
+	boundPods       = "pods"
+	boundLargeItems = "large-items"
+	boundMinNodes   = "min-nodes"
+)
+
+// ComputeLowerBound returns a lower bound on the nodes and cost needed to
+// host the pods the packing placed, and the gap between the packing and the
+// bound. The node bound is the largest of:
+//
+//   - the CPU and memory requested over the capacity of the largest node;
+//   - the pod count over the largest MaxPods;
+//   - the large-item bound (Martello and Toth's L2) in each dimension, which
+//     counts pods too large to share a node with each other;
+//   - the scenario's MinNodes.
+//
+// The cost bound is the larger of the node bound at the cheapest node price
+// and the resources requested at the cheapest price per unit of each. It
+// returns nil when the scenario has no node templates.
+func ComputeLowerBound(input PackInput, result *PackResult) *model.LowerBound {
+	if len(input.NodeTemplates) == 0 {
+		return nil
+	}
+	dsOverhead := model.SumEffectiveResources(input.DaemonSets)
+
+	var cpu, mem []int64
+	var sumCPU, sumMem int64
+	for i := range result.Nodes {
+		for j := range result.Nodes[i].Workloads {
+			w := &result.Nodes[i].Workloads[j]
+			cpu = append(cpu, w.EffectiveCPUMillis)
+			mem = append(mem, w.EffectiveMemoryBytes)
+			sumCPU += w.EffectiveCPUMillis
+			sumMem += w.EffectiveMemoryBytes
+		}
+	}
+
+	// Largest capacity in each dimension, and cheapest price per node and
+	// per unit of each resource
+	var capCPU, capMem int64
+	var maxPods int32
+	nodePrice, cpuPrice, memPrice := math.MaxFloat64, math.MaxFloat64, math.MaxFloat64
+	for i := range input.NodeTemplates {
+		t := &input.NodeTemplates[i]
+		c := t.AllocatableCPUMillis - dsOverhead.CPUMillis - input.SystemReserved.CPUMillis
+		m := t.AllocatableMemoryBytes - dsOverhead.MemoryBytes - input.SystemReserved.MemoryBytes
+		if c <= 0 || m <= 0 {
+			continue
+		}
+		capCPU, capMem, maxPods = max(capCPU, c), max(capMem, m), max(maxPods, t.MaxPods)
+
+		price := blendedMonthlyPrice(t, input.SpotRatio)
+		nodePrice = min(nodePrice, price)
+		cpuPrice = min(cpuPrice, price/float64(c))
+		memPrice = min(memPrice, price/float64(m))
+	}
+	if capCPU == 0 {
+		return nil
+	}
+
+	lb := &model.LowerBound{}
+	raise := func(nodes int, binding string) {
+		if nodes > lb.Nodes {
+			lb.Nodes, lb.Binding = nodes, binding
+		}
+	}
+	raise(ceilDiv(sumCPU, capCPU), boundCPU)
+	raise(ceilDiv(sumMem, capMem), boundMemory)
+	if maxPods > 0 {
+		raise(ceilDiv(int64(len(cpu)), int64(maxPods)), boundPods)
+	}
+	raise(max(largeItemBound(cpu, capCPU), largeItemBound(mem, capMem)), boundLargeItems)
+	raise(input.MinNodes, boundMinNodes)
+
+	lb.MonthlyCost = max(
+		float64(lb.Nodes)*nodePrice,
+		float64(sumCPU)*cpuPrice,
+		float64(sumMem)*memPrice,
+	)
+
+	lb.NodeGap = max(0, len(result.Nodes)-lb.Nodes)
+	if lb.MonthlyCost > 0 {
+		lb.CostGap = max(0, (nodesMonthlyCost(result.Nodes)-lb.MonthlyCost)/lb.MonthlyCost*100)
+	}
+	return lb
+}
+
+// blendedMonthlyPrice returns the monthly price of a node of the template
+// when the given fraction of nodes runs on spot.
+func blendedMonthlyPrice(t *model.NodeTemplate, spotRatio float64) float64 {
+	spot := t.OnDemandPricePerHour
+	if t.SpotPricePerHour > 0 {
+		spot = t.SpotPricePerHour
+	}
+	return ((1-spotRatio)*t.OnDemandPricePerHour + spotRatio*spot) * model.HoursPerMonth
+}
+
+// largeItemBound returns Martello and Toth's L2 bound on the bins of the
+// given capacity needed to hold the sizes. For every threshold k up to half
+// the capacity, items larger than capacity-k each need their own bin, items
+// larger than half need one each too, and the items of at least k must
+// spill into new bins whatever the latter leave free.
+func largeItemBound(sizes []int64, capacity int64) int {
+	if len(sizes) == 0 || capacity <= 0 {
+		return 0
+	}
+	sorted := slices.Clone(sizes)
+	slices.Sort(sorted)
+	n := len(sorted)
+	prefix := make([]int64, n+1)
+	for i, s := range sorted {
+		prefix[i+1] = prefix[i] + s
+	}
+	// firstAbove returns the index of the first size greater than v
+	firstAbove := func(v int64) int {
+		return sort.Search(n, func(i int) bool { return sorted[i] > v })
+	}
+	half := firstAbove(capacity / 2) // sizes over half the capacity
+
+	best := 0
+	thresholds := append([]int64{0}, sorted[:half]...)
+	for i, k := range thresholds {
+		if i > 0 && k == thresholds[i-1] {
+			continue
+		}
+		huge := firstAbove(capacity - k) // sizes > capacity-k
+		small := sort.Search(n, func(j int) bool { return sorted[j] >= k })
+
+		n1 := n - huge
+		n2 := huge - half
+		sum2 := prefix[huge] - prefix[half]
+		sum3 := prefix[half] - prefix[small]
+
+		bound := n1 + n2
+		if spill := sum3 - (int64(n2)*capacity - sum2); spill > 0 {
+			bound += ceilDiv(spill, capacity)
+		}
+		best = max(best, bound)
+	}
+	return best
+}
+
+// ceilDiv returns a/b rounded up, for positive b.
+func ceilDiv(a, b int64) int {
+	if a <= 0 {
+		return 0
+	}
+	return int((a + b - 1) / b)
+}
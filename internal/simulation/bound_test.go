@@ -0,0 +1,134 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestLargeItemBound(t *testing.T) {
+	tests := []struct {
+		name  string
+		sizes []int64
+		want  int
+	}{
+		{"empty", nil, 0},
+		{"fits one bin", []int64{3, 3, 3}, 1},
+		{"large items", []int64{6, 6, 6, 1}, 3},
+		{"large and medium", []int64{7, 7, 4, 4, 4}, 4},
+		{"volume", []int64{5, 5, 5, 5, 5}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := largeItemBound(tt.sizes, 10); got != tt.want {
+				t.Errorf("largeItemBound(%v, 10) = %d, want %d", tt.sizes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeLowerBound_Binding(t *testing.T) {
+	tmpl := makeTemplate("m5.xlarge", 4000, 16*gib, 4, 0.2)
+	many := func(n int, cpu, mem int64) []model.WorkloadProfile {
+		ws := make([]model.WorkloadProfile, n)
+		for i := range ws {
+			ws[i] = makeWorkload("pod", cpu, mem)
+		}
+		return ws
+	}
+
+	tests := []struct {
+		name      string
+		workloads []model.WorkloadProfile
+		minNodes  int
+		nodes     int
+		binding   string
+	}{
+		{"cpu", many(3, 2000, gib), 0, 2, boundCPU},
+		{"memory", many(3, 100, 6*gib), 0, 2, boundMemory},
+		{"pods", many(9, 100, gib), 0, 3, boundPods},
+		{"large items", many(3, 2500, gib), 0, 3, boundLargeItems},
+		{"min nodes", many(1, 100, gib), 3, 3, boundMinNodes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := PackInput{
+				Workloads:     tt.workloads,
+				NodeTemplates: []model.NodeTemplate{tmpl},
+				MinNodes:      tt.minNodes,
+			}
+			result, err := (&BestFitDecreasing{}).Pack(context.Background(), input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lb := ComputeLowerBound(input, result)
+			if lb.Nodes != tt.nodes || lb.Binding != tt.binding {
+				t.Errorf("bound = %d nodes (%s), want %d (%s)", lb.Nodes, lb.Binding, tt.nodes, tt.binding)
+			}
+			if lb.NodeGap != 0 || lb.CostGap != 0 {
+				t.Errorf("gap = %d nodes, %.1f%%, want none for an optimal packing", lb.NodeGap, lb.CostGap)
+			}
+		})
+	}
+}
+
+func TestComputeLowerBound_Gap(t *testing.T) {
+	tmpl := makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.2)
+	input := PackInput{NodeTemplates: []model.NodeTemplate{tmpl}}
+
+	// Two half-empty nodes where one would do
+	result := &PackResult{Nodes: []model.NodeAllocation{
+		{Template: tmpl, Workloads: []model.WorkloadProfile{makeWorkload("a", 1000, gib)}},
+		{Template: tmpl, Workloads: []model.WorkloadProfile{makeWorkload("b", 1000, gib)}},
+	}}
+	lb := ComputeLowerBound(input, result)
+	if lb.Nodes != 1 || lb.NodeGap != 1 {
+		t.Errorf("bound %d nodes, gap %d, want 1 and 1", lb.Nodes, lb.NodeGap)
+	}
+	if math.Abs(lb.MonthlyCost-0.2*model.HoursPerMonth) > 0.01 || math.Abs(lb.CostGap-100) > 0.01 {
+		t.Errorf("cost bound $%.2f, gap %.1f%%, want one node and 100%%", lb.MonthlyCost, lb.CostGap)
+	}
+
+	sr := makeSimResult(result.Nodes[0].Template.MonthlyCost()*2, 0.25, 0.06, 2)
+	sr.LowerBound = lb
+	warnings := generateWarnings(sr)
+	if !containsSubstring(warnings, "lower bound") {
+		t.Errorf("warnings = %v, want the optimality gap flagged", warnings)
+	}
+}
+
+func TestComputeLowerBound_MixedAndSpot(t *testing.T) {
+	small := makeTemplate("m5.large", 2000, 8*gib, 29, 0.1)
+	large := makeTemplate("m5.2xlarge", 8000, 32*gib, 58, 0.4)
+	large.SpotPricePerHour = 0.2
+	input := PackInput{
+		Workloads:     []model.WorkloadProfile{makeWorkload("a", 3000, gib), makeWorkload("b", 3000, gib)},
+		NodeTemplates: []model.NodeTemplate{small, large},
+		SpotRatio:     0.5,
+	}
+	result, err := (&BestFitDecreasing{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := ComputeLowerBound(input, result)
+	if lb.Nodes != 1 {
+		t.Errorf("bound = %d nodes, want 1 large node", lb.Nodes)
+	}
+	// 6 vCPU at the cheapest price per vCPU: the large node at half spot
+	want := 6000 * (0.3 * model.HoursPerMonth / 8000)
+	if math.Abs(lb.MonthlyCost-want) > 0.01 {
+		t.Errorf("cost bound = $%.2f, want $%.2f", lb.MonthlyCost, want)
+	}
+}
+
+func containsSubstring(values []string, sub string) bool {
+	for _, v := range values {
+		if strings.Contains(v, sub) {
+			return true
+		}
+	}
+	return false
+}
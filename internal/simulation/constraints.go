@@ -0,0 +1,249 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// Unschedulable reasons reported on pods the packer could not place.
+const (
+	reasonArchitecture = "no candidate instance type matches required architecture"
+	reasonNodeSelector = "no candidate instance type matches node selector"
+	reasonTaints       = "no candidate instance type without untolerated taints"
+	reasonTooLarge     = "exceeds allocatable capacity of every candidate instance type"
+	reasonMaxNodes     = "max node count reached"
+	reasonAffinity     = "pod affinity cannot be satisfied"
+	reasonAntiAffinity = "pod anti-affinity leaves no eligible node"
+	reasonSpread       = "topology spread constraint cannot be satisfied"
+	reasonZone         = "no simulated zone matches node selector"
+)
+
+// admits reports whether a pod may be scheduled on a node of the given template,
+// considering required architecture, node selector, and taints. It returns an
+// empty string when the placement is allowed, or the violated constraint otherwise.
+// The zone is a property of the node rather than the template and is checked
+// separately by zoneMatches.
+func admits(t *model.NodeTemplate, w *model.WorkloadProfile) string {
+	if w.Architecture != "" && t.Architecture != "" && w.Architecture != t.Architecture {
+		return reasonArchitecture
+	}
+	if len(w.NodeSelector) > 0 {
+		labels := t.NodeLabels()
+		for k, v := range w.NodeSelector {
+			if k == model.TopologyZone {
+				continue
+			}
+			if labels[k] != v {
+				return reasonNodeSelector
+			}
+		}
+	}
+	for _, taint := range t.Taints {
+		if taint.Blocks() && !w.Tolerates(taint) {
+			return reasonTaints
+		}
+	}
+	return ""
+}
+
+// explainUnschedulable returns a human-readable reason why no template can host w.
+// Constraint violations take precedence over capacity, and the message lists
+// the offending requirement so the report is actionable.
+func explainUnschedulable(templates []model.NodeTemplate, w *model.WorkloadProfile) string {
+	violations := make(map[string]bool)
+	for i := range templates {
+		reason := admits(&templates[i], w)
+		if reason == "" {
+			return reasonTooLarge
+		}
+		violations[reason] = true
+	}
+
+	switch {
+	case violations[reasonArchitecture] && len(violations) == 1:
+		return fmt.Sprintf("%s (%s)", reasonArchitecture, w.Architecture)
+	case violations[reasonNodeSelector] && len(violations) == 1:
+		return fmt.Sprintf("%s (%s)", reasonNodeSelector, formatSelector(w.NodeSelector))
+	case violations[reasonTaints] && len(violations) == 1:
+		return reasonTaints
+	}
+
+	reasons := make([]string, 0, len(violations))
+	for r := range violations {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+	return strings.Join(reasons, "; ")
+}
+
+// zoneMatches reports whether a node's zone satisfies the workload's zone
+// selector. Nodes without a zone (zone-agnostic simulation) match any selector.
+func zoneMatches(labels map[string]string, w *model.WorkloadProfile) bool {
+	want, ok := w.NodeSelector[model.TopologyZone]
+	if !ok {
+		return true
+	}
+	zone, modeled := labels[model.TopologyZone]
+	return !modeled || zone == want
+}
+
+// formatSelector renders a node selector deterministically as k=v,k=v.
+func formatSelector(sel map[string]string) string {
+	keys := make([]string, 0, len(sel))
+	for k := range sel {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + sel[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+// topologyAllows checks the pod affinity, anti-affinity and hard topology spread
+// rules of w against placing it on candidate, given the nodes already in the
+// cluster (candidate may be one of them or a node about to be opened) and the
+// simulated zones. It returns an empty string when placement is allowed, or
+// the violated rule.
+//
+// Only the workload's own terms are evaluated; anti-affinity declared by pods
+// already placed is not applied symmetrically. Affinity to a group with no
+// placed pods is treated as satisfied, since that group may be packed later.
+// Terms whose topology key is not modeled on the node (e.g. zones when the
+// simulation has none) are ignored.
+func topologyAllows(nodes []nodeState, candidate *nodeState, w *model.WorkloadProfile, zones []string) string {
+	if !w.HasTopologyConstraints() {
+		return ""
+	}
+	self := w.GroupKey()
+
+	for _, term := range w.PodAntiAffinity {
+		target := termGroup(term, self)
+		domain, ok := candidate.labels[term.TopologyKey]
+		if target == "" || !ok {
+			continue
+		}
+		if domainCount(nodes, candidate, term.TopologyKey, domain, target) > 0 {
+			return reasonAntiAffinity
+		}
+	}
+
+	for _, term := range w.PodAffinity {
+		target := termGroup(term, self)
+		if target == "" || groupTotal(nodes, target) == 0 {
+			continue
+		}
+		domain, ok := candidate.labels[term.TopologyKey]
+		if ok && domainCount(nodes, candidate, term.TopologyKey, domain, target) == 0 {
+			return reasonAffinity
+		}
+	}
+
+	for _, sc := range w.TopologySpread {
+		if !sc.Hard || self == "" {
+			continue
+		}
+		domain, ok := candidate.labels[sc.TopologyKey]
+		if !ok {
+			continue
+		}
+		counts := spreadDomains(nodes, candidate, w, sc.TopologyKey, self, zones)
+		minCount := math.MaxInt
+		for _, c := range counts {
+			if c < minCount {
+				minCount = c
+			}
+		}
+		if counts[domain]+1-minCount > int(sc.MaxSkew) {
+			return reasonSpread
+		}
+	}
+
+	return ""
+}
+
+// termGroup resolves the target group of an affinity term.
+func termGroup(term model.PodAffinityTerm, self string) string {
+	if term.Group != "" {
+		return term.Group
+	}
+	return self
+}
+
+// domainCount returns the number of pods of group placed in the given topology
+// domain, counting the candidate node when it is not yet part of nodes.
+func domainCount(nodes []nodeState, candidate *nodeState, key, domain, group string) int {
+	count := 0
+	seenCandidate := false
+	for j := range nodes {
+		if &nodes[j] == candidate {
+			seenCandidate = true
+		}
+		if nodes[j].labels[key] == domain {
+			count += nodes[j].groups[group]
+		}
+	}
+	if !seenCandidate {
+		count += candidate.groups[group]
+	}
+	return count
+}
+
+// groupTotal returns the number of pods of group placed anywhere in the cluster.
+func groupTotal(nodes []nodeState, group string) int {
+	total := 0
+	for j := range nodes {
+		total += nodes[j].groups[group]
+	}
+	return total
+}
+
+// spreadDomains returns the per-domain pod count of group over every eligible
+// domain: domains of existing nodes that admit w, plus the candidate's domain.
+// For zone spread every simulated zone the pod may run in is eligible, since
+// node pools span all zones and can scale up in an empty one.
+func spreadDomains(nodes []nodeState, candidate *nodeState, w *model.WorkloadProfile, key, group string, zones []string) map[string]int {
+	counts := make(map[string]int)
+	if key == model.TopologyZone {
+		for _, z := range zones {
+			if zoneMatches(map[string]string{model.TopologyZone: z}, w) {
+				counts[z] = 0
+			}
+		}
+	}
+	for j := range nodes {
+		domain, ok := nodes[j].labels[key]
+		if !ok || admits(&nodes[j].template, w) != "" || !zoneMatches(nodes[j].labels, w) {
+			continue
+		}
+		counts[domain] += nodes[j].groups[group]
+	}
+	if domain, ok := candidate.labels[key]; ok {
+		if _, seen := counts[domain]; !seen {
+			counts[domain] = candidate.groups[group]
+		}
+	}
+	return counts
+}
+
+// stripTopologyConstraints returns copies of the workloads without affinity,
+// anti-affinity or spread rules, used to measure their node-count overhead.
+func stripTopologyConstraints(workloads []model.WorkloadProfile) ([]model.WorkloadProfile, bool) {
+	stripped := make([]model.WorkloadProfile, len(workloads))
+	found := false
+	for i := range workloads {
+		stripped[i] = workloads[i]
+		if workloads[i].HasTopologyConstraints() {
+			found = true
+			stripped[i].PodAffinity = nil
+			stripped[i].PodAntiAffinity = nil
+			stripped[i].TopologySpread = nil
+		}
+	}
+	return stripped, found
+}
@@ -0,0 +1,137 @@
+package simulation
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// StrategyCrossFamily labels scenarios pooling every size of several
+// instance families, as a Karpenter NodePool spanning them would.
+const StrategyCrossFamily = "cross-family"
+
+// CrossFamilyOptions bounds the pools GenerateCrossFamilyScenarios builds.
+type CrossFamilyOptions struct {
+	MaxFamilies     int // families per pool, from 2 up to this
+	MaxCombinations int // pools returned
+}
+
+// familyCombination is a candidate combination of instance families.
+type familyCombination struct {
+	families []string
+	score    float64
+}
+
+// GenerateCrossFamilyScenarios builds mixed scenarios across instance
+// families: every combination of 2 to MaxFamilies families, each holding
+// all sizes of its families. Families of different architectures may share
+// a pool; pods requiring one architecture only land on its nodes.
+//
+// There are too many combinations to simulate them all, so they are ranked
+// by how cheaply the pool can buy CPU and memory: the pool's lowest price
+// per vCPU and per GiB across its families, each relative to the lowest of
+// any family, summed. A pool of a CPU-optimized and a memory-optimized
+// family buys each resource from the family cheapest at it; a pool of
+// similar families, such as m6i, m6a and m7i, diversifies spot capacity at
+// a similar price. Pools of each size are taken in turn, cheapest first,
+// until MaxCombinations are chosen.
+func GenerateCrossFamilyScenarios(templates []model.NodeTemplate, opts CrossFamilyOptions, spotRatio float64, minNodes int) []Scenario {
+	byFamily := make(map[string][]model.NodeTemplate)
+	cpuPrice := make(map[string]float64)
+	memPrice := make(map[string]float64)
+	for i := range templates {
+		t := &templates[i]
+		if t.InstanceFamily == "" || t.AllocatableCPUMillis <= 0 || t.AllocatableMemoryBytes <= 0 {
+			continue
+		}
+		f := t.InstanceFamily
+		if _, ok := byFamily[f]; !ok {
+			cpuPrice[f], memPrice[f] = math.MaxFloat64, math.MaxFloat64
+		}
+		byFamily[f] = append(byFamily[f], *t)
+		price := blendedMonthlyPrice(t, spotRatio)
+		cpuPrice[f] = min(cpuPrice[f], price/float64(t.AllocatableCPUMillis))
+		memPrice[f] = min(memPrice[f], price/float64(t.AllocatableMemoryBytes))
+	}
+
+	families := make([]string, 0, len(byFamily))
+	for f := range byFamily {
+		families = append(families, f)
+	}
+	slices.Sort(families)
+	if len(families) < 2 || opts.MaxFamilies < 2 || opts.MaxCombinations <= 0 {
+		return nil
+	}
+	bestCPU, bestMem := math.MaxFloat64, math.MaxFloat64
+	for _, f := range families {
+		bestCPU, bestMem = min(bestCPU, cpuPrice[f]), min(bestMem, memPrice[f])
+	}
+
+	// Rank the pools of each size
+	maxSize := min(opts.MaxFamilies, len(families))
+	bySize := make([][]familyCombination, maxSize+1)
+	for size := 2; size <= maxSize; size++ {
+		combinations(families, size, func(pool []string) {
+			poolCPU, poolMem := math.MaxFloat64, math.MaxFloat64
+			for _, f := range pool {
+				poolCPU, poolMem = min(poolCPU, cpuPrice[f]), min(poolMem, memPrice[f])
+			}
+			bySize[size] = append(bySize[size], familyCombination{
+				families: slices.Clone(pool),
+				score:    poolCPU/bestCPU + poolMem/bestMem,
+			})
+		})
+		slices.SortStableFunc(bySize[size], func(a, b familyCombination) int {
+			return cmp.Compare(a.score, b.score)
+		})
+	}
+
+	// Take the best remaining pool of each size in turn
+	var scenarios []Scenario
+	for rank := 0; len(scenarios) < opts.MaxCombinations; rank++ {
+		added := false
+		for size := 2; size <= maxSize && len(scenarios) < opts.MaxCombinations; size++ {
+			if rank >= len(bySize[size]) {
+				continue
+			}
+			pool := bySize[size][rank]
+			var types []model.NodeTemplate
+			for _, f := range pool.families {
+				types = append(types, byFamily[f]...)
+			}
+			scenarios = append(scenarios, Scenario{
+				Name:          "cross-family-" + strings.Join(pool.families, "+"),
+				InstanceTypes: types,
+				Strategy:      StrategyCrossFamily,
+				SpotRatio:     spotRatio,
+				MinNodes:      minNodes,
+			})
+			added = true
+		}
+		if !added {
+			break
+		}
+	}
+	return scenarios
+}
+
+// combinations calls fn with every combination of size elements of items,
+// in lexicographic order. The slice passed to fn is reused between calls.
+func combinations(items []string, size int, fn func([]string)) {
+	combo := make([]string, size)
+	var walk func(start, depth int)
+	walk = func(start, depth int) {
+		if depth == size {
+			fn(combo)
+			return
+		}
+		for i := start; i <= len(items)-(size-depth); i++ {
+			combo[depth] = items[i]
+			walk(i+1, depth+1)
+		}
+	}
+	walk(0, 0)
+}
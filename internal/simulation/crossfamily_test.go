@@ -0,0 +1,96 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// familyTemplates returns a large and an xlarge size of a family, priced
+// per hour for the large size.
+func familyTemplates(family string, memPerCPU int64, price float64) []model.NodeTemplate {
+	var types []model.NodeTemplate
+	for i, size := range []string{"large", "xlarge"} {
+		cpu := int64(2000 << i)
+		t := makeTemplate(family+"."+size, cpu, cpu/1000*memPerCPU*gib, 29, price*float64(int(1)<<i))
+		t.InstanceFamily = family
+		types = append(types, t)
+	}
+	return types
+}
+
+func crossFamilyTemplates() []model.NodeTemplate {
+	var templates []model.NodeTemplate
+	templates = append(templates, familyTemplates("c6i", 2, 0.085)...)
+	templates = append(templates, familyTemplates("m6i", 4, 0.096)...)
+	templates = append(templates, familyTemplates("m6a", 4, 0.086)...)
+	templates = append(templates, familyTemplates("r6i", 8, 0.126)...)
+	return templates
+}
+
+func TestGenerateCrossFamilyScenarios(t *testing.T) {
+	scenarios := GenerateCrossFamilyScenarios(crossFamilyTemplates(), CrossFamilyOptions{MaxFamilies: 3, MaxCombinations: 4}, 0.5, 2)
+	if len(scenarios) != 4 {
+		t.Fatalf("got %d scenarios, want 4", len(scenarios))
+	}
+
+	// Pools of two and three families alternate, best priced first: CPU from
+	// c6i and memory from r6i
+	if scenarios[0].Name != "cross-family-c6i+r6i" {
+		t.Errorf("first pool = %s, want cross-family-c6i+r6i", scenarios[0].Name)
+	}
+	for i, s := range scenarios {
+		families := make(map[string]bool)
+		for _, it := range s.InstanceTypes {
+			families[it.InstanceFamily] = true
+		}
+		if want := 2 + i%2; len(families) != want || len(s.InstanceTypes) != 2*want {
+			t.Errorf("%s: %d families, %d types, want %d families of two sizes", s.Name, len(families), len(s.InstanceTypes), want)
+		}
+		if s.Strategy != StrategyCrossFamily || s.SpotRatio != 0.5 || s.MinNodes != 2 {
+			t.Errorf("%s: strategy %s, spot %v, min nodes %d", s.Name, s.Strategy, s.SpotRatio, s.MinNodes)
+		}
+	}
+
+	label := model.InstanceConfig{InstanceTypes: scenarios[0].InstanceTypes, Strategy: StrategyCrossFamily}.Label()
+	if label != "c6i+r6i (cross-family)" {
+		t.Errorf("label = %q", label)
+	}
+
+	if got := GenerateCrossFamilyScenarios(familyTemplates("m6i", 4, 0.096), CrossFamilyOptions{MaxFamilies: 3, MaxCombinations: 4}, 0, 0); got != nil {
+		t.Errorf("single family: got %d scenarios, want none", len(got))
+	}
+}
+
+func TestCrossFamily_BeatsSingleFamily(t *testing.T) {
+	// CPU-heavy and memory-heavy pods: a c6i+r6i pool is cheaper than
+	// either family alone
+	input := PackInput{}
+	for i := range 4 {
+		input.Workloads = append(input.Workloads, makeWorkload("api-"+string(rune('a'+i)), 1900, gib))
+	}
+	for i := range 2 {
+		input.Workloads = append(input.Workloads, makeWorkload("cache-"+string(rune('a'+i)), 100, 7*gib))
+	}
+	cost := func(templates []model.NodeTemplate) float64 {
+		in := input
+		in.NodeTemplates = templates
+		res, err := (&BestFitDecreasing{}).Pack(context.Background(), in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.UnschedulablePods) > 0 {
+			t.Fatalf("%d unschedulable pods", len(res.UnschedulablePods))
+		}
+		return nodesMonthlyCost(res.Nodes)
+	}
+
+	pool := GenerateCrossFamilyScenarios(crossFamilyTemplates(), CrossFamilyOptions{MaxFamilies: 2, MaxCombinations: 1}, 0, 0)[0]
+	mixed := cost(pool.InstanceTypes)
+	for _, family := range [][]model.NodeTemplate{familyTemplates("c6i", 2, 0.085), familyTemplates("r6i", 8, 0.126)} {
+		if single := cost(family); mixed >= single {
+			t.Errorf("%s: $%.0f/mo, not cheaper than %s alone at $%.0f/mo", pool.Name, mixed, family[0].InstanceFamily, single)
+		}
+	}
+}
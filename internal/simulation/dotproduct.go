@@ -0,0 +1,55 @@
+package simulation
+
+import (
+	"context"
+	"math"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// DotProduct is a vector bin-packer: each workload, largest first, goes to
+// the node whose remaining capacity points in the same direction as the
+// workload's demand, by cosine similarity of the CPU and memory fractions.
+// CPU-heavy pods then fill the CPU left on memory-heavy nodes, keeping both
+// dimensions in balance.
+type DotProduct struct{}
+
+// Name returns the strategy name.
+func (d *DotProduct) Name() string { return AlgorithmDotProduct }
+
+// Pack places workloads onto nodes by cosine similarity.
+func (d *DotProduct) Pack(ctx context.Context, input PackInput) (*PackResult, error) {
+	return packGreedy(ctx, input, dotProductFit)
+}
+
+// dotProductFit returns the index of the node whose remaining capacity is
+// best aligned with w's demand, or -1 when no node can take it. Ties go to
+// the node with the least capacity left.
+func dotProductFit(nodes []nodeState, w *model.WorkloadProfile, zones []string) int {
+	bestIdx := -1
+	bestSim, bestRemaining := -1.0, math.MaxFloat64
+	for j := range nodes {
+		n := &nodes[j]
+		if !canFit(n, w) || topologyAllows(nodes, n, w, zones) != "" {
+			continue
+		}
+		alloc := n.template.AllocatableResources()
+		if alloc.CPUMillis == 0 || alloc.MemoryBytes == 0 {
+			continue
+		}
+		dCPU := float64(w.EffectiveCPUMillis) / float64(alloc.CPUMillis)
+		dMem := float64(w.EffectiveMemoryBytes) / float64(alloc.MemoryBytes)
+		rCPU := float64(n.remainingCPU) / float64(alloc.CPUMillis)
+		rMem := float64(n.remainingMem) / float64(alloc.MemoryBytes)
+
+		var sim float64
+		if norm := math.Hypot(dCPU, dMem) * math.Hypot(rCPU, rMem); norm > 0 {
+			sim = (dCPU*rCPU + dMem*rMem) / norm
+		}
+		remaining := math.Hypot(rCPU, rMem)
+		if sim > bestSim || (sim == bestSim && remaining < bestRemaining) {
+			bestIdx, bestSim, bestRemaining = j, sim, remaining
+		}
+	}
+	return bestIdx
+}
@@ -16,6 +16,7 @@ type Engine struct {
 	Packer      BinPacker
 	Scorer      *Scorer
 	Parallelism int
+	Commitments model.Commitments // RIs and Savings Plans applied to every result's cost
 }
 
 // NewEngine creates a simulation engine.
@@ -31,9 +32,10 @@ func NewEngine(packer BinPacker, scorer *Scorer) *Engine {
 type Scenario struct {
 	Name          string
 	InstanceTypes []model.NodeTemplate
-	Strategy      string  // "homogeneous" or "mixed"
+	Strategy      string  // "homogeneous", "mixed", "cross-family" or "karpenter"
 	SpotRatio     float64
 	MinNodes      int
+	Zones         []string // availability zones nodes are spread across; empty = zone-agnostic
 }
 
 // RunAll executes all scenarios and returns ranked recommendations.
@@ -97,16 +99,44 @@ func (e *Engine) runOne(
 ) (model.SimulationResult, error) {
 	start := time.Now()
 
-	input := PackInput{
-		Workloads:      state.Workloads,
-		DaemonSets:     state.DaemonSets,
-		NodeTemplates:  scenario.InstanceTypes,
-		SystemReserved: state.SystemReserved,
-		MinNodes:       scenario.MinNodes,
-		SpotRatio:      scenario.SpotRatio,
+	input := scenarioInput(scenario, state)
+	stripped, constrained := stripTopologyConstraints(state.Workloads)
+
+	// A search time budget covers every packing of the scenario
+	packer := e.Packer
+	if sharer, ok := packer.(budgetSharer); ok {
+		packings := 1
+		if constrained {
+			packings++
+		}
+		if len(input.Zones) >= 2 {
+			packings += len(input.Zones)
+		}
+		packer = sharer.share(packings)
 	}
 
-	result, err := e.Packer.Pack(ctx, input)
+	result, err := packer.Pack(ctx, input)
+	if err != nil {
+		return model.SimulationResult{}, fmt.Errorf("packing scenario %q: %w", scenario.Name, err)
+	}
+
+	// Measure what affinity/spread rules cost by re-packing without them
+	var overhead *model.ConstraintOverhead
+	if constrained {
+		freeInput := input
+		freeInput.Workloads = stripped
+		free, err := packer.Pack(ctx, freeInput)
+		if err != nil {
+			return model.SimulationResult{}, fmt.Errorf("packing scenario %q without topology constraints: %w", scenario.Name, err)
+		}
+		overhead = &model.ConstraintOverhead{
+			UnconstrainedNodes: len(free.Nodes),
+			ExtraNodes:         len(result.Nodes) - len(free.Nodes),
+			ExtraMonthlyCost:   nodesMonthlyCost(result.Nodes) - nodesMonthlyCost(free.Nodes),
+		}
+	}
+
+	zoneFailures, err := simulateZoneFailures(ctx, packer, input, result)
 	if err != nil {
 		return model.SimulationResult{}, fmt.Errorf("packing scenario %q: %w", scenario.Name, err)
 	}
@@ -115,9 +145,133 @@ func (e *Engine) runOne(
 
 	// Build simulation result
 	simResult := buildSimulationResult(result, scenario, duration, state.AggregateMetrics)
+	simResult.Algorithm = e.Packer.Name()
+	simResult.Optimality = result.Optimality
+	simResult.ConstraintOverhead = overhead
+	simResult.ZoneFailures = zoneFailures
+	// Peak-aware packing sizes nodes by combined usage over time, which the
+	// bound on effective sizes does not model
+	if _, peak := e.Packer.(*PeakAwareBestFit); !peak {
+		simResult.LowerBound = ComputeLowerBound(input, result)
+	}
+	if !e.Commitments.IsZero() {
+		cc := e.Commitments.Apply(nodeTemplates(result.Nodes))
+		simResult.CommittedCost = &cc
+	}
 	return simResult, nil
 }
 
+// scenarioInput returns the packing input for a scenario.
+func scenarioInput(scenario Scenario, state model.ClusterState) PackInput {
+	return PackInput{
+		Workloads:      state.Workloads,
+		DaemonSets:     state.DaemonSets,
+		NodeTemplates:  scenario.InstanceTypes,
+		SystemReserved: state.SystemReserved,
+		MinNodes:       scenario.MinNodes,
+		SpotRatio:      scenario.SpotRatio,
+		Zones:          scenario.Zones,
+	}
+}
+
+// ComparePackers packs the scenario with each packer, reporting node counts
+// against the first.
+func ComparePackers(ctx context.Context, packers []BinPacker, scenario Scenario, state model.ClusterState) ([]model.PackerRun, error) {
+	input := scenarioInput(scenario, state)
+	runs := make([]model.PackerRun, 0, len(packers))
+	for _, p := range packers {
+		start := time.Now()
+		result, err := p.Pack(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("packing scenario %q with %s: %w", scenario.Name, p.Name(), err)
+		}
+		run := model.PackerRun{
+			Algorithm:         p.Name(),
+			Nodes:             len(result.Nodes),
+			MonthlyCost:       nodesMonthlyCost(result.Nodes),
+			UnschedulablePods: len(result.UnschedulablePods),
+			Duration:          time.Since(start),
+		}
+		if len(runs) > 0 {
+			run.NodeDelta = run.Nodes - runs[0].Nodes
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// CompareReplicaLevels packs the scenario with controllers at each replica
+// level (see model.ClusterState.AtReplicas).
+func CompareReplicaLevels(ctx context.Context, packer BinPacker, scenario Scenario, state model.ClusterState, levels []string) ([]model.ReplicaLevelRun, error) {
+	runs := make([]model.ReplicaLevelRun, 0, len(levels))
+	for _, level := range levels {
+		scaled := state.AtReplicas(level)
+		result, err := packer.Pack(ctx, scenarioInput(scenario, scaled))
+		if err != nil {
+			return nil, fmt.Errorf("packing scenario %q at %s replicas: %w", scenario.Name, level, err)
+		}
+		runs = append(runs, model.ReplicaLevelRun{
+			Level:             level,
+			Pods:              scaled.WorkloadCount(),
+			Nodes:             len(result.Nodes),
+			MonthlyCost:       nodesMonthlyCost(result.Nodes),
+			UnschedulablePods: len(result.UnschedulablePods),
+		})
+	}
+	return runs, nil
+}
+
+// simulateZoneFailures re-packs the cluster once per zone with that zone
+// removed, reporting whether the workloads still fit in the surviving zones
+// and what the resulting cluster costs compared to the baseline.
+func simulateZoneFailures(ctx context.Context, packer BinPacker, input PackInput, baseline *PackResult) ([]model.ZoneFailure, error) {
+	if len(input.Zones) < 2 {
+		return nil, nil
+	}
+
+	baseCost := nodesMonthlyCost(baseline.Nodes)
+	failures := make([]model.ZoneFailure, 0, len(input.Zones))
+	for i, lost := range input.Zones {
+		survivors := make([]string, 0, len(input.Zones)-1)
+		survivors = append(survivors, input.Zones[:i]...)
+		survivors = append(survivors, input.Zones[i+1:]...)
+
+		degraded := input
+		degraded.Zones = survivors
+		result, err := packer.Pack(ctx, degraded)
+		if err != nil {
+			return nil, fmt.Errorf("without zone %s: %w", lost, err)
+		}
+
+		failures = append(failures, model.ZoneFailure{
+			Zone:              lost,
+			Fits:              len(result.UnschedulablePods) <= len(baseline.UnschedulablePods),
+			Nodes:             len(result.Nodes),
+			UnschedulablePods: len(result.UnschedulablePods),
+			ExtraMonthlyCost:  nodesMonthlyCost(result.Nodes) - baseCost,
+		})
+	}
+	return failures, nil
+}
+
+// nodeTemplates returns the template of every allocated node.
+func nodeTemplates(nodes []model.NodeAllocation) []model.NodeTemplate {
+	templates := make([]model.NodeTemplate, len(nodes))
+	for i := range nodes {
+		templates[i] = nodes[i].Template
+	}
+	return templates
+}
+
+// nodesMonthlyCost returns the total monthly cost of a set of node allocations.
+func nodesMonthlyCost(nodes []model.NodeAllocation) float64 {
+	var total float64
+	for i := range nodes {
+		total += nodes[i].Template.MonthlyCost()
+	}
+	return total
+}
+
 // buildSimulationResult computes aggregate metrics from pack results.
 func buildSimulationResult(
 	pr *PackResult,
@@ -130,6 +284,7 @@ func buildSimulationResult(
 			InstanceTypes: scenario.InstanceTypes,
 			SpotRatio:     scenario.SpotRatio,
 			Strategy:      scenario.Strategy,
+			Zones:         scenario.Zones,
 		},
 		Nodes:              pr.Nodes,
 		TotalNodes:         len(pr.Nodes),
@@ -160,6 +315,12 @@ func buildSimulationResult(
 	// Fragmentation analysis
 	sr.Fragmentation = AnalyzeFragmentation(pr.Nodes)
 
+	// Node failure headroom
+	sr.FailureHeadroom = AnalyzeFailureHeadroom(pr.Nodes, scenario.Zones)
+
+	// Spot interruptions
+	sr.SpotDisruption = AnalyzeSpotDisruption(pr.Nodes, scenario.SpotRatio)
+
 	// Scaling efficiency: estimate trough utilization using aggregate metrics
 	if aggMetrics != nil && aggMetrics.MaxNodeCount > 0 && len(pr.Nodes) > 0 {
 		ratio := aggMetrics.ScalingRatio()
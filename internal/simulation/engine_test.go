@@ -2,7 +2,9 @@ package simulation
 
 import (
 	"context"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/guimove/clusterfit/internal/model"
 )
@@ -54,6 +56,148 @@ func TestEngine_RunAll(t *testing.T) {
 	}
 }
 
+func TestEngine_ConstraintOverhead(t *testing.T) {
+	engine := NewEngine(&BestFitDecreasing{}, NewScorer(model.DefaultScoringWeights()))
+
+	replicas := makeReplicas("api", 3, 200, 256*1024*1024)
+	for i := range replicas {
+		replicas[i].PodAntiAffinity = []model.PodAffinityTerm{{TopologyKey: model.TopologyHostname}}
+	}
+	state := model.ClusterState{Workloads: replicas}
+	scenarios := []Scenario{{
+		Name:          "m5.xlarge",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*1024*1024*1024, 58, 0.192)},
+		Strategy:      "homogeneous",
+	}}
+
+	recs, err := engine.RunAll(context.Background(), scenarios, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overhead := recs[0].SimulationResult.ConstraintOverhead
+	if overhead == nil {
+		t.Fatal("expected constraint overhead to be reported")
+	}
+	if overhead.UnconstrainedNodes != 1 || overhead.ExtraNodes != 2 {
+		t.Errorf("overhead = %+v, want 1 unconstrained node and 2 extra", overhead)
+	}
+	if overhead.ExtraMonthlyCost <= 0 {
+		t.Errorf("expected positive extra cost, got %v", overhead.ExtraMonthlyCost)
+	}
+}
+
+func TestEngine_ZoneFailures(t *testing.T) {
+	engine := NewEngine(&BestFitDecreasing{}, NewScorer(model.DefaultScoringWeights()))
+
+	pinned := makeWorkload("pinned", 500, 1*1024*1024*1024)
+	pinned.NodeSelector = map[string]string{model.TopologyZone: "us-east-1a"}
+	state := model.ClusterState{Workloads: []model.WorkloadProfile{
+		pinned,
+		makeWorkload("app-1", 1500, 1*1024*1024*1024),
+		makeWorkload("app-2", 1500, 1*1024*1024*1024),
+	}}
+	scenarios := []Scenario{{
+		Name:          "m5.large",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096)},
+		Strategy:      "homogeneous",
+		Zones:         []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+	}}
+
+	recs, err := engine.RunAll(context.Background(), scenarios, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := recs[0].SimulationResult
+	if len(sr.ZoneFailures) != 3 {
+		t.Fatalf("expected 3 zone failures, got %d", len(sr.ZoneFailures))
+	}
+	for _, zf := range sr.ZoneFailures {
+		wantFits := zf.Zone != "us-east-1a"
+		if zf.Fits != wantFits {
+			t.Errorf("zone %s: fits = %v, want %v", zf.Zone, zf.Fits, wantFits)
+		}
+	}
+	if worst := sr.WorstZoneFailure(); worst == nil || worst.Zone != "us-east-1a" || worst.UnschedulablePods != 1 {
+		t.Errorf("worst zone failure = %+v, want us-east-1a with 1 pod", worst)
+	}
+}
+
+// sharingPacker records the share of its budget the engine asks for.
+type sharingPacker struct {
+	BestFitDecreasing
+	shares []int
+}
+
+func (p *sharingPacker) share(n int) BinPacker {
+	p.shares = append(p.shares, n)
+	return &p.BestFitDecreasing
+}
+
+func TestEngine_SharesTimeBudget(t *testing.T) {
+	packer := &sharingPacker{}
+	engine := NewEngine(packer, NewScorer(model.DefaultScoringWeights()))
+
+	replicas := makeReplicas("api", 3, 200, 256*1024*1024)
+	for i := range replicas {
+		replicas[i].PodAntiAffinity = []model.PodAffinityTerm{{TopologyKey: model.TopologyHostname}}
+	}
+	state := model.ClusterState{Workloads: replicas}
+	scenarios := []Scenario{{
+		Name:          "m5.large",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096)},
+		Strategy:      "homogeneous",
+		Zones:         []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+	}}
+
+	if _, err := engine.RunAll(context.Background(), scenarios, state); err != nil {
+		t.Fatal(err)
+	}
+	// The packing, the unconstrained re-pack and one re-pack per zone
+	if len(packer.shares) != 1 || packer.shares[0] != 5 {
+		t.Errorf("shares = %v, want the budget split 5 ways", packer.shares)
+	}
+
+	b := &BranchAndBound{TimeBudget: 10 * time.Second}
+	if got := b.share(5).(*BranchAndBound).TimeBudget; got != 2*time.Second || b.TimeBudget != 10*time.Second {
+		t.Errorf("shared budget = %v (original %v), want 2s of 10s", got, b.TimeBudget)
+	}
+}
+
+func TestEngine_Commitments(t *testing.T) {
+	engine := NewEngine(&BestFitDecreasing{}, NewScorer(model.DefaultScoringWeights()))
+	engine.Commitments = model.Commitments{
+		ReservedInstances: []model.ReservedInstance{{InstanceType: "m5.large", Count: 1, HourlyRate: 0.06}},
+	}
+
+	state := model.ClusterState{Workloads: []model.WorkloadProfile{
+		makeWorkload("app-1", 500, 1*1024*1024*1024),
+	}}
+	scenarios := []Scenario{{
+		Name:          "m5.large",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.large", 2000, 8*1024*1024*1024, 29, 0.096)},
+		Strategy:      "homogeneous",
+		MinNodes:      2,
+	}}
+
+	recs, err := engine.RunAll(context.Background(), scenarios, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := recs[0]
+	cc := rec.SimulationResult.CommittedCost
+	if cc == nil {
+		t.Fatal("expected committed cost")
+	}
+	wantList := 2 * 0.096 * model.HoursPerMonth
+	wantEffective := (0.06 + 0.096) * model.HoursPerMonth
+	if math.Abs(rec.ListMonthlyCost-wantList) > 1e-6 || math.Abs(rec.MonthlyCost-wantEffective) > 1e-6 {
+		t.Errorf("list/effective = %v/%v, want %v/%v", rec.ListMonthlyCost, rec.MonthlyCost, wantList, wantEffective)
+	}
+	if cc.ReservedNodes != 1 {
+		t.Errorf("reserved nodes = %v, want 1", cc.ReservedNodes)
+	}
+}
+
 func TestEngine_NoScenarios(t *testing.T) {
 	packer := &BestFitDecreasing{}
 	scorer := NewScorer(model.DefaultScoringWeights())
@@ -0,0 +1,312 @@
+package simulation
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+const (
+	// defaultExactTimeBudget is how long BranchAndBound searches when
+	// TimeBudget is not set.
+	defaultExactTimeBudget = 5 * time.Second
+
+	// defaultExactMaxPods is the largest pod count BranchAndBound searches
+	// when MaxPods is not set.
+	defaultExactMaxPods = 200
+)
+
+// BranchAndBound searches for the cheapest packing of the pods across the
+// scenario's instance types, such as every size of a family. It starts from
+// the best-fit-decreasing packing and explores every placement of each pod,
+// largest first, on an open node or a new node of each type, pruning
+// branches whose lower bound costs at least as much as the best packing
+// found so far.
+//
+// Optimal means that no packing costs less at on-demand prices. When the
+// search completes, the result is optimal. When the time budget expires
+// first, or the cluster has more than MaxPods pods, it returns the best
+// packing found, which is never worse than best-fit decreasing. With zones,
+// new nodes are placed in zones as best-fit decreasing does, and with a spot
+// ratio the nodes are priced at blended rates the search does not minimise,
+// so the result is only the best found. Pods best-fit decreasing cannot place
+// stay unschedulable.
+type BranchAndBound struct {
+	TimeBudget time.Duration // search time per scenario; 0 = defaultExactTimeBudget
+	MaxPods    int           // larger clusters get best-fit decreasing; 0 = defaultExactMaxPods
+}
+
+// Name returns the strategy name.
+func (b *BranchAndBound) Name() string { return AlgorithmBranchAndBound }
+
+// share returns a copy searching for 1/n of the time budget, so that the n
+// packings of one scenario together take the budget.
+func (b *BranchAndBound) share(n int) BinPacker {
+	shared := *b
+	shared.TimeBudget = b.budget() / time.Duration(max(1, n))
+	return &shared
+}
+
+// budget returns the search time budget.
+func (b *BranchAndBound) budget() time.Duration {
+	if b.TimeBudget <= 0 {
+		return defaultExactTimeBudget
+	}
+	return b.TimeBudget
+}
+
+// Pack runs best-fit decreasing, then searches for a cheaper packing.
+func (b *BranchAndBound) Pack(ctx context.Context, input PackInput) (*PackResult, error) {
+	input = input.expanded()
+	if len(input.NodeTemplates) == 0 {
+		return &PackResult{UnschedulablePods: input.Workloads}, nil
+	}
+	nodes, unschedulable, err := placeGreedy(ctx, input, bestFit)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPods := b.MaxPods
+	if maxPods <= 0 {
+		maxPods = defaultExactMaxPods
+	}
+	if len(input.Workloads)-len(unschedulable) > maxPods {
+		result := finishPack(nodes, unschedulable, input)
+		result.Optimality = model.OptimalityBestFound
+		return result, nil
+	}
+
+	s := newExactSearch(input, nodes, time.Now().Add(b.budget()))
+	s.search(ctx, 0)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := finishPack(s.best, unschedulable, input)
+	result.Optimality = model.OptimalityBestFound
+	if !s.expired && len(input.Zones) == 0 && input.SpotRatio == 0 {
+		result.Optimality = model.OptimalityOptimal
+	}
+	return result, nil
+}
+
+// exactSearch holds the state of one BranchAndBound search. Costs are
+// on-demand prices per hour, as best-fit decreasing compares templates.
+type exactSearch struct {
+	input      PackInput
+	dsOverhead model.ResourceQuantity
+
+	pods             []model.WorkloadProfile // largest first
+	restCPU, restMem []int64                 // demand of pods[i:]
+	sameAsPrev       []bool                  // pod i is interchangeable with pod i-1
+	podNode          []int                   // node index of each placed pod
+	symmetric        bool                    // no pod has topology constraints
+
+	templates []model.NodeTemplate // one per instance type, cheapest first
+	cpuPrice  float64              // cheapest price per millicore
+	memPrice  float64              // cheapest price per byte
+	padPrice  float64              // price of the nodes padding to MinNodes
+
+	nodes []nodeState
+	cost  float64
+
+	best     []nodeState
+	bestCost float64
+
+	deadline time.Time
+	steps    int
+	expired  bool
+}
+
+func newExactSearch(input PackInput, incumbent []nodeState, deadline time.Time) *exactSearch {
+	s := &exactSearch{
+		input:      input,
+		dsOverhead: model.SumEffectiveResources(input.DaemonSets),
+		symmetric:  true,
+		cpuPrice:   math.MaxFloat64,
+		memPrice:   math.MaxFloat64,
+		padPrice:   cheapestTemplate(input.NodeTemplates).OnDemandPricePerHour,
+		deadline:   deadline,
+	}
+
+	for i := range incumbent {
+		s.pods = append(s.pods, incumbent[i].workloads...)
+	}
+	sortByDominance(s.pods, input.NodeTemplates)
+	n := len(s.pods)
+	s.restCPU, s.restMem = make([]int64, n+1), make([]int64, n+1)
+	for i := n - 1; i >= 0; i-- {
+		s.restCPU[i] = s.restCPU[i+1] + s.pods[i].EffectiveCPUMillis
+		s.restMem[i] = s.restMem[i+1] + s.pods[i].EffectiveMemoryBytes
+	}
+	for i := range s.pods {
+		if s.pods[i].HasTopologyConstraints() {
+			s.symmetric = false
+		}
+	}
+	s.sameAsPrev = make([]bool, n)
+	for i := 1; i < n && s.symmetric; i++ {
+		s.sameAsPrev[i] = interchangeable(&s.pods[i-1], &s.pods[i])
+	}
+	s.podNode = make([]int, n)
+
+	seen := make(map[string]bool)
+	for _, t := range input.NodeTemplates {
+		c := t.AllocatableCPUMillis - s.dsOverhead.CPUMillis - input.SystemReserved.CPUMillis
+		m := t.AllocatableMemoryBytes - s.dsOverhead.MemoryBytes - input.SystemReserved.MemoryBytes
+		if c <= 0 || m <= 0 || seen[t.InstanceType] {
+			continue
+		}
+		seen[t.InstanceType] = true
+		s.templates = append(s.templates, t)
+		s.cpuPrice = min(s.cpuPrice, t.OnDemandPricePerHour/float64(c))
+		s.memPrice = min(s.memPrice, t.OnDemandPricePerHour/float64(m))
+	}
+	slices.SortStableFunc(s.templates, func(a, b model.NodeTemplate) int {
+		return cmp.Compare(a.OnDemandPricePerHour, b.OnDemandPricePerHour)
+	})
+
+	s.best = incumbent
+	s.bestCost = s.objective(incumbent)
+	return s
+}
+
+// interchangeable reports whether two pods may swap nodes in any packing.
+func interchangeable(a, b *model.WorkloadProfile) bool {
+	return a.EffectiveCPUMillis == b.EffectiveCPUMillis &&
+		a.EffectiveMemoryBytes == b.EffectiveMemoryBytes &&
+		a.Architecture == b.Architecture &&
+		maps.Equal(a.NodeSelector, b.NodeSelector) &&
+		slices.Equal(a.Tolerations, b.Tolerations) &&
+		a.ToleratesSpot() == b.ToleratesSpot()
+}
+
+// objective returns the hourly cost of a packing once padded to MinNodes.
+func (s *exactSearch) objective(nodes []nodeState) float64 {
+	var cost float64
+	for i := range nodes {
+		cost += nodes[i].template.OnDemandPricePerHour
+	}
+	return cost + float64(max(0, s.input.MinNodes-len(nodes)))*s.padPrice
+}
+
+// bound returns a lower bound on the cost of any packing completing the
+// current one from pod i: the demand the open nodes cannot absorb must be
+// bought at the cheapest price per unit, and the cluster padded to MinNodes.
+func (s *exactSearch) bound(i int) float64 {
+	var freeCPU, freeMem int64
+	for j := range s.nodes {
+		freeCPU += max(0, s.nodes[j].remainingCPU)
+		freeMem += max(0, s.nodes[j].remainingMem)
+	}
+	extra := max(
+		float64(max(0, s.restCPU[i]-freeCPU))*s.cpuPrice,
+		float64(max(0, s.restMem[i]-freeMem))*s.memPrice,
+		float64(max(0, s.input.MinNodes-len(s.nodes)))*s.padPrice,
+	)
+	return s.cost + extra
+}
+
+// search places pods[i:] in every way that may beat the best packing.
+func (s *exactSearch) search(ctx context.Context, i int) {
+	if s.expired {
+		return
+	}
+	if s.steps++; s.steps%1024 == 0 && (ctx.Err() != nil || time.Now().After(s.deadline)) {
+		s.expired = true
+		return
+	}
+	const eps = 1e-9
+	if i == len(s.pods) {
+		if c := s.objective(s.nodes); c < s.bestCost-eps {
+			s.bestCost = c
+			s.best = make([]nodeState, len(s.nodes))
+			for j := range s.nodes {
+				s.best[j] = cloneNode(&s.nodes[j])
+			}
+		}
+		return
+	}
+	if s.bound(i) >= s.bestCost-eps {
+		return
+	}
+
+	w := &s.pods[i]
+	for _, j := range s.openCandidates(i) {
+		place(&s.nodes[j], w)
+		s.podNode[i] = j
+		s.search(ctx, i+1)
+		unplace(&s.nodes[j], len(s.nodes[j].workloads)-1)
+		if s.expired {
+			return
+		}
+	}
+
+	if s.input.MaxNodes > 0 && len(s.nodes) >= s.input.MaxNodes {
+		return
+	}
+	for _, t := range s.templates {
+		if admits(&t, w) != "" {
+			continue
+		}
+		n := openPackNode(s.input, t, s.dsOverhead)
+		if !canFit(&n, w) || assignZone(s.nodes, &n, w, s.input.Zones) != "" {
+			continue
+		}
+		place(&n, w)
+		s.nodes = addNode(s.nodes, n)
+		s.cost += t.OnDemandPricePerHour
+		s.podNode[i] = len(s.nodes) - 1
+		s.search(ctx, i+1)
+		s.cost -= t.OnDemandPricePerHour
+		s.nodes = s.nodes[:len(s.nodes)-1]
+		if s.expired {
+			return
+		}
+	}
+}
+
+// openCandidates returns the open nodes pod i may go to, tightest fit
+// first. Without topology constraints, nodes in the same state are
+// interchangeable and only the first is tried, and a pod interchangeable
+// with the previous one goes no earlier than its node.
+func (s *exactSearch) openCandidates(i int) []int {
+	w := &s.pods[i]
+	from := 0
+	if s.sameAsPrev[i] {
+		from = s.podNode[i-1]
+	}
+
+	type nodeKey struct {
+		instanceType string
+		zone         string
+		cpu, mem     int64
+		pods         int32
+		intolerant   bool
+	}
+	seen := make(map[nodeKey]bool)
+	var candidates []int
+	for j := from; j < len(s.nodes); j++ {
+		n := &s.nodes[j]
+		if !canFit(n, w) || topologyAllows(s.nodes, n, w, s.input.Zones) != "" {
+			continue
+		}
+		if s.symmetric {
+			k := nodeKey{n.template.InstanceType, n.labels[model.TopologyZone], n.remainingCPU, n.remainingMem, n.podCount, n.intolerant > 0}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		candidates = append(candidates, j)
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		return cmp.Compare(compositeRemaining(&s.nodes[a], w), compositeRemaining(&s.nodes[b], w))
+	})
+	return candidates
+}
@@ -0,0 +1,114 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestBranchAndBound_BeatsBestFit(t *testing.T) {
+	// Best-fit decreasing fills the first node with both 4s and needs three
+	// nodes; two nodes of 4+3+3 hold everything
+	input := PackInput{NodeTemplates: []model.NodeTemplate{makeTemplate("m5.2xlarge", 10000, 64*gib, 29, 0.4)}}
+	for i, cpu := range []int64{4000, 4000, 3000, 3000, 3000, 3000} {
+		input.Workloads = append(input.Workloads, makeWorkload(string(rune('a'+i)), cpu, gib))
+	}
+
+	bfd, err := (&BestFitDecreasing{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bfd.Nodes) != 3 {
+		t.Fatalf("best-fit decreasing: %d nodes, want 3", len(bfd.Nodes))
+	}
+
+	res, err := (&BranchAndBound{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 2 || res.Optimality != model.OptimalityOptimal {
+		t.Errorf("branch-and-bound: %d nodes (%s), want 2 (optimal)", len(res.Nodes), res.Optimality)
+	}
+}
+
+func TestBranchAndBound_MixedFamily(t *testing.T) {
+	// One pod per small node costs $0.30/h; a large node with two pods and
+	// a small one with the third costs $0.25/h
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{
+			makeWorkload("a", 1500, gib), makeWorkload("b", 1500, gib), makeWorkload("c", 1500, gib),
+		},
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.large", 2000, 8*gib, 29, 0.10),
+			makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.15),
+		},
+	}
+
+	res, err := (&BranchAndBound{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, n := range res.Nodes {
+		counts[n.Template.InstanceType]++
+	}
+	if counts["m5.large"] != 1 || counts["m5.xlarge"] != 1 || res.Optimality != model.OptimalityOptimal {
+		t.Errorf("nodes = %v (%s), want one m5.large and one m5.xlarge (optimal)", counts, res.Optimality)
+	}
+
+	// Spot nodes are ranked at blended prices the search does not minimise
+	input.SpotRatio = 0.5
+	res, err = (&BranchAndBound{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Optimality != model.OptimalityBestFound {
+		t.Errorf("optimality with a spot ratio = %s, want %s", res.Optimality, model.OptimalityBestFound)
+	}
+}
+
+func TestBranchAndBound_MinNodes(t *testing.T) {
+	input := PackInput{
+		Workloads:     []model.WorkloadProfile{makeWorkload("a", 1500, gib), makeWorkload("b", 1500, gib)},
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.large", 2000, 8*gib, 29, 0.10), makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.15)},
+		MinNodes:      2,
+	}
+	res, err := (&BranchAndBound{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Padding to two nodes makes two small nodes cheaper than one large
+	for _, n := range res.Nodes {
+		if n.Template.InstanceType != "m5.large" || len(n.Workloads) != 1 {
+			t.Errorf("got %s with %d pods, want two m5.large with one pod each", n.Template.InstanceType, len(n.Workloads))
+		}
+	}
+}
+
+func TestBranchAndBound_BestFound(t *testing.T) {
+	input := PackInput{NodeTemplates: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.2)}}
+	for i := range 10 {
+		input.Workloads = append(input.Workloads, makeWorkload(string(rune('a'+i)), 1500, gib))
+	}
+
+	// Too many pods: best-fit decreasing, reported as best found
+	res, err := (&BranchAndBound{MaxPods: 5}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Optimality != model.OptimalityBestFound || len(res.Nodes) != 5 {
+		t.Errorf("got %d nodes (%s), want best-fit decreasing's 5 (best-found)", len(res.Nodes), res.Optimality)
+	}
+
+	// Zones: the zone of each new node is not searched
+	input.Zones = []string{"us-east-1a", "us-east-1b"}
+	res, err = (&BranchAndBound{TimeBudget: time.Second}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Optimality != model.OptimalityBestFound {
+		t.Errorf("optimality with zones = %s, want best-found", res.Optimality)
+	}
+}
@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// AnalyzeFailureHeadroom simulates the loss of the one and two most-loaded
+// nodes. Pods on the failed nodes are re-packed best-fit onto the survivors,
+// honoring capacity, scheduling constraints and topology rules; no new nodes
+// are provisioned, so the result reflects the headroom before the autoscaler
+// reacts.
+func AnalyzeFailureHeadroom(nodes []model.NodeAllocation, zones []string) *model.FailureHeadroom {
+	return &model.FailureHeadroom{
+		N1: simulateNodeFailure(nodes, 1, zones),
+		N2: simulateNodeFailure(nodes, 2, zones),
+	}
+}
+
+// simulateNodeFailure removes the k most-loaded nodes and re-places their pods.
+func simulateNodeFailure(nodes []model.NodeAllocation, k int, zones []string) model.NodeFailure {
+	f := model.NodeFailure{FailedNodes: k}
+
+	order := make([]int, len(nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		li, lj := nodeLoad(&nodes[order[i]]), nodeLoad(&nodes[order[j]])
+		if li != lj {
+			return li > lj
+		}
+		return nodes[order[i]].PodCount > nodes[order[j]].PodCount
+	})
+
+	if k > len(order) {
+		k = len(order)
+	}
+	var evicted []model.WorkloadProfile
+	for _, idx := range order[:k] {
+		evicted = append(evicted, nodes[idx].Workloads...)
+	}
+	f.DisplacedPods = len(evicted)
+
+	survivors := make([]nodeState, 0, len(nodes)-k)
+	templates := make([]model.NodeTemplate, 0, len(nodes)-k)
+	for _, idx := range order[k:] {
+		survivors = append(survivors, restoreNode(&nodes[idx], fmt.Sprintf("node-%d", idx)))
+		templates = append(templates, nodes[idx].Template)
+	}
+	sortByDominance(evicted, templates)
+
+	for i := range evicted {
+		w := &evicted[i]
+		bestIdx := bestFit(survivors, w, zones)
+		if bestIdx < 0 {
+			f.StrandedPods++
+			continue
+		}
+		place(&survivors[bestIdx], w)
+	}
+
+	f.Survives = f.StrandedPods == 0
+	return f
+}
+
+// nodeLoad returns the utilization of a node's most constrained dimension.
+func nodeLoad(n *model.NodeAllocation) float64 {
+	return math.Max(n.CPUUtilization, n.MemUtilization)
+}
+
+// restoreNode rebuilds the packing state of an allocated node so further
+// pods can be placed on it.
+func restoreNode(a *model.NodeAllocation, hostname string) nodeState {
+	alloc := a.Template.AllocatableResources()
+	labels := a.Template.NodeLabels()
+	labels[model.TopologyHostname] = hostname
+	if a.Zone != "" {
+		labels[model.TopologyZone] = a.Zone
+	}
+
+	n := nodeState{
+		template:     a.Template,
+		workloads:    append([]model.WorkloadProfile(nil), a.Workloads...),
+		remainingCPU: alloc.CPUMillis - a.UsedCPU,
+		remainingMem: alloc.MemoryBytes - a.UsedMem,
+		podCount:     a.PodCount,
+		labels:       labels,
+		groups:       make(map[string]int),
+	}
+	for i := range a.Workloads {
+		if g := a.Workloads[i].GroupKey(); g != "" {
+			n.groups[g]++
+		}
+	}
+	return n
+}
@@ -0,0 +1,89 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// makeLoadedNode builds a 4 vCPU / 16 GiB node hosting the given workloads.
+func makeLoadedNode(workloads ...model.WorkloadProfile) model.NodeAllocation {
+	n := model.NodeAllocation{
+		Template:  makeTemplate("m5.xlarge", 4000, memGiB(16), 58, 0.192),
+		Workloads: workloads,
+		PodCount:  int32(len(workloads)),
+	}
+	for _, w := range workloads {
+		n.UsedCPU += w.EffectiveCPUMillis
+		n.UsedMem += w.EffectiveMemoryBytes
+	}
+	n.CPUUtilization = float64(n.UsedCPU) / 4000
+	n.MemUtilization = float64(n.UsedMem) / float64(memGiB(16))
+	return n
+}
+
+func TestFailureHeadroom_SurvivesBoth(t *testing.T) {
+	nodes := []model.NodeAllocation{
+		makeLoadedNode(makeWorkload("a", 1000, memGiB(2))),
+		makeLoadedNode(makeWorkload("b", 1000, memGiB(2))),
+		makeLoadedNode(makeWorkload("c", 1000, memGiB(2))),
+		makeLoadedNode(),
+	}
+
+	fh := AnalyzeFailureHeadroom(nodes, nil)
+	if !fh.N1.Survives || !fh.N2.Survives {
+		t.Errorf("expected to survive N-1 and N-2, got %+v", fh)
+	}
+	if fh.N1.DisplacedPods != 1 || fh.N2.DisplacedPods != 2 {
+		t.Errorf("displaced = %d/%d, want 1/2", fh.N1.DisplacedPods, fh.N2.DisplacedPods)
+	}
+}
+
+func TestFailureHeadroom_NoHeadroom(t *testing.T) {
+	// Every node is 75% CPU-loaded: no survivor can absorb another 3000m pod
+	nodes := []model.NodeAllocation{
+		makeLoadedNode(makeWorkload("a", 3000, memGiB(2))),
+		makeLoadedNode(makeWorkload("b", 3000, memGiB(2))),
+		makeLoadedNode(makeWorkload("c", 3000, memGiB(2))),
+	}
+
+	fh := AnalyzeFailureHeadroom(nodes, nil)
+	if fh.N1.Survives {
+		t.Error("expected N-1 failure to strand pods")
+	}
+	if fh.N1.StrandedPods != 1 || fh.N2.StrandedPods != 2 {
+		t.Errorf("stranded = %d/%d, want 1/2", fh.N1.StrandedPods, fh.N2.StrandedPods)
+	}
+}
+
+func TestFailureHeadroom_EvictsMostLoaded(t *testing.T) {
+	// The busy node's pods only fit on the idle node, which must not be the one removed
+	nodes := []model.NodeAllocation{
+		makeLoadedNode(),
+		makeLoadedNode(makeWorkload("busy", 3500, memGiB(2))),
+	}
+
+	fh := AnalyzeFailureHeadroom(nodes, nil)
+	if !fh.N1.Survives || fh.N1.DisplacedPods != 1 {
+		t.Errorf("expected busy node to fail over to the idle node, got %+v", fh.N1)
+	}
+	if fh.N2.Survives {
+		t.Error("losing both nodes cannot be survived")
+	}
+}
+
+func TestFailureHeadroom_RespectsAntiAffinity(t *testing.T) {
+	replicas := makeReplicas("api", 2, 200, memGiB(1))
+	for i := range replicas {
+		replicas[i].PodAntiAffinity = []model.PodAffinityTerm{{TopologyKey: model.TopologyHostname}}
+	}
+	nodes := []model.NodeAllocation{
+		makeLoadedNode(replicas[0]),
+		makeLoadedNode(replicas[1]),
+	}
+
+	fh := AnalyzeFailureHeadroom(nodes, nil)
+	if fh.N1.Survives {
+		t.Error("replica must not fail over onto a node already hosting its sibling")
+	}
+}
@@ -0,0 +1,382 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// Unschedulable reasons specific to Karpenter provisioning.
+const (
+	reasonNodePools  = "no NodePool allows any candidate instance type"
+	reasonPoolLimits = "NodePool limits reached"
+)
+
+// StrategyKarpenter labels scenarios provisioned by KarpenterProvisioner.
+const StrategyKarpenter = "karpenter"
+
+// KarpenterProvisioner simulates Karpenter provisioning the cluster for its
+// pending pods. Every node is a NodeClaim that keeps the instance types of
+// its NodePool still able to hold all the pods placed on it. Pods, largest
+// CPU first, go to the claim with the fewest pods that can take them, or
+// else open a claim from the highest-weight NodePool that can. Each claim
+// launches the cheapest instance type it has left, and NodePool limits cap
+// the capacity launched per pool.
+//
+// Spot ratio and minimum node count do not apply: the capacity type comes
+// from the NodePool, and Karpenter launches only what pending pods need.
+type KarpenterProvisioner struct {
+	NodePools []model.NodePool
+}
+
+// Name returns the strategy name.
+func (k *KarpenterProvisioner) Name() string { return StrategyKarpenter }
+
+// nodeClaim tracks the instance types a provisioned node may still become.
+type nodeClaim struct {
+	pool    int
+	options []model.NodeTemplate // cheapest first; options[0] is the node's template
+}
+
+// Pack provisions nodes for the workloads from the NodePools' instance types
+// found in input.NodeTemplates.
+func (k *KarpenterProvisioner) Pack(ctx context.Context, input PackInput) (*PackResult, error) {
+	input = input.expanded()
+	dsOverhead := model.SumEffectiveResources(input.DaemonSets)
+	overhead := dsOverhead.Add(input.SystemReserved)
+
+	candidates := make([][]model.NodeTemplate, len(k.NodePools))
+	var all []model.NodeTemplate
+	for i := range k.NodePools {
+		c := k.NodePools[i].Templates(input.NodeTemplates)
+		sort.SliceStable(c, func(a, b int) bool {
+			return c[a].EffectivePricePerHour() < c[b].EffectivePricePerHour()
+		})
+		candidates[i] = c
+		all = append(all, c...)
+	}
+	if len(all) == 0 {
+		unschedulable := make([]model.WorkloadProfile, len(input.Workloads))
+		for i := range input.Workloads {
+			unschedulable[i] = markUnschedulable(input.Workloads[i], reasonNodePools)
+		}
+		return &PackResult{UnschedulablePods: unschedulable}, nil
+	}
+
+	// Karpenter schedules the largest pods first: by CPU, then memory
+	workloads := make([]model.WorkloadProfile, len(input.Workloads))
+	copy(workloads, input.Workloads)
+	sort.SliceStable(workloads, func(i, j int) bool {
+		if workloads[i].EffectiveCPUMillis != workloads[j].EffectiveCPUMillis {
+			return workloads[i].EffectiveCPUMillis > workloads[j].EffectiveCPUMillis
+		}
+		return workloads[i].EffectiveMemoryBytes > workloads[j].EffectiveMemoryBytes
+	})
+
+	p := &provisioning{
+		pools:      k.NodePools,
+		order:      poolsByWeight(k.NodePools),
+		candidates: candidates,
+		all:        all,
+		dsOverhead: dsOverhead,
+		sysReserve: input.SystemReserved,
+		overhead:   overhead,
+		zones:      input.Zones,
+		usage:      make([]model.ResourceQuantity, len(k.NodePools)),
+	}
+
+	var unschedulable []model.WorkloadProfile
+	for i := range workloads {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		w := &workloads[i]
+		if p.addToClaim(w) {
+			continue
+		}
+		if input.MaxNodes > 0 && len(p.nodes) >= input.MaxNodes {
+			unschedulable = append(unschedulable, markUnschedulable(*w, reasonMaxNodes))
+			continue
+		}
+		if reason := p.openClaim(w); reason != "" {
+			unschedulable = append(unschedulable, markUnschedulable(*w, reason))
+		}
+	}
+
+	return &PackResult{
+		Nodes:             buildAllocations(p.nodes),
+		UnschedulablePods: unschedulable,
+	}, nil
+}
+
+// provisioning is the state of one KarpenterProvisioner run.
+type provisioning struct {
+	pools      []model.NodePool
+	order      []int                  // pool indices, highest weight first
+	candidates [][]model.NodeTemplate // per pool, cheapest first
+	all        []model.NodeTemplate
+	dsOverhead model.ResourceQuantity
+	sysReserve model.ResourceQuantity
+	overhead   model.ResourceQuantity // DaemonSets + system reserved
+	zones      []string
+
+	nodes  []nodeState
+	claims []nodeClaim
+	usage  []model.ResourceQuantity // launched capacity per pool
+}
+
+// addToClaim places w on the existing claim with the fewest pods that can
+// take it, growing the claim's instance type if needed.
+func (p *provisioning) addToClaim(w *model.WorkloadProfile) bool {
+	idx := make([]int, len(p.nodes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return p.nodes[idx[a]].podCount < p.nodes[idx[b]].podCount })
+
+	for _, j := range idx {
+		n := &p.nodes[j]
+		if !zoneMatches(n.labels, w) || topologyAllows(p.nodes, n, w, p.zones) != "" {
+			continue
+		}
+		c := &p.claims[j]
+		used := n.template.AllocatableResources().Sub(p.overhead).Sub(model.ResourceQuantity{
+			CPUMillis: n.remainingCPU, MemoryBytes: n.remainingMem,
+		})
+		others := p.usage[c.pool].Sub(nodeCapacity(n.template))
+		opts := p.fitting(c.options, used, n.podCount, w)
+		opts = withinLimits(p.pools[c.pool], others, opts)
+		if len(opts) == 0 {
+			continue
+		}
+
+		if opts[0].InstanceType != n.template.InstanceType {
+			p.usage[c.pool] = others.Add(nodeCapacity(opts[0]))
+			retemplate(n, opts[0], used, p.overhead)
+		}
+		c.options = opts
+		place(n, w)
+		return true
+	}
+	return false
+}
+
+// openClaim launches a node for w from the highest-weight NodePool that can
+// host it. It returns the reason w is unschedulable when none can.
+func (p *provisioning) openClaim(w *model.WorkloadProfile) string {
+	reason := ""
+	for _, pi := range p.order {
+		pool := p.pools[pi]
+		opts := p.fitting(p.candidates[pi], model.ResourceQuantity{}, 0, w)
+		if len(opts) == 0 {
+			continue
+		}
+		if opts = withinLimits(pool, p.usage[pi], opts); len(opts) == 0 {
+			reason = reasonPoolLimits
+			continue
+		}
+
+		var zones []string
+		for _, z := range p.zones {
+			if pool.AllowsZone(z) {
+				zones = append(zones, z)
+			}
+		}
+		if len(p.zones) > 0 && len(zones) == 0 {
+			reason = reasonZone
+			continue
+		}
+
+		n := openNode(opts[0], p.dsOverhead, p.sysReserve)
+		if r := assignZone(p.nodes, &n, w, zones); r != "" {
+			reason = r
+			continue
+		}
+		place(&n, w)
+		p.nodes = addNode(p.nodes, n)
+		p.claims = append(p.claims, nodeClaim{pool: pi, options: opts})
+		p.usage[pi] = p.usage[pi].Add(nodeCapacity(opts[0]))
+		return ""
+	}
+	if reason == "" {
+		reason = explainUnschedulable(p.all, w)
+	}
+	return reason
+}
+
+// fitting returns the options that admit w and hold it on top of the used
+// resources and pod count.
+func (p *provisioning) fitting(options []model.NodeTemplate, used model.ResourceQuantity, pods int32, w *model.WorkloadProfile) []model.NodeTemplate {
+	need := used.Add(model.ResourceQuantity{CPUMillis: w.EffectiveCPUMillis, MemoryBytes: w.EffectiveMemoryBytes})
+	var fit []model.NodeTemplate
+	for i := range options {
+		o := &options[i]
+		if pods >= o.MaxPods || admits(o, w) != "" || !need.FitsIn(o.AllocatableResources().Sub(p.overhead)) {
+			continue
+		}
+		fit = append(fit, *o)
+	}
+	return fit
+}
+
+// withinLimits returns the options that keep the pool within its limits,
+// given the capacity of its other nodes.
+func withinLimits(pool model.NodePool, others model.ResourceQuantity, options []model.NodeTemplate) []model.NodeTemplate {
+	if pool.LimitCPUMillis == 0 && pool.LimitMemoryBytes == 0 {
+		return options
+	}
+	var fit []model.NodeTemplate
+	for _, o := range options {
+		total := others.Add(nodeCapacity(o))
+		if (pool.LimitCPUMillis == 0 || total.CPUMillis <= pool.LimitCPUMillis) &&
+			(pool.LimitMemoryBytes == 0 || total.MemoryBytes <= pool.LimitMemoryBytes) {
+			fit = append(fit, o)
+		}
+	}
+	return fit
+}
+
+// retemplate switches a claim to another instance type, keeping its pods,
+// hostname and zone.
+func retemplate(n *nodeState, t model.NodeTemplate, used, overhead model.ResourceQuantity) {
+	labels := t.NodeLabels()
+	labels[model.TopologyHostname] = n.labels[model.TopologyHostname]
+	if z, ok := n.labels[model.TopologyZone]; ok {
+		labels[model.TopologyZone] = z
+	}
+	free := t.AllocatableResources().Sub(overhead).Sub(used)
+	n.template = t
+	n.labels = labels
+	n.remainingCPU = free.CPUMillis
+	n.remainingMem = free.MemoryBytes
+}
+
+// nodeCapacity is the capacity a node counts against NodePool limits.
+func nodeCapacity(t model.NodeTemplate) model.ResourceQuantity {
+	return model.ResourceQuantity{
+		CPUMillis:   int64(t.VCPUs) * 1000,
+		MemoryBytes: t.MemoryMiB * 1024 * 1024,
+	}
+}
+
+// poolsByWeight returns pool indices by descending weight, then name.
+func poolsByWeight(pools []model.NodePool) []int {
+	order := make([]int, len(pools))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := pools[order[a]], pools[order[b]]
+		if pa.Weight != pb.Weight {
+			return pa.Weight > pb.Weight
+		}
+		return pa.Name < pb.Name
+	})
+	return order
+}
+
+// GenerateKarpenterScenarios creates one scenario with every instance type
+// the NodePools allow, plus one per instance family when there are several,
+// to find out whether narrowing the NodePools would lower the cost.
+func GenerateKarpenterScenarios(templates []model.NodeTemplate, pools []model.NodePool) []Scenario {
+	var allowed []model.NodeTemplate
+	families := make(map[string][]model.NodeTemplate)
+	for _, t := range templates {
+		for _, p := range pools {
+			if p.Allows(t) {
+				allowed = append(allowed, t)
+				families[t.InstanceFamily] = append(families[t.InstanceFamily], t)
+				break
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	scenarios := []Scenario{{
+		Name:          "karpenter-nodepools",
+		InstanceTypes: allowed,
+		Strategy:      StrategyKarpenter,
+	}}
+	if len(families) < 2 {
+		return scenarios
+	}
+	names := make([]string, 0, len(families))
+	for f := range families {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	for _, f := range names {
+		scenarios = append(scenarios, Scenario{
+			Name:          "karpenter-" + f,
+			InstanceTypes: families[f],
+			Strategy:      StrategyKarpenter,
+		})
+	}
+	return scenarios
+}
+
+// SuggestNodePools derives, for every NodePool that launched nodes in the
+// result, a requirements block narrowed to the instance families and vCPU
+// range it used.
+func SuggestNodePools(pools []model.NodePool, sr model.SimulationResult) []model.NodePoolSuggestion {
+	var suggestions []model.NodePoolSuggestion
+	for _, pool := range pools {
+		s := model.NodePoolSuggestion{NodePool: pool.Name}
+		var families []string
+		var minCPU, maxCPU int32
+		prices := make(map[string]float64)
+		for i := range sr.Nodes {
+			t := sr.Nodes[i].Template
+			if t.Labels[model.LabelNodePool] != pool.Name {
+				continue
+			}
+			s.Nodes++
+			s.MonthlyCost += t.MonthlyCost()
+			prices[t.InstanceType] = t.EffectivePricePerHour()
+			families = append(families, t.InstanceFamily)
+			if minCPU == 0 || t.VCPUs < minCPU {
+				minCPU = t.VCPUs
+			}
+			maxCPU = max(maxCPU, t.VCPUs)
+		}
+		if s.Nodes == 0 {
+			continue
+		}
+		for it := range prices {
+			s.InstanceTypes = append(s.InstanceTypes, it)
+		}
+		sort.Slice(s.InstanceTypes, func(i, j int) bool {
+			a, b := s.InstanceTypes[i], s.InstanceTypes[j]
+			if prices[a] != prices[b] {
+				return prices[a] < prices[b]
+			}
+			return a < b
+		})
+		sort.Strings(families)
+		s.Requirements = pool.SuggestRequirements(slices.Compact(families), minCPU, maxCPU)
+		suggestions = append(suggestions, s)
+	}
+	return suggestions
+}
+
+// KarpenterWarnings reports NodePool settings that keep Karpenter from
+// reaching the simulated packing.
+func KarpenterWarnings(pools []model.NodePool) []string {
+	var warnings []string
+	for _, p := range pools {
+		switch {
+		case p.ConsolidationPolicy == model.ConsolidateWhenEmpty:
+			warnings = append(warnings, fmt.Sprintf(
+				"NodePool %s only consolidates empty nodes; underutilized nodes stay until they drain, so real costs will exceed the simulation", p.Name))
+		case p.ConsolidateAfter < 0:
+			warnings = append(warnings, fmt.Sprintf(
+				"NodePool %s never consolidates (consolidateAfter: Never); real costs will exceed the simulation", p.Name))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,234 @@
+package simulation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// helper to create a node template with the shape fields NodePool requirements match on
+func makeKarpenterTemplate(instanceType string, vcpus int32, memGiB int64, pricePerHour float64) model.NodeTemplate {
+	family, size, _ := strings.Cut(instanceType, ".")
+	return model.NodeTemplate{
+		InstanceType:           instanceType,
+		InstanceFamily:         family,
+		Size:                   size,
+		Architecture:           model.ArchARM64,
+		VCPUs:                  vcpus,
+		MemoryMiB:              memGiB * 1024,
+		AllocatableCPUMillis:   int64(vcpus) * 1000,
+		AllocatableMemoryBytes: memGiB * 1024 * 1024 * 1024,
+		MaxPods:                58,
+		OnDemandPricePerHour:   pricePerHour,
+		CapacityType:           model.CapacityOnDemand,
+	}
+}
+
+func karpenterCatalog() []model.NodeTemplate {
+	return []model.NodeTemplate{
+		makeKarpenterTemplate("m7g.large", 2, 8, 0.08),
+		makeKarpenterTemplate("m7g.xlarge", 4, 16, 0.16),
+		makeKarpenterTemplate("c7g.large", 2, 4, 0.07),
+		makeKarpenterTemplate("c7g.xlarge", 4, 8, 0.14),
+	}
+}
+
+func familyPool(name string, weight int32, families ...string) model.NodePool {
+	return model.NodePool{
+		Name:   name,
+		Weight: weight,
+		Requirements: []model.NodeRequirement{
+			{Key: model.LabelInstanceFamily, Operator: model.OpIn, Values: families},
+		},
+	}
+}
+
+func TestKarpenter_ClaimGrowsToCheapestFit(t *testing.T) {
+	packer := &KarpenterProvisioner{NodePools: []model.NodePool{familyPool("default", 0, "m7g")}}
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{
+			makeWorkload("pod-1", 1500, 2*1024*1024*1024),
+			makeWorkload("pod-2", 1500, 2*1024*1024*1024),
+		},
+		NodeTemplates: karpenterCatalog(),
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(result.Nodes))
+	}
+	n := result.Nodes[0]
+	if n.Template.InstanceType != "m7g.xlarge" || n.PodCount != 2 {
+		t.Errorf("node = %s with %d pods, want m7g.xlarge with 2", n.Template.InstanceType, n.PodCount)
+	}
+	if n.UsedCPU != 3000 {
+		t.Errorf("used CPU = %d, want 3000", n.UsedCPU)
+	}
+	if n.Template.Labels[model.LabelNodePool] != "default" {
+		t.Errorf("node labels = %v, want the NodePool label", n.Template.Labels)
+	}
+}
+
+func TestKarpenter_WeightOrder(t *testing.T) {
+	packer := &KarpenterProvisioner{NodePools: []model.NodePool{
+		familyPool("cheap", 0, "c7g"),
+		familyPool("preferred", 50, "m7g"),
+	}}
+	input := PackInput{
+		Workloads:     []model.WorkloadProfile{makeWorkload("pod-1", 500, 1024*1024*1024)},
+		NodeTemplates: karpenterCatalog(),
+	}
+
+	result, err := packer.Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 1 || result.Nodes[0].Template.InstanceType != "m7g.large" {
+		t.Fatalf("nodes = %v, want one m7g.large from the higher-weight pool", result.Nodes)
+	}
+}
+
+func TestKarpenter_Limits(t *testing.T) {
+	limited := familyPool("limited", 10, "c7g")
+	limited.LimitCPUMillis = 2000
+	workloads := []model.WorkloadProfile{
+		makeWorkload("pod-1", 1500, 1024*1024*1024),
+		makeWorkload("pod-2", 1500, 1024*1024*1024),
+	}
+
+	// Overflows to the next pool once the limit is reached
+	packer := &KarpenterProvisioner{NodePools: []model.NodePool{limited, familyPool("overflow", 0, "m7g")}}
+	result, err := packer.Pack(context.Background(), PackInput{Workloads: workloads, NodeTemplates: karpenterCatalog()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 2 || len(result.UnschedulablePods) != 0 {
+		t.Fatalf("got %d nodes, %d unschedulable; want 2 nodes", len(result.Nodes), len(result.UnschedulablePods))
+	}
+	pools := map[string]bool{}
+	for _, n := range result.Nodes {
+		pools[n.Template.Labels[model.LabelNodePool]] = true
+	}
+	if !pools["limited"] || !pools["overflow"] {
+		t.Errorf("nodes from pools %v, want limited and overflow", pools)
+	}
+
+	// Unschedulable without another pool
+	packer = &KarpenterProvisioner{NodePools: []model.NodePool{limited}}
+	result, err = packer.Pack(context.Background(), PackInput{Workloads: workloads, NodeTemplates: karpenterCatalog()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 1 || result.UnschedulablePods[0].UnschedulableReason != reasonPoolLimits {
+		t.Errorf("unschedulable = %v, want one pod for %q", result.UnschedulablePods, reasonPoolLimits)
+	}
+}
+
+func TestKarpenter_NoAllowedTypes(t *testing.T) {
+	packer := &KarpenterProvisioner{NodePools: []model.NodePool{familyPool("gpu", 0, "g5")}}
+	result, err := packer.Pack(context.Background(), PackInput{
+		Workloads:     []model.WorkloadProfile{makeWorkload("pod-1", 500, 1024*1024*1024)},
+		NodeTemplates: karpenterCatalog(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.UnschedulablePods) != 1 || result.UnschedulablePods[0].UnschedulableReason != reasonNodePools {
+		t.Errorf("unschedulable = %v, want one pod for %q", result.UnschedulablePods, reasonNodePools)
+	}
+}
+
+func TestKarpenter_ZoneRequirement(t *testing.T) {
+	pool := familyPool("zonal", 0, "m7g")
+	pool.Requirements = append(pool.Requirements, model.NodeRequirement{
+		Key: model.TopologyZone, Operator: model.OpIn, Values: []string{"us-east-1b"},
+	})
+	packer := &KarpenterProvisioner{NodePools: []model.NodePool{pool}}
+	result, err := packer.Pack(context.Background(), PackInput{
+		Workloads:     makeReplicas("api", 3, 1500, 1024*1024*1024),
+		NodeTemplates: karpenterCatalog(),
+		Zones:         []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) == 0 || len(result.UnschedulablePods) != 0 {
+		t.Fatalf("got %d nodes, %d unschedulable", len(result.Nodes), len(result.UnschedulablePods))
+	}
+	for _, n := range result.Nodes {
+		if n.Zone != "us-east-1b" {
+			t.Errorf("node in zone %s, want us-east-1b", n.Zone)
+		}
+	}
+}
+
+func TestGenerateKarpenterScenarios(t *testing.T) {
+	pools := []model.NodePool{{Name: "any"}}
+	scenarios := GenerateKarpenterScenarios(karpenterCatalog(), pools)
+	if len(scenarios) != 3 {
+		t.Fatalf("got %d scenarios, want all types plus 2 families", len(scenarios))
+	}
+	if scenarios[0].Name != "karpenter-nodepools" || len(scenarios[0].InstanceTypes) != 4 {
+		t.Errorf("first scenario = %s with %d types", scenarios[0].Name, len(scenarios[0].InstanceTypes))
+	}
+	if scenarios[1].Name != "karpenter-c7g" || scenarios[2].Name != "karpenter-m7g" {
+		t.Errorf("family scenarios = %s, %s", scenarios[1].Name, scenarios[2].Name)
+	}
+
+	if got := GenerateKarpenterScenarios(karpenterCatalog(), []model.NodePool{familyPool("m", 0, "m7g")}); len(got) != 1 {
+		t.Errorf("single family: got %d scenarios, want 1", len(got))
+	}
+}
+
+func TestSuggestNodePools(t *testing.T) {
+	pools := []model.NodePool{familyPool("default", 0, "m7g", "c7g"), familyPool("idle", 0, "r7g")}
+	packer := &KarpenterProvisioner{NodePools: pools}
+	result, err := packer.Pack(context.Background(), PackInput{
+		Workloads: []model.WorkloadProfile{
+			makeWorkload("big", 3000, 10*1024*1024*1024),
+			makeWorkload("small", 1500, 1024*1024*1024),
+		},
+		NodeTemplates: karpenterCatalog(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suggestions := SuggestNodePools(pools, model.SimulationResult{Nodes: result.Nodes})
+	if len(suggestions) != 1 {
+		t.Fatalf("got %d suggestions, want 1 (idle pools launch nothing)", len(suggestions))
+	}
+	s := suggestions[0]
+	if s.NodePool != "default" || s.Nodes != 2 {
+		t.Errorf("suggestion = %+v", s)
+	}
+	if len(s.InstanceTypes) != 2 || s.InstanceTypes[0] != "c7g.large" || s.InstanceTypes[1] != "m7g.xlarge" {
+		t.Errorf("instance types = %v, want cheapest first", s.InstanceTypes)
+	}
+	var families model.NodeRequirement
+	for _, r := range s.Requirements {
+		if r.Key == model.LabelInstanceFamily {
+			families = r
+		}
+	}
+	if strings.Join(families.Values, ",") != "c7g,m7g" {
+		t.Errorf("family requirement = %v", families)
+	}
+}
+
+func TestKarpenterWarnings(t *testing.T) {
+	pools := []model.NodePool{
+		{Name: "a", ConsolidationPolicy: model.ConsolidateWhenEmpty},
+		{Name: "b", ConsolidationPolicy: model.ConsolidateWhenEmptyOrUnderutilized, ConsolidateAfter: -1},
+		{Name: "c", ConsolidationPolicy: model.ConsolidateWhenEmptyOrUnderutilized},
+	}
+	warnings := KarpenterWarnings(pools)
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "NodePool a") || !strings.Contains(warnings[1], "NodePool b") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
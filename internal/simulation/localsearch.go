@@ -0,0 +1,201 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// defaultLocalSearchIterations is the number of moves LocalSearch attempts
+// when Iterations is not set.
+const defaultLocalSearchIterations = 500
+
+// LocalSearch improves the best-fit-decreasing packing with randomized
+// moves, keeping every move that does not make the cluster more expensive:
+//
+//   - drain a lightly loaded node, re-placing its pods best-fit on the others;
+//   - move a pod of a lightly loaded node to the node it fits most tightly,
+//     which lets the search escape packings no single drain improves;
+//   - replace a node with a cheaper instance type that still fits its pods.
+//
+// The seed makes runs reproducible.
+type LocalSearch struct {
+	Iterations int    // moves attempted; 0 = defaultLocalSearchIterations
+	Seed       uint64 // random seed
+}
+
+// Name returns the strategy name.
+func (l *LocalSearch) Name() string { return AlgorithmLocalSearch }
+
+// Pack runs best-fit decreasing, then improves the result.
+func (l *LocalSearch) Pack(ctx context.Context, input PackInput) (*PackResult, error) {
+	input = input.expanded()
+	if len(input.NodeTemplates) == 0 {
+		return &PackResult{UnschedulablePods: input.Workloads}, nil
+	}
+	nodes, unschedulable, err := placeGreedy(ctx, input, bestFit)
+	if err != nil {
+		return nil, err
+	}
+
+	iterations := l.Iterations
+	if iterations <= 0 {
+		iterations = defaultLocalSearchIterations
+	}
+	ls := &localSearch{
+		rng:        rand.New(rand.NewPCG(l.Seed, l.Seed)),
+		input:      input,
+		dsOverhead: model.SumEffectiveResources(input.DaemonSets),
+	}
+	for range iterations {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if len(nodes) == 0 {
+			break
+		}
+		switch ls.rng.IntN(3) {
+		case 0:
+			if trial, ok := evict(nodes, ls.pickLight(nodes), input.Zones); ok {
+				nodes = trial
+			}
+		case 1:
+			nodes = ls.relocate(nodes)
+		case 2:
+			ls.downsize(nodes)
+		}
+	}
+
+	return finishPack(nodes, unschedulable, input), nil
+}
+
+// localSearch holds the state of one LocalSearch run.
+type localSearch struct {
+	rng        *rand.Rand
+	input      PackInput
+	dsOverhead model.ResourceQuantity
+}
+
+// pickLight returns a random node, biased towards lightly loaded ones: the
+// less loaded of two picked at random.
+func (ls *localSearch) pickLight(nodes []nodeState) int {
+	a, b := ls.rng.IntN(len(nodes)), ls.rng.IntN(len(nodes))
+	if stateLoad(&nodes[b]) < stateLoad(&nodes[a]) {
+		return b
+	}
+	return a
+}
+
+// relocate moves a random pod of a lightly loaded node to the other node it
+// fits most tightly. A node left empty is removed.
+func (ls *localSearch) relocate(nodes []nodeState) []nodeState {
+	i := ls.pickLight(nodes)
+	if len(nodes) < 2 || len(nodes[i].workloads) == 0 {
+		return nodes
+	}
+	w := unplace(&nodes[i], ls.rng.IntN(len(nodes[i].workloads)))
+
+	bestIdx := -1
+	bestScore := math.MaxFloat64
+	for j := range nodes {
+		if j == i {
+			continue
+		}
+		if !canFit(&nodes[j], &w) || topologyAllows(nodes, &nodes[j], &w, ls.input.Zones) != "" {
+			continue
+		}
+		if score := compositeRemaining(&nodes[j], &w); score < bestScore {
+			bestScore, bestIdx = score, j
+		}
+	}
+	if bestIdx < 0 {
+		place(&nodes[i], &w)
+		return nodes
+	}
+	place(&nodes[bestIdx], &w)
+	if nodes[i].podCount == 0 {
+		return slices.Delete(nodes, i, i+1)
+	}
+	return nodes
+}
+
+// downsize replaces a random node with the cheapest cheaper instance type
+// that still fits all its pods.
+func (ls *localSearch) downsize(nodes []nodeState) {
+	i := ls.rng.IntN(len(nodes))
+	current := &nodes[i]
+	var best *nodeState
+	for k := range ls.input.NodeTemplates {
+		t := ls.input.NodeTemplates[k]
+		if t.OnDemandPricePerHour >= current.template.OnDemandPricePerHour {
+			continue
+		}
+		if best != nil && t.OnDemandPricePerHour >= best.template.OnDemandPricePerHour {
+			continue
+		}
+		n := openPackNode(ls.input, t, ls.dsOverhead)
+		n.labels[model.TopologyHostname] = current.labels[model.TopologyHostname]
+		if z, ok := current.labels[model.TopologyZone]; ok {
+			n.labels[model.TopologyZone] = z
+		}
+		fits := true
+		for j := range current.workloads {
+			if !canFit(&n, &current.workloads[j]) {
+				fits = false
+				break
+			}
+			place(&n, &current.workloads[j])
+		}
+		if fits {
+			best = &n
+		}
+	}
+	if best != nil {
+		nodes[i] = *best
+	}
+}
+
+// evict re-places the pods of node i best-fit onto the other nodes. It
+// returns the remaining nodes, or false if a pod fits nowhere; nodes is left
+// unchanged either way. Only the nodes receiving pods are copied.
+func evict(nodes []nodeState, i int, zones []string) ([]nodeState, bool) {
+	trial := make([]nodeState, 0, len(nodes)-1)
+	trial = append(trial, nodes[:i]...)
+	trial = append(trial, nodes[i+1:]...)
+
+	copied := make(map[int]bool)
+	for k := range nodes[i].workloads {
+		w := &nodes[i].workloads[k]
+		j := bestFit(trial, w, zones)
+		if j < 0 {
+			return nil, false
+		}
+		if !copied[j] {
+			trial[j] = cloneNode(&trial[j])
+			copied[j] = true
+		}
+		place(&trial[j], w)
+	}
+	return trial, true
+}
+
+// unplace removes the k-th workload from a node, releasing its resources.
+func unplace(n *nodeState, k int) model.WorkloadProfile {
+	w := n.workloads[k]
+	n.workloads = slices.Delete(n.workloads, k, k+1)
+	n.remainingCPU += w.EffectiveCPUMillis
+	n.remainingMem += w.EffectiveMemoryBytes
+	n.podCount--
+	if !w.ToleratesSpot() {
+		n.intolerant--
+	}
+	if g := w.GroupKey(); g != "" {
+		if n.groups[g]--; n.groups[g] <= 0 {
+			delete(n.groups, g)
+		}
+	}
+	return w
+}
@@ -15,19 +15,69 @@ type BinPacker interface {
 	Name() string
 }
 
-// PackInput is the input to a bin-packing run.
+// budgetSharer is implemented by packers with a search time budget per
+// scenario, which the engine shares across the packings of one scenario.
+type budgetSharer interface {
+	// share returns a packer searching for 1/n of the budget.
+	share(n int) BinPacker
+}
+
+// PackInput is the input to a bin-packing run. Workloads may hold
+// representative profiles of a controller's replicas, which packers expand
+// into one pod per replica.
 type PackInput struct {
 	Workloads      []model.WorkloadProfile
 	DaemonSets     []model.WorkloadProfile
 	NodeTemplates  []model.NodeTemplate
 	SystemReserved model.ResourceQuantity
-	MaxNodes       int     // 0 = unlimited
-	MinNodes       int     // 0 = no minimum; pad with empty nodes if packing uses fewer
-	SpotRatio      float64 // Fraction of nodes to be spot (0.0 - 1.0)
+	MaxNodes       int      // 0 = unlimited
+	MinNodes       int      // 0 = no minimum; pad with empty nodes if packing uses fewer
+	SpotRatio      float64  // Fraction of nodes to be spot (0.0 - 1.0)
+	Zones          []string // Availability zones to spread nodes across; empty = zone-agnostic
+}
+
+// expanded returns the input with every workload's replicas expanded into
+// pods.
+func (in PackInput) expanded() PackInput {
+	in.Workloads = model.ExpandReplicas(in.Workloads)
+	return in
 }
 
 // PackResult is the output of a bin-packing run.
 type PackResult struct {
 	Nodes             []model.NodeAllocation
 	UnschedulablePods []model.WorkloadProfile
+	Optimality        string // model.OptimalityOptimal or model.OptimalityBestFound; empty for heuristics
+}
+
+// Packing algorithms, selected with the simulation.algorithm setting.
+const (
+	AlgorithmBestFit        = "best-fit-decreasing"
+	AlgorithmFirstFit       = "first-fit-decreasing"
+	AlgorithmDotProduct     = "dot-product"
+	AlgorithmLocalSearch    = "local-search"
+	AlgorithmBranchAndBound = "branch-and-bound"
+)
+
+// Algorithms lists the selectable packing algorithms, in the order they are
+// compared.
+var Algorithms = []string{
+	AlgorithmBestFit, AlgorithmFirstFit, AlgorithmDotProduct, AlgorithmLocalSearch, AlgorithmBranchAndBound,
+}
+
+// NewPacker returns the packer for an algorithm name. Unknown names get
+// best-fit decreasing; configuration validation rejects them.
+func NewPacker(algorithm string) BinPacker {
+	switch algorithm {
+	case AlgorithmFirstFit:
+		return &FirstFitDecreasing{}
+	case AlgorithmDotProduct:
+		return &DotProduct{}
+	case AlgorithmLocalSearch:
+		return &LocalSearch{}
+	case AlgorithmBranchAndBound:
+		return &BranchAndBound{}
+	default:
+		return &BestFitDecreasing{}
+	}
 }
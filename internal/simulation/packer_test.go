@@ -0,0 +1,198 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestNewPacker(t *testing.T) {
+	for _, name := range Algorithms {
+		if got := NewPacker(name).Name(); got != name {
+			t.Errorf("NewPacker(%q).Name() = %q", name, got)
+		}
+	}
+	if got := NewPacker("").Name(); got != AlgorithmBestFit {
+		t.Errorf("default packer = %q, want %q", got, AlgorithmBestFit)
+	}
+}
+
+func TestPackers_PlaceEveryPod(t *testing.T) {
+	input := PackInput{
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.192),
+			makeTemplate("c5.xlarge", 4000, 8*gib, 29, 0.170),
+			makeTemplate("r5.xlarge", 4000, 32*gib, 29, 0.252),
+		},
+		MinNodes: 2,
+	}
+	for i := range 40 {
+		input.Workloads = append(input.Workloads, makeWorkload(
+			"app-"+string(rune('a'+i%26))+string(rune('0'+i/26)),
+			int64(200+(i*137)%1500), int64(1+(i*7)%6)*gib/2))
+	}
+
+	for _, name := range Algorithms {
+		p := NewPacker(name)
+		if bb, ok := p.(*BranchAndBound); ok {
+			bb.TimeBudget = 100 * time.Millisecond
+		}
+		res, err := p.Pack(context.Background(), input)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(res.UnschedulablePods) > 0 {
+			t.Errorf("%s: %d unschedulable pods", name, len(res.UnschedulablePods))
+		}
+		placed := 0
+		for _, n := range res.Nodes {
+			placed += len(n.Workloads)
+			if n.UsedCPU > n.Template.AllocatableCPUMillis || n.UsedMem > n.Template.AllocatableMemoryBytes {
+				t.Errorf("%s: node %s overcommitted", name, n.Template.InstanceType)
+			}
+		}
+		if placed != len(input.Workloads) {
+			t.Errorf("%s: placed %d pods, want %d", name, placed, len(input.Workloads))
+		}
+	}
+}
+
+func TestPackers_ExpandReplicas(t *testing.T) {
+	api := makeWorkload("api", 1500, 2*gib)
+	api.OwnerKind, api.OwnerName, api.Replicas = "Deployment", "api", 5
+	api.ReplicaSizes = []model.ResourceQuantity{
+		{CPUMillis: 1500, MemoryBytes: 2 * gib}, {CPUMillis: 1500, MemoryBytes: 2 * gib},
+		{CPUMillis: 900, MemoryBytes: gib}, {CPUMillis: 600, MemoryBytes: gib}, {CPUMillis: 600, MemoryBytes: gib},
+	}
+	worker := makeWorkload("worker", 1000, 3*gib)
+	worker.OwnerKind, worker.OwnerName, worker.Replicas = "StatefulSet", "worker", 4
+	aggregated := PackInput{
+		Workloads:     []model.WorkloadProfile{api, worker, makeWorkload("debug", 100, gib)},
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.192)},
+	}
+	expanded := aggregated
+	expanded.Workloads = model.ExpandReplicas(aggregated.Workloads)
+
+	for _, name := range Algorithms {
+		got, err := NewPacker(name).Pack(context.Background(), aggregated)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want, err := NewPacker(name).Pack(context.Background(), expanded)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		placed := 0
+		for _, n := range got.Nodes {
+			placed += len(n.Workloads)
+			for _, w := range n.Workloads {
+				if w.Replicas != 0 {
+					t.Errorf("%s: placed %s with %d replicas, want single pods", name, w.Name, w.Replicas)
+				}
+			}
+		}
+		if placed != 10 || len(got.Nodes) != len(want.Nodes) {
+			t.Errorf("%s: placed %d pods on %d nodes, want 10 on %d", name, placed, len(got.Nodes), len(want.Nodes))
+		}
+	}
+}
+
+func TestDotProduct_Alignment(t *testing.T) {
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{
+			makeWorkload("cpu-heavy", 3500, 1*gib),
+			makeWorkload("mem-heavy", 1000, 13*gib),
+			makeWorkload("small", 100, 2*gib),
+		},
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.192)},
+	}
+	nodeOf := func(res *PackResult, name string) int {
+		for i, n := range res.Nodes {
+			for _, w := range n.Workloads {
+				if w.Name == name {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	// Best-fit puts the memory-leaning pod where the least is left, the
+	// memory-poor node; dot-product puts it where memory is left
+	bfd, _ := (&BestFitDecreasing{}).Pack(context.Background(), input)
+	dot, _ := (&DotProduct{}).Pack(context.Background(), input)
+	if got, want := nodeOf(bfd, "small"), nodeOf(bfd, "mem-heavy"); got != want {
+		t.Errorf("best-fit: small on node %d, want the mem-heavy node %d", got, want)
+	}
+	if got, want := nodeOf(dot, "small"), nodeOf(dot, "cpu-heavy"); got != want {
+		t.Errorf("dot-product: small on node %d, want the cpu-heavy node %d", got, want)
+	}
+}
+
+func TestFirstFitDecreasing(t *testing.T) {
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{
+			makeWorkload("a", 2500, gib),
+			makeWorkload("b", 2000, gib),
+			makeWorkload("c", 1000, gib),
+		},
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.192)},
+	}
+
+	// c goes to the first node with room left
+	res, err := (&FirstFitDecreasing{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 2 || len(res.Nodes[0].Workloads) != 2 {
+		t.Errorf("got %d nodes, first with %d pods, want 2 nodes with c on the first", len(res.Nodes), len(res.Nodes[0].Workloads))
+	}
+}
+
+func TestComparePackers(t *testing.T) {
+	state := model.ClusterState{}
+	for _, cpu := range []int64{4000, 4000, 3000, 3000, 3000, 3000} {
+		state.Workloads = append(state.Workloads, makeWorkload("app", cpu, gib))
+	}
+	scenario := Scenario{
+		Name:          "m5.2xlarge",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.2xlarge", 10000, 32*gib, 58, 0.384)},
+	}
+
+	runs, err := ComparePackers(context.Background(), []BinPacker{&BestFitDecreasing{}, &LocalSearch{}}, scenario, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Algorithm != AlgorithmBestFit || runs[1].Algorithm != AlgorithmLocalSearch {
+		t.Fatalf("runs = %+v", runs)
+	}
+	if runs[0].Nodes != 3 || runs[1].Nodes != 2 || runs[1].NodeDelta != -1 {
+		t.Errorf("nodes %d and %d (delta %d), want 3 and 2 (-1)", runs[0].Nodes, runs[1].Nodes, runs[1].NodeDelta)
+	}
+}
+
+func TestCompareReplicaLevels(t *testing.T) {
+	api := makeWorkload("api", 3000, gib)
+	api.OwnerKind, api.OwnerName, api.Replicas = "Deployment", "api", 3
+	api.ReplicaRange = &model.ReplicaRange{Min: 1, P95: 4, Max: 9}
+	state := model.ClusterState{Workloads: []model.WorkloadProfile{api}}
+	scenario := Scenario{
+		Name:          "m5.2xlarge",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.2xlarge", 10000, 32*gib, 58, 0.384)},
+	}
+
+	runs, err := CompareReplicaLevels(context.Background(), &BestFitDecreasing{}, scenario, state, model.ReplicaLevels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("got %d runs, want one per level", len(runs))
+	}
+	for i, want := range []struct{ pods, nodes int }{{3, 1}, {1, 1}, {4, 2}, {9, 3}} {
+		if runs[i].Pods != want.pods || runs[i].Nodes != want.nodes {
+			t.Errorf("%s: %d pods on %d nodes, want %d on %d", runs[i].Level, runs[i].Pods, runs[i].Nodes, want.pods, want.nodes)
+		}
+	}
+}
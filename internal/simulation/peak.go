@@ -0,0 +1,260 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// PeakAwareBestFit is a best-fit-decreasing packer that sizes nodes by the
+// combined usage of their pods over time rather than by the sum of each
+// pod's percentile. A pod fits a node when the requests still fit, as the
+// scheduler requires, and the node's combined usage at the overcommit
+// percentile of the timesteps stays within its capacity. Workloads that peak
+// at different times can then share a node.
+//
+// It needs the usage series of the workloads (WorkloadProfile.Series). Pods
+// without one count at their effective size at every step; when no pod has a
+// series it packs exactly like BestFitDecreasing.
+type PeakAwareBestFit struct {
+	// Percentile of the timesteps at which a node's combined usage must fit
+	// its capacity: 1.0 requires it at every step, 0.99 tolerates overcommit
+	// at 1% of them. Zero means 1.0.
+	Percentile float64
+}
+
+// Name returns the strategy name.
+func (p *PeakAwareBestFit) Name() string { return "peak-aware-best-fit" }
+
+// peakNode tracks the combined usage of a node's pods at every timestep.
+type peakNode struct {
+	capCPU, capMem int64   // allocatable after DaemonSets and system reserved
+	reqCPU, reqMem int64   // sum of the pods' requests
+	cpu, mem       []int64 // combined usage per step
+}
+
+// demand is a workload's usage at every timestep of the packing.
+type demand struct {
+	cpu, mem []int64
+}
+
+// Pack places workloads best-fit, checking every node's combined usage over
+// the timesteps.
+func (p *PeakAwareBestFit) Pack(ctx context.Context, input PackInput) (*PackResult, error) {
+	input = input.expanded()
+	if len(input.NodeTemplates) == 0 {
+		return &PackResult{UnschedulablePods: input.Workloads}, nil
+	}
+
+	workloads := make([]model.WorkloadProfile, len(input.Workloads))
+	copy(workloads, input.Workloads)
+	sortByDominance(workloads, input.NodeTemplates)
+
+	demands := usageDemands(workloads)
+	if demands == nil {
+		return (&BestFitDecreasing{}).Pack(ctx, input)
+	}
+	steps := len(demands[0].cpu)
+	// Steps at which a node may exceed its capacity
+	tolerated := int(math.Floor((1 - p.percentile()) * float64(steps)))
+
+	dsOverhead := model.SumEffectiveResources(input.DaemonSets)
+
+	var nodes []nodeState
+	var peaks []peakNode
+	var unschedulable []model.WorkloadProfile
+
+	for i := range workloads {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		w := &workloads[i]
+		d := &demands[i]
+
+		if j := peakBestFit(nodes, peaks, w, d, input.Zones, tolerated); j >= 0 {
+			place(&nodes[j], w)
+			peaks[j].add(w, d)
+			continue
+		}
+
+		if input.MaxNodes > 0 && len(nodes) >= input.MaxNodes {
+			unschedulable = append(unschedulable, markUnschedulable(*w, reasonMaxNodes))
+			continue
+		}
+
+		tmpl := selectBestTemplate(input.NodeTemplates, w, dsOverhead, input.SystemReserved)
+		if tmpl == nil {
+			reason := explainUnschedulable(input.NodeTemplates, w)
+			unschedulable = append(unschedulable, markUnschedulable(*w, reason))
+			continue
+		}
+
+		n := openPackNode(input, *tmpl, dsOverhead)
+		if reason := assignZone(nodes, &n, w, input.Zones); reason != "" {
+			unschedulable = append(unschedulable, markUnschedulable(*w, reason))
+			continue
+		}
+		pn := newPeakNode(&n, steps)
+		place(&n, w)
+		pn.add(w, d)
+		nodes = addNode(nodes, n)
+		peaks = append(peaks, pn)
+	}
+
+	// Report each node's usage at the percentile rather than the sum of its
+	// pods' effective sizes
+	for j := range nodes {
+		nodes[j].remainingCPU = peaks[j].capCPU - percentileOf(peaks[j].cpu, p.percentile())
+		nodes[j].remainingMem = peaks[j].capMem - percentileOf(peaks[j].mem, p.percentile())
+	}
+
+	return finishPack(nodes, unschedulable, input), nil
+}
+
+func (p *PeakAwareBestFit) percentile() float64 {
+	if p.Percentile <= 0 || p.Percentile > 1 {
+		return 1
+	}
+	return p.Percentile
+}
+
+// peakBestFit returns the index of the node whose peak usage grows the least
+// when w is added, or -1 when no node can take it. Ties go to the node with
+// the least average headroom.
+func peakBestFit(nodes []nodeState, peaks []peakNode, w *model.WorkloadProfile, d *demand, zones []string, tolerated int) int {
+	bestIdx := -1
+	bestGrowth, bestHeadroom := math.MaxFloat64, math.MaxFloat64
+	for j := range nodes {
+		n := &nodes[j]
+		if n.podCount >= n.template.MaxPods || admits(&n.template, w) != "" || !zoneMatches(n.labels, w) || !spotCompatible(n, w) {
+			continue
+		}
+		pn := &peaks[j]
+		if pn.reqCPU+w.Requested.CPUMillis > pn.capCPU || pn.reqMem+w.Requested.MemoryBytes > pn.capMem {
+			continue
+		}
+		growth, headroom, ok := pn.fit(d, tolerated)
+		if !ok || topologyAllows(nodes, n, w, zones) != "" {
+			continue
+		}
+		if growth < bestGrowth || (growth == bestGrowth && headroom < bestHeadroom) {
+			bestIdx, bestGrowth, bestHeadroom = j, growth, headroom
+		}
+	}
+	return bestIdx
+}
+
+func newPeakNode(n *nodeState, steps int) peakNode {
+	return peakNode{
+		capCPU: n.remainingCPU,
+		capMem: n.remainingMem,
+		cpu:    make([]int64, steps),
+		mem:    make([]int64, steps),
+	}
+}
+
+// fit checks whether the node can take the demand, exceeding its capacity
+// at no more than tolerated steps. It returns how much the node's peak
+// usage grows and its average headroom afterwards, both as the Euclidean
+// norm of the CPU and memory fractions of capacity.
+func (pn *peakNode) fit(d *demand, tolerated int) (growth, headroom float64, ok bool) {
+	if pn.capCPU <= 0 || pn.capMem <= 0 {
+		return 0, 0, false
+	}
+	var over int
+	var peakCPU, peakMem, newPeakCPU, newPeakMem, sumCPU, sumMem int64
+	for t := range pn.cpu {
+		cpu, mem := pn.cpu[t]+d.cpu[t], pn.mem[t]+d.mem[t]
+		if cpu > pn.capCPU || mem > pn.capMem {
+			if over++; over > tolerated {
+				return 0, 0, false
+			}
+		}
+		peakCPU, peakMem = max(peakCPU, pn.cpu[t]), max(peakMem, pn.mem[t])
+		newPeakCPU, newPeakMem = max(newPeakCPU, cpu), max(newPeakMem, mem)
+		sumCPU += cpu
+		sumMem += mem
+	}
+
+	steps := float64(len(pn.cpu))
+	growth = math.Hypot(
+		float64(newPeakCPU-peakCPU)/float64(pn.capCPU),
+		float64(newPeakMem-peakMem)/float64(pn.capMem))
+	headroom = math.Hypot(
+		1-float64(sumCPU)/steps/float64(pn.capCPU),
+		1-float64(sumMem)/steps/float64(pn.capMem))
+	return growth, headroom, true
+}
+
+// add accounts for a pod placed on the node.
+func (pn *peakNode) add(w *model.WorkloadProfile, d *demand) {
+	pn.reqCPU += w.Requested.CPUMillis
+	pn.reqMem += w.Requested.MemoryBytes
+	for t := range pn.cpu {
+		pn.cpu[t] += d.cpu[t]
+		pn.mem[t] += d.mem[t]
+	}
+}
+
+// usageDemands aligns the usage series of the workloads on a common
+// timeline, one step per series step from the earliest start to the latest
+// end. Steps outside a pod's series, and pods without one, count at the
+// effective size. It returns nil when no workload has a series.
+func usageDemands(workloads []model.WorkloadProfile) []demand {
+	var start, end time.Time
+	var step time.Duration
+	for i := range workloads {
+		s := workloads[i].Series
+		if s == nil || s.Step <= 0 || len(s.CPUMillis) == 0 {
+			continue
+		}
+		if step == 0 || s.Start.Before(start) {
+			start = s.Start
+		}
+		if e := s.Start.Add(time.Duration(len(s.CPUMillis)) * s.Step); e.After(end) {
+			end = e
+		}
+		step = max(step, s.Step)
+	}
+	if step == 0 {
+		return nil
+	}
+	steps := int(end.Sub(start) / step)
+
+	demands := make([]demand, len(workloads))
+	for i := range workloads {
+		w := &workloads[i]
+		d := demand{cpu: make([]int64, steps), mem: make([]int64, steps)}
+		for t := range steps {
+			d.cpu[t], d.mem[t] = w.EffectiveCPUMillis, w.EffectiveMemoryBytes
+		}
+		if s := w.Series; s != nil && s.Step == step {
+			offset := int(s.Start.Sub(start) / step)
+			for k := range s.CPUMillis {
+				if t := offset + k; t >= 0 && t < steps {
+					d.cpu[t] = s.CPUMillis[k]
+					if k < len(s.MemoryBytes) {
+						d.mem[t] = s.MemoryBytes[k]
+					}
+				}
+			}
+		}
+		demands[i] = d
+	}
+	return demands
+}
+
+// percentileOf returns the value at percentile p of the values, by the
+// nearest-rank method.
+func percentileOf(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
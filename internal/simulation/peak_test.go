@@ -0,0 +1,136 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// seriesWorkload returns a pod sized at its peak CPU, using 1 GiB, with the
+// given CPU usage at hourly steps.
+func seriesWorkload(name string, cpu ...int64) model.WorkloadProfile {
+	w := makeWorkload(name, 0, gib)
+	w.Series = &model.UsageSeries{
+		Start:       time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Step:        time.Hour,
+		CPUMillis:   cpu,
+		MemoryBytes: make([]int64, len(cpu)),
+	}
+	for i, c := range cpu {
+		w.EffectiveCPUMillis = max(w.EffectiveCPUMillis, c)
+		w.Series.MemoryBytes[i] = gib
+	}
+	return w
+}
+
+func peakInput(workloads ...model.WorkloadProfile) PackInput {
+	return PackInput{
+		Workloads:     workloads,
+		NodeTemplates: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.2)},
+	}
+}
+
+func TestPeakAware_AntiCorrelated(t *testing.T) {
+	// Web peaks by day, batch by night: summed peaks need two nodes, the
+	// combined usage never exceeds one
+	input := peakInput(
+		seriesWorkload("web", 3000, 3000, 500, 500),
+		seriesWorkload("batch", 500, 500, 3000, 3000),
+	)
+
+	bfd, err := (&BestFitDecreasing{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bfd.Nodes) != 2 {
+		t.Fatalf("best-fit decreasing: %d nodes, want 2", len(bfd.Nodes))
+	}
+
+	res, err := (&PeakAwareBestFit{Percentile: 1}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 1 {
+		t.Fatalf("peak-aware: %d nodes, want 1", len(res.Nodes))
+	}
+	if res.Nodes[0].UsedCPU != 3500 {
+		t.Errorf("used CPU = %d, want the combined peak of 3500", res.Nodes[0].UsedCPU)
+	}
+}
+
+func TestPeakAware_Correlated(t *testing.T) {
+	input := peakInput(
+		seriesWorkload("web-1", 3000, 3000, 500, 500),
+		seriesWorkload("web-2", 3000, 3000, 500, 500),
+	)
+	res, err := (&PeakAwareBestFit{Percentile: 1}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 2 {
+		t.Errorf("got %d nodes, want 2 for pods peaking together", len(res.Nodes))
+	}
+}
+
+func TestPeakAware_OvercommitPercentile(t *testing.T) {
+	// The pods overlap at one step of four
+	input := peakInput(
+		seriesWorkload("web", 3000, 500, 500, 500),
+		seriesWorkload("batch", 3000, 500, 500, 3000),
+	)
+
+	res, err := (&PeakAwareBestFit{Percentile: 1}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 2 {
+		t.Errorf("percentile 1: %d nodes, want 2", len(res.Nodes))
+	}
+
+	res, err = (&PeakAwareBestFit{Percentile: 0.75}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 1 {
+		t.Errorf("percentile 0.75: %d nodes, want 1", len(res.Nodes))
+	}
+}
+
+func TestPeakAware_Requests(t *testing.T) {
+	// Usage would fit, but the scheduler still needs room for the requests
+	web := seriesWorkload("web", 3000, 500)
+	batch := seriesWorkload("batch", 500, 3000)
+	web.Requested.CPUMillis = 2500
+	batch.Requested.CPUMillis = 2500
+
+	res, err := (&PeakAwareBestFit{Percentile: 1}).Pack(context.Background(), peakInput(web, batch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 2 {
+		t.Errorf("got %d nodes, want 2 when requests exceed capacity", len(res.Nodes))
+	}
+}
+
+func TestPeakAware_NoSeries(t *testing.T) {
+	input := peakInput(
+		makeWorkload("a", 3000, gib),
+		makeWorkload("b", 500, gib),
+		makeWorkload("c", 3000, gib),
+	)
+	input.MinNodes = 3
+
+	want, err := (&BestFitDecreasing{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&PeakAwareBestFit{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Nodes) != len(want.Nodes) {
+		t.Errorf("got %d nodes, want %d as best-fit decreasing", len(got.Nodes), len(want.Nodes))
+	}
+}
@@ -0,0 +1,306 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+// Autoscaler policies a replay can follow when removing nodes.
+const (
+	// PolicyClusterAutoscaler drains nodes below a utilization threshold
+	// whose pods fit on the remaining nodes, one node at a time.
+	PolicyClusterAutoscaler = "cluster-autoscaler"
+
+	// PolicyKarpenter replaces the whole fleet with a fresh bin-packing of
+	// the running pods whenever that is cheaper.
+	PolicyKarpenter = "karpenter"
+)
+
+// ReplayOptions configures how a replay scales the cluster.
+type ReplayOptions struct {
+	Policy               string  // PolicyClusterAutoscaler or PolicyKarpenter
+	RepackEvery          int     // steps between scale-down passes; 0 = never scale down
+	ScaleDownUtilization float64 // cluster-autoscaler only: nodes below this utilization are drained
+}
+
+// Replayer simulates an autoscaled cluster over a usage timeline. At every
+// step the running pods, sized by their usage at that step, are scheduled
+// best-fit onto the existing nodes; pods that fit nowhere are packed onto new
+// nodes, as a scale-up would. Every RepackEvery steps nodes are removed
+// according to the policy.
+type Replayer struct {
+	Packer  BinPacker
+	Options ReplayOptions
+}
+
+// replay holds the state of one replay run.
+type replay struct {
+	packer     BinPacker
+	opts       ReplayOptions
+	scenario   Scenario
+	state      model.ClusterState
+	dsOverhead model.ResourceQuantity
+	byKey      map[string]*model.WorkloadProfile // a snapshot pod per controller, for requests and constraints
+	hosts      int                               // hostnames handed out so far
+}
+
+// Replay runs the scenario's instance types over the timeline and reports
+// the node-hours and cost integrated over time.
+func (r *Replayer) Replay(
+	ctx context.Context,
+	scenario Scenario,
+	state model.ClusterState,
+	timeline *model.UsageTimeline,
+) (*model.ReplayResult, error) {
+	steps := timeline.Steps()
+	if steps == 0 || timeline.Step <= 0 {
+		return nil, fmt.Errorf("replaying scenario %q: empty usage timeline", scenario.Name)
+	}
+
+	rp := &replay{
+		packer:     r.Packer,
+		opts:       r.Options,
+		scenario:   scenario,
+		state:      state,
+		dsOverhead: model.SumEffectiveResources(state.DaemonSets),
+		byKey:      make(map[string]*model.WorkloadProfile),
+	}
+	for i := range state.Workloads {
+		if k := state.Workloads[i].ControllerKey(); rp.byKey[k] == nil {
+			rp.byKey[k] = &state.Workloads[i]
+		}
+	}
+
+	res := &model.ReplayResult{
+		Policy:     r.Options.Policy,
+		Steps:      steps,
+		Step:       timeline.Step,
+		MinNodes:   math.MaxInt,
+		NodeCounts: make([]int, 0, steps),
+	}
+	stepHours := timeline.Step.Hours()
+	var cpuUtil, memUtil float64
+
+	var nodes []nodeState
+	for t := 0; t < steps; t++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		pods := rp.podsAt(timeline, t)
+
+		var unschedulable int
+		if t == 0 {
+			var err error
+			nodes, unschedulable, err = rp.pack(ctx, pods, scenario.MinNodes)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			var pending []model.WorkloadProfile
+			nodes, pending = rp.schedule(nodes, pods)
+			if len(pending) > 0 {
+				added, n, err := rp.pack(ctx, pending, 0)
+				if err != nil {
+					return nil, err
+				}
+				unschedulable = n
+				if len(added) > 0 {
+					res.ScaleUps++
+					nodes = append(nodes, added...)
+				}
+				res.PeakPendingPods = max(res.PeakPendingPods, len(pending)-n)
+			}
+		}
+		res.UnschedulablePods = max(res.UnschedulablePods, unschedulable)
+
+		var allocCPU, allocMem, usedCPU, usedMem int64
+		for i := range nodes {
+			n := &nodes[i]
+			res.Cost += n.template.EffectivePricePerHour() * stepHours
+			allocCPU += n.template.AllocatableCPUMillis
+			allocMem += n.template.AllocatableMemoryBytes
+			usedCPU += n.template.AllocatableCPUMillis - n.remainingCPU
+			usedMem += n.template.AllocatableMemoryBytes - n.remainingMem
+		}
+		if allocCPU > 0 {
+			cpuUtil += float64(usedCPU) / float64(allocCPU)
+		}
+		if allocMem > 0 {
+			memUtil += float64(usedMem) / float64(allocMem)
+		}
+		res.NodeHours += float64(len(nodes)) * stepHours
+		res.MinNodes = min(res.MinNodes, len(nodes))
+		res.PeakNodes = max(res.PeakNodes, len(nodes))
+		res.NodeCounts = append(res.NodeCounts, len(nodes))
+
+		if r.Options.RepackEvery > 0 && (t+1)%r.Options.RepackEvery == 0 {
+			var err error
+			nodes, err = rp.scaleDown(ctx, nodes, pods, unschedulable)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	hours := float64(steps) * stepHours
+	res.AvgNodes = res.NodeHours / hours
+	res.MonthlyCost = res.Cost * model.HoursPerMonth / hours
+	res.AvgCPUUtilization = cpuUtil / float64(steps)
+	res.AvgMemUtilization = memUtil / float64(steps)
+	return res, nil
+}
+
+// podsAt returns the pods running at step t. Each pod takes its share of
+// its controller's usage, and never less than its request.
+func (rp *replay) podsAt(timeline *model.UsageTimeline, t int) []model.WorkloadProfile {
+	var pods []model.WorkloadProfile
+	for i := range timeline.Series {
+		s := &timeline.Series[i]
+		if t >= len(s.Pods) || s.Pods[t] <= 0 {
+			continue
+		}
+		count := int64(s.Pods[t])
+
+		base := model.WorkloadProfile{Namespace: s.Namespace, OwnerKind: s.OwnerKind, OwnerName: s.OwnerName}
+		if w := rp.byKey[s.Key()]; w != nil {
+			base = w.Expand()[0]
+		}
+		var cpu, mem int64
+		if t < len(s.CPUMillis) {
+			cpu = s.CPUMillis[t] / count
+		}
+		if t < len(s.MemoryBytes) {
+			mem = s.MemoryBytes[t] / count
+		}
+		base.EffectiveCPUMillis = max(base.Requested.CPUMillis, cpu)
+		base.EffectiveMemoryBytes = max(base.Requested.MemoryBytes, mem)
+
+		for j := range count {
+			pod := base
+			pod.Name = fmt.Sprintf("%s-%d", s.OwnerName, j)
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+// schedule places the pods best-fit onto empty copies of the nodes, and
+// returns the pods that fit nowhere.
+func (rp *replay) schedule(nodes []nodeState, pods []model.WorkloadProfile) ([]nodeState, []model.WorkloadProfile) {
+	fresh := make([]nodeState, len(nodes))
+	for i := range nodes {
+		fresh[i] = openNode(nodes[i].template, rp.dsOverhead, rp.state.SystemReserved)
+		fresh[i].labels = maps.Clone(nodes[i].labels)
+	}
+
+	sortByDominance(pods, rp.scenario.InstanceTypes)
+	var pending []model.WorkloadProfile
+	for i := range pods {
+		w := &pods[i]
+		if j := bestFit(fresh, w, rp.scenario.Zones); j >= 0 {
+			place(&fresh[j], w)
+			continue
+		}
+		pending = append(pending, *w)
+	}
+	return fresh, pending
+}
+
+// pack bin-packs the pods onto new nodes, and returns the nodes and the
+// number of pods no instance type could host.
+func (rp *replay) pack(ctx context.Context, pods []model.WorkloadProfile, minNodes int) ([]nodeState, int, error) {
+	result, err := rp.packer.Pack(ctx, PackInput{
+		Workloads:      pods,
+		DaemonSets:     rp.state.DaemonSets,
+		NodeTemplates:  rp.scenario.InstanceTypes,
+		SystemReserved: rp.state.SystemReserved,
+		MinNodes:       minNodes,
+		SpotRatio:      rp.scenario.SpotRatio,
+		Zones:          rp.scenario.Zones,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("replaying scenario %q: %w", rp.scenario.Name, err)
+	}
+
+	nodes := make([]nodeState, len(result.Nodes))
+	for i := range result.Nodes {
+		nodes[i] = restoreNode(&result.Nodes[i], fmt.Sprintf("node-%d", rp.hosts))
+		rp.hosts++
+	}
+	return nodes, len(result.UnschedulablePods), nil
+}
+
+// scaleDown removes nodes according to the replay policy.
+func (rp *replay) scaleDown(ctx context.Context, nodes []nodeState, pods []model.WorkloadProfile, unschedulable int) ([]nodeState, error) {
+	if rp.opts.Policy == PolicyKarpenter {
+		repacked, n, err := rp.pack(ctx, pods, rp.scenario.MinNodes)
+		if err != nil {
+			return nil, err
+		}
+		if n <= unschedulable && hourlyCost(repacked) < hourlyCost(nodes) {
+			return repacked, nil
+		}
+		return nodes, nil
+	}
+
+	// Consider the least utilized nodes first
+	order := make([]string, len(nodes))
+	for i := range nodes {
+		order[i] = nodes[i].labels[model.TopologyHostname]
+	}
+	load := make(map[string]float64, len(nodes))
+	for i := range nodes {
+		load[order[i]] = stateLoad(&nodes[i])
+	}
+	sort.SliceStable(order, func(i, j int) bool { return load[order[i]] < load[order[j]] })
+
+	for _, host := range order {
+		if len(nodes) <= rp.scenario.MinNodes {
+			break
+		}
+		i := slices.IndexFunc(nodes, func(n nodeState) bool { return n.labels[model.TopologyHostname] == host })
+		if stateLoad(&nodes[i]) >= rp.opts.ScaleDownUtilization {
+			continue
+		}
+		if trial, ok := evict(nodes, i, rp.scenario.Zones); ok {
+			nodes = trial
+		}
+	}
+	return nodes, nil
+}
+
+// cloneNode returns a copy of n that can be modified independently.
+func cloneNode(n *nodeState) nodeState {
+	c := *n
+	c.workloads = slices.Clone(n.workloads)
+	c.labels = maps.Clone(n.labels)
+	c.groups = maps.Clone(n.groups)
+	return c
+}
+
+// stateLoad returns the utilization of a node's most constrained dimension,
+// overhead included.
+func stateLoad(n *nodeState) float64 {
+	alloc := n.template.AllocatableResources()
+	if alloc.CPUMillis == 0 || alloc.MemoryBytes == 0 {
+		return 0
+	}
+	cpu := float64(alloc.CPUMillis-n.remainingCPU) / float64(alloc.CPUMillis)
+	mem := float64(alloc.MemoryBytes-n.remainingMem) / float64(alloc.MemoryBytes)
+	return math.Max(cpu, mem)
+}
+
+// hourlyCost returns the price per hour of the nodes.
+func hourlyCost(nodes []nodeState) float64 {
+	var total float64
+	for i := range nodes {
+		total += nodes[i].template.EffectivePricePerHour()
+	}
+	return total
+}
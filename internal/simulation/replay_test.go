@@ -0,0 +1,128 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+const gib = 1024 * 1024 * 1024
+
+// diurnalTimeline returns one Deployment running the given number of pods at
+// each hourly step, every pod using 2 vCPUs and 1 GiB.
+func diurnalTimeline(pods ...int32) *model.UsageTimeline {
+	s := model.WorkloadSeries{Namespace: "default", OwnerKind: "Deployment", OwnerName: "api"}
+	for _, n := range pods {
+		s.Pods = append(s.Pods, n)
+		s.CPUMillis = append(s.CPUMillis, int64(n)*2000)
+		s.MemoryBytes = append(s.MemoryBytes, int64(n)*gib)
+	}
+	return &model.UsageTimeline{Step: time.Hour, Series: []model.WorkloadSeries{s}}
+}
+
+func replayScenario(minNodes int) Scenario {
+	return Scenario{
+		Name:          "m5.xlarge",
+		InstanceTypes: []model.NodeTemplate{makeTemplate("m5.xlarge", 4000, 16*gib, 29, 0.2)},
+		Strategy:      "homogeneous",
+		MinNodes:      minNodes,
+	}
+}
+
+func TestReplay_ClusterAutoscaler(t *testing.T) {
+	r := &Replayer{
+		Packer:  &BestFitDecreasing{},
+		Options: ReplayOptions{Policy: PolicyClusterAutoscaler, RepackEvery: 2, ScaleDownUtilization: 0.5},
+	}
+	timeline := diurnalTimeline(4, 4, 4, 4, 1, 1, 1, 1, 4, 4, 4, 4)
+
+	res, err := r.Replay(context.Background(), replayScenario(0), model.ClusterState{}, timeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The empty node goes at the scale-down pass after step 5, and a node
+	// comes back when the load returns at step 8
+	want := []int{2, 2, 2, 2, 2, 2, 1, 1, 2, 2, 2, 2}
+	if !slices.Equal(res.NodeCounts, want) {
+		t.Errorf("node counts = %v, want %v", res.NodeCounts, want)
+	}
+	if res.NodeHours != 22 || math.Abs(res.Cost-4.4) > 1e-9 {
+		t.Errorf("node-hours %v cost %v, want 22 and 4.4", res.NodeHours, res.Cost)
+	}
+	if res.MinNodes != 1 || res.PeakNodes != 2 {
+		t.Errorf("nodes min %d peak %d, want 1 and 2", res.MinNodes, res.PeakNodes)
+	}
+	if res.ScaleUps != 1 || res.PeakPendingPods != 2 {
+		t.Errorf("scale-ups %d pending %d, want 1 and 2", res.ScaleUps, res.PeakPendingPods)
+	}
+	if math.Abs(res.MonthlyCost-4.4*model.HoursPerMonth/12) > 1e-9 {
+		t.Errorf("monthly cost = %v, want cost scaled to a month", res.MonthlyCost)
+	}
+}
+
+func TestReplay_MinNodes(t *testing.T) {
+	r := &Replayer{
+		Packer:  &BestFitDecreasing{},
+		Options: ReplayOptions{Policy: PolicyClusterAutoscaler, RepackEvery: 1, ScaleDownUtilization: 0.5},
+	}
+	res, err := r.Replay(context.Background(), replayScenario(2), model.ClusterState{}, diurnalTimeline(4, 0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MinNodes != 2 || res.NodeHours != 8 {
+		t.Errorf("nodes min %d, node-hours %v, want the HA minimum of 2 kept", res.MinNodes, res.NodeHours)
+	}
+}
+
+func TestReplay_Karpenter(t *testing.T) {
+	r := &Replayer{
+		Packer:  &BestFitDecreasing{},
+		Options: ReplayOptions{Policy: PolicyKarpenter, RepackEvery: 2},
+	}
+
+	// The fleet is replaced by a fresh packing of the single remaining pod at
+	// the pass after step 5
+	res, err := r.Replay(context.Background(), replayScenario(0), model.ClusterState{}, diurnalTimeline(1, 2, 3, 4, 1, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 1, 2, 2, 2, 2, 1}
+	if !slices.Equal(res.NodeCounts, want) {
+		t.Errorf("node counts = %v, want %v", res.NodeCounts, want)
+	}
+	if res.PeakNodes != 2 || res.UnschedulablePods != 0 {
+		t.Errorf("peak %d unschedulable %d, want 2 and 0", res.PeakNodes, res.UnschedulablePods)
+	}
+}
+
+func TestReplay_RequestsFloor(t *testing.T) {
+	// The snapshot pod requests 3 vCPUs: usage below that does not shrink it
+	state := model.ClusterState{Workloads: []model.WorkloadProfile{{
+		Namespace: "default",
+		Name:      "api-7d4b9c8f6d-x2k4p",
+		OwnerKind: "ReplicaSet",
+		OwnerName: "api-7d4b9c8f6d",
+		Requested: model.ResourceQuantity{CPUMillis: 3000},
+	}}}
+	r := &Replayer{Packer: &BestFitDecreasing{}, Options: ReplayOptions{Policy: PolicyClusterAutoscaler}}
+
+	res, err := r.Replay(context.Background(), replayScenario(0), state, diurnalTimeline(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PeakNodes != 2 {
+		t.Errorf("peak nodes = %d, want one per 3-vCPU pod", res.PeakNodes)
+	}
+}
+
+func TestReplay_EmptyTimeline(t *testing.T) {
+	r := &Replayer{Packer: &BestFitDecreasing{}}
+	if _, err := r.Replay(context.Background(), replayScenario(0), model.ClusterState{}, &model.UsageTimeline{Step: time.Hour}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -27,14 +27,14 @@ func (s *Scorer) RankResults(results []model.SimulationResult, baseline *model.S
 		return nil
 	}
 
-	// Find cost bounds for normalization
-	minCost, maxCost := results[0].TotalCost, results[0].TotalCost
+	// Find cost bounds for normalization (after commitments, when modeled)
+	minCost, maxCost := results[0].EffectiveCost(), results[0].EffectiveCost()
 	for _, r := range results[1:] {
-		if r.TotalCost < minCost {
-			minCost = r.TotalCost
+		if c := r.EffectiveCost(); c < minCost {
+			minCost = c
 		}
-		if r.TotalCost > maxCost {
-			maxCost = r.TotalCost
+		if c := r.EffectiveCost(); c > maxCost {
+			maxCost = c
 		}
 	}
 
@@ -63,21 +63,23 @@ func (s *Scorer) score(
 ) model.Recommendation {
 	rec := model.Recommendation{
 		SimulationResult: r,
-		MonthlyCost:      r.TotalCost,
+		MonthlyCost:      r.EffectiveCost(),
+		ListMonthlyCost:  r.TotalCost,
 	}
 
 	// Cost score: 100 = cheapest, 0 = most expensive
 	costRange := maxCost - minCost
 	if costRange > 0 {
-		rec.CostScore = (1.0 - (r.TotalCost-minCost)/costRange) * 100
+		rec.CostScore = (1.0 - (rec.MonthlyCost-minCost)/costRange) * 100
 	} else {
 		rec.CostScore = 100
 	}
 
 	// Cost vs baseline
-	if baseline != nil && baseline.TotalCost > 0 {
-		rec.CostVsBaseline = ((r.TotalCost - baseline.TotalCost) / baseline.TotalCost) * 100
-		rec.AnnualSavings = (baseline.TotalCost - r.TotalCost) * 12
+	if baseline != nil && baseline.EffectiveCost() > 0 {
+		baseCost := baseline.EffectiveCost()
+		rec.CostVsBaseline = ((rec.MonthlyCost - baseCost) / baseCost) * 100
+		rec.AnnualSavings = (baseCost - rec.MonthlyCost) * 12
 	}
 
 	// Utilization score: average of CPU and memory utilization (0-100)
@@ -87,27 +89,9 @@ func (s *Scorer) score(
 	rec.FragmentationScore = r.Fragmentation.ResourceBalanceScore * 100 *
 		(1.0 - r.Fragmentation.UnderutilizedNodeFraction)
 
-	// Resilience score: penalize too few nodes (single point of failure)
-	// and too many nodes (management overhead, DaemonSet waste per AWS best practices).
-	// "Fewer, larger instances are better, especially with many DaemonSets."
-	switch {
-	case r.TotalNodes <= 1:
-		rec.ResilienceScore = 20
-	case r.TotalNodes <= 2:
-		rec.ResilienceScore = 50
-	case r.TotalNodes <= 5:
-		rec.ResilienceScore = 90
-	case r.TotalNodes <= 15:
-		rec.ResilienceScore = 100
-	case r.TotalNodes <= 30:
-		rec.ResilienceScore = 85
-	case r.TotalNodes <= 50:
-		rec.ResilienceScore = 70
-	case r.TotalNodes <= 100:
-		rec.ResilienceScore = 55
-	default:
-		rec.ResilienceScore = 40
-	}
+	// Resilience score: how much of the load displaced by losing the one and
+	// two most-loaded nodes the survivors can absorb
+	rec.ResilienceScore = resilienceFromHeadroom(r.FailureHeadroom)
 
 	// Penalize high DaemonSet overhead: each DS runs on every node,
 	// so more nodes = more wasted resources on DS replicas
@@ -123,6 +107,19 @@ func (s *Scorer) score(
 		rec.ResilienceScore = math.Max(0, rec.ResilienceScore-penalty)
 	}
 
+	// Penalize layouts that cannot survive the loss of an availability zone
+	if zf := r.WorstZoneFailure(); zf != nil && !zf.Fits {
+		penalty := math.Min(float64(zf.UnschedulablePods)*10, 30)
+		rec.ResilienceScore = math.Max(0, rec.ResilienceScore-penalty)
+	}
+
+	// Penalize spot interruptions: a point per percent of pods evicted per
+	// month, up to 20
+	if sd := r.SpotDisruption; sd != nil {
+		penalty := math.Min(sd.EvictedFraction*100, 20)
+		rec.ResilienceScore = math.Max(0, rec.ResilienceScore-penalty)
+	}
+
 	// Penalize poor trough utilization when scaling data is available
 	if r.ScalingEfficiency != nil && r.ScalingEfficiency.EstTroughCPUUtil < 0.30 {
 		// Scale penalty: 0% trough → -25 points, 30% trough → 0 points
@@ -145,12 +142,30 @@ func (s *Scorer) score(
 	return rec
 }
 
+// resilienceFromHeadroom scores node failure tolerance from 0 to 100, weighting
+// N-1 at 60 points and N-2 at 40, each scaled by the fraction of displaced pods
+// the surviving nodes can re-host. Results without a failure analysis score 100.
+func resilienceFromHeadroom(fh *model.FailureHeadroom) float64 {
+	if fh == nil {
+		return 100
+	}
+	return 60*absorbedFraction(fh.N1) + 40*absorbedFraction(fh.N2)
+}
+
+// absorbedFraction returns the share of displaced pods that found a new node.
+func absorbedFraction(f model.NodeFailure) float64 {
+	if f.DisplacedPods == 0 {
+		return 1
+	}
+	return float64(f.DisplacedPods-f.StrandedPods) / float64(f.DisplacedPods)
+}
+
 func generateRationale(rec model.Recommendation) string {
 	r := rec.SimulationResult
 	label := r.InstanceConfig.Label()
 
 	rationale := fmt.Sprintf("%s: %d nodes, $%.0f/mo, CPU %.0f%%, Mem %.0f%%",
-		label, r.TotalNodes, r.TotalCost,
+		label, r.TotalNodes, rec.MonthlyCost,
 		r.AvgCPUUtilization*100, r.AvgMemUtilization*100)
 
 	if rec.CostVsBaseline < 0 {
@@ -166,6 +181,7 @@ func generateWarnings(r model.SimulationResult) []string {
 	if len(r.UnschedulablePods) > 0 {
 		warnings = append(warnings,
 			fmt.Sprintf("%d pods could not be scheduled", len(r.UnschedulablePods)))
+		warnings = append(warnings, unschedulableReasonWarnings(r.UnschedulablePods)...)
 	}
 
 	if r.AvgCPUUtilization > HighUtilThreshold {
@@ -190,10 +206,81 @@ func generateWarnings(r model.SimulationResult) []string {
 				r.ScalingEfficiency.ObservedMaxNodes))
 	}
 
+	// Affinity / spread overhead
+	if r.ConstraintOverhead != nil && r.ConstraintOverhead.ExtraNodes > 0 {
+		warnings = append(warnings,
+			fmt.Sprintf("Affinity and topology spread rules cost %d extra nodes ($%.0f/mo)",
+				r.ConstraintOverhead.ExtraNodes, r.ConstraintOverhead.ExtraMonthlyCost))
+	}
+
+	// Node failure headroom
+	if fh := r.FailureHeadroom; fh != nil && !fh.N1.Survives {
+		warnings = append(warnings,
+			fmt.Sprintf("Losing the most-loaded node strands %d of %d displaced pods (no N+1 headroom)",
+				fh.N1.StrandedPods, fh.N1.DisplacedPods))
+	}
+
+	// Zone failure
+	if zf := r.WorstZoneFailure(); zf != nil && !zf.Fits {
+		warnings = append(warnings,
+			fmt.Sprintf("Losing zone %s leaves %d pods unschedulable", zf.Zone, zf.UnschedulablePods))
+	}
+
+	// Commitments paid for but not used by this configuration
+	if cc := r.CommittedCost; cc != nil && cc.UnusedCommitmentMonthly >= 1 {
+		warnings = append(warnings,
+			fmt.Sprintf("$%.0f/mo of Reserved Instance and Savings Plan commitments go unused", cc.UnusedCommitmentMonthly))
+	}
+
+	// Distance from the lower bound
+	if lb := r.LowerBound; lb != nil && lb.CostGap > HighOptimalityGap*100 && r.Optimality != model.OptimalityOptimal {
+		warning := fmt.Sprintf("Packing costs %.0f%% more than the lower bound of %d nodes ($%.0f/mo); a better packing may exist",
+			lb.CostGap, lb.Nodes, lb.MonthlyCost)
+		if r.Algorithm != AlgorithmLocalSearch && r.Algorithm != AlgorithmBranchAndBound {
+			warning += " (try --algorithm local-search)"
+		}
+		warnings = append(warnings, warning)
+	}
+
 	// Spot warnings
 	if r.InstanceConfig.SpotRatio > HighSpotRatio {
 		warnings = append(warnings, "High spot ratio increases interruption risk")
 	}
+	if sd := r.SpotDisruption; sd != nil {
+		if sd.EvictedFraction > HighSpotDisruption {
+			warnings = append(warnings,
+				fmt.Sprintf("Spot interruptions are expected to evict %.0f pods per month (%.0f%% of pods)",
+					sd.EvictionsPerMonth, sd.EvictedFraction*100))
+		}
+		if sd.SpotShortfall > 0 {
+			warnings = append(warnings,
+				fmt.Sprintf("%d nodes stay on-demand for %d StatefulSet, PDB-protected or on-demand pods; spot ratio not reached",
+					sd.SpotShortfall, sd.ProtectedPods))
+		}
+	}
 
 	return warnings
 }
+
+// unschedulableReasonWarnings groups unschedulable pods by reason, one warning
+// per distinct reason in first-seen order.
+func unschedulableReasonWarnings(pods []model.WorkloadProfile) []string {
+	counts := make(map[string]int)
+	var order []string
+	for i := range pods {
+		reason := pods[i].UnschedulableReason
+		if reason == "" {
+			continue
+		}
+		if counts[reason] == 0 {
+			order = append(order, reason)
+		}
+		counts[reason]++
+	}
+
+	warnings := make([]string, len(order))
+	for i, reason := range order {
+		warnings[i] = fmt.Sprintf("%d unschedulable pods: %s", counts[reason], reason)
+	}
+	return warnings
+}
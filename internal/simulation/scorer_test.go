@@ -1,6 +1,8 @@
 package simulation
 
 import (
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/guimove/clusterfit/internal/model"
@@ -43,6 +45,30 @@ func TestScorer_CheapestWins(t *testing.T) {
 	}
 }
 
+func TestScorer_CommittedCostRanks(t *testing.T) {
+	scorer := NewScorer(model.ScoringWeights{Cost: 1.0})
+
+	// The list-price winner loses once the other option's RIs are counted
+	covered := makeSimResult(1000, 0.5, 0.5, 10)
+	covered.CommittedCost = &model.CommittedCost{EffectiveMonthlyCost: 600}
+	uncovered := makeSimResult(800, 0.5, 0.5, 10)
+	uncovered.CommittedCost = &model.CommittedCost{EffectiveMonthlyCost: 1400, UnusedCommitmentMonthly: 600}
+
+	recs := scorer.RankResults([]model.SimulationResult{uncovered, covered}, nil)
+	if recs[0].MonthlyCost != 600 || recs[0].ListMonthlyCost != 1000 {
+		t.Errorf("top = $%v effective / $%v list, want 600 / 1000", recs[0].MonthlyCost, recs[0].ListMonthlyCost)
+	}
+	found := false
+	for _, w := range recs[1].Warnings {
+		if strings.Contains(w, "$600/mo of Reserved Instance and Savings Plan commitments go unused") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected unused commitment warning, got %v", recs[1].Warnings)
+	}
+}
+
 func TestScorer_UtilizationMatters(t *testing.T) {
 	scorer := NewScorer(model.ScoringWeights{
 		Cost: 0, Utilization: 1.0, Fragmentation: 0, Resilience: 0,
@@ -188,3 +214,39 @@ func TestScorer_EmptyResults(t *testing.T) {
 		t.Errorf("expected nil for empty results, got %v", recs)
 	}
 }
+
+func TestScorer_ResilienceFromHeadroom(t *testing.T) {
+	scorer := NewScorer(model.ScoringWeights{Resilience: 1.0})
+
+	robust := makeSimResult(500, 0.6, 0.6, 4)
+	robust.FailureHeadroom = &model.FailureHeadroom{
+		N1: model.NodeFailure{FailedNodes: 1, DisplacedPods: 10, Survives: true},
+		N2: model.NodeFailure{FailedNodes: 2, DisplacedPods: 20, Survives: true},
+	}
+	fragile := makeSimResult(500, 0.9, 0.9, 4)
+	fragile.FailureHeadroom = &model.FailureHeadroom{
+		N1: model.NodeFailure{FailedNodes: 1, DisplacedPods: 10, StrandedPods: 5},
+		N2: model.NodeFailure{FailedNodes: 2, DisplacedPods: 20, StrandedPods: 20},
+	}
+
+	recsRobust := scorer.RankResults([]model.SimulationResult{robust}, nil)
+	recsFragile := scorer.RankResults([]model.SimulationResult{fragile}, nil)
+
+	if got := recsRobust[0].ResilienceScore; got != 100 {
+		t.Errorf("robust resilience = %v, want 100", got)
+	}
+	// 60 * 0.5 (half of N-1 absorbed) + 40 * 0
+	if got := recsFragile[0].ResilienceScore; math.Abs(got-30) > 0.01 {
+		t.Errorf("fragile resilience = %v, want 30", got)
+	}
+
+	found := false
+	for _, w := range recsFragile[0].Warnings {
+		if strings.Contains(w, "N+1") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected N+1 headroom warning, got %v", recsFragile[0].Warnings)
+	}
+}
@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"math"
+	"slices"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+const (
+	// DefaultSpotInterruptionRate is the share of spot instances assumed
+	// interrupted per month for instance types without interruption data:
+	// AWS reports fewer than 5% interrupted on average.
+	DefaultSpotInterruptionRate = 0.05
+
+	// HighSpotDisruption is the share of pods evicted by spot interruptions
+	// per month above which a disruption warning fires.
+	HighSpotDisruption = 0.05
+)
+
+// interruptionRate returns the monthly interruption rate of a spot node of
+// the template, or the default when the instance type has no data.
+func interruptionRate(t *model.NodeTemplate) float64 {
+	if t.SpotInterruptionRate > 0 {
+		return t.SpotInterruptionRate
+	}
+	return DefaultSpotInterruptionRate
+}
+
+// AnalyzeSpotDisruption estimates the disruption spot interruptions cause:
+// the interruptions expected per month across spot nodes, and the pods they
+// evict. It also counts the pods kept off spot and the spot nodes the ratio
+// asked for but could not get because of them. It returns nil when there is
+// no spot capacity and none was asked for.
+func AnalyzeSpotDisruption(nodes []model.NodeAllocation, spotRatio float64) *model.SpotDisruption {
+	d := &model.SpotDisruption{}
+	var pods int
+	estimated := make(map[string]bool)
+	for i := range nodes {
+		n := &nodes[i]
+		pods += len(n.Workloads)
+		for j := range n.Workloads {
+			if !n.Workloads[j].ToleratesSpot() {
+				d.ProtectedPods++
+			}
+		}
+		if n.Template.CapacityType != model.CapacitySpot {
+			continue
+		}
+		rate := interruptionRate(&n.Template)
+		d.SpotNodes++
+		d.InterruptionsPerMonth += rate
+		d.EvictionsPerMonth += rate * float64(len(n.Workloads))
+		if n.Template.SpotInterruptionRate <= 0 && !estimated[n.Template.InstanceType] {
+			estimated[n.Template.InstanceType] = true
+			d.EstimatedTypes = append(d.EstimatedTypes, n.Template.InstanceType)
+		}
+	}
+	if spotRatio > 0 {
+		target := min(len(nodes), int(math.Round(float64(len(nodes))*spotRatio)))
+		d.SpotShortfall = max(0, target-d.SpotNodes)
+	}
+	if d.SpotNodes == 0 && d.SpotShortfall == 0 {
+		return nil
+	}
+	if pods > 0 {
+		d.EvictedFraction = d.EvictionsPerMonth / float64(pods)
+	}
+	slices.Sort(d.EstimatedTypes)
+	return d
+}
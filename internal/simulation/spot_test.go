@@ -0,0 +1,197 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/guimove/clusterfit/internal/model"
+)
+
+func TestApplySpotRatio_PrefersReliableTypes(t *testing.T) {
+	flaky := makeTemplate("m5.large", 2000, 8*gib, 29, 0.096)
+	flaky.SpotInterruptionRate = 0.25
+	steady := makeTemplate("c5.large", 2000, 4*gib, 29, 0.085)
+	steady.SpotInterruptionRate = 0.025
+
+	var nodes []nodeState
+	for _, tmpl := range []model.NodeTemplate{flaky, steady, flaky, steady} {
+		n := openNode(tmpl, model.ResourceQuantity{}, model.ResourceQuantity{})
+		w := makeWorkload("app", 500, gib)
+		place(&n, &w)
+		nodes = append(nodes, n)
+	}
+	applySpotRatio(nodes, 0.5)
+
+	for i := range nodes {
+		wantSpot := nodes[i].template.InstanceType == "c5.large"
+		if got := nodes[i].template.CapacityType == model.CapacitySpot; got != wantSpot {
+			t.Errorf("node %d (%s) spot = %v, want %v", i, nodes[i].template.InstanceType, got, wantSpot)
+		}
+	}
+}
+
+func TestBFD_SpotRatio_KeepsProtectedPodsOnDemand(t *testing.T) {
+	db := makeWorkload("db-0", 1500, gib)
+	db.OwnerKind, db.OwnerName = "StatefulSet", "db"
+	api := makeWorkload("api-1", 1500, gib)
+	api.DisruptionBudget = true
+
+	input := PackInput{
+		Workloads: []model.WorkloadProfile{db, api, makeWorkload("batch-1", 1500, gib), makeWorkload("batch-2", 1500, gib)},
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.large", 2000, 8*gib, 29, 0.096),
+		},
+		SpotRatio: 1.0,
+	}
+	result, err := (&BestFitDecreasing{}).Pack(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spot int
+	for _, n := range result.Nodes {
+		isSpot := n.Template.CapacityType == model.CapacitySpot
+		if isSpot {
+			spot++
+		}
+		if isSpot && !n.Workloads[0].ToleratesSpot() {
+			t.Errorf("%s landed on a spot node", n.Workloads[0].Name)
+		}
+	}
+	if spot != 2 {
+		t.Errorf("spot nodes = %d, want 2 (the batch pods)", spot)
+	}
+
+	d := AnalyzeSpotDisruption(result.Nodes, input.SpotRatio)
+	if d == nil || d.SpotShortfall != 2 || d.ProtectedPods != 2 {
+		t.Fatalf("disruption = %+v, want a shortfall of 2 nodes for 2 protected pods", d)
+	}
+}
+
+func TestSpotRatio_SeparatesProtectedPods(t *testing.T) {
+	// Four 500m pods fill a node; in input order every node would get one
+	// StatefulSet replica and none could be converted to spot
+	var pods []model.WorkloadProfile
+	for i := range 16 {
+		w := makeWorkload("batch-"+strconv.Itoa(i), 500, gib)
+		if i%4 == 0 {
+			w.Name = "db-" + strconv.Itoa(i)
+			w.OwnerKind, w.OwnerName = "StatefulSet", "db"
+		}
+		pods = append(pods, w)
+	}
+	input := PackInput{
+		Workloads: pods,
+		NodeTemplates: []model.NodeTemplate{
+			makeTemplate("m5.large", 2000, 8*gib, 29, 0.096),
+		},
+		SpotRatio: 0.75,
+	}
+
+	packers := []BinPacker{
+		&BestFitDecreasing{},
+		&FirstFitDecreasing{},
+		&LocalSearch{Seed: 1},
+		&BranchAndBound{},
+		&PeakAwareBestFit{},
+	}
+	for _, p := range packers {
+		t.Run(p.Name(), func(t *testing.T) {
+			result, err := p.Pack(context.Background(), input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Nodes) != 4 {
+				t.Fatalf("nodes = %d, want 4", len(result.Nodes))
+			}
+			var spot int
+			for _, n := range result.Nodes {
+				if n.Template.CapacityType != model.CapacitySpot {
+					continue
+				}
+				spot++
+				for _, w := range n.Workloads {
+					if !w.ToleratesSpot() {
+						t.Errorf("%s landed on a spot node", w.Name)
+					}
+				}
+			}
+			if spot != 3 {
+				t.Errorf("spot nodes = %d, want 3", spot)
+			}
+			if d := AnalyzeSpotDisruption(result.Nodes, input.SpotRatio); d == nil || d.SpotShortfall != 0 {
+				t.Errorf("disruption = %+v, want no shortfall", d)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSpotDisruption(t *testing.T) {
+	known := makeTemplate("c5.large", 2000, 4*gib, 29, 0.085)
+	known.SpotInterruptionRate = 0.125
+	known.CapacityType = model.CapacitySpot
+	unknown := makeTemplate("m5.large", 2000, 8*gib, 29, 0.096)
+	unknown.CapacityType = model.CapacitySpot
+	onDemand := makeTemplate("m5.large", 2000, 8*gib, 29, 0.096)
+
+	pods := func(n int) []model.WorkloadProfile {
+		ws := make([]model.WorkloadProfile, n)
+		for i := range ws {
+			ws[i] = makeWorkload("app", 100, gib)
+		}
+		return ws
+	}
+	nodes := []model.NodeAllocation{
+		{Template: known, Workloads: pods(4)},
+		{Template: unknown, Workloads: pods(2)},
+		{Template: onDemand, Workloads: pods(4)},
+	}
+
+	d := AnalyzeSpotDisruption(nodes, 0.5)
+	if d == nil {
+		t.Fatal("expected a disruption estimate")
+	}
+	if d.SpotNodes != 2 || d.SpotShortfall != 0 {
+		t.Errorf("spot nodes = %d, shortfall %d, want 2 and 0", d.SpotNodes, d.SpotShortfall)
+	}
+	wantInterruptions := 0.125 + DefaultSpotInterruptionRate
+	wantEvictions := 0.125*4 + DefaultSpotInterruptionRate*2
+	if math.Abs(d.InterruptionsPerMonth-wantInterruptions) > 1e-9 || math.Abs(d.EvictionsPerMonth-wantEvictions) > 1e-9 {
+		t.Errorf("interruptions %.3f, evictions %.3f, want %.3f and %.3f",
+			d.InterruptionsPerMonth, d.EvictionsPerMonth, wantInterruptions, wantEvictions)
+	}
+	if math.Abs(d.EvictedFraction-wantEvictions/10) > 1e-9 {
+		t.Errorf("evicted fraction = %.4f, want %.4f", d.EvictedFraction, wantEvictions/10)
+	}
+	if len(d.EstimatedTypes) != 1 || d.EstimatedTypes[0] != "m5.large" {
+		t.Errorf("estimated types = %v, want [m5.large]", d.EstimatedTypes)
+	}
+
+	if got := AnalyzeSpotDisruption(nodes[2:], 0); got != nil {
+		t.Errorf("on-demand only = %+v, want nil", got)
+	}
+}
+
+func TestScorer_SpotDisruption(t *testing.T) {
+	scorer := NewScorer(model.ScoringWeights{Resilience: 1.0})
+	calm := makeSimResult(1000, 0.5, 0.5, 10)
+	calm.SpotDisruption = &model.SpotDisruption{SpotNodes: 5, EvictionsPerMonth: 1, EvictedFraction: 0.01}
+	stormy := makeSimResult(1000, 0.5, 0.5, 10)
+	stormy.SpotDisruption = &model.SpotDisruption{SpotNodes: 5, EvictionsPerMonth: 20, EvictedFraction: 0.2}
+
+	recs := scorer.RankResults([]model.SimulationResult{stormy, calm}, nil)
+	if recs[0].SimulationResult.SpotDisruption != calm.SpotDisruption {
+		t.Error("the result with fewer expected evictions should rank first")
+	}
+	if math.Abs(recs[0].ResilienceScore-recs[1].ResilienceScore-19) > 1e-9 {
+		t.Errorf("resilience %.1f vs %.1f, want a 19-point gap", recs[0].ResilienceScore, recs[1].ResilienceScore)
+	}
+	if !containsSubstring(recs[1].Warnings, "evict 20 pods per month") {
+		t.Errorf("warnings = %v, want the expected evictions flagged", recs[1].Warnings)
+	}
+	if containsSubstring(recs[0].Warnings, "evict") {
+		t.Errorf("warnings = %v, want no eviction warning at 1%%", recs[0].Warnings)
+	}
+}